@@ -0,0 +1,387 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ArrowValue is the go type of the values of an ArrowColumn.
+type ArrowValue interface {
+	Number | bool | string
+}
+
+// The Arrow types (the ids of the Type union of Schema.fbs)
+// of the columns.
+const (
+	arrowInt       = 2
+	arrowFloat     = 3
+	arrowUtf8      = 5
+	arrowBool      = 6
+	arrowTimestamp = 10
+)
+
+// The ids of the MessageHeader union of Message.fbs.
+const (
+	arrowSchemaMessage      = 1
+	arrowRecordBatchMessage = 3
+)
+
+// arrowVersion is the metadata version (V5) of the messages.
+const arrowVersion = 4
+
+// ArrowColumn holds the values of a column of an Arrow record batch
+// (see ArrowStream) until the batch is written.  Each value (or null)
+// is appended with AppendArrow, AppendTime, or AppendNull.
+type ArrowColumn struct {
+	name     string
+	nullable bool
+	typ      uint8
+	width    int
+	signed   bool
+	ts       Timestamp
+
+	n       int
+	nulls   int
+	valid   []byte
+	values  []byte
+	offsets []int32
+}
+
+// NewArrowColumn returns a column of values of the type T, which
+// can be null if nullable is true.  Its values must be appended with
+// AppendArrow[T].
+func NewArrowColumn[T ArrowValue](name string, nullable bool) *ArrowColumn {
+	c := &ArrowColumn{name: name, nullable: nullable}
+	var v T
+	switch interface{}(v).(type) {
+	case int32, uint32:
+		c.typ, c.width = arrowInt, 4
+	case int64, uint64:
+		c.typ, c.width = arrowInt, 8
+	case float32:
+		c.typ, c.width = arrowFloat, 4
+	case float64:
+		c.typ, c.width = arrowFloat, 8
+	case Float16:
+		c.typ, c.width = arrowFloat, 2
+	case bool:
+		c.typ = arrowBool
+	case string:
+		c.typ = arrowUtf8
+		c.offsets = []int32{0}
+	}
+
+	switch interface{}(v).(type) {
+	case int32, int64:
+		c.signed = true
+	}
+	return c
+}
+
+// NewArrowTimeColumn returns a column of time.Times, which are
+// stored the way that ts stores them in parquet (with the same unit,
+// and in UTC unless it is a local time).  Its values must be appended
+// with AppendTime.
+func NewArrowTimeColumn(name string, nullable bool, ts Timestamp) *ArrowColumn {
+	return &ArrowColumn{name: name, nullable: nullable, typ: arrowTimestamp, width: 8, ts: ts}
+}
+
+// AppendArrow appends v to c, which must have been
+// created by NewArrowColumn[T].
+func AppendArrow[T ArrowValue](c *ArrowColumn, v T) {
+	c.appendValid(true)
+	var buf [8]byte
+	switch v := interface{}(v).(type) {
+	case int32:
+		binary.LittleEndian.PutUint32(buf[:], uint32(v))
+	case uint32:
+		binary.LittleEndian.PutUint32(buf[:], v)
+	case int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+	case uint64:
+		binary.LittleEndian.PutUint64(buf[:], v)
+	case float32:
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+	case float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+	case Float16:
+		binary.LittleEndian.PutUint16(buf[:], uint16(v))
+	case bool:
+		c.appendBit(v)
+		return
+	case string:
+		c.values = append(c.values, v...)
+		c.offsets = append(c.offsets, int32(len(c.values)))
+		return
+	}
+	c.values = append(c.values, buf[:c.width]...)
+}
+
+// AppendTime appends t to c, which must have
+// been created by NewArrowTimeColumn.
+func (c *ArrowColumn) AppendTime(t time.Time) {
+	c.appendValid(true)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(c.ts.Int64(t)))
+	c.values = append(c.values, buf[:]...)
+}
+
+// AppendNull appends a null to c.
+func (c *ArrowColumn) AppendNull() {
+	c.appendValid(false)
+	c.nulls++
+	switch c.typ {
+	case arrowBool:
+		c.appendBit(false)
+	case arrowUtf8:
+		c.offsets = append(c.offsets, int32(len(c.values)))
+	default:
+		c.values = append(c.values, make([]byte, c.width)...)
+	}
+}
+
+func (c *ArrowColumn) appendValid(v bool) {
+	if c.n%8 == 0 {
+		c.valid = append(c.valid, 0)
+	}
+	if v {
+		c.valid[c.n/8] |= 1 << (c.n % 8)
+	}
+	c.n++
+}
+
+// appendBit appends the value of a bool column (which is
+// packed the same way as the validity bitmap).
+func (c *ArrowColumn) appendBit(v bool) {
+	i := c.n - 1
+	if i%8 == 0 {
+		c.values = append(c.values, 0)
+	}
+	if v {
+		c.values[i/8] |= 1 << (i % 8)
+	}
+}
+
+func (c *ArrowColumn) reset() {
+	c.n, c.nulls = 0, 0
+	c.valid = c.valid[:0]
+	c.values = c.values[:0]
+	if c.typ == arrowUtf8 {
+		c.offsets = c.offsets[:1]
+	}
+}
+
+// ArrowStream writes the values of its columns to an Arrow IPC stream
+// (the format that is read by arrow's ipc.NewReader, for example) as
+// record batches.  The stream's schema is written before the first
+// batch.
+type ArrowStream struct {
+	w      io.Writer
+	cols   []*ArrowColumn
+	schema bool
+}
+
+// NewArrowStream returns an ArrowStream that writes the record
+// batches of cols to w.
+func NewArrowStream(w io.Writer, cols ...*ArrowColumn) *ArrowStream {
+	return &ArrowStream{w: w, cols: cols}
+}
+
+// WriteBatch writes the values that have been appended to the columns
+// as a record batch and removes them from the columns.  Every column
+// must have the same number of values.  Nothing is written if there
+// aren't any values (other than the schema, if it hasn't been written
+// yet).
+func (s *ArrowStream) WriteBatch() error {
+	if err := s.writeSchema(); err != nil {
+		return err
+	}
+
+	var rows int
+	for i, c := range s.cols {
+		if i == 0 {
+			rows = c.n
+		}
+
+		if c.n != rows {
+			return fmt.Errorf("column %s has %d values but column %s has %d", c.name, c.n, s.cols[0].name, rows)
+		}
+
+		if len(c.values) > math.MaxInt32 && c.typ == arrowUtf8 {
+			return fmt.Errorf("column %s has %d bytes of strings, which is more than a record batch can hold", c.name, len(c.values))
+		}
+	}
+
+	if rows == 0 {
+		return nil
+	}
+
+	var nodes, buffers []byte
+	var body [][]byte
+	var size int64
+	buffer := func(p []byte) {
+		buffers = append(buffers, arrowStruct(size, int64(len(p)))...)
+		body = append(body, p)
+		size += int64(arrowPad(len(p)))
+	}
+
+	for _, c := range s.cols {
+		nodes = append(nodes, arrowStruct(int64(c.n), int64(c.nulls))...)
+
+		// the validity bitmap can be left out if nothing is null
+		if c.nulls > 0 {
+			buffer(c.valid)
+		} else {
+			buffer(nil)
+		}
+
+		if c.typ == arrowUtf8 {
+			offsets := make([]byte, 4*len(c.offsets))
+			for i, o := range c.offsets {
+				binary.LittleEndian.PutUint32(offsets[4*i:], uint32(o))
+			}
+			buffer(offsets)
+		}
+		buffer(c.values)
+	}
+
+	b := newFBBuilder()
+	nodesOff := b.createStructs(nodes, len(s.cols), 8)
+	buffersOff := b.createStructs(buffers, len(buffers)/16, 8)
+	b.startTable()
+	b.addInt64(0, int64(rows))
+	b.addOffset(1, nodesOff)
+	b.addOffset(2, buffersOff)
+	batch := b.endTable()
+
+	if err := s.writeMessage(b, arrowRecordBatchMessage, batch, size); err != nil {
+		return err
+	}
+
+	var pad [8]byte
+	for _, p := range body {
+		if _, err := s.w.Write(p); err != nil {
+			return err
+		}
+		if _, err := s.w.Write(pad[:arrowPad(len(p))-len(p)]); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range s.cols {
+		c.reset()
+	}
+	return nil
+}
+
+// Close writes the end of the stream (and the schema if no record
+// batches were written).  It doesn't write the values that haven't
+// been written with WriteBatch, and it doesn't close the io.Writer.
+func (s *ArrowStream) Close() error {
+	if err := s.writeSchema(); err != nil {
+		return err
+	}
+
+	_, err := s.w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+func (s *ArrowStream) writeSchema() error {
+	if s.schema {
+		return nil
+	}
+	s.schema = true
+
+	b := newFBBuilder()
+	fields := make([]int, len(s.cols))
+	for i, c := range s.cols {
+		typ := c.arrowType(b)
+		name := b.createString(c.name)
+		// readers expect a vector of children, even if it is empty
+		children := b.createOffsets(nil)
+
+		b.startTable()
+		b.addOffset(0, name)
+		b.addBool(1, c.nullable)
+		b.addUint8(2, c.typ)
+		b.addOffset(3, typ)
+		b.addOffset(5, children)
+		fields[i] = b.endTable()
+	}
+
+	vec := b.createOffsets(fields)
+	b.startTable()
+	b.addOffset(1, vec)
+	schema := b.endTable()
+	return s.writeMessage(b, arrowSchemaMessage, schema, 0)
+}
+
+// arrowType builds the table of c's type (the Int,
+// FloatingPoint, Utf8, Bool, or Timestamp) and returns its offset.
+func (c *ArrowColumn) arrowType(b *fbBuilder) int {
+	var tz int
+	if c.typ == arrowTimestamp && (c.ts.AdjustedToUTC || c.ts.Epoch) {
+		tz = b.createString("UTC")
+	}
+
+	b.startTable()
+	switch c.typ {
+	case arrowInt:
+		b.addInt32(0, int32(8*c.width))
+		b.addBool(1, c.signed)
+	case arrowFloat:
+		// the precision is HALF (0), SINGLE (1), or DOUBLE (2)
+		b.addInt16(0, int16(c.width/4))
+	case arrowTimestamp:
+		// the unit is SECOND (0), MILLISECOND (1),
+		// MICROSECOND (2), or NANOSECOND (3)
+		unit := int16(c.ts.Unit) + 1
+		if c.ts.Unit == Seconds {
+			unit = 0
+		}
+		b.addInt16(0, unit)
+		if tz > 0 {
+			b.addOffset(1, tz)
+		}
+	}
+	return b.endTable()
+}
+
+// writeMessage finishes b as a Message whose header is the table at
+// header, and writes it (the message's body must be written next).
+func (s *ArrowStream) writeMessage(b *fbBuilder, typ uint8, header int, bodyLength int64) error {
+	b.startTable()
+	b.addInt64(3, bodyLength)
+	b.addOffset(2, header)
+	b.addInt16(0, arrowVersion)
+	b.addUint8(1, typ)
+	msg := b.finish(b.endTable())
+
+	// the continuation marker and the size of the metadata (which is
+	// padded so that the body starts at a multiple of 8)
+	prefix := make([]byte, 8, 8+arrowPad(len(msg)))
+	binary.LittleEndian.PutUint32(prefix, 0xffffffff)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(arrowPad(len(msg))))
+	prefix = append(prefix, msg...)
+	prefix = append(prefix, make([]byte, arrowPad(len(msg))-len(msg))...)
+	_, err := s.w.Write(prefix)
+	return err
+}
+
+// arrowStruct returns the bytes of a FieldNode (a column's length
+// and null count) or a Buffer (its offset in the body and length).
+func arrowStruct(a, b int64) []byte {
+	out := make([]byte, 16)
+	binary.LittleEndian.PutUint64(out, uint64(a))
+	binary.LittleEndian.PutUint64(out[8:], uint64(b))
+	return out
+}
+
+// arrowPad rounds n up to a multiple of 8, which
+// is the alignment of the buffers of a message.
+func arrowPad(n int) int {
+	return (n + 7) &^ 7
+}
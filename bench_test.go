@@ -0,0 +1,219 @@
+package parquet_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/rclayton-godaddy/parquet"
+)
+
+// benchRows is the number of rows (all in one page) that each
+// column of BenchmarkFields has.
+const benchRows = 10000
+
+// benchFields are the columns of each type (required and optional)
+// that BenchmarkFields writes and reads.
+var benchFields = []struct {
+	name  string
+	field func() Field
+}{
+	{"int32", func() Field { return NewInt32Field(readID, writeID, []string{"id"}) }},
+	{"int32_optional", func() Field { return NewInt32OptionalField(readAge, writeAge, []string{"age"}, []int{1}) }},
+	{"int64", func() Field { return NewInt64Field(readHappiness, writeHappiness, []string{"happiness"}) }},
+	{"int64_optional", func() Field { return NewInt64OptionalField(readSadness, writeSadness, []string{"sadness"}, []int{1}) }},
+	{"uint64_optional", func() Field {
+		return NewUint64OptionalField(readAnniversary, writeAnniversary, []string{"anniversary"}, []int{1})
+	}},
+	{"float32", func() Field { return NewFloat32Field(readFunkiness, writeFunkiness, []string{"funkiness"}) }},
+	{"float32_optional", func() Field {
+		return NewFloat32OptionalField(readLameness, writeLameness, []string{"lameness"}, []int{1})
+	}},
+	{"float64", func() Field { return NewFloat64Field(readBoldness, writeBoldness, []string{"boldness"}) }},
+	{"bool", func() Field { return NewBoolField(readHungry, writeHungry, []string{"hungry"}) }},
+	{"bool_optional", func() Field { return NewBoolOptionalField(readKeen, writeKeen, []string{"keen"}, []int{1}) }},
+	{"string", func() Field { return NewStringField(readName, writeName, []string{"name"}) }},
+	{"string_optional", func() Field { return NewStringOptionalField(readCode, writeCode, []string{"code"}, []int{1}) }},
+	{"time", func() Field {
+		return NewTimeField(readBorn, writeBorn, []string{"born"}, parquet.Timestamp{Unit: parquet.Micros, AdjustedToUTC: true})
+	}},
+	{"time_optional", func() Field {
+		return NewTimeOptionalField(readDied, writeDied, []string{"died"}, []int{1}, parquet.Timestamp{Unit: parquet.Millis})
+	}},
+}
+
+// BenchmarkFields writes and reads a column of each type on its own
+// (so that a change to one type's field can be measured without the
+// others), and reports the allocations of each.
+func BenchmarkFields(b *testing.B) {
+	rows := getPeople(benchRows, benchRows)[0]
+	for i := range rows {
+		rows[i].Name = fmt.Sprintf("person-%d", i)
+	}
+
+	for _, bf := range benchFields {
+		data, err := writeColumn(bf.field(), rows)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(bf.name+"/write", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := writeColumn(bf.field(), rows); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(bf.name+"/read", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := readColumn(bf.field(), data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// writeColumn writes a file that only has f's column,
+// whose values are from rows, as one page.
+func writeColumn(f Field, rows []Person) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	meta := parquet.New(f.Schema())
+	for _, p := range rows {
+		f.Add(p)
+		meta.NextDoc()
+	}
+
+	if err := f.Write(&buf, meta); err != nil {
+		return nil, err
+	}
+
+	if err := meta.EndRowGroup(int64(len(rows))); err != nil {
+		return nil, err
+	}
+
+	if err := meta.Footer(&buf); err != nil {
+		return nil, err
+	}
+	buf.Write([]byte("PAR1"))
+	return buf.Bytes(), nil
+}
+
+// readColumn reads (and scans every row of) the
+// column of f in data, which writeColumn wrote.
+func readColumn(f Field, data []byte) error {
+	r := bytes.NewReader(data)
+	meta := parquet.New(f.Schema())
+	if err := meta.ReadFooter(r); err != nil {
+		return err
+	}
+
+	pages, err := meta.Pages()
+	if err != nil {
+		return err
+	}
+
+	for _, pg := range pages[f.Name()] {
+		if _, err := r.Seek(pg.Offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(r, pg); err != nil {
+			return err
+		}
+
+		var p Person
+		for i := int64(0); i < pg.Rows; i++ {
+			f.Scan(&p)
+		}
+	}
+	return nil
+}
+
+// internRows is the number of values in the column
+// (with internDistinct distinct values) of BenchmarkInternStrings.
+const (
+	internRows     = 10000000
+	internDistinct = 50
+)
+
+// BenchmarkInternStrings reports how much heap the strings of a column
+// with few distinct values take up once they have been read, with
+// and without an Interner.
+func BenchmarkInternStrings(b *testing.B) {
+	names := make([]string, internDistinct)
+	for i := range names {
+		names[i] = fmt.Sprintf("category-%d", i)
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+	f := NewStringField(readName, writeName, []string{"name"})
+	meta := parquet.New(f.Schema())
+	for i := 0; i < internRows; i++ {
+		f.AddValues(names[i%internDistinct : i%internDistinct+1])
+		meta.NextDoc()
+	}
+	if err := f.Write(&buf, meta); err != nil {
+		b.Fatal(err)
+	}
+	if err := meta.EndRowGroup(internRows); err != nil {
+		b.Fatal(err)
+	}
+	if err := meta.Footer(&buf); err != nil {
+		b.Fatal(err)
+	}
+	buf.Write([]byte("PAR1"))
+	data := buf.Bytes()
+	f = nil
+
+	for _, interner := range []*parquet.Interner{nil, parquet.NewInterner()} {
+		name := "plain"
+		if interner != nil {
+			name = "interned"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				var before runtime.MemStats
+				runtime.ReadMemStats(&before)
+
+				// the values are read (but not scanned) so that
+				// the field holds on to them
+				f := NewStringField(readName, writeName, []string{"name"})
+				f.SetInterner(interner)
+				r := bytes.NewReader(data)
+				meta := parquet.New(f.Schema())
+				if err := meta.ReadFooter(r); err != nil {
+					b.Fatal(err)
+				}
+				pages, err := meta.Pages()
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, pg := range pages["name"] {
+					if _, err := r.Seek(pg.Offset, io.SeekStart); err != nil {
+						b.Fatal(err)
+					}
+					if err := f.Read(r, pg); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				runtime.GC()
+				var after runtime.MemStats
+				runtime.ReadMemStats(&after)
+				b.ReportMetric(float64(after.HeapAlloc)-float64(before.HeapAlloc), "heap-B")
+				runtime.KeepAlive(f)
+			}
+		})
+	}
+}
@@ -14,6 +14,14 @@ import (
 // Write generates the code for initializing a struct
 // with data from a parquet file.
 func Write(f fields.Field) string {
+	if f.Getter != "" {
+		return writeMethod(f)
+	}
+
+	if f.Null != "" || f.EmptyAsNull || f.NullSentinel != "" {
+		return writeNull(f)
+	}
+
 	if f.Repeated() {
 		return writeRepeated(f)
 	}
@@ -28,6 +36,14 @@ func Write(f fields.Field) string {
 // Read generates the code for reading a struct
 // and using the data to write to a parquet file.
 func Read(f fields.Field) string {
+	if f.Getter != "" {
+		return readMethod(f)
+	}
+
+	if f.Null != "" || f.EmptyAsNull || f.NullSentinel != "" {
+		return readNull(f)
+	}
+
 	if f.Repeated() {
 		return readRepeated(f)
 	}
@@ -44,3 +60,19 @@ func writeRequired(f fields.Field) string {
 	x.%s = vals[0]
 }`, fmt.Sprintf("write%s", strings.Join(f.FieldNames(), "")), f.StructType(), f.TypeName(), strings.Join(f.FieldNames(), "."))
 }
+
+// Reset generates the statement that sets the top level field that
+// holds f back to its zero value (zero is the struct's zero value).
+// Required fields that are bound to a set method are always set when
+// reading, so there isn't anything to reset and the statement is empty.
+func Reset(f fields.Field) string {
+	if f.Setter != "" {
+		if f.Optional() {
+			return fmt.Sprintf("x.%s(nil)", f.Setter)
+		}
+		return ""
+	}
+
+	name := f.FieldNames()[0]
+	return fmt.Sprintf("x.%s = zero.%s", name, name)
+}
@@ -2,11 +2,25 @@ package dremel_test
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"io"
 	"testing"
+	"time"
 
+	"github.com/rclayton-godaddy/parquet"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/dispatch"
 	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/doc"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/float16"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/implements"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/methods"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/monomorphic"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/null"
 	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/person"
 	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/repetition"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel/testcases/unixtime"
+	sch "github.com/rclayton-godaddy/parquet/schema"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -135,6 +149,59 @@ func TestPersonLevels(t *testing.T) {
 	assert.Equal(t, expected, pr.Levels())
 }
 
+// TestOptionalGroup makes sure that a nil pointer to a struct is a
+// null group: its columns are defined at level 0, and the columns of
+// a group that isn't nil are defined at least at level 1.
+func TestOptionalGroup(t *testing.T) {
+	input := []person.Person{
+		{Name: "nil hobby"},
+		{Name: "empty hobby", Hobby: &person.Hobby{Name: "napping"}},
+		{
+			Name: "full hobby",
+			Hobby: &person.Hobby{
+				Name:       "chess",
+				Difficulty: pint32(7),
+				Skills:     []person.Skill{{Name: "openings", Difficulty: "hard"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	pw, err := person.NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, p := range input {
+		pw.Add(p)
+	}
+	assert.NoError(t, pw.Write())
+	assert.NoError(t, pw.Close())
+
+	pr, err := person.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := []person.Levels{
+		{Name: "name"},
+		{Name: "hobby.name", Defs: []uint8{0, 1, 1}},
+		{Name: "hobby.difficulty", Defs: []uint8{0, 1, 2}},
+		{Name: "hobby.skills.name", Defs: []uint8{0, 1, 2}, Reps: []uint8{0, 0, 0}},
+		{Name: "hobby.skills.difficulty", Defs: []uint8{0, 1, 2}, Reps: []uint8{0, 0, 0}},
+	}
+	assert.Equal(t, expected, pr.Levels())
+
+	var out []person.Person
+	for pr.Next() {
+		var p person.Person
+		pr.Scan(&p)
+		out = append(out, p)
+	}
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, input, out)
+}
+
 // TestDremel uses the example from the dremel paper and writes then
 // reads from a parquet file to make sure nested fields work correctly.
 func TestDremel(t *testing.T) {
@@ -277,3 +344,618 @@ func TestRepetition(t *testing.T) {
 
 	assert.Equal(t, repetitionDocs, out)
 }
+
+func TestMethods(t *testing.T) {
+	people := []methods.Person{
+		methods.NewPerson(1, "a", pstring("Al")),
+		methods.NewPerson(2, "b", nil),
+		methods.NewPerson(3, "c", pstring("Cy")),
+	}
+
+	var buf bytes.Buffer
+	pw, err := methods.NewParquetWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range people {
+		pw.Add(p)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := methods.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []methods.Person
+	for pr.Next() {
+		var p methods.Person
+		pr.Scan(&p)
+		out = append(out, p)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, people, out)
+
+	// the nickname is copied with its get and set methods
+	c := people[0].Clone()
+	assert.True(t, people[0].Equal(c))
+	*c.Nickname() = "Bo"
+	assert.False(t, people[0].Equal(c))
+	assert.Equal(t, "Al", *people[0].Nickname())
+}
+
+func TestNull(t *testing.T) {
+	rows := []null.Row{
+		{
+			ID:      1,
+			Name:    sql.NullString{String: "a", Valid: true},
+			Count:   sql.NullInt64{Int64: 10, Valid: true},
+			Small:   sql.NullInt32{Int32: -1, Valid: true},
+			Score:   sql.NullFloat64{Float64: 1.5, Valid: true},
+			Active:  sql.NullBool{Bool: false, Valid: true},
+			Updated: sql.NullTime{Time: time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC), Valid: true},
+		},
+		{ID: 2},
+		{
+			ID:     3,
+			Name:   sql.NullString{Valid: true},
+			Active: sql.NullBool{Bool: true, Valid: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	pw, err := null.NewParquetWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rows {
+		pw.Add(r)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := null.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same Row is reused to make sure the nulls
+	// of the second row are reset
+	var out []null.Row
+	var r null.Row
+	for pr.Next() {
+		pr.Scan(&r)
+		out = append(out, r)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, rows, out)
+
+	// the values of invalid Null types aren't compared
+	a, b := rows[1], rows[1]
+	b.Name.String = "ignored"
+	assert.True(t, a.Equal(b))
+	b.Name.Valid = true
+	assert.False(t, a.Equal(b))
+}
+
+func TestEmptyAsNull(t *testing.T) {
+	rows := []null.Row{
+		{ID: 1, Email: "a@example.com", Phone: pstring("")},
+		{ID: 2, Email: "", Phone: pstring("555-0100")},
+		{ID: 3, Email: "c@example.com"},
+	}
+
+	var buf bytes.Buffer
+	pw, err := null.NewParquetWriter(&buf, null.DataPageV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rows {
+		pw.Add(r)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := null.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty strings are written as nulls
+	for col, nulls := range map[string]int32{"email": 1, "phone": 2} {
+		var n int32
+		err := pr.ForEachPage(col, func(ph sch.PageHeader) error {
+			n += ph.DataPageHeaderV2.NumNulls
+			return nil
+		})
+		assert.NoError(t, err, col)
+		assert.Equal(t, nulls, n, col)
+	}
+
+	var out []null.Row
+	for pr.Next() {
+		var r null.Row
+		pr.Scan(&r)
+		out = append(out, r)
+	}
+
+	// a null is read back as a nil *string
+	rows[0].Phone = nil
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, rows, out)
+}
+
+func TestNullSentinel(t *testing.T) {
+	rows := []null.Row{
+		{ID: 1, Rank: 3, Ratio: 0.25},
+		{ID: 2, Rank: -1, Ratio: -0.5},
+		{ID: 3, Rank: 0, Ratio: 0},
+	}
+
+	var buf bytes.Buffer
+	pw, err := null.NewParquetWriter(&buf, null.DataPageV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rows {
+		pw.Add(r)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := null.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sentinels are written as nulls (and zero isn't)
+	for _, col := range []string{"rank", "ratio"} {
+		var n int32
+		err := pr.ForEachPage(col, func(ph sch.PageHeader) error {
+			n += ph.DataPageHeaderV2.NumNulls
+			return nil
+		})
+		assert.NoError(t, err, col)
+		assert.Equal(t, int32(1), n, col)
+	}
+
+	// a null is read back as the sentinel, even into a reused struct
+	var out []null.Row
+	r := null.Row{Rank: 7, Ratio: 7}
+	for pr.Next() {
+		pr.Scan(&r)
+		out = append(out, r)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, rows, out)
+}
+
+func TestArrowNull(t *testing.T) {
+	rows := []null.Row{
+		{ID: 1, Name: sql.NullString{String: "a", Valid: true}, Email: "a@example.com", Rank: 3},
+		{ID: 2, Rank: -1, Ratio: -0.5},
+	}
+
+	var buf bytes.Buffer
+	w := null.NewArrowWriter(&buf)
+	for _, r := range rows {
+		w.Add(r)
+	}
+	assert.NoError(t, w.Close())
+
+	// the stream ends with a continuation marker and a size of 0
+	out := buf.Bytes()
+	if assert.True(t, len(out) > 8) {
+		assert.Equal(t, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, out[len(out)-8:])
+	}
+}
+
+// TestColumns checks that the columns' values added by the AddColumn
+// methods are written the same way as the records they make up.
+func TestColumns(t *testing.T) {
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	rows := []null.Row{
+		{
+			ID:      1,
+			Name:    sql.NullString{String: "a", Valid: true},
+			Count:   sql.NullInt64{Int64: 10, Valid: true},
+			Small:   sql.NullInt32{Int32: -1, Valid: true},
+			Score:   sql.NullFloat64{Float64: 1.5, Valid: true},
+			Active:  sql.NullBool{Bool: false, Valid: true},
+			Updated: sql.NullTime{Time: updated, Valid: true},
+			Email:   "a@example.com",
+			Rank:    -1,
+			Ratio:   0.25,
+		},
+		{ID: 2, Phone: pstring("555-1234")},
+		{
+			ID:     3,
+			Name:   sql.NullString{Valid: true},
+			Active: sql.NullBool{Bool: true, Valid: true},
+			Rank:   3,
+		},
+	}
+
+	var want bytes.Buffer
+	pw, err := null.NewParquetWriter(&want, null.MaxPageSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		pw.Add(r)
+	}
+	assert.NoError(t, pw.Write())
+	assert.NoError(t, pw.Close())
+
+	var got bytes.Buffer
+	pw, err = null.NewParquetWriter(&got, null.MaxPageSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pw.AddColumnID([]int32{1, 2})
+	pw.AddColumnID([]int32{3})
+	pw.AddColumnName([]*string{pstring("a"), nil, pstring("")})
+	pw.AddColumnCount([]*int64{ptr(int64(10)), nil, nil})
+	pw.AddColumnSmall([]*int32{pint32(-1), nil, nil})
+	pw.AddColumnScore([]*float64{ptr(1.5), nil, nil})
+	pw.AddColumnActive([]*bool{ptr(false), nil, ptr(true)})
+	pw.AddColumnUpdated([]*time.Time{&updated, nil, nil})
+	pw.AddColumnEmail([]*string{pstring("a@example.com"), nil, nil})
+	pw.AddColumnPhone([]*string{nil, pstring("555-1234"), nil})
+	pw.AddColumnRank([]*int64{nil, ptr(int64(0)), ptr(int64(3))})
+
+	// the ratio column is missing
+	assert.EqualError(t, pw.Commit(), "column ratio has 0 values but column id has 3")
+
+	pw.AddColumnRatio([]*float32{ptr(float32(0.25)), ptr(float32(0)), ptr(float32(0))})
+	assert.NoError(t, pw.Commit())
+	assert.NoError(t, pw.Write())
+	assert.NoError(t, pw.Close())
+
+	assert.Equal(t, want.Bytes(), got.Bytes())
+
+	// after Close the values aren't added
+	pw.AddColumnID([]int32{4})
+	assert.Equal(t, parquet.ErrWriterClosed, pw.Commit())
+	assert.Equal(t, want.Bytes(), got.Bytes())
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestUnixTime(t *testing.T) {
+	at := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	seen := at.Add(1500 * time.Millisecond)
+	rows := []unixtime.Event{
+		{ID: 1, At: at, Seen: &seen, Ticks: []time.Time{at.Add(time.Nanosecond), at.Add(2 * time.Nanosecond)}},
+		{ID: 2, At: at.Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	pw, err := unixtime.NewParquetWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rows {
+		pw.Add(r)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := unixtime.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the columns are plain int64s of the units since the epoch
+	root, err := pr.SchemaTree()
+	if assert.NoError(t, err) {
+		for _, n := range root.Children[1:] {
+			assert.Equal(t, sch.Type_INT64, *n.Type, n.Name)
+			assert.Nil(t, n.LogicalType, n.Name)
+			assert.Nil(t, n.ConvertedType, n.Name)
+		}
+	}
+
+	var min []byte
+	assert.NoError(t, pr.ForEachPage("at", func(ph sch.PageHeader) error {
+		min = ph.DataPageHeader.Statistics.MinValue
+		return nil
+	}))
+	assert.Equal(t, uint64(at.Unix()), binary.LittleEndian.Uint64(min))
+
+	var out []unixtime.Event
+	for pr.Next() {
+		var r unixtime.Event
+		pr.Scan(&r)
+		out = append(out, r)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, rows, out)
+}
+
+func TestFloat16(t *testing.T) {
+	h := parquet.NewFloat16
+	rows := []float16.Embedding{
+		{ID: 1, Weight: h(0.5), Bias: ptr(h(-1.25)), Vector: []parquet.Float16{h(1), h(-2), h(65504)}},
+		{ID: 2, Weight: h(-3)},
+		{ID: 3, Weight: h(2), Vector: []parquet.Float16{h(0.1)}},
+	}
+
+	var buf bytes.Buffer
+	pw, err := float16.NewParquetWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rows {
+		pw.Add(r)
+	}
+
+	if err := pw.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+
+	pr, err := float16.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the columns are FIXED_LEN_BYTE_ARRAY(2)s with the FLOAT16 logical type
+	root, err := pr.SchemaTree()
+	if assert.NoError(t, err) {
+		for _, n := range root.Children[1:] {
+			assert.Equal(t, sch.Type_FIXED_LEN_BYTE_ARRAY, *n.Type, n.Name)
+			assert.Equal(t, int32(2), n.TypeLength, n.Name)
+			if assert.NotNil(t, n.LogicalType, n.Name) {
+				assert.NotNil(t, n.LogicalType.FLOAT16, n.Name)
+			}
+		}
+	}
+
+	// the min and max are compared as floats (not by their bits)
+	var min, max []byte
+	assert.NoError(t, pr.ForEachPage("weight", func(ph sch.PageHeader) error {
+		min, max = ph.DataPageHeader.Statistics.MinValue, ph.DataPageHeader.Statistics.MaxValue
+		return nil
+	}))
+	assert.Equal(t, h(-3), parquet.Float16(binary.LittleEndian.Uint16(min)))
+	assert.Equal(t, h(2), parquet.Float16(binary.LittleEndian.Uint16(max)))
+
+	var out []float16.Embedding
+	for pr.Next() {
+		var r float16.Embedding
+		pr.Scan(&r)
+		out = append(out, r)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, rows, out)
+	assert.Equal(t, float32(-1.25), out[0].Bias.Float32())
+}
+
+func TestImplements(t *testing.T) {
+	rows := []implements.Event{
+		{ID: 1, Name: pstring("a")},
+		{ID: 2},
+	}
+
+	// the writer and reader are only used through the interfaces
+	var buf bytes.Buffer
+	pw, err := implements.NewParquetWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w implements.RecordWriter = pw
+	assert.NoError(t, w.AddAny(rows[0]))
+	assert.NoError(t, w.AddAny(&rows[1]))
+	assert.EqualError(t, w.AddAny(3), "can't add a int, the records are Event")
+	assert.EqualError(t, w.AddAny((*implements.Event)(nil)), "can't add a nil *Event")
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, parquet.ErrWriterClosed, w.AddAny(rows[0]))
+
+	pr, err := implements.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r implements.RecordReader = pr
+	var out []implements.Event
+	for r.Next() {
+		var e implements.Event
+		assert.EqualError(t, r.ScanAny(e), "can't scan into a implements.Event, it must be a non-nil *Event")
+		assert.NoError(t, r.ScanAny(&e))
+		out = append(out, e)
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, rows, out)
+
+	// AddAny returns the error of a record that OnAdd rejects
+	errNoName := errors.New("no name")
+	pw, err = implements.NewParquetWriter(&buf, implements.OnAdd(func(e *implements.Event) error {
+		if e.Name == nil {
+			return errNoName
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, pw.AddAny(rows[0]))
+	assert.Equal(t, errNoName, pw.AddAny(rows[1]))
+	assert.NoError(t, pw.Write())
+}
+
+func TestMonomorphic(t *testing.T) {
+	rows := make([]monomorphic.Point, 25)
+	for i := range rows {
+		rows[i] = monomorphic.Point{A: int64(i), B: -int64(i), C: int64(i * i), D: 7}
+	}
+
+	// the rows are split across pages and row groups so that
+	// the fields are replaced as they are written and read
+	var buf bytes.Buffer
+	pw, err := monomorphic.NewParquetWriter(&buf, monomorphic.MaxPageSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range rows {
+		pw.Add(r)
+		if i%10 == 9 {
+			assert.NoError(t, pw.Write())
+		}
+	}
+	assert.NoError(t, pw.Write())
+	assert.NoError(t, pw.Close())
+
+	pr, err := monomorphic.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []monomorphic.Point
+	for i := 0; pr.Next(); i++ {
+		// a row that isn't scanned is skipped
+		if i == 3 {
+			continue
+		}
+		var p monomorphic.Point
+		pr.Scan(&p)
+		out = append(out, p)
+	}
+
+	assert.NoError(t, pr.Error())
+	assert.Equal(t, append(rows[:3:3], rows[4:]...), out)
+}
+
+// monoRows is the number of rows that each op of
+// BenchmarkMonomorphic adds or scans.
+const monoRows = 10000
+
+// BenchmarkMonomorphic compares adding and scanning the rows of
+// the same four int64 columns through the Field interface (dispatch)
+// and with -monomorphic.
+func BenchmarkMonomorphic(b *testing.B) {
+	var dbuf, mbuf bytes.Buffer
+	dw, err := dispatch.NewParquetWriter(&dbuf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	mw, err := monomorphic.NewParquetWriter(&mbuf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < monoRows; i++ {
+		dw.Add(dispatch.Point{A: int64(i), B: int64(i), C: int64(i), D: int64(i)})
+		mw.Add(monomorphic.Point{A: int64(i), B: int64(i), C: int64(i), D: int64(i)})
+	}
+	for _, w := range []interface {
+		Write() error
+		Close() error
+	}{dw, mw} {
+		if err := w.Write(); err != nil {
+			b.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("dispatch/add", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w, err := dispatch.NewParquetWriter(io.Discard, dispatch.MaxPageSize(monoRows))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := 0; j < monoRows; j++ {
+				w.Add(dispatch.Point{A: int64(j), B: int64(j), C: int64(j), D: int64(j)})
+			}
+		}
+	})
+
+	b.Run("monomorphic/add", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w, err := monomorphic.NewParquetWriter(io.Discard, monomorphic.MaxPageSize(monoRows))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := 0; j < monoRows; j++ {
+				w.Add(monomorphic.Point{A: int64(j), B: int64(j), C: int64(j), D: int64(j)})
+			}
+		}
+	})
+
+	b.Run("dispatch/scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := dispatch.NewParquetReader(bytes.NewReader(dbuf.Bytes()))
+			if err != nil {
+				b.Fatal(err)
+			}
+			var p dispatch.Point
+			for r.Next() {
+				r.Scan(&p)
+			}
+			if err := r.Error(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("monomorphic/scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := monomorphic.NewParquetReader(bytes.NewReader(mbuf.Bytes()))
+			if err != nil {
+				b.Fatal(err)
+			}
+			var p monomorphic.Point
+			for r.Next() {
+				r.Scan(&p)
+			}
+			if err := r.Error(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package dremel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
+)
+
+// readMethod generates the code for reading a field that is bound
+// to a get method.  Only fields of the top level struct can be bound
+// to methods, so there is at most one definition level.
+func readMethod(f fields.Field) string {
+	name := strings.Join(f.FieldNames(), "")
+	if !f.Optional() {
+		return fmt.Sprintf(`func read%s(x %s) %s {
+	return x.%s()
+}`, name, f.StructType(), f.TypeName(), f.Getter)
+	}
+
+	return fmt.Sprintf(`func read%s(x %s, vals []%s, defs, reps []uint8) ([]%s, []uint8, []uint8) {
+	switch v := x.%s(); {
+	case v == nil:
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, *v)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
+}`, name, f.StructType(), cleanTypeName(f.Type), cleanTypeName(f.Type), f.Getter)
+}
+
+// writeMethod generates the code for initializing a field that
+// is bound to a set method.
+func writeMethod(f fields.Field) string {
+	name := strings.Join(f.FieldNames(), "")
+	if !f.Optional() {
+		return fmt.Sprintf(`func write%s(x *%s, vals []%s) {
+	x.%s(vals[0])
+}`, name, f.StructType(), f.TypeName(), f.Setter)
+	}
+
+	return fmt.Sprintf(`func write%s(x *%s, vals []%s, defs, reps []uint8) (int, int) {
+	def := defs[0]
+	switch def {
+	case 1:
+		x.%s(%s(vals[0]))
+		return 1, 1
+	}
+
+	return 0, 1
+}`, name, f.StructType(), cleanTypeName(f.Type), f.Setter, fields.PtrFunc(f.Type))
+}
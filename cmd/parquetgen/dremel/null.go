@@ -0,0 +1,83 @@
+package dremel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
+)
+
+// readNull generates the code for reading a field that is null for
+// some other reason than being a nil pointer: it is one of the
+// database/sql Null types or it has the empty_as_null or null_sentinel
+// option.  Like fields that are bound to methods, only fields of the
+// top level struct are supported so there is one definition level.
+func readNull(f fields.Field) string {
+	name := strings.Join(f.FieldNames(), "")
+	isNull, val := nullValue(f)
+	return fmt.Sprintf(`func read%s(x %s, vals []%s, defs, reps []uint8) ([]%s, []uint8, []uint8) {
+	switch {
+	case %s:
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, %s)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
+}`, name, f.StructType(), cleanTypeName(f.Type), cleanTypeName(f.Type), isNull, val)
+}
+
+// writeNull generates the code for initializing a field that
+// readNull reads.
+func writeNull(f fields.Field) string {
+	name := strings.Join(f.FieldNames(), "")
+	val := fmt.Sprintf("%s(vals[0])", fields.PtrFunc(f.Type))
+	switch {
+	case f.Null != "":
+		val = fmt.Sprintf("%s{%s: vals[0], Valid: true}", f.Null, strings.TrimPrefix(f.Null, "sql.Null"))
+	case f.NoPointer:
+		val = "vals[0]"
+	}
+
+	// a null is read as the sentinel
+	var null string
+	if f.NullSentinel != "" {
+		null = fmt.Sprintf("\n\tx.%s = %s", strings.Join(f.FieldNames(), "."), f.NullSentinel)
+	}
+
+	return fmt.Sprintf(`func write%s(x *%s, vals []%s, defs, reps []uint8) (int, int) {
+	def := defs[0]
+	switch def {
+	case 1:
+		x.%s = %s
+		return 1, 1
+	}
+%s
+	return 0, 1
+}`, name, f.StructType(), cleanTypeName(f.Type), strings.Join(f.FieldNames(), "."), val, null)
+}
+
+// nullValue returns the condition that is true if f is null
+// and the expression of f's value when it isn't.
+func nullValue(f fields.Field) (string, string) {
+	fld := "x." + strings.Join(f.FieldNames(), ".")
+	var isNull, val string
+	switch {
+	case f.Null != "":
+		isNull = fmt.Sprintf("!%s.Valid", fld)
+		val = fmt.Sprintf("%s.%s", fld, strings.TrimPrefix(f.Null, "sql.Null"))
+	case f.NullSentinel != "":
+		return fmt.Sprintf("%s == %s", fld, f.NullSentinel), fld
+	case f.NoPointer:
+		return fmt.Sprintf(`%s == ""`, fld), fld
+	default:
+		isNull = fmt.Sprintf("%s == nil", fld)
+		val = "*" + fld
+	}
+
+	if f.EmptyAsNull {
+		isNull = fmt.Sprintf(`%s || %s == ""`, isNull, val)
+	}
+	return isNull, val
+}
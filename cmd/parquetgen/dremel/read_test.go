@@ -42,6 +42,66 @@ func TestRead(t *testing.T) {
 		defs = append(defs, 1)
 		return vals, defs, reps
 	}
+}`,
+		},
+		{
+			name: "required with methods",
+			f: fields.Field{
+				Type: "string", Name: "code", RepetitionType: fields.Required, Getter: "Code", Setter: "SetCode",
+			},
+			result: `func readcode(x Person) string {
+	return x.Code()
+}`,
+		},
+		{
+			name: "optional with methods",
+			f: fields.Field{
+				Type: "int32", Name: "age", RepetitionType: fields.Optional, Getter: "Age", Setter: "SetAge",
+			},
+			result: `func readage(x Person, vals []int32, defs, reps []uint8) ([]int32, []uint8, []uint8) {
+	switch v := x.Age(); {
+	case v == nil:
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, *v)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
+}`,
+		},
+		{
+			name: "sql null type",
+			f: fields.Field{
+				Type: "string", Name: "Name", RepetitionType: fields.Optional, Null: "sql.NullString",
+			},
+			result: `func readName(x Person, vals []string, defs, reps []uint8) ([]string, []uint8, []uint8) {
+	switch {
+	case !x.Name.Valid:
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, x.Name.String)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
+}`,
+		},
+		{
+			name: "empty as null",
+			f: fields.Field{
+				Type: "string", Name: "Name", RepetitionType: fields.Optional, EmptyAsNull: true,
+			},
+			result: `func readName(x Person, vals []string, defs, reps []uint8) ([]string, []uint8, []uint8) {
+	switch {
+	case x.Name == nil || *x.Name == "":
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, *x.Name)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
 }`,
 		},
 		{
@@ -0,0 +1,12 @@
+package dispatch
+
+//go:generate parquetgen -input point.go -type Point -package dispatch -output generated.go
+
+// Point is the same as the monomorphic package's Point, but its
+// fields are added and scanned through the Field interface.
+type Point struct {
+	A int64 `parquet:"a"`
+	B int64 `parquet:"b"`
+	C int64 `parquet:"c"`
+	D int64 `parquet:"d"`
+}
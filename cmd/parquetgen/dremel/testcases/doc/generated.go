@@ -8,13 +8,17 @@ import (
 	"io"
 	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rclayton-godaddy/parquet"
 	sch "github.com/rclayton-godaddy/parquet/schema"
 	"github.com/valyala/bytebufferpool"
 )
 
-var _ = math.MaxInt32 // to avoid unused import
+var _ = math.MaxInt32       // to avoid unused import
+var _ = time.Second         // to avoid unused import
+var _ = binary.LittleEndian // to avoid unused import
 
 type compression int
 
@@ -43,6 +47,90 @@ type ParquetWriter struct {
 	meta        *parquet.Metadata
 	w           io.Writer
 	compression compression
+
+	// dictionary (and sorted) make the string columns dictionary
+	// encoded.  dicts holds the current row group's dictionaries,
+	// which are shared by the row group's pages.
+	dictionary bool
+	sorted     bool
+	dicts      map[string]*parquet.Dictionary
+
+	// delta makes the integer columns DELTA_BINARY_PACKED
+	delta bool
+
+	// deltaLength holds the string columns that are
+	// DELTA_LENGTH_BYTE_ARRAY encoded
+	deltaLength map[string]bool
+
+	// byteBools holds the bool columns that are written
+	// with one byte per value (see ByteBools)
+	byteBools map[string]bool
+
+	// dataPageV2 makes the pages DATA_PAGE_V2
+	dataPageV2 bool
+
+	// truncate is the max length of the string
+	// columns' min and max statistics
+	truncate int
+
+	// statsMode is when the columns compute their statistics
+	statsMode parquet.StatsMode
+
+	// pageIndex writes the offset and column indexes
+	pageIndex bool
+
+	// footerProtocol is the thrift protocol
+	// of the footer (see FooterProtocol)
+	footerProtocol parquet.ThriftProtocol
+
+	// syncMarkers writes a sync marker after
+	// each row group (see SyncMarkers)
+	syncMarkers bool
+
+	// alignment pads the file so that each column
+	// chunk starts at a multiple of it (see PageAlignment)
+	alignment int
+
+	// schemaName is the name of the root of the
+	// footer's schema (see SchemaName)
+	schemaName string
+
+	// schemaOverride replaces the schema of columns
+	// in the footer (see SchemaOverride)
+	schemaOverride map[string]parquet.Field
+
+	// sortingColumns are the columns that the rows are
+	// sorted by in the footer (see SortingColumns)
+	sortingColumns []parquet.SortingColumn
+
+	// onAdd checks (and can change) each record
+	// before it is added (see OnAdd)
+	onAdd func(*Document) error
+
+	// rejected is the number of records that onAdd has rejected
+	// since the last call to Write (or WriteWithMeta), and
+	// rejectedErr is the error of the first of them
+	rejected    int
+	rejectedErr error
+
+	// single holds every row until Close, which writes
+	// them as one row group (see SingleRowGroup)
+	single bool
+
+	// interval is how often the rows that have been added are
+	// written as a row group (see FlushInterval).  mu is held by
+	// Add, Write, and the goroutine that writes the row groups,
+	// stop ends that goroutine (stopOnce makes sure that only one
+	// Close closes it), and flushErr is the error it got.
+	mu       sync.Mutex
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	flushErr error
+
+	// closed is true once Close has been called
+	closed bool
 }
 
 func Fields(compression compression) []Field {
@@ -56,6 +144,27 @@ func Fields(compression compression) []Field {
 	}
 }
 
+// fieldSchema is the schema of the Fields.  It never changes, so it is
+// only built once and is shared (and must not be modified) by every
+// reader and writer.
+var fieldSchema = newFieldSchema()
+
+func newFieldSchema() []parquet.Field {
+	ff := Fields(compressionUnknown)
+	schema := make([]parquet.Field, len(ff))
+	for i, f := range ff {
+		schema[i] = f.Schema()
+	}
+	return schema
+}
+
+// SchemaJSON returns a JSON document that describes the columns of
+// Document (their paths, physical types, nullability, and
+// annotations) for a data dictionary (see parquet.SchemaJSON).
+func SchemaJSON() string {
+	return parquet.SchemaJSON(fieldSchema)
+}
+
 func readDocID(x Document) int64 {
 	return x.DocID
 }
@@ -365,7 +474,17 @@ func optionalFieldCompression(c compression) func(*parquet.OptionalField) {
 }
 
 func NewParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*ParquetWriter, error) {
-	return newParquetWriter(w, append(opts, begin)...)
+	p, err := newParquetWriter(w, append(opts, begin)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.interval > 0 {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.flush()
+	}
+	return p, nil
 }
 
 func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*ParquetWriter, error) {
@@ -373,6 +492,7 @@ func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*Parquet
 		max:         1000,
 		w:           w,
 		compression: compressionSnappy,
+		schemaName:  "Document",
 	}
 
 	for _, opt := range opts {
@@ -381,427 +501,1764 @@ func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*Parquet
 		}
 	}
 
+	if p.single && p.interval > 0 {
+		return nil, fmt.Errorf("SingleRowGroup can't be used with FlushInterval, which writes a row group every %s", p.interval)
+	}
+
 	p.fields = Fields(p.compression)
+	p.setDictionaries()
+	p.setDelta()
+	p.setDeltaLength()
+	p.setByteBools()
+	p.setDataPageV2()
+	p.setStatsTruncateLength()
+	p.setStatsMode()
 	if p.meta == nil {
-		ff := Fields(p.compression)
-		schema := make([]parquet.Field, len(ff))
-		for i, f := range ff {
-			schema[i] = f.Schema()
+		p.meta = parquet.New(fieldSchema...)
+		p.meta.SetSchemaName(p.schemaName)
+	}
+
+	if p.schemaOverride != nil {
+		if err := p.meta.SetSchemaOverride(p.schemaOverride); err != nil {
+			return nil, err
 		}
-		p.meta = parquet.New(schema...)
 	}
 
+	if len(p.sortingColumns) > 0 {
+		if err := p.meta.SetSortingColumns(p.sortingColumns...); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.pageIndex {
+		p.meta.WritePageIndex()
+	}
+
+	if err := p.meta.SetFooterProtocol(p.footerProtocol); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
 // MaxPageSize is the maximum number of rows in each row groups' page.
+// It must be at least 1.
 func MaxPageSize(m int) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
+		if m < 1 {
+			return fmt.Errorf("invalid max page size %d, it must be at least 1", m)
+		}
 		p.max = m
 		return nil
 	}
 }
 
-var par1 = []byte("PAR1")
-
-func begin(p *ParquetWriter) error {
-	_, err := p.w.Write(par1)
-	return err
+// PageAlignment pads the file with zeros so that each column chunk
+// starts at a multiple of n bytes (4096 lines them up with the pages
+// of a memory mapped file, for example).  The footer has the chunks'
+// true offsets and sizes, so readers skip the padding.  n must be at
+// least 1 (1 doesn't pad anything).
+func PageAlignment(n int) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if n < 1 {
+			return fmt.Errorf("invalid page alignment %d, it must be at least 1", n)
+		}
+		p.alignment = n
+		return nil
+	}
 }
 
-func withMeta(m *parquet.Metadata) func(*ParquetWriter) error {
+// SchemaName sets the name of the root of the schema in the footer
+// (the message name), which some tools show or check.  It is the name
+// of the type (Document) by default.
+func SchemaName(name string) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
-		p.meta = m
+		if name == "" {
+			return fmt.Errorf("invalid schema name, it can't be empty")
+		}
+		p.schemaName = name
 		return nil
 	}
 }
 
-func Uncompressed(p *ParquetWriter) error {
-	p.compression = compressionUncompressed
-	return nil
+// SchemaOverride replaces the schema of the columns (keyed by their
+// dotted paths) in the footer with the fields of overrides, for a file
+// that has to match a schema that the generated one doesn't (an int64
+// that is annotated as a TIMESTAMP, or a column with another name, for
+// example).  The values are written the same way, so an override can't
+// change a column's physical type or repetition types.  The fields of
+// an override that are left empty are the column's own (see
+// parquet.Metadata.SetSchemaOverride).  A file with a renamed column
+// can't be read by the generated reader.
+func SchemaOverride(overrides map[string]parquet.Field) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.schemaOverride = overrides
+		return nil
+	}
 }
 
-func Snappy(p *ParquetWriter) error {
-	p.compression = compressionSnappy
-	return nil
+// SortingColumns records in the footer that the rows of each row group
+// are sorted by cols (see parquet.Metadata.SetSortingColumns), so that
+// readers can rely on the order.  It doesn't sort the rows, which must
+// be added in that order (see SortedWriter, which parquetgen generates
+// with -sorted).
+func SortingColumns(cols ...parquet.SortingColumn) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.sortingColumns = cols
+		return nil
+	}
 }
 
-func Gzip(p *ParquetWriter) error {
-	p.compression = compressionGzip
-	return nil
+// OnAdd calls fn with each record that is passed to Add before the
+// record is added, so that records can be checked (or changed, since
+// the record that fn changes is the one that is added) in one place.  A
+// record that fn returns an error for isn't added.  Add returns the
+// error, and so that it isn't lost if Add's error isn't checked, the
+// next call to Write, WriteWithMeta, or Close returns it (with the
+// number of records that were rejected) once the records that were
+// added have been written.  The rows that Commit adds aren't records, so fn isn't
+// called for them.
+func OnAdd(fn func(*Document) error) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.onAdd = fn
+		return nil
+	}
 }
 
-func withCompression(c compression) func(*ParquetWriter) error {
+// FlushInterval writes the rows that have been added as a row group
+// every d, so a slow stream of records doesn't sit in memory (and out
+// of the file) until enough of them have been added.  Nothing is written
+// if no rows have been added since the last row group.  The writing
+// happens in a goroutine that is stopped by Close, and Add and Write
+// are safe to call while it runs.  An error that the goroutine gets
+// is returned by the next call to Write or Close.
+func FlushInterval(d time.Duration) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
-		p.compression = c
+		if d <= 0 {
+			return fmt.Errorf("invalid flush interval %s, it must be greater than 0", d)
+		}
+		p.interval = d
 		return nil
 	}
 }
 
-func (p *ParquetWriter) Write() error {
-	for i, f := range p.fields {
-		if err := f.Write(p.w, p.meta); err != nil {
-			return err
-		}
+// SingleRowGroup writes every row as one row group when the writer is
+// closed, for readers that are slow with many small row groups (or to
+// make sure that a sorted column is sorted across the whole file).
+// Write doesn't write anything (WriteWithMeta only sets the row
+// group's metadata), so every row is held in memory until Close.  It
+// can't be used with FlushInterval.
+func SingleRowGroup(p *ParquetWriter) error {
+	p.single = true
+	return nil
+}
 
-		for child := p.child; child != nil; child = child.child {
-			if err := child.fields[i].Write(p.w, p.meta); err != nil {
-				return err
+func (p *ParquetWriter) flush() {
+	defer close(p.done)
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.mu.Lock()
+			if p.len > 0 && p.flushErr == nil {
+				p.flushErr = p.write()
 			}
+			p.mu.Unlock()
 		}
 	}
+}
 
-	p.fields = Fields(p.compression)
-	p.child = nil
-	p.len = 0
+func begin(p *ParquetWriter) error {
+	return parquet.WriteHeader(p.w)
+}
 
-	schema := make([]parquet.Field, len(p.fields))
-	for i, f := range p.fields {
-		schema[i] = f.Schema()
-	}
-	p.meta.StartRowGroup(schema...)
+// Dictionary dictionary encodes the string columns.  The distinct
+// values of each column chunk are written once, in the chunk's
+// dictionary page, and the data pages only hold indices into it.
+func Dictionary(p *ParquetWriter) error {
+	p.dictionary = true
 	return nil
 }
 
-func (p *ParquetWriter) Close() error {
-	if err := p.meta.Footer(p.w); err != nil {
-		return err
-	}
+// SortedDictionary is like Dictionary but the values in each
+// dictionary page are sorted, and the page is marked as sorted.
+func SortedDictionary(p *ParquetWriter) error {
+	p.dictionary = true
+	p.sorted = true
+	return nil
+}
 
-	_, err := p.w.Write(par1)
-	return err
+func withDictionaries(dicts map[string]*parquet.Dictionary, sorted bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.dictionary = true
+		p.sorted = sorted
+		p.dicts = dicts
+		return nil
+	}
 }
 
-func (p *ParquetWriter) Add(rec Document) {
-	if p.len == p.max {
-		if p.child == nil {
-			// an error can't happen here
-			p.child, _ = newParquetWriter(p.w, MaxPageSize(p.max), withMeta(p.meta), withCompression(p.compression))
-		}
+type dictionaryField interface {
+	SetDictionary(*parquet.Dictionary)
+}
 
-		p.child.Add(rec)
+// setDictionaries gives each string field the dictionary of its
+// column (every page of a column chunk shares one dictionary).
+func (p *ParquetWriter) setDictionaries() {
+	if !p.dictionary {
 		return
 	}
 
-	p.meta.NextDoc()
-	for _, f := range p.fields {
-		f.Add(rec)
+	if p.dicts == nil {
+		p.dicts = map[string]*parquet.Dictionary{}
 	}
 
-	p.len++
+	for _, f := range p.fields {
+		df, ok := f.(dictionaryField)
+		if !ok {
+			continue
+		}
+
+		d, ok := p.dicts[f.Name()]
+		if !ok {
+			d = parquet.NewDictionary(p.sorted)
+			p.dicts[f.Name()] = d
+		}
+		df.SetDictionary(d)
+	}
 }
 
-type Field interface {
-	Add(r Document)
-	Write(w io.Writer, meta *parquet.Metadata) error
-	Schema() parquet.Field
-	Scan(r *Document)
-	Read(r io.ReadSeeker, pg parquet.Page) error
-	Name() string
-	Levels() ([]uint8, []uint8)
+// Delta writes the int32, int64, uint32, and uint64 columns with the
+// DELTA_BINARY_PACKED encoding, which is much smaller than PLAIN for
+// sorted or slowly changing values (like ids or counters).
+func Delta(p *ParquetWriter) error {
+	p.delta = true
+	return nil
 }
 
-func getFields(ff []Field) map[string]Field {
-	m := make(map[string]Field, len(ff))
-	for _, f := range ff {
-		m[f.Name()] = f
-	}
-	return m
+type deltaField interface {
+	SetDelta()
 }
 
-func NewParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
-	ff := Fields(compressionUnknown)
-	pr := &ParquetReader{
-		r: r,
+func (p *ParquetWriter) setDelta() {
+	if !p.delta {
+		return
 	}
 
-	for _, opt := range opts {
-		opt(pr)
+	for _, f := range p.fields {
+		if df, ok := f.(deltaField); ok {
+			df.SetDelta()
+		}
 	}
+}
 
-	schema := make([]parquet.Field, len(ff))
-	for i, f := range ff {
-		pr.fieldNames = append(pr.fieldNames, f.Name())
-		schema[i] = f.Schema()
-	}
+// DeltaLength writes the string columns cols (or every string column
+// if there are none) with the DELTA_LENGTH_BYTE_ARRAY encoding.  The
+// lengths of the values are written together instead of before each
+// value, which is much smaller for columns that are often empty.  These
+// columns aren't dictionary encoded.
+func DeltaLength(cols ...string) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		strs := map[string]bool{}
+		for _, f := range Fields(compressionUnknown) {
+			if _, ok := f.(deltaLengthField); ok {
+				strs[f.Name()] = true
+			}
+		}
 
-	meta := parquet.New(schema...)
-	if err := meta.ReadFooter(r); err != nil {
-		return nil, err
-	}
-	pr.rows = meta.Rows()
-	var err error
-	pr.pages, err = meta.Pages()
-	if err != nil {
-		return nil, err
-	}
+		if len(cols) == 0 {
+			p.deltaLength = strs
+			return nil
+		}
 
-	pr.rowGroups = meta.RowGroups()
-	_, err = r.Seek(4, io.SeekStart)
-	if err != nil {
-		return nil, err
+		p.deltaLength = map[string]bool{}
+		for _, col := range cols {
+			if !strs[col] {
+				return fmt.Errorf("%s isn't a string column", col)
+			}
+			p.deltaLength[col] = true
+		}
+		return nil
 	}
-	pr.meta = meta
-
-	return pr, pr.readRowGroup()
 }
 
-func readerIndex(i int) func(*ParquetReader) {
-	return func(p *ParquetReader) {
-		p.index = i
+func withDeltaLength(cols map[string]bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.deltaLength = cols
+		return nil
 	}
 }
 
-// ParquetReader reads one page from a row group.
-type ParquetReader struct {
-	fields         map[string]Field
-	fieldNames     []string
-	index          int
-	cursor         int64
-	rows           int64
-	rowGroupCursor int64
-	rowGroupCount  int64
-	pages          map[string][]parquet.Page
-	meta           *parquet.Metadata
-	err            error
+type deltaLengthField interface {
+	SetDeltaLength()
+}
 
-	r         io.ReadSeeker
-	rowGroups []parquet.RowGroup
+func (p *ParquetWriter) setDeltaLength() {
+	for _, f := range p.fields {
+		if df, ok := f.(deltaLengthField); ok && p.deltaLength[f.Name()] {
+			df.SetDeltaLength()
+		}
+	}
 }
 
-type Levels struct {
-	Name string
-	Defs []uint8
-	Reps []uint8
+// ByteBools writes the bool columns cols (or every bool column if
+// there are none) with one byte (0 or 1) per value instead of one bit,
+// for a reader that can't read bit packed bools.  This isn't the PLAIN
+// encoding of a BOOLEAN (even though the pages say that it is), so
+// other readers can't read these columns, and this package's readers
+// only can with the ReadByteBools option.
+func ByteBools(cols ...string) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		var err error
+		p.byteBools, err = byteBoolColumns(cols)
+		return err
+	}
 }
 
-func (p *ParquetReader) Levels() []Levels {
-	var out []Levels
-	//for {
-	for _, name := range p.fieldNames {
-		f := p.fields[name]
-		d, r := f.Levels()
-		out = append(out, Levels{Name: f.Name(), Defs: d, Reps: r})
+func withByteBools(cols map[string]bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.byteBools = cols
+		return nil
 	}
-	//	if err := p.readRowGroup(); err != nil {
-	//		break
-	//	}
-	//}
-	return out
 }
 
-func (p *ParquetReader) Error() error {
-	return p.err
+type byteBoolField interface {
+	SetByteBools()
 }
 
-func (p *ParquetReader) readRowGroup() error {
-	p.rowGroupCursor = 0
+// byteBoolColumns returns the columns of ByteBools (or ReadByteBools),
+// which must be bool columns.
+func byteBoolColumns(cols []string) (map[string]bool, error) {
+	bools := map[string]bool{}
+	for _, f := range Fields(compressionUnknown) {
+		if _, ok := f.(byteBoolField); ok {
+			bools[f.Name()] = true
+		}
+	}
 
-	if len(p.rowGroups) == 0 {
-		p.rowGroupCount = 0
-		return nil
+	if len(cols) == 0 {
+		return bools, nil
 	}
 
-	rg := p.rowGroups[0]
-	p.fields = getFields(Fields(compressionUnknown))
-	p.rowGroupCount = rg.Rows
-	p.rowGroupCursor = 0
-	for _, col := range rg.Columns() {
-		name := strings.Join(col.MetaData.PathInSchema, ".")
-		f, ok := p.fields[name]
-		if !ok {
-			return fmt.Errorf("unknown field: %s", name)
-		}
-		pages := p.pages[name]
-		if len(pages) <= p.index {
-			break
+	out := map[string]bool{}
+	for _, col := range cols {
+		if !bools[col] {
+			return nil, fmt.Errorf("%s isn't a bool column", col)
 		}
+		out[col] = true
+	}
+	return out, nil
+}
 
-		pg := pages[0]
-		if err := f.Read(p.r, pg); err != nil {
-			return fmt.Errorf("unable to read field %s, err: %s", f.Name(), err)
-		}
-		p.pages[name] = p.pages[name][1:]
+// setByteBools makes f write (or read) one byte per value if it
+// is one of cols.
+func setByteBools(f Field, cols map[string]bool) {
+	if bf, ok := f.(byteBoolField); ok && cols[f.Name()] {
+		bf.SetByteBools()
 	}
-	p.rowGroups = p.rowGroups[1:]
-	return nil
 }
 
-func (p *ParquetReader) Rows() int64 {
-	return p.rows
+func (p *ParquetWriter) setByteBools() {
+	for _, f := range p.fields {
+		setByteBools(f, p.byteBools)
+	}
 }
 
-func (p *ParquetReader) Next() bool {
-	if p.err == nil && p.cursor >= p.rows {
-		return false
+// StatsTruncateLength truncates the min and max statistics of the
+// string columns to at most n bytes, which keeps the page headers of
+// columns with long values small.  A truncated max is incremented so
+// that it is still greater than or equal to every value of the page.
+func StatsTruncateLength(n int) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if n < 1 {
+			return fmt.Errorf("invalid stats truncate length %d, it must be at least 1", n)
+		}
+		p.truncate = n
+		return nil
 	}
-	if p.rowGroupCursor >= p.rowGroupCount {
-		p.err = p.readRowGroup()
-		if p.err != nil {
+}
+
+type statsTruncateField interface {
+	SetStatsTruncateLength(int)
+}
+
+func (p *ParquetWriter) setStatsTruncateLength() {
+	if p.truncate == 0 {
+		return
+	}
+
+	for _, f := range p.fields {
+		if sf, ok := f.(statsTruncateField); ok {
+			sf.SetStatsTruncateLength(p.truncate)
+		}
+	}
+}
+
+// Statistics sets when the columns compute the statistics (min, max,
+// and null count) of their pages.  With parquet.StatsOnAdd (the
+// default) they are updated as each row is added.  parquet.StatsOnWrite
+// computes them in one pass over a page's values when it is written,
+// which makes Add cheaper.
+func Statistics(mode parquet.StatsMode) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if mode != parquet.StatsOnAdd && mode != parquet.StatsOnWrite {
+			return fmt.Errorf("invalid stats mode %d", mode)
+		}
+		p.statsMode = mode
+		return nil
+	}
+}
+
+type statsModeField interface {
+	SetStatsMode(parquet.StatsMode)
+}
+
+func (p *ParquetWriter) setStatsMode() {
+	if p.statsMode == parquet.StatsOnAdd {
+		return
+	}
+
+	for _, f := range p.fields {
+		if sf, ok := f.(statsModeField); ok {
+			sf.SetStatsMode(p.statsMode)
+		}
+	}
+}
+
+// PageIndex writes (if enabled is true) the offset index and column
+// index of each column chunk between the last row group and the footer.
+// The indexes have the location, first row, min, and max of every page,
+// so a reader can skip the pages it doesn't need.
+func PageIndex(enabled bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.pageIndex = enabled
+		return nil
+	}
+}
+
+// FooterProtocol sets the thrift protocol that the footer is written
+// with.  The default, parquet.CompactProtocol, is the one the spec
+// requires.  parquet.BinaryProtocol is only for debugging: other
+// readers (and ParquetReader) can't read a file whose footer uses it.
+func FooterProtocol(proto parquet.ThriftProtocol) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.footerProtocol = proto
+		return nil
+	}
+}
+
+// SyncMarkers writes (if enabled is true) a sync marker after each row
+// group, which is a copy of the footer of the row groups written so far.
+// If the writer never gets to Close (the process crashes, for example),
+// parquet.Recover rebuilds the footer from the last sync marker so the
+// row groups that were written can still be read.
+func SyncMarkers(enabled bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.syncMarkers = enabled
+		return nil
+	}
+}
+
+// DataPageV2 writes DATA_PAGE_V2 pages instead of DATA_PAGE pages.
+// The header of a v2 page has the number of nulls and rows in the
+// page, along with the page's statistics (min, max, and null count),
+// and the page's levels aren't compressed.
+func DataPageV2(p *ParquetWriter) error {
+	p.dataPageV2 = true
+	return nil
+}
+
+type dataPageV2Field interface {
+	SetDataPageV2()
+}
+
+func (p *ParquetWriter) setDataPageV2() {
+	if !p.dataPageV2 {
+		return
+	}
+
+	for _, f := range p.fields {
+		if df, ok := f.(dataPageV2Field); ok {
+			df.SetDataPageV2()
+		}
+	}
+}
+
+func withMeta(m *parquet.Metadata) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.meta = m
+		return nil
+	}
+}
+
+func Uncompressed(p *ParquetWriter) error {
+	p.compression = compressionUncompressed
+	return nil
+}
+
+func Snappy(p *ParquetWriter) error {
+	p.compression = compressionSnappy
+	return nil
+}
+
+func Gzip(p *ParquetWriter) error {
+	p.compression = compressionGzip
+	return nil
+}
+
+func withCompression(c compression) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.compression = c
+		return nil
+	}
+}
+
+// Write writes the rows that have been added as a row group.  The
+// column chunks are written one at a time, each page is written to
+// the io.Writer as soon as it is encoded, and a column's values are
+// let go of once its chunk is written.  So, while the row group is
+// written, the memory that is used shrinks with each column instead
+// of holding every column until the end.
+// Nothing is written (and an error is returned) if a column doesn't
+// have the same number of rows as the others.
+func (p *ParquetWriter) Write() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if !p.single {
+		if err := p.write(); err != nil {
+			return err
+		}
+	}
+	return p.rejection()
+}
+
+// WriteWithMeta writes the rows that have been added as a row group
+// (see Write) with the key/value metadata meta, which can be read with
+// ParquetReader.RowGroupMetadata.  See parquet.RowGroupMetadataPrefix
+// for how it is stored.
+func (p *ParquetWriter) WriteWithMeta(meta map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if err := p.checkRows(); err != nil {
+		return err
+	}
+
+	if p.len > 0 {
+		p.meta.SetRowGroupMetadata(meta)
+		if !p.single {
+			if err := p.write(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.rejection()
+}
+
+// rejection returns the error of the records that OnAdd rejected
+// (if it rejected any), which it only returns once.
+func (p *ParquetWriter) rejection() error {
+	if p.rejected == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("%d record(s) rejected by OnAdd, the first with: %w", p.rejected, p.rejectedErr)
+	p.rejected, p.rejectedErr = 0, nil
+	return err
+}
+
+func (p *ParquetWriter) write() error {
+	if err := p.checkRows(); err != nil {
+		return err
+	}
+
+	// the footer leaves out a row group without rows,
+	// so its (empty) pages mustn't be written
+	if p.len == 0 {
+		return nil
+	}
+
+	// the writer is reset before the columns are written so that
+	// nothing but chunks refers to the values that are written
+	chunks := [][]Field{p.fields}
+	rows := p.len
+	for child := p.child; child != nil; child = child.child {
+		chunks = append(chunks, child.fields)
+		rows += child.len
+	}
+
+	p.fields = Fields(p.compression)
+	p.child = nil
+	p.len = 0
+	if p.dictionary {
+		p.dicts = nil
+		p.setDictionaries()
+	}
+	p.setDelta()
+	p.setDeltaLength()
+	p.setByteBools()
+	p.setDataPageV2()
+	p.setStatsTruncateLength()
+	p.setStatsMode()
+
+	for i := range chunks[0] {
+		if err := p.meta.Align(p.w, chunks[0][i].Name(), p.alignment); err != nil {
+			return err
+		}
+
+		for _, fields := range chunks {
+			if err := fields[i].Write(p.w, p.meta); err != nil {
+				return err
+			}
+			fields[i] = nil
+		}
+	}
+
+	if err := p.meta.EndRowGroup(int64(rows)); err != nil {
+		return err
+	}
+
+	if p.syncMarkers {
+		if err := p.meta.WriteSyncMarker(p.w); err != nil {
+			return err
+		}
+	}
+
+	p.meta.StartRowGroup(fieldSchema...)
+	return nil
+}
+
+// checkRows makes sure that every column has a value (or levels) for
+// each of the rows that have been added, so a row group isn't written
+// with columns of different lengths.
+func (p *ParquetWriter) checkRows() error {
+	for w := p; w != nil; w = w.child {
+		for _, f := range w.fields {
+			if n := f.Rows(); n != w.len {
+				return fmt.Errorf("column %s has %d rows but %d rows have been added", f.Name(), n, w.len)
+			}
+		}
+	}
+	return nil
+}
+
+// Close writes the footer (it doesn't write the rows that have been
+// added since the last row group was written, unless the writer was
+// made with SingleRowGroup) and stops the goroutine started by
+// FlushInterval.  Once it has been called the writer can't be used:
+// Add, Write, WriteWithMeta, and Close return parquet.ErrWriterClosed.
+func (p *ParquetWriter) Close() error {
+	// the goroutine is stopped before mu is held, because it
+	// holds mu while it writes a row group
+	p.stopOnce.Do(func() {
+		if p.stop != nil {
+			close(p.stop)
+			<-p.done
+		}
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+	p.closed = true
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if p.single {
+		if err := p.write(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.meta.WriteTrailer(p.w); err != nil {
+		return err
+	}
+	return p.rejection()
+}
+
+// Add adds rec to the row group that is being written (see
+// MaxPageSize).  After Write has written a row group, the records that
+// are added go into the next one.  After Close, rec isn't added and Add
+// returns parquet.ErrWriterClosed.  It returns the error of a record
+// that OnAdd rejects too, which the next call to Write, WriteWithMeta,
+// or Close also returns.
+func (p *ParquetWriter) Add(rec Document) error {
+	err := p.tryAdd(rec)
+	if err != nil && err != parquet.ErrWriterClosed {
+		p.mu.Lock()
+		p.reject(err)
+		p.mu.Unlock()
+	}
+	return err
+}
+
+// reject records that OnAdd rejected a record with err.
+func (p *ParquetWriter) reject(err error) {
+	if p.rejected == 0 {
+		p.rejectedErr = err
+	}
+	p.rejected++
+}
+
+// tryAdd adds rec unless OnAdd rejects it, and returns OnAdd's error
+// (or parquet.ErrWriterClosed if the writer is closed).
+func (p *ParquetWriter) tryAdd(rec Document) error {
+	if p.onAdd != nil {
+		if err := p.onAdd(&rec); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+
+	p.add(rec)
+	return nil
+}
+
+func (p *ParquetWriter) add(rec Document) {
+	if p.len == p.max {
+		if p.child == nil {
+			p.child = p.newChild()
+		}
+
+		p.child.add(rec)
+		return
+	}
+
+	p.meta.NextDoc()
+	for _, f := range p.fields {
+		f.Add(rec)
+	}
+
+	p.len++
+}
+
+// newChild returns the writer of the page of rows that comes after
+// p's (see MaxPageSize), which has the same options as p.
+func (p *ParquetWriter) newChild() *ParquetWriter {
+	// an error can't happen here
+	opts := []func(*ParquetWriter) error{MaxPageSize(p.max), withMeta(p.meta), withCompression(p.compression)}
+	if p.dictionary {
+		opts = append(opts, withDictionaries(p.dicts, p.sorted))
+	}
+	if p.delta {
+		opts = append(opts, Delta)
+	}
+	if p.deltaLength != nil {
+		opts = append(opts, withDeltaLength(p.deltaLength))
+	}
+	if p.byteBools != nil {
+		opts = append(opts, withByteBools(p.byteBools))
+	}
+	if p.dataPageV2 {
+		opts = append(opts, DataPageV2)
+	}
+	if p.truncate > 0 {
+		opts = append(opts, StatsTruncateLength(p.truncate))
+	}
+	if p.statsMode != parquet.StatsOnAdd {
+		opts = append(opts, Statistics(p.statsMode))
+	}
+	child, _ := newParquetWriter(p.w, opts...)
+	return child
+}
+
+// SuggestRowGroupRows returns the number of rows of a row group that
+// is about targetBytes bytes.  It writes sample with opts (which
+// should be the options that the row groups will be written with,
+// since compression and encodings change how big they are) and scales
+// the sample's size per row up to targetBytes.  The sample should be
+// big enough (and varied enough) to look like the real data.  The
+// number of rows is at least 1.  It returns an error if the sample
+// isn't written as a row group by Write, as with SingleRowGroup.
+func SuggestRowGroupRows(sample []Document, targetBytes int64, opts ...func(*ParquetWriter) error) (int, error) {
+	if len(sample) == 0 {
+		return 0, fmt.Errorf("the sample must have at least 1 row")
+	}
+
+	if targetBytes < 1 {
+		return 0, fmt.Errorf("invalid target size %d, it must be at least 1", targetBytes)
+	}
+
+	cw := &countWriter{w: io.Discard}
+	pw, err := NewParquetWriter(cw, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	// the leading marker isn't part of the row group
+	start := cw.n
+	for _, rec := range sample {
+		pw.Add(rec)
+	}
+
+	if err := pw.Write(); err != nil {
+		return 0, err
+	}
+
+	size := cw.n - start
+	if err := pw.Close(); err != nil {
+		return 0, err
+	}
+
+	if size == 0 {
+		return 0, fmt.Errorf("the sample wasn't written by Write, so its size is unknown (SingleRowGroup can't be used)")
+	}
+
+	n := int(float64(targetBytes) * float64(len(sample)) / float64(size))
+	if n < 1 {
+		return 1, nil
+	}
+	return n, nil
+}
+
+// countWriter keeps track of the number of bytes
+// written to a file.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type Field interface {
+	Add(r Document)
+	Write(w io.Writer, meta *parquet.Metadata) error
+	Schema() parquet.Field
+	Scan(r *Document)
+	Read(r io.ReadSeeker, pg parquet.Page) error
+	Name() string
+	Levels() ([]uint8, []uint8)
+	Rows() int
+	SetAllocator(parquet.Allocator)
+	SetBuffers(*parquet.FieldBuffers)
+	SetInterner(*parquet.Interner)
+	Value() (interface{}, bool)
+}
+
+func getFields(ff []Field) map[string]Field {
+	m := make(map[string]Field, len(ff))
+	for _, f := range ff {
+		m[f.Name()] = f
+	}
+	return m
+}
+
+func NewParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	pr, err := newParquetReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return pr, pr.readRowGroup()
+}
+
+// newParquetReader reads the footer (but none of the row groups).
+func newParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	ff := Fields(compressionUnknown)
+	pr := &ParquetReader{
+		r: r,
+	}
+
+	for _, opt := range opts {
+		opt(pr)
+	}
+
+	if pr.readByteBools {
+		var err error
+		if pr.byteBools, err = byteBoolColumns(pr.byteBoolCols); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range ff {
+		pr.fieldNames = append(pr.fieldNames, f.Name())
+	}
+
+	meta := parquet.New(fieldSchema...)
+	if err := meta.ReadFooter(r); err != nil {
+		return nil, err
+	}
+	if pr.requireExact {
+		if err := meta.RequireSchema(pr.exactSchema); err != nil {
+			return nil, err
+		}
+	}
+	if pr.maxPageBytes > 0 {
+		meta.SetMaxPageBytes(pr.maxPageBytes)
+	}
+	meta.SetLenient(pr.lenient)
+	meta.SetDecimalAsFloat(pr.decimalAsFloat)
+	pr.rows = meta.Rows()
+	if pr.limited && pr.limit < pr.rows {
+		pr.rows = pr.limit
+		if pr.rows < 0 {
+			pr.rows = 0
+		}
+	}
+	var err error
+	pr.pages, err = meta.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pr.rowGroups = meta.RowGroups()
+	_, err = r.Seek(4, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	pr.meta = meta
+	return pr, nil
+}
+
+// SafeRead reads every record from r.  It is meant for files that
+// come from an untrusted source: the sizes and counts in a malformed
+// file are checked before they are used, so it returns an error
+// instead of causing a panic (or a huge allocation).
+func SafeRead(r io.ReadSeeker, opts ...func(*ParquetReader)) ([]Document, error) {
+	pr, err := NewParquetReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Document
+	for pr.Next() {
+		var x Document
+		pr.Scan(&x)
+		out = append(out, x)
+	}
+	return out, pr.Error()
+}
+
+// WithAllocator sets the parquet.Allocator that page data is
+// decoded into.
+func WithAllocator(a parquet.Allocator) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.alloc = a
+	}
+}
+
+// WithBuffers sets the parquet.FieldBuffers that each row group's
+// values and levels are decoded into.  Passing the same FieldBuffers
+// to the readers of files that have the same schema (one after
+// another) reuses the slices instead of allocating them for each
+// file.  The buffers aren't used by the reader's ColumnReaders.
+func WithBuffers(b *parquet.FieldBuffers) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.buffers = b
+	}
+}
+
+// Limit caps the number of rows that are read.  Row groups
+// past the limit are never read.  Limit(0) (or a negative n)
+// reads no rows, so a limit that is computed can be 0.
+func Limit(n int64) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.limit = n
+		p.limited = true
+	}
+}
+
+// MaxPageBytes sets the size of the largest (decompressed) page that
+// is read.  A page that is larger (a decompression bomb in a file from
+// an untrusted source, for example) returns an error before it is
+// allocated.  It is parquet.DefaultMaxPageBytes if n isn't at least 1.
+func MaxPageBytes(n int32) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.maxPageBytes = n
+	}
+}
+
+// Lenient reads a page that claims to be snappy compressed, but
+// isn't valid snappy and is the size of the uncompressed page, as
+// though it wasn't compressed.  Some writers leave pages uncompressed
+// without saying so, and without Lenient those files can't be read.
+// It also reads a page that was compressed with snappy's framing
+// format instead of the block format that parquet uses.
+func Lenient(p *ParquetReader) {
+	p.lenient = true
+}
+
+// InternStrings makes the values of string columns that are the same
+// share their memory (see parquet.Interner), which saves a lot of heap
+// when a column only has a few distinct values.  Each reader has its
+// own table of strings, which is dropped along with the reader.
+func InternStrings(p *ParquetReader) {
+	p.interner = parquet.NewInterner()
+}
+
+// DecimalAsFloat reads the INT32 and INT64 columns that are annotated
+// as DECIMAL into float fields as the unscaled value divided by
+// 10^scale (the column's scale in the file's schema).  The values are
+// approximate, so a DECIMAL column that needs to be exact shouldn't
+// be read this way.
+func DecimalAsFloat(p *ParquetReader) {
+	p.decimalAsFloat = true
+}
+
+// IgnoreUnknownColumns skips the columns of a parquet file that
+// aren't part of the reader's type instead of returning a
+// parquet.UnknownColumnError.  This allows a file to be read
+// into a struct that only has a subset of its columns.
+func IgnoreUnknownColumns(p *ParquetReader) {
+	p.ignoreUnknown = true
+}
+
+// ReadByteBools reads the bool columns cols (or every bool column if
+// there are none) of a file that was written with the ByteBools option,
+// which stores one byte per value instead of one bit.  Nothing in the
+// file says that it was, so the same columns must be given here.
+func ReadByteBools(cols ...string) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.byteBoolCols = cols
+		p.readByteBools = true
+	}
+}
+
+// RequireExactSchema makes NewParquetReader return a
+// *parquet.SchemaMismatchError, before it reads anything but the
+// footer, unless the file's columns are exactly fields (see
+// parquet.Metadata.RequireSchema).  Without it a file can leave out
+// some of the reader's columns (they are zero when scanned), and with
+// IgnoreUnknownColumns it can have others, so this is for files that
+// must have nothing but the expected columns.
+func RequireExactSchema(fields []parquet.Field) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.exactSchema = fields
+		p.requireExact = true
+	}
+}
+
+func readerIndex(i int) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.index = i
+	}
+}
+
+// ParquetReader reads one page from a row group.
+type ParquetReader struct {
+	fields         map[string]Field
+	fieldNames     []string
+	index          int
+	cursor         int64
+	rows           int64
+	rowGroupCursor int64
+	rowGroupCount  int64
+	pages          map[string][]parquet.Page
+	meta           *parquet.Metadata
+	err            error
+	alloc          parquet.Allocator
+	buffers        *parquet.FieldBuffers
+	interner       *parquet.Interner
+	limit          int64
+	limited        bool
+	maxPageBytes   int32
+	ignoreUnknown  bool
+	lenient        bool
+	requireExact   bool
+	exactSchema    []parquet.Field
+	decimalAsFloat bool
+
+	// byteBools holds the bool columns that are read with one
+	// byte per value (see ReadByteBools)
+	readByteBools bool
+	byteBoolCols  []string
+	byteBools     map[string]bool
+
+	// unscanned is true if the row that Next read hasn't
+	// been scanned, so the fields' next values are its values.
+	unscanned bool
+
+	// scanned is the cursor of the row that Scan
+	// set last (0 before the first one, see Pos)
+	scanned int64
+
+	r         io.ReadSeeker
+	rowGroups []parquet.RowGroup
+}
+
+type Levels struct {
+	Name string
+	Defs []uint8
+	Reps []uint8
+}
+
+func (p *ParquetReader) Levels() []Levels {
+	var out []Levels
+	//for {
+	for _, name := range p.fieldNames {
+		f := p.fields[name]
+		d, r := f.Levels()
+		out = append(out, Levels{Name: f.Name(), Defs: d, Reps: r})
+	}
+	//	if err := p.readRowGroup(); err != nil {
+	//		break
+	//	}
+	//}
+	return out
+}
+
+func (p *ParquetReader) Error() error {
+	return p.err
+}
+
+// ColumnChunkLocation returns the byte offset and length of a
+// column chunk within the parquet file.
+func (p *ParquetReader) ColumnChunkLocation(rg int, col string) (offset, length int64, err error) {
+	return p.meta.ColumnChunkLocation(rg, col)
+}
+
+// ColumnCompressedSize returns the size (in bytes) of col's pages in
+// every row group, which is how much of the file reading the column
+// reads (see parquet.Metadata.ColumnCompressedSize).
+func (p *ParquetReader) ColumnCompressedSize(col string) (int64, error) {
+	return p.meta.ColumnCompressedSize(col)
+}
+
+// PageIndex returns the pages of col in row group rg as they are
+// described by the file's offset and column indexes (nil if the file
+// doesn't have them).  It seeks back to where it was, so it can be
+// used while the ParquetReader is being read.
+func (p *ParquetReader) PageIndex(rg int, col string) ([]parquet.IndexedPage, error) {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := p.meta.PageIndex(p.r, rg, col)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return pages, err
+}
+
+// PageSkip calls skip with each of col's pages (in the row groups
+// that have a page index) and returns the pages that can be skipped
+// (the ones skip returned true for) by row group.  For example, skip
+// could compare each page's Min and Max to the values of a query.
+func (p *ParquetReader) PageSkip(col string, skip func(parquet.IndexedPage) bool) ([][]parquet.IndexedPage, error) {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := p.meta.PageSkip(p.r, col, skip)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return pages, err
+}
+
+// RowGroupMetadata returns the key/value metadata that
+// was written with row group rg by WriteWithMeta.
+func (p *ParquetReader) RowGroupMetadata(rg int) (map[string]string, error) {
+	return p.meta.RowGroupMetadata(rg)
+}
+
+// SortingColumns returns the columns that the rows of row group rg
+// are sorted by, if the writer recorded them (see SortingColumns).
+func (p *ParquetReader) SortingColumns(rg int) ([]parquet.SortingColumn, error) {
+	return p.meta.SortingColumns(rg)
+}
+
+// SchemaTree returns the hierarchical schema of the parquet file.
+func (p *ParquetReader) SchemaTree() (*parquet.SchemaNode, error) {
+	return p.meta.SchemaTree()
+}
+
+// SchemaFields returns the columns of the parquet file's schema with
+// their types and annotations (see parquet.Metadata.SchemaFields).
+func (p *ParquetReader) SchemaFields() ([]parquet.Field, error) {
+	return p.meta.SchemaFields()
+}
+
+// ForEachPage calls fn with the header of each of col's pages without
+// reading the pages' data.  col is the column's path joined by dots.
+// It can be called between calls to Next.
+func (p *ParquetReader) ForEachPage(col string, fn func(sch.PageHeader) error) error {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	err = p.meta.ForEachPage(p.r, col, fn)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// OptionalColumn returns the values and definition levels of col (the
+// column's path joined by dots) in every row group without scanning any
+// records.  col must be optional and not repeated.  The values are a
+// slice of the column's type (for example, []int32 for a *int32 field)
+// that only holds the values that aren't null, and a row's value is
+// null if its definition level is less than the column's maximum
+// definition level.  It seeks back to where it was, so it can be used
+// while the ParquetReader is being read.
+func (p *ParquetReader) OptionalColumn(col string) (interface{}, []int64, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return nil, nil, &parquet.UnknownColumnError{Column: col}
+	}
+
+	var optional bool
+	for _, t := range f.Schema().Types {
+		switch parquet.RepetitionType(t) {
+		case parquet.Optional:
+			optional = true
+		case parquet.Repeated:
+			return nil, nil, fmt.Errorf("column %s is repeated", col)
+		}
+	}
+	if !optional {
+		return nil, nil, fmt.Errorf("column %s isn't optional", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	if err := p.readColumnChunks(f, b); err != nil {
+		return nil, nil, err
+	}
+
+	levels, _ := f.Levels()
+	defs := make([]int64, len(levels))
+	for i, d := range levels {
+		defs[i] = int64(d)
+	}
+	return b.Column(col), defs, nil
+}
+
+// ReadInt64Column appends the values of col (the path of a
+// required int64 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadInt64Column(col string, dst []int64) ([]int64, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Int64Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required int64 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]int64), nil
+}
+
+// readColumnChunks reads f's column chunks in every row group into b
+// (the values and levels of each one are appended to the ones before
+// it) and then seeks back to where it was.
+func (p *ParquetReader) readColumnChunks(f Field, b *parquet.FieldBuffers) error {
+	pages, err := p.meta.Pages()
+	if err != nil {
+		return err
+	}
+
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	f.SetBuffers(b)
+	f.SetInterner(p.interner)
+	setByteBools(f, p.byteBools)
+	if p.alloc != nil {
+		f.SetAllocator(p.alloc)
+	}
+	for _, pg := range pages[f.Name()] {
+		if _, err := p.r.Seek(pg.Offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(p.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: f.Name(), RowGroup: pg.RowGroup, Offset: pg.Offset, Err: err}
+		}
+	}
+
+	_, err = p.r.Seek(pos, io.SeekStart)
+	return err
+}
+
+// ColumnReader reads one column, row group by row group, without
+// reading the file's other columns.  It is much faster than
+// ParquetReader when only one column of a wide file is needed.
+type ColumnReader struct {
+	r         io.ReadSeeker
+	meta      *parquet.Metadata
+	cols      []string
+	alloc     parquet.Allocator
+	interner  *parquet.Interner
+	byteBools map[string]bool
+	fields    []Field
+	pages     map[string][]parquet.Page
+	rowGroups []parquet.RowGroup
+	rowGroup  int
+
+	cursor         int64
+	rows           int64
+	rowGroupCursor int64
+	rowGroupCount  int64
+	err            error
+}
+
+// ReadColumn returns a ColumnReader for col (the column's path joined
+// by dots).  A column of a nested struct can only be scanned after
+// the columns before it in the struct, so those columns are read too.
+// The ColumnReader seeks to each of the column chunks and then back to
+// where it was, so it can be used while the ParquetReader is being read.
+func (p *ParquetReader) ReadColumn(col string) (*ColumnReader, error) {
+	var cols []string
+	for _, f := range Fields(compressionUnknown) {
+		if f.Schema().Path[0] != strings.Split(col, ".")[0] {
+			continue
+		}
+
+		cols = append(cols, f.Name())
+		if f.Name() == col {
+			break
+		}
+	}
+
+	if len(cols) == 0 || cols[len(cols)-1] != col {
+		return nil, &parquet.UnknownColumnError{Column: col}
+	}
+
+	return p.readColumns(cols)
+}
+
+// readColumns returns a ColumnReader for cols, which must be in the
+// order of Fields.
+func (p *ParquetReader) readColumns(cols []string) (*ColumnReader, error) {
+	pages, err := p.meta.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	rowGroups := p.meta.RowGroups()
+	return &ColumnReader{
+		r:         p.r,
+		meta:      p.meta,
+		cols:      cols,
+		alloc:     p.alloc,
+		interner:  p.interner,
+		byteBools: p.byteBools,
+		pages:     pages,
+		rowGroups: rowGroups,
+		rows:      p.rows,
+	}, nil
+}
+
+func (c *ColumnReader) readRowGroup() error {
+	pos, err := c.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	rg := c.rowGroups[c.rowGroup]
+	fields := getFields(Fields(compressionUnknown))
+	c.fields = c.fields[:0]
+	for _, col := range c.cols {
+		// a row group doesn't have to have every column (see
+		// ParquetReader.Scan), and the field of a column that
+		// it doesn't have isn't read, so Scan leaves it zero
+		if len(c.pages[col]) == 0 || c.pages[col][0].RowGroup != c.rowGroup {
+			continue
+		}
+
+		pg := c.pages[col][0]
+		c.pages[col] = c.pages[col][1:]
+		if int64(pg.N) < rg.Rows && parquet.NumValuesOff(rg.Rows, int64(pg.N)) {
+			// see ParquetReader.readRowGroup
+			return fmt.Errorf("column %s has %d values but the row group has %d rows", col, pg.N, rg.Rows)
+		}
+
+		f := fields[col]
+		f.SetInterner(c.interner)
+		setByteBools(f, c.byteBools)
+		if c.alloc != nil {
+			f.SetAllocator(c.alloc)
+		}
+
+		offset, _, err := c.meta.ColumnChunkLocation(c.rowGroup, col)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(c.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: col, RowGroup: c.rowGroup, Offset: offset, Err: err}
+		}
+		c.fields = append(c.fields, f)
+	}
+
+	if _, err := c.r.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+
+	c.rowGroupCount = rg.Rows
+	c.rowGroupCursor = 0
+	c.rowGroup++
+	return nil
+}
+
+// Next reads the next row of the column.  It returns false
+// when there are no more rows or there was an error.
+func (c *ColumnReader) Next() bool {
+	if c.err != nil || c.cursor >= c.rows {
+		return false
+	}
+
+	for c.rowGroupCursor >= c.rowGroupCount {
+		if c.rowGroup >= len(c.rowGroups) {
+			c.err = fmt.Errorf("expected %d rows, only found %d", c.rows, c.cursor)
+			return false
+		}
+
+		c.err = c.readRowGroup()
+		if c.err != nil {
 			return false
 		}
 	}
 
-	p.cursor++
-	p.rowGroupCursor++
+	c.cursor++
+	c.rowGroupCursor++
 	return true
 }
 
-func (p *ParquetReader) Scan(x *Document) {
-	if p.err != nil {
+// Scan sets the column's field of x (and the fields of the other
+// columns that were read).  The rest of x is left as is.
+func (c *ColumnReader) Scan(x *Document) {
+	if c.err != nil {
 		return
 	}
 
-	for _, name := range p.fieldNames {
-		f := p.fields[name]
+	resetColumn(x, c.cols[0])
+	c.scan(x)
+}
+
+func (c *ColumnReader) scan(x *Document) {
+	for _, f := range c.fields {
 		f.Scan(x)
 	}
 }
 
-type Int64Field struct {
-	vals []int64
-	parquet.RequiredField
-	read  func(r Document) int64
-	write func(r *Document, vals []int64)
-	stats *int64stats
+// Error returns the error (if any) that stopped Next.
+func (c *ColumnReader) Error() error {
+	return c.err
 }
 
-func NewInt64Field(read func(r Document) int64, write func(r *Document, vals []int64), path []string, opts ...func(*parquet.RequiredField)) *Int64Field {
-	return &Int64Field{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newInt64stats(),
+func (p *ParquetReader) readRowGroup() error {
+	p.rowGroupCursor = 0
+
+	if len(p.rowGroups) == 0 {
+		p.rowGroupCount = 0
+		return nil
 	}
-}
 
-func (f *Int64Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int64Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
-}
+	rg := p.rowGroups[0]
+	p.fields = getFields(Fields(compressionUnknown))
+	if p.alloc != nil {
+		for _, f := range p.fields {
+			f.SetAllocator(p.alloc)
+		}
+	}
+	if p.buffers != nil {
+		for _, f := range p.fields {
+			f.SetBuffers(p.buffers)
+		}
+	}
+	if p.interner != nil {
+		for _, f := range p.fields {
+			f.SetInterner(p.interner)
+		}
+	}
+	for _, f := range p.fields {
+		setByteBools(f, p.byteBools)
+	}
+	p.rowGroupCount = rg.Rows
+	p.rowGroupCursor = 0
+	for _, col := range rg.Columns() {
+		name := strings.Join(col.MetaData.PathInSchema, ".")
+		if col.MetaData.NumValues < rg.Rows && parquet.NumValuesOff(rg.Rows, col.MetaData.NumValues) {
+			// every row has at least one value (or null) in each
+			// column, so the footer is too far off to be trusted
+			// (a footer that is only a little off is read with the
+			// number of values in the pages, see parquet.NumValuesOff)
+			return fmt.Errorf("column %s has %d values but the row group has %d rows", name, col.MetaData.NumValues, rg.Rows)
+		}
 
-func (f *Int64Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+		f, ok := p.fields[name]
+		if !ok {
+			if !p.ignoreUnknown {
+				return &parquet.UnknownColumnError{Column: name}
+			}
+			continue
+		}
+		pages := p.pages[name]
+		if len(pages) <= p.index {
+			break
+		}
+
+		// the column chunks aren't always next to each
+		// other (see PageAlignment)
+		pg := pages[0]
+		if _, err := p.r.Seek(pg.Offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(p.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: f.Name(), RowGroup: pg.RowGroup, Offset: pg.Offset, Err: err}
+		}
+		p.pages[name] = p.pages[name][1:]
 	}
+	p.rowGroups = p.rowGroups[1:]
+	return nil
+}
 
-	v := make([]int64, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+func (p *ParquetReader) Rows() int64 {
+	return p.rows
 }
 
-func (f *Int64Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+func (p *ParquetReader) Next() bool {
+	if p.unscanned {
+		p.skip()
+	}
 
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, uint64(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
+	if p.err == nil && p.cursor >= p.rows {
+		return false
+	}
+	for p.rowGroupCursor >= p.rowGroupCount {
+		if len(p.rowGroups) == 0 {
+			p.err = fmt.Errorf("expected %d rows, only found %d", p.rows, p.cursor)
+			return false
+		}
+
+		p.err = p.readRowGroup()
+		if p.err != nil {
+			return false
 		}
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+
+	p.cursor++
+	p.rowGroupCursor++
+	p.unscanned = true
+	return true
 }
 
-func (f *Int64Field) Scan(r *Document) {
-	if len(f.vals) == 0 {
+// Scan sets every field of x that is read from parquet, so the
+// same x can be reused for each record.  A field that is null (or
+// empty) in the record is set to its zero value, and so is the field
+// of a column that the record's row group doesn't have (the row groups
+// of a file that was put together from other files don't always have
+// the same columns).
+func (p *ParquetReader) Scan(x *Document) {
+	if p.err != nil {
 		return
 	}
 
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
+	resetRecord(x)
+	for _, name := range p.fieldNames {
+		f := p.fields[name]
+		f.Scan(x)
+	}
+	p.unscanned = false
+	p.scanned = p.cursor
 }
 
-func (f *Int64Field) Add(r Document) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
+// Pos returns the index (in the file, starting at 0) of the row that
+// the most recent call to Scan set, or -1 if Scan hasn't been called.
+// A row that Next read but that wasn't scanned doesn't change it, so
+// Pos is the last row that was processed (to checkpoint, for example).
+func (p *ParquetReader) Pos() int64 {
+	return p.scanned - 1
 }
 
-func (f *Int64Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+// skip moves the fields past the row that Next read, which keeps
+// them in step with Next when a row isn't scanned.
+func (p *ParquetReader) skip() {
+	var x Document
+	for _, name := range p.fieldNames {
+		p.fields[name].Scan(&x)
+	}
+	p.unscanned = false
 }
 
-type Int64OptionalField struct {
-	parquet.OptionalField
-	vals  []int64
-	read  func(r Document, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8)
-	write func(r *Document, vals []int64, defs, reps []uint8) (int, int)
-	stats *int64optionalStats
-}
+// ReadTail scans the last n rows of the file (all of them if it has
+// fewer) into dst, which must have room for them, and returns how many
+// it scanned.  The row groups before the first of those rows are
+// skipped without being read, so only the end of a large file is read.
+// The reader can't go back to a row it has already passed, and once
+// ReadTail returns it is at the end of the file.
+func (p *ParquetReader) ReadTail(n int, dst []Document) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
 
-func NewInt64OptionalField(read func(r Document, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8), write func(r *Document, vals []int64, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int64OptionalField {
-	return &Int64OptionalField{
-		read:          read,
-		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newint64optionalStats(maxDef(types)),
+	start := p.rows - int64(n)
+	if start < 0 {
+		start = 0
+	}
+
+	if start < p.cursor {
+		return 0, fmt.Errorf("can't read the last %d rows, the reader has already passed row %d", n, start)
+	}
+
+	if int64(len(dst)) < p.rows-start {
+		return 0, fmt.Errorf("can't read the last %d rows into a slice of length %d", p.rows-start, len(dst))
+	}
+
+	if err := p.seekToRow(start); err != nil {
+		return 0, err
 	}
-}
 
-func (f *Int64OptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int64Type, RepetitionType: f.RepetitionType, Types: f.Types}
+	var i int
+	for p.Next() {
+		p.Scan(&dst[i])
+		i++
+	}
+	return i, p.err
 }
 
-func (f *Int64OptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+// seekToRow moves the reader forward so that the next call to Next
+// reads row (the index of the row in the file).  The row groups that
+// end before it are dropped without being read, and the rows before it
+// in its own row group are skipped.
+func (p *ParquetReader) seekToRow(row int64) error {
+	if p.unscanned {
+		p.skip()
+	}
 
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, uint64(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
+	// the rest of the row group that has been read
+	if left := p.rowGroupCount - p.rowGroupCursor; p.cursor+left <= row {
+		p.cursor += left
+		p.rowGroupCursor = p.rowGroupCount
+	}
+
+	for len(p.rowGroups) > 0 && p.rowGroupCursor >= p.rowGroupCount && p.cursor+p.rowGroups[0].Rows <= row {
+		rg := p.rowGroups[0]
+		for _, col := range rg.Columns() {
+			name := strings.Join(col.MetaData.PathInSchema, ".")
+			if len(p.pages[name]) > 0 {
+				p.pages[name] = p.pages[name][1:]
+			}
 		}
+		p.rowGroups = p.rowGroups[1:]
+		p.cursor += rg.Rows
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
+
+	for p.cursor < row {
+		if !p.Next() {
+			break
+		}
+		p.skip()
+	}
+	return p.err
 }
 
-func (f *Int64OptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+// Value returns the value of col (the column's path joined by dots)
+// in the row that Next read without scanning the rest of the row.
+// The value of a repeated column is a slice of the row's values.  ok
+// is false if the value is null (or there aren't any values), col
+// isn't one of the columns that are read, or the row has already been
+// scanned.
+func (p *ParquetReader) Value(col string) (interface{}, bool) {
+	if p.err != nil || !p.unscanned {
+		return nil, false
 	}
 
-	v := make([]int64, f.Values()-len(f.vals))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+	f, ok := p.fields[col]
+	if !ok {
+		return nil, false
+	}
+	return f.Value()
 }
 
-func (f *Int64OptionalField) Add(r Document) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
+// resetRecord sets each of x's fields that are read from parquet
+// back to its zero value so that a reused struct doesn't keep the
+// values of the previous record.
+func resetRecord(x *Document) {
+	var zero Document
+	x.DocID = zero.DocID
+	x.Links = zero.Links
+	x.Names = zero.Names
 }
 
-func (f *Int64OptionalField) Scan(r *Document) {
-	if len(f.Defs) == 0 {
-		return
+// resetColumn is like resetRecord but it only resets the
+// field that holds col (or, for a nested column, its struct).
+func resetColumn(x *Document, col string) {
+	var zero Document
+	switch strings.Split(col, ".")[0] {
+	case "docid":
+		x.DocID = zero.DocID
+	case "link":
+		x.Links = zero.Links
+	case "names":
+		x.Names = zero.Names
 	}
+}
 
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
-	}
+type Int64Field = parquet.NumericField[int64, Document]
+
+func NewInt64Field(read func(r Document) int64, write func(r *Document, vals []int64), path []string, opts ...func(*parquet.RequiredField)) *Int64Field {
+	return parquet.NewNumericField(read, write, path, opts...)
 }
 
-func (f *Int64OptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
+type Int64OptionalField = parquet.OptionalNumericField[int64, Document]
+
+func NewInt64OptionalField(read func(r Document, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8), write func(r *Document, vals []int64, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int64OptionalField {
+	return parquet.NewOptionalNumericField(read, write, path, types, opts...)
 }
 
 type StringOptionalField struct {
 	parquet.OptionalField
-	vals  []string
-	read  func(r Document, vals []string, def, rep []uint8) ([]string, []uint8, []uint8)
-	write func(r *Document, vals []string, def, rep []uint8) (int, int)
-	stats *stringOptionalStats
+	vals         []string
+	read         func(r Document, vals []string, def, rep []uint8) ([]string, []uint8, []uint8)
+	write        func(r *Document, vals []string, def, rep []uint8) (int, int)
+	stats        *stringOptionalStats
+	dict         *parquet.Dictionary
+	deltaLength  bool
+	statsOnWrite bool
 }
 
 func NewStringOptionalField(read func(r Document, vals []string, def, rep []uint8) ([]string, []uint8, []uint8), write func(r *Document, vals []string, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *StringOptionalField {
@@ -814,12 +2271,29 @@ func NewStringOptionalField(read func(r Document, vals []string, def, rep []uint
 }
 
 func (f *StringOptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, RepetitionType: f.RepetitionType, Types: f.Types}
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, ConvertedType: pconvertedType(sch.ConvertedType_UTF8), LogicalType: &sch.LogicalType{STRING: &sch.StringType{}}, RepetitionType: f.RepetitionType, Types: f.Types}
 }
 
 func (f *StringOptionalField) Add(r Document) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *StringOptionalField) AddValues(vals []*string) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+func (f *StringOptionalField) add(vals []string, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	if f.dict != nil {
+		for _, v := range vals[len(f.vals):] {
+			f.dict.Add(v)
+		}
+	}
 	f.vals = vals
 	f.Defs = defs
 	f.Reps = reps
@@ -838,7 +2312,48 @@ func (f *StringOptionalField) Scan(r *Document) {
 	}
 }
 
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *StringOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
+}
+
+// SetDictionary makes the field dictionary encoded.
+func (f *StringOptionalField) SetDictionary(d *parquet.Dictionary) {
+	f.dict = d
+}
+
+// SetDeltaLength makes the field DELTA_LENGTH_BYTE_ARRAY encoded
+// instead of dictionary encoded.
+func (f *StringOptionalField) SetDeltaLength() {
+	f.deltaLength = true
+	f.dict = nil
+}
+
+// SetStatsTruncateLength truncates the field's min and max
+// statistics to at most n bytes.
+func (f *StringOptionalField) SetStatsTruncateLength(n int) {
+	f.stats.truncate = n
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *StringOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
 func (f *StringOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.deltaLength {
+		return f.DoWriteDeltaLength(w, meta, f.vals, len(f.Defs), f.stats)
+	}
+
+	if f.dict != nil {
+		return f.DoWriteDictionary(w, meta, f.dict, f.vals, len(f.Defs), f.stats)
+	}
+
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
 
@@ -859,138 +2374,49 @@ func (f *StringOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	if err != nil {
 		return err
 	}
+	defer f.Release()
 
-	for j := 0; j < f.Values(); j++ {
+	// each value has at least its length, so the number of values
+	// (which comes from the file) is checked before they are allocated
+	n := f.ReadValues()
+	if n > rr.Len()/4 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	f.vals = parquet.Reserve(f.vals, n)
+	for j := 0; j < n; j++ {
 		var x int32
 		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
 			return err
 		}
-		s := make([]byte, x)
-		if _, err := rr.Read(s); err != nil {
-			return err
-		}
-
-		f.vals = append(f.vals, string(s))
-	}
-	return nil
-}
-
-func (f *StringOptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
-}
-
-type int64stats struct {
-	min int64
-	max int64
-}
-
-func newInt64stats() *int64stats {
-	return &int64stats{
-		min: int64(math.MaxInt64),
-	}
-}
-
-func (i *int64stats) add(val int64) {
-	if val < i.min {
-		i.min = val
-	}
-	if val > i.max {
-		i.max = val
-	}
-}
-
-func (f *int64stats) bytes(v int64) []byte {
-	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, uint64(v))
-	return bs
-}
-
-func (f *int64stats) NullCount() *int64 {
-	return nil
-}
-
-func (f *int64stats) DistinctCount() *int64 {
-	return nil
-}
-
-func (f *int64stats) Min() []byte {
-	return f.bytes(f.min)
-}
-
-func (f *int64stats) Max() []byte {
-	return f.bytes(f.max)
-}
-
-type int64optionalStats struct {
-	min     int64
-	max     int64
-	nils    int64
-	nonNils int64
-	maxDef  uint8
-}
-
-func newint64optionalStats(d uint8) *int64optionalStats {
-	return &int64optionalStats{
-		min:    int64(math.MaxInt64),
-		maxDef: d,
-	}
-}
-
-func (f *int64optionalStats) add(vals []int64, defs []uint8) {
-	var i int
-	for _, def := range defs {
-		if def < f.maxDef {
-			f.nils++
-		} else {
-			val := vals[i]
-			i++
-
-			f.nonNils++
-			if val < f.min {
-				f.min = val
-			}
-			if val > f.max {
-				f.max = val
-			}
+		if x < 0 || int(x) > rr.Len() {
+			return fmt.Errorf("invalid string length %d", x)
 		}
+		f.vals = append(f.vals, f.Intern(rr.Next(int(x))))
 	}
-}
-
-func (f *int64optionalStats) bytes(v int64) []byte {
-	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, uint64(v))
-	return bs
-}
-
-func (f *int64optionalStats) NullCount() *int64 {
-	return &f.nils
-}
-
-func (f *int64optionalStats) DistinctCount() *int64 {
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return nil
 }
 
-func (f *int64optionalStats) Min() []byte {
-	if f.nonNils == 0 {
-		return nil
-	}
-	return f.bytes(f.min)
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *StringOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[string](b, f.Name())
 }
 
-func (f *int64optionalStats) Max() []byte {
-	if f.nonNils == 0 {
-		return nil
-	}
-	return f.bytes(f.max)
+func (f *StringOptionalField) Levels() ([]uint8, []uint8) {
+	return f.Defs, f.Reps
 }
 
 const nilOptString = "__#NIL#__"
 
 type stringOptionalStats struct {
-	min    string
-	max    string
-	nils   int64
-	maxDef uint8
+	min      string
+	max      string
+	nils     int64
+	maxDef   uint8
+	truncate int
 }
 
 func newStringOptionalStats(d uint8) *stringOptionalStats {
@@ -1039,24 +2465,27 @@ func (s *stringOptionalStats) Min() []byte {
 	if s.min == nilOptString {
 		return nil
 	}
-	return []byte(s.min)
+	return parquet.TruncateMin([]byte(s.min), s.truncate)
 }
 
 func (s *stringOptionalStats) Max() []byte {
 	if s.max == nilOptString {
 		return nil
 	}
-	return []byte(s.max)
+	return parquet.TruncateMax([]byte(s.max), s.truncate)
 }
 
-func pint32(i int32) *int32       { return &i }
-func puint32(i uint32) *uint32    { return &i }
-func pint64(i int64) *int64       { return &i }
-func puint64(i uint64) *uint64    { return &i }
-func pbool(b bool) *bool          { return &b }
-func pstring(s string) *string    { return &s }
-func pfloat32(f float32) *float32 { return &f }
-func pfloat64(f float64) *float64 { return &f }
+func pint32(i int32) *int32                                 { return &i }
+func puint32(i uint32) *uint32                              { return &i }
+func pint64(i int64) *int64                                 { return &i }
+func puint64(i uint64) *uint64                              { return &i }
+func pbool(b bool) *bool                                    { return &b }
+func pstring(s string) *string                              { return &s }
+func pfloat32(f float32) *float32                           { return &f }
+func pfloat64(f float64) *float64                           { return &f }
+func ptimeTime(t time.Time) *time.Time                      { return &t }
+func pparquetFloat16(f parquet.Float16) *parquet.Float16    { return &f }
+func pconvertedType(c sch.ConvertedType) *sch.ConvertedType { return &c }
 
 // keeps track of the indices of repeated fields
 // that have already been handled by a previous field
@@ -1090,8 +2519,6 @@ func Int32Type(se *sch.SchemaElement) {
 func Uint32Type(se *sch.SchemaElement) {
 	t := sch.Type_INT32
 	se.Type = &t
-	ct := sch.ConvertedType_UINT_32
-	se.ConvertedType = &ct
 }
 
 func Int64Type(se *sch.SchemaElement) {
@@ -1102,8 +2529,6 @@ func Int64Type(se *sch.SchemaElement) {
 func Uint64Type(se *sch.SchemaElement) {
 	t := sch.Type_INT64
 	se.Type = &t
-	ct := sch.ConvertedType_UINT_64
-	se.ConvertedType = &ct
 }
 
 func Float32Type(se *sch.SchemaElement) {
@@ -1125,3 +2550,8 @@ func StringType(se *sch.SchemaElement) {
 	t := sch.Type_BYTE_ARRAY
 	se.Type = &t
 }
+
+func TimeType(se *sch.SchemaElement) {
+	t := sch.Type_INT64
+	se.Type = &t
+}
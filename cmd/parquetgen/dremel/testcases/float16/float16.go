@@ -0,0 +1,14 @@
+package float16
+
+import "github.com/rclayton-godaddy/parquet"
+
+//go:generate parquetgen -input float16.go -type Embedding -package float16 -output generated.go -arrow
+
+// Embedding has half precision floats, which are stored
+// as FLOAT16 (FIXED_LEN_BYTE_ARRAY(2)) columns.
+type Embedding struct {
+	ID     int32             `parquet:"id"`
+	Weight parquet.Float16   `parquet:"weight"`
+	Bias   *parquet.Float16  `parquet:"bias"`
+	Vector []parquet.Float16 `parquet:"vector"`
+}
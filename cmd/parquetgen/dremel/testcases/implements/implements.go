@@ -0,0 +1,23 @@
+package implements
+
+//go:generate parquetgen -input implements.go -type Event -package implements -output generated.go -implements RecordWriter -implements reader=RecordReader
+
+// Event is written and read through RecordWriter and RecordReader.
+type Event struct {
+	ID   int32   `parquet:"id"`
+	Name *string `parquet:"name"`
+}
+
+// RecordWriter writes any type of record.
+type RecordWriter interface {
+	AddAny(rec interface{}) error
+	Write() error
+	Close() error
+}
+
+// RecordReader reads any type of record.
+type RecordReader interface {
+	Next() bool
+	ScanAny(x interface{}) error
+	Error() error
+}
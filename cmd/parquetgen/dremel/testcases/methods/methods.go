@@ -0,0 +1,32 @@
+package methods
+
+//go:generate parquetgen -input methods.go -type Person -package methods -output generated.go -helpers
+
+// Person doesn't export the fields that hold its data, they
+// are read and written with get and set methods.
+type Person struct {
+	ID       int32   `parquet:"id"`
+	code     string  `parquet:"code,methods"`
+	nickname *string `parquet:"nickname,get=Nickname,set=Rename"`
+	ignored  int32
+}
+
+func NewPerson(id int32, code string, nickname *string) Person {
+	return Person{ID: id, code: code, nickname: nickname}
+}
+
+func (p Person) Code() string {
+	return p.code
+}
+
+func (p *Person) SetCode(c string) {
+	p.code = c
+}
+
+func (p Person) Nickname() *string {
+	return p.nickname
+}
+
+func (p *Person) Rename(n *string) {
+	p.nickname = n
+}
@@ -0,0 +1,13 @@
+package monomorphic
+
+//go:generate parquetgen -input point.go -type Point -package monomorphic -output generated.go -monomorphic
+
+// Point has only int64 columns, so its fields are added and scanned
+// without the Field interface (see the dispatch package, which has the
+// same Point without -monomorphic).
+type Point struct {
+	A int64 `parquet:"a"`
+	B int64 `parquet:"b"`
+	C int64 `parquet:"c"`
+	D int64 `parquet:"d"`
+}
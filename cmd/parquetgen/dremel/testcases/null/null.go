@@ -0,0 +1,21 @@
+package null
+
+import "database/sql"
+
+//go:generate parquetgen -input null.go -type Row -package null -output generated.go -helpers -arrow
+
+// Row is a row of a database query, its nullable columns are
+// scanned into database/sql Null types, empty strings, or sentinels.
+type Row struct {
+	ID      int32           `parquet:"id"`
+	Name    sql.NullString  `parquet:"name"`
+	Count   sql.NullInt64   `parquet:"count"`
+	Small   sql.NullInt32   `parquet:"small"`
+	Score   sql.NullFloat64 `parquet:"score"`
+	Active  sql.NullBool    `parquet:"active"`
+	Updated sql.NullTime    `parquet:"updated,unit=millis"`
+	Email   string          `parquet:"email,empty_as_null"`
+	Phone   *string         `parquet:"phone,empty_as_null"`
+	Rank    int64           `parquet:"rank,null_sentinel=-1"`
+	Ratio   float32         `parquet:"ratio,null_sentinel=-0.5"`
+}
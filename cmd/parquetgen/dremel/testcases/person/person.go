@@ -1,6 +1,6 @@
 package person
 
-//go:generate parquetgen -input person.go -type Person -package person -output generated.go
+//go:generate parquetgen -input person.go -type Person -package person -output generated.go -helpers
 
 type Skill struct {
 	Name       string `parquet:"name"`
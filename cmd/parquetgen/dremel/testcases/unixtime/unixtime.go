@@ -0,0 +1,14 @@
+package unixtime
+
+import "time"
+
+//go:generate parquetgen -input unixtime.go -type Event -package unixtime -output generated.go
+
+// Event has times that are stored as plain int64 columns
+// of seconds, millis, or nanos since the unix epoch.
+type Event struct {
+	ID    int32       `parquet:"id"`
+	At    time.Time   `parquet:"at,unixtime=s"`
+	Seen  *time.Time  `parquet:"seen,unixtime=ms"`
+	Ticks []time.Time `parquet:"ticks,unixtime=ns"`
+}
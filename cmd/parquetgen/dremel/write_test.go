@@ -39,6 +39,47 @@ func TestWrite(t *testing.T) {
 		return 1, 1
 	}
 
+	return 0, 1
+}`,
+		},
+		{
+			name: "required with methods",
+			field: fields.Field{
+				Type: "string", Name: "code", RepetitionType: fields.Required, Getter: "Code", Setter: "SetCode",
+			},
+			result: `func writecode(x *Person, vals []string) {
+	x.SetCode(vals[0])
+}`,
+		},
+		{
+			name: "optional with methods",
+			field: fields.Field{
+				Type: "int32", Name: "age", RepetitionType: fields.Optional, Getter: "Age", Setter: "SetAge",
+			},
+			result: `func writeage(x *Person, vals []int32, defs, reps []uint8) (int, int) {
+	def := defs[0]
+	switch def {
+	case 1:
+		x.SetAge(pint32(vals[0]))
+		return 1, 1
+	}
+
+	return 0, 1
+}`,
+		},
+		{
+			name: "sql null type",
+			field: fields.Field{
+				Type: "int64", Name: "Count", RepetitionType: fields.Optional, Null: "sql.NullInt64",
+			},
+			result: `func writeCount(x *Person, vals []int64, defs, reps []uint8) (int, int) {
+	def := defs[0]
+	switch def {
+	case 1:
+		x.Count = sql.NullInt64{Int64: vals[0], Valid: true}
+		return 1, 1
+	}
+
 	return 0, 1
 }`,
 		},
@@ -784,3 +825,50 @@ func TestWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestReset(t *testing.T) {
+	testCases := []struct {
+		name   string
+		field  fields.Field
+		result string
+	}{
+		{
+			name:   "required",
+			field:  fields.Field{Type: "int32", Name: "ID", RepetitionType: fields.Required},
+			result: "x.ID = zero.ID",
+		},
+		{
+			name:   "optional",
+			field:  fields.Field{Type: "int32", Name: "ID", RepetitionType: fields.Optional},
+			result: "x.ID = zero.ID",
+		},
+		{
+			name: "nested",
+			field: fields.Field{
+				Name: "Other", RepetitionType: fields.Optional, Children: []fields.Field{
+					{Name: "Hobby", RepetitionType: fields.Repeated, Children: []fields.Field{
+						{Type: "int32", Name: "Difficulty", RepetitionType: fields.Required},
+					}},
+				},
+			},
+			result: "x.Other = zero.Other",
+		},
+		{
+			name:  "required with methods",
+			field: fields.Field{Type: "string", Name: "code", RepetitionType: fields.Required, Getter: "Code", Setter: "SetCode"},
+		},
+		{
+			name:   "optional with methods",
+			field:  fields.Field{Type: "int32", Name: "age", RepetitionType: fields.Optional, Getter: "Age", Setter: "SetAge"},
+			result: "x.SetAge(nil)",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%02d %s", i, tc.name), func(t *testing.T) {
+			flds := fields.Field{Type: "Person", Children: []fields.Field{tc.field}}.Fields()
+			f := flds[len(flds)-1]
+			assert.Equal(t, tc.result, dremel.Reset(f))
+		})
+	}
+}
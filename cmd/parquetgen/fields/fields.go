@@ -17,6 +17,32 @@ type Field struct {
 	Embedded       bool
 	NthChild       int
 	Defined        bool
+	// TimeUnit (millis, micros, or nanos) and LocalTime are set by the
+	// unit and utc tag options of a time.Time field.
+	TimeUnit  string
+	LocalTime bool
+	// Getter and Setter are the names of the methods that are used
+	// instead of accessing the field directly (see the methods, get,
+	// and set tag options).
+	Getter string
+	Setter string
+	// Null is the database/sql type (like sql.NullString) of an
+	// optional field that uses its Valid flag instead of a pointer.
+	Null string
+	// EmptyAsNull is set by the empty_as_null tag option of a string
+	// field: an empty string is written as a null.  If the field isn't
+	// a pointer (NoPointer) it is still an optional column and a null
+	// is read as an empty string.
+	EmptyAsNull bool
+	NoPointer   bool
+	// NullSentinel is set by the null_sentinel tag option of a numeric
+	// field that isn't a pointer (NoPointer): the value (a go literal)
+	// is written as a null and a null is read as the value.
+	NullSentinel string
+	// UnixTime is set by the unixtime tag option of a time.Time field:
+	// the unit (s, ms, us, or ns) of a plain int64 column (without a
+	// TIMESTAMP annotation) that holds the time since the unix epoch.
+	UnixTime string
 }
 
 type input struct {
@@ -400,15 +426,15 @@ func (f Field) Init(def, rep int) string {
 		case Optional:
 			if fld.Primitive() {
 				if f.NthChild == 0 && fld.Parent.Optional() && !fld.Parent.Repeated() {
-					right = fmt.Sprintf(right, fmt.Sprintf("%s: p%s(vals[0])%%s", fld.Name, fld.Type))
+					right = fmt.Sprintf(right, fmt.Sprintf("%s: %s(vals[0])%%s", fld.Name, PtrFunc(fld.Type)))
 				} else if fld.Parent.RepetitionType == Repeated {
-					right = fmt.Sprintf(right, fmt.Sprintf("p%s(vals[nVals])%%s", fld.Type))
+					right = fmt.Sprintf(right, fmt.Sprintf("%s(vals[nVals])%%s", PtrFunc(fld.Type)))
 				} else if fld.Parent.Repeated() && f.NthChild == 0 {
-					right = fmt.Sprintf(right, fmt.Sprintf("%s: p%s(vals[nVals])%%s", fld.Name, fld.Type))
+					right = fmt.Sprintf(right, fmt.Sprintf("%s: %s(vals[nVals])%%s", fld.Name, PtrFunc(fld.Type)))
 				} else if fld.Parent.Repeated() && f.NthChild > 0 {
-					right = fmt.Sprintf(right, fmt.Sprintf("p%s(vals[nVals])%%s", fld.Type))
+					right = fmt.Sprintf(right, fmt.Sprintf("%s(vals[nVals])%%s", PtrFunc(fld.Type)))
 				} else {
-					right = fmt.Sprintf(right, fmt.Sprintf("p%s(vals[0])%%s", fld.Type))
+					right = fmt.Sprintf(right, fmt.Sprintf("%s(vals[0])%%s", PtrFunc(fld.Type)))
 				}
 			} else {
 				if j == 0 {
@@ -450,6 +476,12 @@ func (f Field) Init(def, rep int) string {
 	return fmt.Sprintf("x%s = %s", left, right)
 }
 
+// PtrFunc is the name of the generated function that returns
+// a pointer to a value of type typ (for example: pint32).
+func PtrFunc(typ string) string {
+	return "p" + strings.Replace(typ, ".", "", -1)
+}
+
 // IsRep is true if this fields is one being repeated
 func (f Field) IsRep(rep int) bool {
 	var reps int
@@ -494,6 +526,64 @@ func (f Field) ParquetType() string {
 	return fmt.Sprintf(ft.name, "", "Type")
 }
 
+// ConvertedType creates gocode for the parquet converted type
+// annotation of the field (nil if there isn't one).
+func (f Field) ConvertedType() string {
+	ft := primitiveTypes[f.Type]
+	if ft.converted == "" {
+		return "nil"
+	}
+	return fmt.Sprintf("pconvertedType(sch.ConvertedType_%s)", ft.converted)
+}
+
+// LogicalType creates gocode for the parquet logical type
+// annotation of the field (nil if there isn't one).
+func (f Field) LogicalType() string {
+	ft := primitiveTypes[f.Type]
+	if ft.logical == "" {
+		return "nil"
+	}
+	return ft.logical
+}
+
+// TimeUnits are the values of the unit tag option of a time.Time
+// field, mapped to the parquet.TimeUnit that is used by the generated
+// code.
+var TimeUnits = map[string]string{
+	"millis": "Millis",
+	"micros": "Micros",
+	"nanos":  "Nanos",
+}
+
+// UnixTimeUnits are the values of the unixtime tag option of a
+// time.Time field, mapped to the parquet.TimeUnit that is used by
+// the generated code.
+var UnixTimeUnits = map[string]string{
+	"s":  "Seconds",
+	"ms": "Millis",
+	"us": "Micros",
+	"ns": "Nanos",
+}
+
+// Timestamp creates gocode for the parquet.Timestamp of a time.Time
+// field (an empty string for other types).  The default is micros
+// adjusted to UTC.
+func (f Field) Timestamp() string {
+	if f.Type != "time.Time" {
+		return ""
+	}
+
+	if unit, ok := UnixTimeUnits[f.UnixTime]; ok {
+		return fmt.Sprintf("parquet.Timestamp{Unit: parquet.%s, AdjustedToUTC: true, Epoch: true}", unit)
+	}
+
+	unit, ok := TimeUnits[f.TimeUnit]
+	if !ok {
+		unit = "Micros"
+	}
+	return fmt.Sprintf("parquet.Timestamp{Unit: parquet.%s, AdjustedToUTC: %t}", unit, !f.LocalTime)
+}
+
 func (f Field) Category() string {
 	var op string
 	if f.Optional() || f.Repeated() {
@@ -513,19 +603,23 @@ func (f Field) TypeName() string {
 }
 
 type fieldType struct {
-	name     string
-	category string
+	name      string
+	category  string
+	converted string
+	logical   string
 }
 
 var primitiveTypes = map[string]fieldType{
-	"int32":   {"Int32%s%s", "numeric%s"},
-	"uint32":  {"Uint32%s%s", "numeric%s"},
-	"int64":   {"Int64%s%s", "numeric%s"},
-	"uint64":  {"Uint64%s%s", "numeric%s"},
-	"float32": {"Float32%s%s", "numeric%s"},
-	"float64": {"Float64%s%s", "numeric%s"},
-	"bool":    {"Bool%s%s", "bool%s"},
-	"string":  {"String%s%s", "string%s"},
+	"int32":           {name: "Int32%s%s", category: "numeric%s"},
+	"uint32":          {name: "Uint32%s%s", category: "numeric%s", converted: "UINT_32", logical: "&sch.LogicalType{INTEGER: &sch.IntType{BitWidth: 32}}"},
+	"int64":           {name: "Int64%s%s", category: "numeric%s"},
+	"uint64":          {name: "Uint64%s%s", category: "numeric%s", converted: "UINT_64", logical: "&sch.LogicalType{INTEGER: &sch.IntType{BitWidth: 64}}"},
+	"float32":         {name: "Float32%s%s", category: "numeric%s"},
+	"float64":         {name: "Float64%s%s", category: "numeric%s"},
+	"bool":            {name: "Bool%s%s", category: "bool%s"},
+	"string":          {name: "String%s%s", category: "string%s", converted: "UTF8", logical: "&sch.LogicalType{STRING: &sch.StringType{}}"},
+	"time.Time":       {name: "Time%s%s", category: "time%s"},
+	"parquet.Float16": {name: "Float16%s%s", category: "numeric%s", logical: "&sch.LogicalType{FLOAT16: &sch.Float16Type{}}"},
 }
 
 func max(i []int) int {
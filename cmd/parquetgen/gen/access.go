@@ -0,0 +1,142 @@
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/parse"
+)
+
+// checkAccess makes sure that the generated code (in package pkg) can
+// use the struct and everything it refers to.  Without -import the
+// code has to be in the struct's package.  With it, the code is in
+// another package so the struct, its nested structs, and the methods
+// its fields are bound to have to be exported, and the struct can't
+// be in package main or an internal package that the output can't
+// import.
+func checkAccess(result *parse.Result, outPth, pkg, imp string) error {
+	typ := result.Parent.Type
+	if imp == "" {
+		if pkg != "" && result.Package != "" && pkg != result.Package {
+			return fmt.Errorf("%s is in package %s, so either -package must be %s or -import must be the import path of package %s", typ, result.Package, result.Package, result.Package)
+		}
+		return nil
+	}
+
+	if result.Package == "main" {
+		return fmt.Errorf("%s is in package main, which can't be imported (generate the code in package main without -import)", typ)
+	}
+
+	if !token.IsExported(typ) {
+		return fmt.Errorf("%s isn't exported, so package %s can't use it", typ, pkg)
+	}
+
+	if err := checkExported(result.Parent.Children, pkg); err != nil {
+		return err
+	}
+
+	return checkInternal(imp, outPth)
+}
+
+// checkExported makes sure that the nested structs and
+// methods of fields are exported.
+func checkExported(flds []fields.Field, pkg string) error {
+	for _, f := range flds {
+		for _, m := range []string{f.Getter, f.Setter} {
+			if m != "" && !token.IsExported(m) {
+				return fmt.Errorf("field %s is bound to method %s, which isn't exported, so package %s can't use it", f.Name, m, pkg)
+			}
+		}
+
+		if f.Primitive() {
+			continue
+		}
+
+		if !token.IsExported(f.Type) {
+			return fmt.Errorf("field %s is a %s, which isn't exported, so package %s can't use it", f.Name, f.Type, pkg)
+		}
+
+		if err := checkExported(f.Children, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInternal makes sure that the package that the output is in can
+// import imp if imp is an internal package (only packages in the tree
+// rooted at the parent of the internal directory can).  The output's
+// import path comes from the go.mod above it, so nothing is checked if
+// there isn't one.
+func checkInternal(imp, outPth string) error {
+	parent, ok := internalParent(imp)
+	if !ok {
+		return nil
+	}
+
+	out, ok := importPath(outPth)
+	if !ok || out == parent || strings.HasPrefix(out, parent+"/") {
+		return nil
+	}
+
+	return fmt.Errorf("%s is an internal package, so %s (package %s) can't import it (it must be in %s)", imp, outPth, out, parent)
+}
+
+// internalParent returns the parent of the last internal
+// element of imp, which the importing package has to be in.
+func internalParent(imp string) (string, bool) {
+	parts := strings.Split(imp, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "internal" {
+			return strings.Join(parts[:i], "/"), true
+		}
+	}
+	return "", false
+}
+
+// importPath returns the import path of the directory that pth is in
+// from the module path in the closest go.mod.
+func importPath(pth string) (string, bool) {
+	dir, err := filepath.Abs(filepath.Dir(pth))
+	if err != nil {
+		return "", false
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		if mod, ok := modulePath(filepath.Join(d, "go.mod")); ok {
+			rel, err := filepath.Rel(d, dir)
+			if err != nil {
+				return "", false
+			}
+			return path.Join(mod, filepath.ToSlash(rel)), true
+		}
+
+		if filepath.Dir(d) == d {
+			return "", false
+		}
+	}
+}
+
+// modulePath reads the module path from a go.mod file.
+func modulePath(pth string) (string, bool) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`), true
+		}
+	}
+	return "", false
+}
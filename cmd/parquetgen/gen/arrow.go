@@ -0,0 +1,52 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
+)
+
+// arrowFields returns the fields that the ArrowWriter (see -arrow)
+// writes: the columns that aren't repeated, since a column of
+// scalars can't hold a list.
+func arrowFields(f fields.Field) []fields.Field {
+	var out []fields.Field
+	for _, fld := range f.Fields() {
+		if !fld.Repeated() {
+			out = append(out, fld)
+		}
+	}
+	return out
+}
+
+// arrowColumn is the expression that creates the parquet.ArrowColumn
+// of f, which is named after f's (dotted) column path.
+func arrowColumn(f fields.Field) string {
+	name := strings.Join(f.ColumnNames(), ".")
+	if f.Type == "time.Time" {
+		return fmt.Sprintf("parquet.NewArrowTimeColumn(%q, %t, %s)", name, !f.Required(), f.Timestamp())
+	}
+	return fmt.Sprintf("parquet.NewArrowColumn[%s](%q, %t)", f.Type, name, !f.Required())
+}
+
+// arrowAppend generates the statements that append the value of f in
+// rec to the column cols[i].  The value comes from f's read function,
+// so an optional column is null unless the value is defined all the
+// way down to f.
+func arrowAppend(i int, f fields.Field) string {
+	col := fmt.Sprintf("a.cols[%d]", i)
+	read := fmt.Sprintf("read%s", strings.Join(f.FieldNames(), ""))
+	appnd := func(v string) string {
+		if f.Type == "time.Time" {
+			return fmt.Sprintf("%s.AppendTime(%s)", col, v)
+		}
+		return fmt.Sprintf("parquet.AppendArrow(%s, %s)", col, v)
+	}
+
+	if f.Required() {
+		return appnd(read + "(rec)")
+	}
+	return fmt.Sprintf("if vals, defs, _ := %s(rec, nil, a.defs[:0], nil); defs[0] == %d {\n%s\n} else {\n%s.AppendNull()\n}",
+		read, f.MaxDef(), appnd("vals[0]"), col)
+}
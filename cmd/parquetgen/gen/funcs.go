@@ -5,7 +5,6 @@ import (
 	"strings"
 	"text/template"
 
-	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/cases"
 	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/dremel"
 	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
 )
@@ -15,12 +14,6 @@ var (
 		"removeStar": func(s string) string {
 			return strings.Replace(strings.Replace(s, "*", "", 1), "[]", "", 1)
 		},
-		"camelCase": func(s string) string {
-			return cases.Camel(s)
-		},
-		"camelCaseRemoveStar": func(s string) string {
-			return cases.Camel(strings.Replace(strings.Replace(s, "*", "", 1), "[]", "", 1))
-		},
 		"dedupe": dedupe,
 		"compressionFunc": func(f fields.Field) string {
 			if strings.Contains(f.Category(), "Optional") {
@@ -59,6 +52,29 @@ var (
 			}
 			return out
 		},
+		"arrowFields":     arrowFields,
+		"arrowColumn":     arrowColumn,
+		"arrowAppend":     arrowAppend,
+		"equal":           equal,
+		"clone":           clone,
+		"columnar":        columnar,
+		"columnType":      columnType,
+		"typedColumn":     typedColumn,
+		"projectionType":  projectionType,
+		"projectionField": projectionField,
+		"projectionValue": projectionValue,
+		"resets":          resets,
+		"needsZero":       needsZero,
+		// usesNull is true if any of the fields are
+		// database/sql Null types
+		"usesNull": func(ff []fields.Field) bool {
+			for _, f := range ff {
+				if f.Null != "" {
+					return true
+				}
+			}
+			return false
+		},
 		"columnName":    func(f fields.Field) string { return strings.Join(f.ColumnNames(), ".") },
 		"writeFunc":     dremel.Write,
 		"readFunc":      dremel.Read,
@@ -70,57 +86,102 @@ var (
 			}
 			return "parquet.RequiredField"
 		},
-		"byteSize": func(f fields.Field) string {
-			var out string
-			switch f.Type {
-			case "int32", "*int32", "uint32", "*uint32", "float32", "*float32":
-				out = "4"
-			case "int64", "*int64", "uint64", "*uint64", "float64", "*float64":
-				out = "8"
-			}
-			return out
-		},
-		// based on binary.Write
-		"putFunc": func(f fields.Field) string {
-			var out string
-			switch f.Type {
-			case "int32", "*int32", "uint32", "*uint32", "float32", "*float32":
-				out = "PutUint32"
-			case "int64", "*int64", "uint64", "*uint64", "float64", "*float64":
-				out = "PutUint64"
-			}
-			return out
-		},
-		// based on binary.Write
-		"uintFunc": func(f fields.Field) string {
-			var out string
-			switch f.Type {
-			case "int32":
-				out = "uint32(v)"
-			case "*int32":
-				out = "uint32(*v)"
-			case "uint32":
-				out = "v"
-			case "*uint32":
-				out = "*v"
-			case "float32":
-				out = "math.Float32bits(v)"
-			case "*float32":
-				out = "math.Float32bits(*v)"
-			case "int64":
-				out = "uint64(v)"
-			case "*int64":
-				out = "uint64(*v)"
-			case "uint64":
-				out = "v"
-			case "*uint64":
-				out = "*v"
-			case "float64":
-				out = "math.Float64bits(v)"
-			case "*float64":
-				out = "math.Float64bits(*v)"
-			}
-			return out
-		},
 	}
 )
+
+// reset is the statement that resets the top level field
+// that holds Column (see dremel.Reset).
+type reset struct {
+	Column string
+	Stmt   string
+	Zero   bool
+}
+
+// resets returns the statements that reset each of the top level
+// fields of the struct that hold ff.
+func resets(ff []fields.Field) []reset {
+	var out []reset
+	seen := map[string]bool{}
+	for _, f := range ff {
+		stmt := dremel.Reset(f)
+		if stmt == "" || seen[stmt] {
+			continue
+		}
+		seen[stmt] = true
+		out = append(out, reset{
+			Column: f.ColumnNames()[0],
+			Stmt:   stmt,
+			Zero:   strings.Contains(stmt, "zero."),
+		})
+	}
+	return out
+}
+
+// needsZero is true if any of the reset statements use
+// the struct's zero value.
+func needsZero(rr []reset) bool {
+	for _, r := range rr {
+		if r.Zero {
+			return true
+		}
+	}
+	return false
+}
+
+// columnar is true if each of the fields is a top level column
+// that isn't repeated, so the ParquetWriter can add a column's values
+// without records (see the AddColumn methods).
+func columnar(ff []fields.Field) bool {
+	for _, f := range ff {
+		if len(f.ColumnNames()) != 1 || f.MaxDef() > 1 || f.MaxRep() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// columnType is the go type of the values of a column
+// that are added by its AddColumn method.
+func columnType(f fields.Field) string {
+	if f.Optional() {
+		return "*" + f.Type
+	}
+	return f.Type
+}
+
+// typedColumn is the name of the type of f's values in the
+// name of its Read...Column method (Int64 for an Int64Field).
+func typedColumn(f fields.Field) string {
+	return strings.TrimSuffix(f.FieldType(), "Field")
+}
+
+// projectionType is the go type of a field of a projection.
+func projectionType(f fields.Field) string {
+	switch {
+	case f.Null != "":
+		return f.Null
+	case f.RepetitionType == fields.Repeated:
+		return "[]" + f.Type
+	case f.RepetitionType == fields.Optional && !f.NoPointer:
+		return "*" + f.Type
+	default:
+		return f.Type
+	}
+}
+
+// projectionField is the name of a projection's field.  A field
+// that is bound to methods is named after its getter.
+func projectionField(f fields.Field) string {
+	if f.Getter != "" {
+		return f.Getter
+	}
+	return f.Name
+}
+
+// projectionValue gets the value of f from the record rec.
+func projectionValue(f fields.Field) string {
+	if f.Getter != "" {
+		return fmt.Sprintf("rec.%s()", f.Getter)
+	}
+	return fmt.Sprintf("rec.%s", f.Name)
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"go/format"
+	"go/token"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/rclayton-godaddy/parquet"
@@ -25,23 +27,86 @@ var (
 	}
 )
 
+// Options are what FromStruct and FromParquet generate besides the
+// reader and writer (most of them are parquetgen's flags).  The zero
+// value generates the reader and writer in one file.
+type Options struct {
+	// Ignore leaves out the fields of the struct whose types aren't
+	// supported instead of returning an error.
+	Ignore bool
+
+	// Split writes the writer, reader, and fields to separate files
+	// (see splitFiles) instead of to one.
+	Split bool
+
+	// Helpers generates the Equal and Clone methods of the struct.
+	Helpers bool
+
+	// Arrow generates an ArrowWriter (see arrow.go).
+	Arrow bool
+
+	// Monomorphic makes the writer and reader add and scan records by
+	// calling the methods of each field's own type instead of the
+	// Field interface.
+	Monomorphic bool
+
+	// SplitWriter, JSONArray, DumpColumn, and Sorted generate a
+	// SplitWriter, WriteJSONArray, DumpColumn, and a SortedWriter.
+	SplitWriter bool
+	JSONArray   bool
+	DumpColumn  bool
+	Sorted      bool
+
+	// Implements are the interfaces that the writer and reader
+	// implement.
+	Implements []Implementation
+
+	// Projections each generate a struct and a reader that only
+	// have some of the columns.
+	Projections []Projection
+}
+
 // FromStruct generates a parquet reader and writer based on the struct
-// of type 'typ' that is defined in the go file at 'pth'.
-func FromStruct(pth, outPth, typ, pkg, imp string, ignore bool) error {
+// of type 'typ' that is defined in the go file at 'pth', and whatever
+// else opts says to generate.
+func FromStruct(pth, outPth, typ, pkg, imp string, opts Options) error {
 	result, err := parse.Fields(typ, pth)
 	if err != nil {
 		return err
 	}
 
-	if len(result.Errors) > 0 && !ignore {
+	if len(result.Errors) > 0 && !opts.Ignore {
 		return fmt.Errorf("not generating parquet.go (-ignore set to false), err: %v", result.Errors)
 	}
 
+	pp, err := getProjections(result.Parent, opts.Projections)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAccess(result, outPth, pkg, imp); err != nil {
+		return err
+	}
+
+	if opts.Helpers && imp != "" {
+		return fmt.Errorf("-helpers generates methods of %s, so the code must be generated in its package (without -import)", typ)
+	}
+
 	i := input{
-		Package: pkg,
-		Type:    typ,
-		Import:  getImport(imp),
-		Parent:  result.Parent,
+		Package:          pkg,
+		Type:             typ,
+		Import:           getImport(imp),
+		Parent:           result.Parent,
+		Projections:      pp,
+		Helpers:          opts.Helpers,
+		Arrow:            opts.Arrow,
+		Monomorphic:      opts.Monomorphic,
+		SplitWriter:      opts.SplitWriter,
+		JSONArray:        opts.JSONArray,
+		DumpColumn:       opts.DumpColumn,
+		Sorted:           opts.Sorted,
+		Implements:       opts.Implements,
+		InterfaceImports: interfaceImports(opts.Implements),
 	}
 
 	tmpl := template.New("output").Funcs(funcs)
@@ -57,13 +122,15 @@ func FromStruct(pth, outPth, typ, pkg, imp string, ignore bool) error {
 		stringOptionalTpl,
 		boolTpl,
 		boolOptionalTpl,
+		requiredTimeTpl,
+		optionalTimeTpl,
 		newFieldTpl,
-		requiredStatsTpl,
-		optionalStatsTpl,
 		boolStatsTpl,
 		boolOptionalStatsTpl,
 		stringStatsTpl,
 		stringOptionalStatsTpl,
+		timeStatsTpl,
+		timeOptionalStatsTpl,
 	} {
 		var err error
 		tmpl, err = tmpl.Parse(t)
@@ -83,7 +150,30 @@ func FromStruct(pth, outPth, typ, pkg, imp string, ignore bool) error {
 		return fmt.Errorf("err: %s, gocode: %s", err, string(buf.Bytes()))
 	}
 
-	f, err := os.Create(outPth)
+	if !opts.Split {
+		return writeFile(outPth, gocode)
+	}
+
+	var readers []string
+	for _, p := range pp {
+		readers = append(readers, p.Name, p.Name+"ParquetReader")
+	}
+
+	parts, err := splitSource(gocode, readers...)
+	if err != nil {
+		return err
+	}
+
+	for p, pth := range splitFiles(outPth) {
+		if err := writeFile(pth, parts[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(pth string, gocode []byte) error {
+	f, err := os.Create(pth)
 	if err != nil {
 		return err
 	}
@@ -97,8 +187,8 @@ func FromStruct(pth, outPth, typ, pkg, imp string, ignore bool) error {
 }
 
 // FromParquet generates a go struct, a reader, and a writer based
-// on the parquet file at 'parq'
-func FromParquet(parq, pth, outPth, typ, pkg, imp string, ignore bool) error {
+// on the parquet file at 'parq' (see FromStruct)
+func FromParquet(parq, pth, outPth, typ, pkg, imp string, opts Options) error {
 	pf, err := os.Open(parq)
 	if err != nil {
 		return err
@@ -121,6 +211,7 @@ func FromParquet(parq, pth, outPth, typ, pkg, imp string, ignore bool) error {
 		Package: pkg,
 		Structs: structs.Struct(typ, footer.Schema),
 	}
+	n.Float16 = strings.Contains(n.Structs, "parquet.Float16")
 
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, n)
@@ -144,14 +235,146 @@ func FromParquet(parq, pth, outPth, typ, pkg, imp string, ignore bool) error {
 	}
 
 	f.Close()
-	return FromStruct(pth, outPth, typ, pkg, imp, ignore)
+	return FromStruct(pth, outPth, typ, pkg, imp, opts)
 }
 
 type input struct {
-	Package string
-	Type    string
-	Import  string
-	Parent  fields.Field
+	Package          string
+	Type             string
+	Import           string
+	Parent           fields.Field
+	Projections      []projection
+	Helpers          bool
+	Arrow            bool
+	Monomorphic      bool
+	SplitWriter      bool
+	JSONArray        bool
+	DumpColumn       bool
+	Sorted           bool
+	Implements       []Implementation
+	InterfaceImports []string
+}
+
+// Implementation is an interface that the generated ParquetWriter or
+// ParquetReader (Type) implements.  Name is the interface's name as
+// the generated code refers to it, and Import is the import path of
+// its package (empty if it is in the generated package).
+type Implementation struct {
+	Type   string
+	Name   string
+	Import string
+}
+
+// ParseImplementation parses an interface like "RecordWriter" or
+// "reader=example.com/pipeline.RecordReader".  The interface is
+// implemented by the ParquetWriter unless it starts with reader=.
+func ParseImplementation(s string) (Implementation, error) {
+	out := Implementation{Type: "ParquetWriter"}
+	iface := s
+	if i := strings.Index(s, "="); i >= 0 {
+		switch s[:i] {
+		case "writer":
+		case "reader":
+			out.Type = "ParquetReader"
+		default:
+			return Implementation{}, fmt.Errorf("invalid interface %q, it must look like [writer=|reader=][import/path.]Name", s)
+		}
+		iface = s[i+1:]
+	}
+
+	// the interface's name follows the last dot after the import path
+	name := iface
+	if i := strings.LastIndex(iface, "."); i > strings.LastIndex(iface, "/") {
+		out.Import, name = iface[:i], iface[i+1:]
+	}
+
+	pkg := out.Import[strings.LastIndex(out.Import, "/")+1:]
+	if !token.IsIdentifier(name) || (out.Import != "" && !token.IsIdentifier(pkg)) {
+		return Implementation{}, fmt.Errorf("invalid interface %q, it must look like [writer=|reader=][import/path.]Name", s)
+	}
+
+	out.Name = name
+	if out.Import != "" {
+		out.Name = pkg + "." + name
+	}
+	return out, nil
+}
+
+// interfaceImports returns the import paths of the
+// packages of implements (each one once).
+func interfaceImports(implements []Implementation) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, impl := range implements {
+		if impl.Import != "" && !seen[impl.Import] {
+			seen[impl.Import] = true
+			out = append(out, impl.Import)
+		}
+	}
+	return out
+}
+
+// Projection is a struct (named Name) that has the fields of some of
+// the top level columns of the generated type.  Its reader (named
+// NameParquetReader) only reads those columns.
+type Projection struct {
+	Name    string
+	Columns []string
+}
+
+// ParseProjection parses a projection like "Summary:id,name,total".
+func ParseProjection(s string) (Projection, error) {
+	i := strings.Index(s, ":")
+	if i < 1 || i == len(s)-1 {
+		return Projection{}, fmt.Errorf("invalid projection %q, it must look like Name:column,column", s)
+	}
+
+	p := Projection{Name: s[:i]}
+	for _, col := range strings.Split(s[i+1:], ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			return Projection{}, fmt.Errorf("invalid projection %q, it has an empty column", s)
+		}
+		p.Columns = append(p.Columns, col)
+	}
+	return p, nil
+}
+
+// projection is a Projection along with
+// the fields of its columns.
+type projection struct {
+	Name   string
+	Fields []fields.Field
+}
+
+func getProjections(parent fields.Field, pp []Projection) ([]projection, error) {
+	children := map[string]fields.Field{}
+	for _, f := range parent.Children {
+		children[f.ColumnName] = f
+	}
+
+	out := make([]projection, len(pp))
+	for i, p := range pp {
+		if !token.IsExported(p.Name) || p.Name == parent.Type {
+			return nil, fmt.Errorf("invalid projection name %s", p.Name)
+		}
+
+		out[i].Name = p.Name
+		seen := map[string]bool{}
+		for _, col := range p.Columns {
+			f, ok := children[col]
+			if !ok {
+				return nil, fmt.Errorf("projection %s: %s isn't a top level column of %s", p.Name, col, parent.Type)
+			}
+
+			if seen[col] {
+				return nil, fmt.Errorf("projection %s: column %s is in it more than once", p.Name, col)
+			}
+			seen[col] = true
+			out[i].Fields = append(out[i].Fields, f)
+		}
+	}
+	return out, nil
 }
 
 func getFieldType(se *sch.SchemaElement) (string, error) {
@@ -200,6 +423,9 @@ type newStruct struct {
 	Package string
 	Structs string
 	Fields  []fields.Field
+	// Float16 is true if the structs have a
+	// parquet.Float16 (see structs.Struct)
+	Float16 bool
 }
 
 type fieldType struct {
@@ -0,0 +1,349 @@
+package gen_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/gen"
+	"github.com/stretchr/testify/assert"
+)
+
+const record = `package %s
+
+type Record struct {
+	ID     int64   ` + "`parquet:\"id\"`" + `
+	Source *Source ` + "`parquet:\"source\"`" + `
+	name   string  ` + "`parquet:\"name,get=Name,set=SetName\"`" + `
+}
+
+type Source struct {
+	Host string ` + "`parquet:\"host\"`" + `
+}
+
+func (r Record) Name() string { return r.name }
+func (r *Record) SetName(s string) { r.name = s }
+`
+
+func TestFromStructAccess(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pkg     string
+		input   string
+		typ     string
+		output  string
+		genPkg  string
+		imp     string
+		replace []string
+		err     string
+	}{
+		{
+			name:   "same package",
+			pkg:    "rec",
+			input:  "rec/record.go",
+			typ:    "Record",
+			output: "rec/generated.go",
+			genPkg: "rec",
+		},
+		{
+			name:   "wrong package",
+			pkg:    "rec",
+			input:  "rec/record.go",
+			typ:    "Record",
+			output: "rec/generated.go",
+			genPkg: "other",
+			err:    "Record is in package rec, so either -package must be rec or -import must be the import path of package rec",
+		},
+		{
+			name:   "import",
+			pkg:    "rec",
+			input:  "rec/record.go",
+			typ:    "Record",
+			output: "other/generated.go",
+			genPkg: "other",
+			imp:    "example.com/m/rec",
+		},
+		{
+			name:   "import main",
+			pkg:    "main",
+			input:  "cmd/record.go",
+			typ:    "Record",
+			output: "other/generated.go",
+			genPkg: "other",
+			imp:    "example.com/m/cmd",
+			err:    "Record is in package main, which can't be imported (generate the code in package main without -import)",
+		},
+		{
+			name:    "unexported type",
+			pkg:     "rec",
+			input:   "rec/record.go",
+			typ:     "record",
+			output:  "other/generated.go",
+			genPkg:  "other",
+			imp:     "example.com/m/rec",
+			replace: []string{"Record", "record"},
+			err:     "record isn't exported, so package other can't use it",
+		},
+		{
+			name:    "unexported struct",
+			pkg:     "rec",
+			input:   "rec/record.go",
+			typ:     "Record",
+			output:  "other/generated.go",
+			genPkg:  "other",
+			imp:     "example.com/m/rec",
+			replace: []string{"*Source", "*source", "type Source", "type source"},
+			err:     "field Source is a source, which isn't exported, so package other can't use it",
+		},
+		{
+			name:    "unexported method",
+			pkg:     "rec",
+			input:   "rec/record.go",
+			typ:     "Record",
+			output:  "other/generated.go",
+			genPkg:  "other",
+			imp:     "example.com/m/rec",
+			replace: []string{"SetName", "setName"},
+			err:     "field name is bound to method setName, which isn't exported, so package other can't use it",
+		},
+		{
+			name:   "internal",
+			pkg:    "rec",
+			input:  "a/internal/rec/record.go",
+			typ:    "Record",
+			output: "a/b/generated.go",
+			genPkg: "b",
+			imp:    "example.com/m/a/internal/rec",
+		},
+		{
+			name:   "internal outside of its tree",
+			pkg:    "rec",
+			input:  "a/internal/rec/record.go",
+			typ:    "Record",
+			output: "other/generated.go",
+			genPkg: "other",
+			imp:    "example.com/m/a/internal/rec",
+			err:    "example.com/m/a/internal/rec is an internal package, so %s (package example.com/m/other) can't import it (it must be in example.com/m/a)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			write(t, filepath.Join(dir, "go.mod"), "module example.com/m\n")
+
+			src := strings.NewReplacer(tc.replace...).Replace(fmt.Sprintf(record, tc.pkg))
+			input := filepath.Join(dir, tc.input)
+			write(t, input, src)
+
+			output := filepath.Join(dir, tc.output)
+			if !assert.NoError(t, os.MkdirAll(filepath.Dir(output), 0755)) {
+				return
+			}
+
+			err := gen.FromStruct(input, output, tc.typ, tc.genPkg, tc.imp, gen.Options{Ignore: true})
+			if tc.err == "" {
+				assert.NoError(t, err)
+				assert.FileExists(t, output)
+				return
+			}
+
+			if strings.Contains(tc.err, "%s") {
+				tc.err = fmt.Sprintf(tc.err, output)
+			}
+			assert.EqualError(t, err, tc.err)
+			assert.NoFileExists(t, output)
+		})
+	}
+}
+
+func TestFromStructSplit(t *testing.T) {
+	testCases := []struct {
+		output string
+		files  map[string]string
+	}{
+		{
+			output: "generated.go",
+			files: map[string]string{
+				"generated_writer.go": "type ParquetWriter struct",
+				"generated_reader.go": "type ParquetReader struct",
+				"generated_fields.go": "func Fields(",
+			},
+		},
+		{
+			output: "generated_test.go",
+			files: map[string]string{
+				"generated_writer_test.go": "type ParquetWriter struct",
+				"generated_reader_test.go": "type ParquetReader struct",
+				"generated_fields_test.go": "func Fields(",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.output, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "record.go")
+			write(t, input, fmt.Sprintf(record, "rec"))
+
+			output := filepath.Join(dir, tc.output)
+			if !assert.NoError(t, gen.FromStruct(input, output, "Record", "rec", "", gen.Options{Ignore: true, Split: true, Sorted: true})) {
+				return
+			}
+			assert.NoFileExists(t, output)
+
+			for name, decl := range tc.files {
+				src, err := os.ReadFile(filepath.Join(dir, name))
+				if !assert.NoError(t, err) {
+					continue
+				}
+
+				_, err = parser.ParseFile(token.NewFileSet(), name, src, parser.ImportsOnly)
+				assert.NoError(t, err, name)
+				assert.Contains(t, string(src), decl, name)
+
+				// only the writer can be left out with the build tag
+				writer := strings.Contains(name, "_writer")
+				assert.Equal(t, writer, strings.HasPrefix(string(src), "//go:build !"+gen.NoWriterTag+"\n"), name)
+				assert.Equal(t, writer, strings.Contains(string(src), "func NewParquetWriter("), name)
+				assert.Equal(t, writer, strings.Contains(string(src), "func NewSortedWriter("), name)
+				assert.Equal(t, strings.Contains(name, "_reader"), strings.Contains(string(src), "func NewParquetReader("), name)
+			}
+		})
+	}
+}
+
+// TestFromStructFlags checks that the optional declarations (and
+// the imports that only they use) are only generated with their flags.
+func TestFromStructFlags(t *testing.T) {
+	decls := []string{
+		"func NewSplitWriter(",
+		"func WriteJSONArray(",
+		`"encoding/json"`,
+		"func DumpColumn(",
+		`"bufio"`,
+		"func NewSortedWriter(",
+		`"container/heap"`,
+		`"os"`,
+		`"sort"`,
+	}
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "record.go")
+			write(t, input, fmt.Sprintf(record, "rec"))
+
+			output := filepath.Join(dir, "generated.go")
+			opts := gen.Options{Ignore: true, SplitWriter: enabled, JSONArray: enabled, DumpColumn: enabled, Sorted: enabled}
+			if !assert.NoError(t, gen.FromStruct(input, output, "Record", "rec", "", opts)) {
+				return
+			}
+
+			src, err := os.ReadFile(output)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = parser.ParseFile(token.NewFileSet(), output, src, 0)
+			assert.NoError(t, err)
+			for _, decl := range decls {
+				assert.Equal(t, enabled, strings.Contains(string(src), decl), decl)
+			}
+		})
+	}
+}
+
+func write(t *testing.T, pth, s string) {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pth, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseImplementation(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected gen.Implementation
+		err      bool
+	}{
+		{in: "RecordWriter", expected: gen.Implementation{Type: "ParquetWriter", Name: "RecordWriter"}},
+		{in: "writer=RecordWriter", expected: gen.Implementation{Type: "ParquetWriter", Name: "RecordWriter"}},
+		{in: "reader=RecordReader", expected: gen.Implementation{Type: "ParquetReader", Name: "RecordReader"}},
+		{in: "reader=example.com/pipeline.RecordReader", expected: gen.Implementation{Type: "ParquetReader", Name: "pipeline.RecordReader", Import: "example.com/pipeline"}},
+		{in: "example.com/pipeline.v2/rec.Writer", expected: gen.Implementation{Type: "ParquetWriter", Name: "rec.Writer", Import: "example.com/pipeline.v2/rec"}},
+		{in: "scanner=RecordReader", err: true},
+		{in: "example.com/.Writer", err: true},
+		{in: "example.com/pipeline", err: true},
+		{in: "", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, err := gen.ParseImplementation(tc.in)
+			if tc.err {
+				assert.EqualError(t, err, fmt.Sprintf("invalid interface %q, it must look like [writer=|reader=][import/path.]Name", tc.in))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestFromStructImplements(t *testing.T) {
+	var implements []gen.Implementation
+	for _, s := range []string{"example.com/pipeline.RecordWriter", "reader=example.com/pipeline.RecordReader"} {
+		impl, err := gen.ParseImplementation(s)
+		if !assert.NoError(t, err) {
+			return
+		}
+		implements = append(implements, impl)
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "record.go")
+	write(t, input, fmt.Sprintf(record, "rec"))
+
+	if !assert.NoError(t, gen.FromStruct(input, filepath.Join(dir, "generated.go"), "Record", "rec", "", gen.Options{Ignore: true, Split: true, Implements: implements})) {
+		return
+	}
+
+	// each assertion goes with the type that implements the interface,
+	// and the package of the interfaces is imported once by each of them
+	for name, decl := range map[string]string{
+		"generated_writer.go": "var _ pipeline.RecordWriter = (*ParquetWriter)(nil)",
+		"generated_reader.go": "var _ pipeline.RecordReader = (*ParquetReader)(nil)",
+	} {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.ImportsOnly)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+
+		var n int
+		for _, imp := range f.Imports {
+			if imp.Path.Value == `"example.com/pipeline"` {
+				n++
+			}
+		}
+		assert.Equal(t, 1, n, name)
+		assert.Contains(t, string(src), decl, name)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "generated_fields.go"))
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(src), "example.com/pipeline")
+	}
+}
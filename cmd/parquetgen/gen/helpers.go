@@ -0,0 +1,117 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rclayton-godaddy/parquet/cmd/parquetgen/fields"
+)
+
+// equal generates the statements of the Equal method (see -helpers)
+// of the struct f: each one returns false if a field of r (that is
+// written to parquet) isn't the same as the field of other.
+func equal(f fields.Field) string {
+	var b strings.Builder
+	equalFields(&b, f.Children, "r", "other", 0)
+	return b.String()
+}
+
+func equalFields(b *strings.Builder, ff []fields.Field, x, y string, depth int) {
+	for _, f := range ff {
+		fx, fy := x+"."+f.Name, y+"."+f.Name
+		if f.Getter != "" {
+			fx, fy = fmt.Sprintf("%s.%s()", x, f.Getter), fmt.Sprintf("%s.%s()", y, f.Getter)
+		}
+
+		i := fmt.Sprintf("i%d", depth)
+		switch {
+		case f.RepetitionType == fields.Repeated:
+			fmt.Fprintf(b, "if len(%s) != len(%s) {\nreturn false\n}\n", fx, fy)
+			fmt.Fprintf(b, "for %s := range %s {\n", i, fx)
+			if f.Primitive() {
+				fmt.Fprintf(b, "if %s {\nreturn false\n}\n", notEqual(f, fx+"["+i+"]", fy+"["+i+"]"))
+			} else {
+				equalFields(b, f.Children, fx+"["+i+"]", fy+"["+i+"]", depth+1)
+			}
+			b.WriteString("}\n")
+		case f.RepetitionType == fields.Optional && !f.NoPointer && f.Null == "":
+			if f.Primitive() {
+				fmt.Fprintf(b, "if (%s == nil) != (%s == nil) || (%s != nil && %s) {\nreturn false\n}\n", fx, fy, fx, notEqual(f, "*"+fx, "*"+fy))
+				continue
+			}
+			fmt.Fprintf(b, "if (%s == nil) != (%s == nil) {\nreturn false\n}\n", fx, fy)
+			fmt.Fprintf(b, "if %s != nil {\n", fx)
+			equalFields(b, f.Children, fx, fy, depth+1)
+			b.WriteString("}\n")
+		case f.Primitive():
+			fmt.Fprintf(b, "if %s {\nreturn false\n}\n", notEqual(f, fx, fy))
+		default:
+			equalFields(b, f.Children, fx, fy, depth)
+		}
+	}
+}
+
+// notEqual is the expression that is true if the values x and y of
+// the primitive field f aren't the same.  Times are compared with
+// Equal (so their locations don't matter), and database/sql Null types
+// only compare their values when they are Valid.
+func notEqual(f fields.Field, x, y string) string {
+	if f.Null != "" {
+		val := strings.TrimPrefix(f.Null, "sql.Null")
+		if f.Type == "time.Time" {
+			return fmt.Sprintf("%s.Valid != %s.Valid || (%s.Valid && !%s.%s.Equal(%s.%s))", x, y, x, x, val, y, val)
+		}
+		return fmt.Sprintf("%s.Valid != %s.Valid || (%s.Valid && %s.%s != %s.%s)", x, y, x, x, val, y, val)
+	}
+
+	if f.Type == "time.Time" {
+		// Equal can be called through a pointer
+		return fmt.Sprintf("!%s.Equal(%s)", strings.TrimPrefix(x, "*"), y)
+	}
+	return fmt.Sprintf("%s != %s", x, y)
+}
+
+// clone generates the statements of the Clone method (see -helpers)
+// of the struct f, which copy the pointers and slices of the fields
+// of r (that are written to parquet) into out.
+func clone(f fields.Field) string {
+	var b strings.Builder
+	cloneFields(&b, f.Children, "out", "r", 0)
+	return b.String()
+}
+
+func cloneFields(b *strings.Builder, ff []fields.Field, dst, src string, depth int) {
+	for _, f := range ff {
+		fdst, fsrc := dst+"."+f.Name, src+"."+f.Name
+		v := fmt.Sprintf("v%d", depth)
+		switch {
+		case f.Getter != "":
+			// fields that are bound to methods are
+			// primitives of the top level struct
+			if f.RepetitionType == fields.Optional && !f.NoPointer {
+				fmt.Fprintf(b, "if p := %s.%s(); p != nil {\n%s := *p\n%s.%s(&%s)\n}\n", src, f.Getter, v, dst, f.Setter, v)
+			}
+		case f.RepetitionType == fields.Repeated:
+			i := fmt.Sprintf("i%d", depth)
+			fmt.Fprintf(b, "if %s != nil {\n", fsrc)
+			fmt.Fprintf(b, "%s = make([]%s, len(%s))\n", fdst, f.Type, fsrc)
+			fmt.Fprintf(b, "copy(%s, %s)\n", fdst, fsrc)
+			if !f.Primitive() {
+				var children strings.Builder
+				cloneFields(&children, f.Children, fdst+"["+i+"]", fsrc+"["+i+"]", depth+1)
+				if children.Len() > 0 {
+					fmt.Fprintf(b, "for %s := range %s {\n%s}\n", i, fsrc, children.String())
+				}
+			}
+			b.WriteString("}\n")
+		case f.RepetitionType == fields.Optional && !f.NoPointer && f.Null == "":
+			fmt.Fprintf(b, "if %s != nil {\n%s := *%s\n", fsrc, v, fsrc)
+			if !f.Primitive() {
+				cloneFields(b, f.Children, v, fsrc, depth+1)
+			}
+			fmt.Fprintf(b, "%s = &%s\n}\n", fdst, v)
+		case !f.Primitive():
+			cloneFields(b, f.Children, fdst, fsrc, depth)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NoWriterTag is the build tag that leaves the writer out of code that
+// is generated with -split, so a binary that only reads doesn't
+// include it.
+const NoWriterTag = "parquet_nowriter"
+
+// The parts of the generated code (see splitSource).
+const (
+	partFields = iota
+	partWriter
+	partReader
+)
+
+// splitFiles returns the names of the files that the writer, reader,
+// and fields of outPth are written to with -split.  For parquet.go
+// they are parquet_writer.go, parquet_reader.go, and parquet_fields.go
+// (and a _test.go file stays one, so x_test.go is x_writer_test.go).
+func splitFiles(outPth string) map[int]string {
+	stem := strings.TrimSuffix(outPth, filepath.Ext(outPth))
+	var suffix string
+	if strings.HasSuffix(stem, "_test") {
+		stem, suffix = strings.TrimSuffix(stem, "_test"), "_test"
+	}
+	return map[int]string{
+		partWriter: stem + "_writer" + suffix + ".go",
+		partReader: stem + "_reader" + suffix + ".go",
+		partFields: stem + "_fields" + suffix + ".go",
+	}
+}
+
+// splitSource splits the generated code into the writer (everything
+// that refers to the ParquetWriter, SplitWriter, ArrowWriter, or
+// SortedWriter), the reader (everything that refers to the
+// ParquetReader, ColumnReader, or the projections' readers), and the
+// fields that they both use.  Each part only imports the packages it
+// uses, and the writer has a build constraint (see NoWriterTag).
+func splitSource(src []byte, readers ...string) (map[int][]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	part := map[string]int{
+		"ParquetWriter": partWriter,
+		"SplitWriter":   partWriter,
+		"ArrowWriter":   partWriter,
+		"SortedWriter":  partWriter,
+		"sortedRun":     partWriter,
+		"sortedMerge":   partWriter,
+		"ParquetReader": partReader,
+		"ColumnReader":  partReader,
+	}
+	for _, r := range readers {
+		part[r] = partReader
+	}
+
+	var decls []ast.Decl
+	var imports []*ast.ImportSpec
+	for _, d := range file.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			for _, spec := range gd.Specs {
+				imports = append(imports, spec.(*ast.ImportSpec))
+			}
+			continue
+		}
+		decls = append(decls, d)
+	}
+
+	// a declaration is part of the writer (or reader) if it refers
+	// to anything that is, and methods go with their types.
+	parts := make([]int, len(decls))
+	for changed := true; changed; {
+		changed = false
+		for i, d := range decls {
+			p := declPart(d, part)
+			if p == parts[i] {
+				continue
+			}
+			parts[i] = p
+			for _, name := range declNames(d) {
+				part[name] = p
+			}
+			changed = true
+		}
+	}
+
+	// the writer can use the reader (a SortedWriter merges its runs
+	// with ParquetReaders), since the reader is always built
+	for i, d := range decls {
+		for name := range declRefs(d) {
+			if p, ok := part[name]; ok && p != partFields && p != parts[i] && (p != partReader || parts[i] != partWriter) {
+				return nil, fmt.Errorf("%s refers to %s, which is in another part of the generated code", strings.Join(declNames(d), ", "), name)
+			}
+		}
+	}
+
+	out := map[int][]byte{}
+	for _, p := range []int{partFields, partWriter, partReader} {
+		var body bytes.Buffer
+		used := map[string]bool{}
+		for i, d := range decls {
+			if parts[i] != p {
+				continue
+			}
+			body.Write(declSource(fset, src, d))
+			body.WriteString("\n\n")
+			for name := range declRefs(d) {
+				used[name] = true
+			}
+		}
+
+		var buf bytes.Buffer
+		if p == partWriter {
+			fmt.Fprintf(&buf, "//go:build !%s\n\n", NoWriterTag)
+		}
+		fmt.Fprintf(&buf, "package %s\n\n", file.Name.Name)
+		buf.WriteString("// Code generated by github.com/rclayton-godaddy/parquet.  DO NOT EDIT.\n\n")
+		// the standard library's imports come first
+		var std, other []string
+		for _, imp := range imports {
+			if !used[importName(imp)] {
+				continue
+			}
+
+			s := string(src[fset.Position(imp.Pos()).Offset:fset.Position(imp.End()).Offset])
+			if strings.Contains(strings.Split(imp.Path.Value, "/")[0], ".") {
+				other = append(other, s)
+			} else {
+				std = append(std, s)
+			}
+		}
+
+		buf.WriteString("import (\n")
+		for _, s := range std {
+			fmt.Fprintf(&buf, "\t%s\n", s)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, s := range other {
+			fmt.Fprintf(&buf, "\t%s\n", s)
+		}
+		buf.WriteString(")\n\n")
+		buf.Write(body.Bytes())
+
+		gocode, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		out[p] = gocode
+	}
+	return out, nil
+}
+
+// declPart returns the part of the generated code that d belongs in.
+func declPart(d ast.Decl, part map[string]int) int {
+	if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv != nil {
+		return part[recvName(fd)]
+	}
+
+	for _, name := range declNames(d) {
+		if p, ok := part[name]; ok && p != partFields {
+			return p
+		}
+	}
+
+	refs := declRefs(d)
+	for _, p := range []int{partWriter, partReader} {
+		for name := range refs {
+			if pp, ok := part[name]; ok && pp == p {
+				return p
+			}
+		}
+	}
+	return partFields
+}
+
+// declNames returns the names that d declares.  A method
+// doesn't declare any (it belongs to its type).
+func declNames(d ast.Decl) []string {
+	var out []string
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			out = append(out, d.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				out = append(out, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name != "_" {
+						out = append(out, n.Name)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// declRefs returns the identifiers that d uses, which include the
+// names of the packages it uses.  The names of struct fields, methods,
+// and parameters aren't included since they can't refer to another
+// declaration.
+func declRefs(d ast.Decl) map[string]bool {
+	out := map[string]bool{}
+	var inspect func(ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			out[n.Name] = true
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, inspect)
+			return false
+		case *ast.KeyValueExpr:
+			if _, ok := n.Key.(*ast.Ident); !ok {
+				ast.Inspect(n.Key, inspect)
+			}
+			ast.Inspect(n.Value, inspect)
+			return false
+		case *ast.Field:
+			if n.Type != nil {
+				ast.Inspect(n.Type, inspect)
+			}
+			return false
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				ast.Inspect(n.Recv, inspect)
+			}
+			ast.Inspect(n.Type, inspect)
+			if n.Body != nil {
+				ast.Inspect(n.Body, inspect)
+			}
+			return false
+		case *ast.TypeSpec:
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.ValueSpec:
+			if n.Type != nil {
+				ast.Inspect(n.Type, inspect)
+			}
+			for _, v := range n.Values {
+				ast.Inspect(v, inspect)
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(d, inspect)
+	return out
+}
+
+// recvName returns the name of the type of a method's receiver.
+func recvName(fd *ast.FuncDecl) string {
+	typ := fd.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// importName returns the name that the code uses for an import.
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	pth, _ := strconv.Unquote(imp.Path.Value)
+	return pth[strings.LastIndex(pth, "/")+1:]
+}
+
+// declSource returns the source of d along with its doc
+// comment and the comment at the end of its last line.
+func declSource(fset *token.FileSet, src []byte, d ast.Decl) []byte {
+	start := d.Pos()
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	}
+
+	from := fset.Position(start).Offset
+	to := fset.Position(d.End()).Offset
+	if i := bytes.IndexByte(src[to:], '\n'); i >= 0 {
+		to += i
+	} else {
+		to = len(src)
+	}
+	return src[from:to]
+}
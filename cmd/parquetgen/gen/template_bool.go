@@ -6,6 +6,7 @@ var boolTpl = `{{define "boolField"}}type BoolField struct {
 	read  func(r {{.StructType}}) {{.TypeName}}
 	write func(r *{{.StructType}}, vals []{{removeStar .TypeName}})
     stats *boolStats
+	byteBools bool
 }
 
 func NewBoolField(read func(r {{.StructType}}) {{.TypeName}}, write func(r *{{.StructType}}, vals []{{removeStar .TypeName}}), path []string, opts ...func(*{{parquetType .}})) *BoolField {
@@ -22,6 +23,10 @@ func (f *BoolField) Schema() parquet.Field {
 
 
 func (f *BoolField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.byteBools {
+		return f.DoWrite(w, meta, parquet.ByteBools(f.vals), len(f.vals), newBoolStats())
+	}
+
 	ln := len(f.vals)
 	n := (ln + 7) / 8
 	rawBuf := make([]byte, n)
@@ -35,16 +40,37 @@ func (f *BoolField) Write(w io.Writer, meta *parquet.Metadata) error {
 	return f.DoWrite(w, meta, rawBuf, len(f.vals), newBoolStats())
 }
 
+// SetByteBools makes the field write (or read) one byte per
+// value instead of one bit (see ByteBools).
+func (f *BoolField) SetByteBools() {
+	f.byteBools = true
+}
+
 func (f *BoolField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	rr, sizes, err := f.DoRead(r, pg)
 	if err != nil {
 		return err
 	}
+	defer f.Release()
 
-	f.vals, err = parquet.GetBools(rr, int(pg.N), sizes)
+	getBools := parquet.GetBools
+	if f.byteBools {
+		getBools = parquet.GetByteBools
+	}
+
+	v, err := getBools(rr, f.ReadValues(), sizes)
+	f.vals = append(f.vals, v...)
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return err
 }
 
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *BoolField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[bool](b, f.Name())
+}
+
 func (f *BoolField) Scan(r *{{.StructType}}) {
 	if len(f.vals) == 0 {
 		return
@@ -54,14 +80,32 @@ func (f *BoolField) Scan(r *{{.StructType}}) {
     f.vals = f.vals[1:]
 }
 
+// Value returns the value of the field's next row.
+func (f *BoolField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
+}
+
 func (f *BoolField) Add(r {{.StructType}}) {
 	v := f.read(r)
 	f.vals = append(f.vals, v)
 }
 
+// AddValues adds the values of rows without records.
+func (f *BoolField) AddValues(vals []bool) {
+	f.vals = append(f.vals, vals...)
+}
+
 func (f *BoolField) Levels() ([]uint8, []uint8) {
 	return nil, nil
 }
+
+// Rows returns the number of rows that have been added.
+func (f *BoolField) Rows() int {
+	return len(f.vals)
+}
 {{end}}`
 
 var boolStatsTpl = `{{define "boolStats"}}
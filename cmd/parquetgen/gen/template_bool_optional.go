@@ -6,6 +6,8 @@ var boolOptionalTpl = `{{define "boolOptionalField"}}type BoolOptionalField stru
 	read   func(r {{.StructType}}, vals []{{removeStar .TypeName}}, defs, reps []uint8) ([]{{removeStar .TypeName}}, []uint8, []uint8)
 	write  func(r *{{.StructType}}, vals []{{removeStar .TypeName}}, defs, reps []uint8) (int, int)
 	stats *boolOptionalStats
+	statsOnWrite bool
+	byteBools bool
 }
 
 func NewBoolOptionalField(read func(r {{.StructType}}, vals []{{removeStar .TypeName}}, defs, reps []uint8) ([]{{removeStar .TypeName}}, []uint8, []uint8), write func(r *{{.StructType}}, vals []{{removeStar .TypeName}}, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *BoolOptionalField {
@@ -26,12 +28,32 @@ func (f *BoolOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	if err != nil {
 		return err
 	}
+	defer f.Release()
 
-	v, err := parquet.GetBools(rr, f.Values()-len(f.vals), sizes)
+	n := f.ReadValues()
+	if n == 0 {
+		// every value in the column chunk is null
+		return nil
+	}
+
+	getBools := parquet.GetBools
+	if f.byteBools {
+		getBools = parquet.GetByteBools
+	}
+
+	v, err := getBools(rr, n, sizes)
 	f.vals = append(f.vals, v...)
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return err
 }
 
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *BoolOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[bool](b, f.Name())
+}
+
 func (f *BoolOptionalField) Scan(r *{{.StructType}}) {
 	if len(f.Defs) == 0 {
 		return
@@ -45,15 +67,46 @@ func (f *BoolOptionalField) Scan(r *{{.StructType}}) {
 	}
 }
 
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *BoolOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
+}
+
 func (f *BoolOptionalField) Add(r {{.StructType}}) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *BoolOptionalField) AddValues(vals []*bool) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+func (f *BoolOptionalField) add(vals []bool, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
 	f.vals = vals
 	f.Defs = defs
 	f.Reps = reps
 }
 
+// SetStatsMode sets when the field computes its stats.
+func (f *BoolOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
 func (f *BoolOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.byteBools {
+		return f.DoWrite(w, meta, parquet.ByteBools(f.vals), len(f.Defs), f.stats)
+	}
+
 	ln := len(f.vals)
 	byteNum := (ln + 7) / 8
 	rawBuf := make([]byte, byteNum)
@@ -67,6 +120,12 @@ func (f *BoolOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
 	return f.DoWrite(w, meta, rawBuf, len(f.Defs), f.stats)
 }
 
+// SetByteBools makes the field write (or read) one byte per
+// value instead of one bit (see ByteBools).
+func (f *BoolOptionalField) SetByteBools() {
+	f.byteBools = true
+}
+
 func (f *BoolOptionalField) Levels() ([]uint8, []uint8) {
 	return f.Defs, f.Reps
 }
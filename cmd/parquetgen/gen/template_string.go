@@ -7,6 +7,9 @@ type StringField struct {
 	read  func(r {{.StructType}}) {{.TypeName}}
 	write func(r *{{.StructType}}, vals []{{removeStar .TypeName}})
 	stats *stringStats
+	dict  *parquet.Dictionary
+	deltaLength bool
+	statsOnWrite bool
 }
 
 func NewStringField(read func(r {{.StructType}}) {{.TypeName}}, write func(r *{{.StructType}}, vals []{{removeStar .TypeName}}), path []string, opts ...func(*parquet.RequiredField)) *StringField {
@@ -19,10 +22,47 @@ func NewStringField(read func(r {{.StructType}}) {{.TypeName}}, write func(r *{{
 }
 
 func (f *StringField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, ConvertedType: pconvertedType(sch.ConvertedType_UTF8), LogicalType: &sch.LogicalType{STRING: &sch.StringType{}}, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+}
+
+// SetDictionary makes the field dictionary encoded.
+func (f *StringField) SetDictionary(d *parquet.Dictionary) {
+	f.dict = d
+}
+
+// SetDeltaLength makes the field DELTA_LENGTH_BYTE_ARRAY encoded
+// instead of dictionary encoded.
+func (f *StringField) SetDeltaLength() {
+	f.deltaLength = true
+	f.dict = nil
+}
+
+// SetStatsTruncateLength truncates the field's min and max
+// statistics to at most n bytes.
+func (f *StringField) SetStatsTruncateLength(n int) {
+	f.stats.truncate = n
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *StringField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
 }
 
 func (f *StringField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		for _, v := range f.vals {
+			f.stats.add(v)
+		}
+	}
+
+	if f.deltaLength {
+		return f.DoWriteDeltaLength(w, meta, f.vals, f.stats)
+	}
+
+	if f.dict != nil {
+		return f.DoWriteDictionary(w, meta, f.dict, f.vals, f.stats)
+	}
+
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
 
@@ -43,22 +83,37 @@ func (f *StringField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	if err != nil {
 		return err
 	}
+	defer f.Release()
+
+	// each value has at least its length, so n (which comes from
+	// the file) is checked before the values are allocated at once
+	n := f.ReadValues()
+	if n > rr.Len()/4 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
 
-	for j := 0; j < pg.N; j++ {
+	f.vals = parquet.Reserve(f.vals, n)
+	for j := 0; j < n; j++ {
 		var x int32
 		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
 			return err
 		}
-		s := make([]byte, x)
-		if _, err := rr.Read(s); err != nil {
-			return err
+		if x < 0 || int(x) > rr.Len() {
+			return fmt.Errorf("invalid string length %d", x)
 		}
-
-		f.vals = append(f.vals, string(s))
+		f.vals = append(f.vals, f.Intern(rr.Next(int(x))))
 	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return nil
 }
 
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *StringField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[string](b, f.Name())
+}
+
 func (f *StringField) Scan(r *{{.StructType}}) {
 	if len(f.vals) == 0 {
 		return
@@ -68,15 +123,43 @@ func (f *StringField) Scan(r *{{.StructType}}) {
 	f.vals = f.vals[1:]
 }
 
+// Value returns the value of the field's next row.
+func (f *StringField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
+}
+
 func (f *StringField) Add(r {{.StructType}}) {
-	v := f.read(r)
-	f.stats.add(v)
+	f.add(f.read(r))
+}
+
+// AddValues adds the values of rows without records.
+func (f *StringField) AddValues(vals []string) {
+	for _, v := range vals {
+		f.add(v)
+	}
+}
+
+func (f *StringField) add(v string) {
+	if !f.statsOnWrite {
+		f.stats.add(v)
+	}
+	if f.dict != nil {
+		f.dict.Add(v)
+	}
 	f.vals = append(f.vals, v)
 }
 
 func (f *StringField) Levels() ([]uint8, []uint8) {
 	return nil, nil
 }
+
+// Rows returns the number of rows that have been added.
+func (f *StringField) Rows() int {
+	return len(f.vals)
+}
 {{end}}`
 
 var stringStatsTpl = `{{define "stringStats"}}
@@ -84,8 +167,9 @@ var stringStatsTpl = `{{define "stringStats"}}
 const nilString = "__#NIL#__"
 
 type stringStats struct {
-	min string
-	max string
+	min      string
+	max      string
+	truncate int
 }
 
 func newStringStats() *stringStats {
@@ -124,13 +208,13 @@ func (s *stringStats) Min() []byte {
 	if s.min == nilString {
 		return nil
 	}
-	return []byte(s.min)
+	return parquet.TruncateMin([]byte(s.min), s.truncate)
 }
 
 func (s *stringStats) Max() []byte {
 	if s.max == nilString {
 		return nil
 	}
-	return []byte(s.max)
+	return parquet.TruncateMax([]byte(s.max), s.truncate)
 }
 {{end}}`
@@ -7,6 +7,9 @@ type StringOptionalField struct {
 	read   func(r {{.StructType}}, vals []{{removeStar .TypeName}}, def, rep []uint8) ([]{{removeStar .TypeName}}, []uint8, []uint8)
 	write  func(r *{{.StructType}}, vals []{{removeStar .TypeName}}, def, rep []uint8) (int, int)
 	stats *stringOptionalStats
+	dict  *parquet.Dictionary
+	deltaLength bool
+	statsOnWrite bool
 }
 
 func NewStringOptionalField(read func(r {{.StructType}}, vals []{{removeStar .TypeName}}, def, rep []uint8) ([]{{removeStar .TypeName}}, []uint8, []uint8), write func(r *{{.StructType}}, vals []{{removeStar .TypeName}}, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *StringOptionalField {
@@ -19,12 +22,29 @@ func NewStringOptionalField(read func(r {{.StructType}}, vals []{{removeStar .Ty
 }
 
 func (f *StringOptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, RepetitionType: f.RepetitionType, Types: f.Types}
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, ConvertedType: pconvertedType(sch.ConvertedType_UTF8), LogicalType: &sch.LogicalType{STRING: &sch.StringType{}}, RepetitionType: f.RepetitionType, Types: f.Types}
 }
 
 func (f *StringOptionalField) Add(r {{.StructType}}) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *StringOptionalField) AddValues(vals []*string) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+func (f *StringOptionalField) add(vals []string, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	if f.dict != nil {
+		for _, v := range vals[len(f.vals):] {
+			f.dict.Add(v)
+		}
+	}
 	f.vals = vals
 	f.Defs = defs
 	f.Reps = reps
@@ -43,7 +63,48 @@ func (f *StringOptionalField) Scan(r *{{.StructType}}) {
 	}
 }
 
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *StringOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
+}
+
+// SetDictionary makes the field dictionary encoded.
+func (f *StringOptionalField) SetDictionary(d *parquet.Dictionary) {
+	f.dict = d
+}
+
+// SetDeltaLength makes the field DELTA_LENGTH_BYTE_ARRAY encoded
+// instead of dictionary encoded.
+func (f *StringOptionalField) SetDeltaLength() {
+	f.deltaLength = true
+	f.dict = nil
+}
+
+// SetStatsTruncateLength truncates the field's min and max
+// statistics to at most n bytes.
+func (f *StringOptionalField) SetStatsTruncateLength(n int) {
+	f.stats.truncate = n
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *StringOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
 func (f *StringOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.deltaLength {
+		return f.DoWriteDeltaLength(w, meta, f.vals, len(f.Defs), f.stats)
+	}
+
+	if f.dict != nil {
+		return f.DoWriteDictionary(w, meta, f.dict, f.vals, len(f.Defs), f.stats)
+	}
+
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
 
@@ -64,22 +125,37 @@ func (f *StringOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	if err != nil {
 		return err
 	}
+	defer f.Release()
+
+	// each value has at least its length, so the number of values
+	// (which comes from the file) is checked before they are allocated
+	n := f.ReadValues()
+	if n > rr.Len()/4 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
 
-	for j := 0; j < f.Values(); j++ {
+	f.vals = parquet.Reserve(f.vals, n)
+	for j := 0; j < n; j++ {
 		var x int32
 		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
 			return err
 		}
-		s := make([]byte, x)
-		if _, err := rr.Read(s); err != nil {
-			return err
+		if x < 0 || int(x) > rr.Len() {
+			return fmt.Errorf("invalid string length %d", x)
 		}
-
-		f.vals = append(f.vals, string(s))
+		f.vals = append(f.vals, f.Intern(rr.Next(int(x))))
 	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return nil
 }
 
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *StringOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[string](b, f.Name())
+}
+
 func (f *StringOptionalField) Levels() ([]uint8, []uint8) {
 	return f.Defs, f.Reps
 }
@@ -90,10 +166,11 @@ var stringOptionalStatsTpl = `{{define "stringOptionalStats"}}
 const nilOptString = "__#NIL#__"
 
 type stringOptionalStats struct {
-	min    string
-	max    string
-	nils int64
-	maxDef uint8
+	min      string
+	max      string
+	nils     int64
+	maxDef   uint8
+	truncate int
 }
 
 func newStringOptionalStats(d uint8) *stringOptionalStats {
@@ -142,13 +219,13 @@ func (s *stringOptionalStats) Min() []byte {
 	if s.min == nilOptString {
 		return nil
 	}
-	return []byte(s.min)
+	return parquet.TruncateMin([]byte(s.min), s.truncate)
 }
 
 func (s *stringOptionalStats) Max() []byte {
 	if s.max == nilOptString {
 		return nil
 	}
-	return []byte(s.max)
+	return parquet.TruncateMax([]byte(s.max), s.truncate)
 }
 {{end}}`
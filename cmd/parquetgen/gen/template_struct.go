@@ -3,5 +3,7 @@ package gen
 var structTpl = `package {{.Package}}
 
 // This code is generated by github.com/rclayton-godaddy/parquet.
-
+{{if .Float16}}
+import "github.com/rclayton-godaddy/parquet"
+{{end}}
 {{.Structs}}`
@@ -0,0 +1,380 @@
+package gen
+
+var requiredTimeTpl = `{{define "timeField"}}
+type TimeField struct {
+	vals []time.Time
+	parquet.RequiredField
+	read  func(r {{.StructType}}) time.Time
+	write func(r *{{.StructType}}, vals []time.Time)
+	ts    parquet.Timestamp
+	stats *timeStats
+	statsOnWrite bool
+}
+
+func NewTimeField(read func(r {{.StructType}}) time.Time, write func(r *{{.StructType}}, vals []time.Time), path []string, ts parquet.Timestamp, opts ...func(*parquet.RequiredField)) *TimeField {
+	return &TimeField{
+		read:          read,
+		write:         write,
+		ts:            ts,
+		RequiredField: parquet.NewRequiredField(path, opts...),
+		stats:         newTimeStats(),
+	}
+}
+
+func (f *TimeField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: TimeType, ConvertedType: f.ts.ConvertedType(), LogicalType: f.ts.LogicalType(), RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+}
+
+func (f *TimeField) Read(r io.ReadSeeker, pg parquet.Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n > rr.Len()/8 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	v := make([]int64, n)
+	if err := binary.Read(rr, binary.LittleEndian, &v); err != nil {
+		return err
+	}
+
+	// the file's TIMESTAMP annotation wins over the field's
+	ts, ok := parquet.TimestampOf(pg.LogicalType, pg.ConvertedType)
+	if !ok {
+		ts = f.ts
+	}
+
+	f.vals = parquet.Reserve(f.vals, len(v))
+	for _, x := range v {
+		f.vals = append(f.vals, ts.Time(x))
+	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
+	return nil
+}
+
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *TimeField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[time.Time](b, f.Name())
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *TimeField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
+func (f *TimeField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		for _, v := range f.vals {
+			f.stats.add(f.ts.Int64(v))
+		}
+	}
+
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	bs := make([]byte, 8)
+	for _, v := range f.vals {
+		binary.LittleEndian.PutUint64(bs, uint64(f.ts.Int64(v)))
+		if _, err := buf.Write(bs); err != nil {
+			return err
+		}
+	}
+	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+}
+
+func (f *TimeField) Scan(r *{{.StructType}}) {
+	if len(f.vals) == 0 {
+		return
+	}
+
+	f.write(r, f.vals)
+	f.vals = f.vals[1:]
+}
+
+// Value returns the value of the field's next row.
+func (f *TimeField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
+}
+
+func (f *TimeField) Add(r {{.StructType}}) {
+	f.add(f.read(r))
+}
+
+// AddValues adds the values of rows without records.
+func (f *TimeField) AddValues(vals []time.Time) {
+	for _, v := range vals {
+		f.add(v)
+	}
+}
+
+func (f *TimeField) add(v time.Time) {
+	if !f.statsOnWrite {
+		f.stats.add(f.ts.Int64(v))
+	}
+	f.vals = append(f.vals, v)
+}
+
+func (f *TimeField) Levels() ([]uint8, []uint8) {
+	return nil, nil
+}
+
+// Rows returns the number of rows that have been added.
+func (f *TimeField) Rows() int {
+	return len(f.vals)
+}
+{{end}}`
+
+var optionalTimeTpl = `{{define "timeOptionalField"}}
+type TimeOptionalField struct {
+	parquet.OptionalField
+	vals  []time.Time
+	read  func(r {{.StructType}}, vals []time.Time, defs, reps []uint8) ([]time.Time, []uint8, []uint8)
+	write func(r *{{.StructType}}, vals []time.Time, defs, reps []uint8) (int, int)
+	ts    parquet.Timestamp
+	stats *timeOptionalStats
+	statsOnWrite bool
+}
+
+func NewTimeOptionalField(read func(r {{.StructType}}, vals []time.Time, defs, reps []uint8) ([]time.Time, []uint8, []uint8), write func(r *{{.StructType}}, vals []time.Time, defs, reps []uint8) (int, int), path []string, types []int, ts parquet.Timestamp, opts ...func(*parquet.OptionalField)) *TimeOptionalField {
+	return &TimeOptionalField{
+		read:          read,
+		write:         write,
+		ts:            ts,
+		OptionalField: parquet.NewOptionalField(path, types, opts...),
+		stats:         newTimeOptionalStats(maxDef(types)),
+	}
+}
+
+func (f *TimeOptionalField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: TimeType, ConvertedType: f.ts.ConvertedType(), LogicalType: f.ts.LogicalType(), RepetitionType: f.RepetitionType, Types: f.Types}
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *TimeOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
+func (f *TimeOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.ts, f.vals, f.Defs)
+	}
+
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	bs := make([]byte, 8)
+	for _, v := range f.vals {
+		binary.LittleEndian.PutUint64(bs, uint64(f.ts.Int64(v)))
+		if _, err := buf.Write(bs); err != nil {
+			return err
+		}
+	}
+	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
+}
+
+func (f *TimeOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n == 0 {
+		// every value in the column chunk is null
+		return nil
+	}
+
+	if n > rr.Len()/8 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	v := make([]int64, n)
+	if err := binary.Read(rr, binary.LittleEndian, &v); err != nil {
+		return err
+	}
+
+	// the file's TIMESTAMP annotation wins over the field's
+	ts, ok := parquet.TimestampOf(pg.LogicalType, pg.ConvertedType)
+	if !ok {
+		ts = f.ts
+	}
+
+	f.vals = parquet.Reserve(f.vals, len(v))
+	for _, x := range v {
+		f.vals = append(f.vals, ts.Time(x))
+	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
+	return nil
+}
+
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *TimeOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[time.Time](b, f.Name())
+}
+
+func (f *TimeOptionalField) Add(r {{.StructType}}) {
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *TimeOptionalField) AddValues(vals []*time.Time) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+func (f *TimeOptionalField) add(vals []time.Time, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(f.ts, vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	f.vals = vals
+	f.Defs = defs
+	f.Reps = reps
+}
+
+func (f *TimeOptionalField) Scan(r *{{.StructType}}) {
+	if len(f.Defs) == 0 {
+		return
+	}
+
+	v, l := f.write(r, f.vals, f.Defs, f.Reps)
+	f.vals = f.vals[v:]
+	f.Defs = f.Defs[l:]
+	if len(f.Reps) > 0 {
+		f.Reps = f.Reps[l:]
+	}
+}
+
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *TimeOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
+}
+
+func (f *TimeOptionalField) Levels() ([]uint8, []uint8) {
+	return f.Defs, f.Reps
+}
+{{end}}`
+
+var timeStatsTpl = `{{define "timeStats"}}
+type timeStats struct {
+	min int64
+	max int64
+}
+
+func newTimeStats() *timeStats {
+	return &timeStats{
+		min: math.MaxInt64,
+		max: math.MinInt64,
+	}
+}
+
+func (t *timeStats) add(val int64) {
+	if val < t.min {
+		t.min = val
+	}
+	if val > t.max {
+		t.max = val
+	}
+}
+
+func (t *timeStats) bytes(v int64) []byte {
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, uint64(v))
+	return bs
+}
+
+func (t *timeStats) NullCount() *int64 {
+	return nil
+}
+
+func (t *timeStats) DistinctCount() *int64 {
+	return nil
+}
+
+func (t *timeStats) Min() []byte {
+	return t.bytes(t.min)
+}
+
+func (t *timeStats) Max() []byte {
+	return t.bytes(t.max)
+}
+{{end}}`
+
+var timeOptionalStatsTpl = `{{define "timeOptionalStats"}}
+type timeOptionalStats struct {
+	min     int64
+	max     int64
+	nils    int64
+	nonNils int64
+	maxDef  uint8
+}
+
+func newTimeOptionalStats(d uint8) *timeOptionalStats {
+	return &timeOptionalStats{
+		min:    math.MaxInt64,
+		max:    math.MinInt64,
+		maxDef: d,
+	}
+}
+
+func (t *timeOptionalStats) add(ts parquet.Timestamp, vals []time.Time, defs []uint8) {
+	var i int
+	for _, def := range defs {
+		if def < t.maxDef {
+			t.nils++
+		} else {
+			val := ts.Int64(vals[i])
+			i++
+
+			t.nonNils++
+			if val < t.min {
+				t.min = val
+			}
+			if val > t.max {
+				t.max = val
+			}
+		}
+	}
+}
+
+func (t *timeOptionalStats) bytes(v int64) []byte {
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, uint64(v))
+	return bs
+}
+
+func (t *timeOptionalStats) NullCount() *int64 {
+	return &t.nils
+}
+
+func (t *timeOptionalStats) DistinctCount() *int64 {
+	return nil
+}
+
+func (t *timeOptionalStats) Min() []byte {
+	if t.nonNils == 0 {
+		return nil
+	}
+	return t.bytes(t.min)
+}
+
+func (t *timeOptionalStats) Max() []byte {
+	if t.nonNils == 0 {
+		return nil
+	}
+	return t.bytes(t.max)
+}
+{{end}}`
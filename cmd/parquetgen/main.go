@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -23,8 +24,55 @@ var (
 	ignore       = flag.Bool("ignore", true, "ignore unsupported fields in -type, otherwise log.Fatal is called when an unsupported type is encountered")
 	parq         = flag.String("parquet", "", "path to a parquet file (if you are generating code based on an existing parquet file or printing the file metadata or page headers)")
 	structOutPth = flag.String("struct-output", "generated_struct.go", "name of the file that is produced, defaults to parquet.go")
+	split        = flag.Bool("split", false, "write the writer, reader, and fields to separate files named after -output (parquet_writer.go, parquet_reader.go, and parquet_fields.go by default)")
+	helpers      = flag.Bool("helpers", false, "generate the Equal and Clone methods of -type, which compare and copy the fields that are written to parquet (-type must be in -package)")
+	arrow        = flag.Bool("arrow", false, "generate an ArrowWriter, which writes the columns of -type that aren't repeated to an Arrow IPC stream")
+	monomorphic  = flag.Bool("monomorphic", false, "call the methods of each column's field directly when adding and scanning records instead of through the Field interface (which is faster for records with many small columns)")
+	splitWriter  = flag.Bool("split-writer", false, "generate a SplitWriter, which writes to a series of files that are each about a target size")
+	jsonArray    = flag.Bool("json", false, "generate WriteJSONArray, which writes a parquet file of the records in a JSON array")
+	dumpColumn   = flag.Bool("dump", false, "generate DumpColumn, which writes the values of a column of a parquet file as text")
+	sorted       = flag.Bool("sorted", false, "generate a SortedWriter, which writes the records in the order of a less function by sorting runs of them in temporary files and merging them")
+	projections  projectionFlag
+	implements   implementsFlag
 )
 
+func init() {
+	flag.Var(&projections, "projection", "a struct and reader that only has some of the top level columns of -type, for example Summary:id,name,total (can be repeated)")
+	flag.Var(&implements, "implements", "an interface that the writer (or the reader, with reader=) implements, for example RecordWriter or reader=example.com/pipeline.RecordReader, which also generates AddAny and ScanAny (can be repeated)")
+}
+
+// projectionFlag collects the -projection flags.
+type projectionFlag []gen.Projection
+
+func (p *projectionFlag) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *projectionFlag) Set(s string) error {
+	pr, err := gen.ParseProjection(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, pr)
+	return nil
+}
+
+// implementsFlag collects the -implements flags.
+type implementsFlag []gen.Implementation
+
+func (i *implementsFlag) String() string {
+	return fmt.Sprint(*i)
+}
+
+func (i *implementsFlag) Set(s string) error {
+	impl, err := gen.ParseImplementation(s)
+	if err != nil {
+		return err
+	}
+	*i = append(*i, impl)
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -32,15 +80,29 @@ func main() {
 		log.Fatal("choose -parquet or -input, but not both")
 	}
 
+	opts := gen.Options{
+		Ignore:      *ignore,
+		Split:       *split,
+		Helpers:     *helpers,
+		Arrow:       *arrow,
+		Monomorphic: *monomorphic,
+		SplitWriter: *splitWriter,
+		JSONArray:   *jsonArray,
+		DumpColumn:  *dumpColumn,
+		Sorted:      *sorted,
+		Implements:  implements,
+		Projections: projections,
+	}
+
 	var err error
 	if *metadata {
 		readFooter()
 	} else if *pageheaders {
 		readPageHeaders()
 	} else if *parq == "" {
-		err = gen.FromStruct(*pth, *outPth, *typ, *pkg, *imp, *ignore)
+		err = gen.FromStruct(*pth, *outPth, *typ, *pkg, *imp, opts)
 	} else {
-		err = gen.FromParquet(*parq, *structOutPth, *outPth, *typ, *pkg, *imp, *ignore)
+		err = gen.FromParquet(*parq, *structOutPth, *outPth, *typ, *pkg, *imp, opts)
 	}
 
 	if err != nil {
@@ -138,7 +138,7 @@ func TestFields(t *testing.T) {
 		{
 			name:   "unsupported fields",
 			typ:    "Unsupported",
-			errors: []error{fmt.Errorf("unsupported type &{time Time}")},
+			errors: []error{fmt.Errorf("unsupported type &{time Duration}")},
 			expected: fields.Field{
 				Children: []fields.Field{
 					{Type: "int32", Name: "ID", ColumnName: "ID", RepetitionType: fields.Required},
@@ -158,8 +158,122 @@ func TestFields(t *testing.T) {
 				},
 			},
 			errors: []error{
-				fmt.Errorf("unsupported type &{time Time}"),
-				fmt.Errorf("unsupported type &{time Time}"),
+				fmt.Errorf("unsupported type &{time Duration}"),
+				fmt.Errorf("unsupported type &{time Duration}"),
+			},
+		},
+		{
+			name: "slices of pointers and pointers to slices",
+			typ:  "SlicePointers",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+				},
+			},
+			errors: []error{
+				fmt.Errorf("field Hobbies is a slice of pointers ([]*Hobby), which isn't supported"),
+				fmt.Errorf("field Names is a pointer to a slice (*[]string), which isn't supported"),
+				fmt.Errorf("field Codes is a slice of pointers ([]*string), which isn't supported"),
+			},
+		},
+		{
+			name: "nested slices of pointers and pointers to slices",
+			typ:  "NestedSlicePointers",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					{Type: "SlicePointers", Name: "Thing", ColumnName: "thing", RepetitionType: fields.Required, Children: []fields.Field{
+						{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					}},
+				},
+			},
+			errors: []error{
+				fmt.Errorf("field Hobbies is a slice of pointers ([]*Hobby), which isn't supported"),
+				fmt.Errorf("field Names is a pointer to a slice (*[]string), which isn't supported"),
+				fmt.Errorf("field Codes is a slice of pointers ([]*string), which isn't supported"),
+			},
+		},
+		{
+			name: "time",
+			typ:  "Timestamps",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "time.Time", Name: "Created", ColumnName: "created", RepetitionType: fields.Required},
+					{Type: "time.Time", Name: "Updated", ColumnName: "updated", RepetitionType: fields.Optional, TimeUnit: "millis", LocalTime: true},
+					{Type: "time.Time", Name: "Seen", ColumnName: "Seen", RepetitionType: fields.Repeated},
+					{Type: "time.Time", Name: "Epoch", ColumnName: "epoch", RepetitionType: fields.Required, UnixTime: "s"},
+				},
+			},
+		},
+		{
+			name: "float16",
+			typ:  "Float16s",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "parquet.Float16", Name: "Weight", ColumnName: "weight", RepetitionType: fields.Required},
+					{Type: "parquet.Float16", Name: "Bias", ColumnName: "bias", RepetitionType: fields.Optional},
+					{Type: "parquet.Float16", Name: "Vector", ColumnName: "vector", RepetitionType: fields.Repeated},
+				},
+			},
+		},
+		{
+			name: "methods",
+			typ:  "Methods",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					{Type: "string", Name: "code", ColumnName: "code", RepetitionType: fields.Required, Getter: "Code", Setter: "SetCode"},
+					{Type: "string", Name: "nickname", ColumnName: "nickname", RepetitionType: fields.Optional, Getter: "Nickname", Setter: "Rename"},
+				},
+			},
+		},
+		{
+			name: "sql null types",
+			typ:  "Nulls",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					{Type: "string", Name: "Name", ColumnName: "name", RepetitionType: fields.Optional, Null: "sql.NullString"},
+					{Type: "time.Time", Name: "Seen", ColumnName: "seen", RepetitionType: fields.Optional, TimeUnit: "millis", Null: "sql.NullTime"},
+				},
+			},
+		},
+		{
+			name: "empty as null",
+			typ:  "EmptyAsNull",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "string", Name: "Name", ColumnName: "name", RepetitionType: fields.Optional, EmptyAsNull: true, NoPointer: true},
+					{Type: "string", Name: "Nickname", ColumnName: "nickname", RepetitionType: fields.Optional, EmptyAsNull: true},
+					{Type: "string", Name: "Code", ColumnName: "code", RepetitionType: fields.Optional, Null: "sql.NullString", EmptyAsNull: true},
+				},
+			},
+		},
+		{
+			name: "null sentinels",
+			typ:  "Sentinels",
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int64", Name: "Rank", ColumnName: "rank", RepetitionType: fields.Optional, NoPointer: true, NullSentinel: "-1"},
+					{Type: "uint32", Name: "Count", ColumnName: "count", RepetitionType: fields.Optional, NoPointer: true, NullSentinel: "4294967295"},
+					{Type: "float64", Name: "Ratio", ColumnName: "ratio", RepetitionType: fields.Optional, NoPointer: true, NullSentinel: "-0.5"},
+				},
+			},
+		},
+		{
+			name: "nested sql null types",
+			typ:  "NestedNulls",
+			errors: []error{
+				fmt.Errorf("field Name of Nulls is a sql.NullString, which is only supported for fields of the top level struct"),
+				fmt.Errorf("field Seen of Nulls is a sql.NullTime, which is only supported for fields of the top level struct"),
+			},
+			expected: fields.Field{
+				Children: []fields.Field{
+					{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					{Type: "Nulls", Name: "Nulls", ColumnName: "nulls", RepetitionType: fields.Required, Children: []fields.Field{
+						{Type: "int32", Name: "ID", ColumnName: "id", RepetitionType: fields.Required},
+					}},
+				},
 			},
 		},
 		{
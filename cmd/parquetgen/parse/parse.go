@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"go/parser"
 	"go/token"
+	gotypes "go/types"
 	"log"
+	"math"
+	"strconv"
 	"strings"
 
 	"go/ast"
@@ -34,6 +37,8 @@ type Result struct {
 	Parent flds.Field
 	// Errors is a list of errors that occurred while parsing a struct.
 	Errors []error
+	// Package is the name of the package that the struct is in.
+	Package string
 }
 
 // Fields gets the fields of the given struct.
@@ -69,8 +74,9 @@ func Fields(typ, pth string) (*Result, error) {
 	errs := getChildren(&parent, fields)
 
 	return &Result{
-		Parent: flds.Field{Type: typ, Children: parent.Children},
-		Errors: errs,
+		Parent:  flds.Field{Type: typ, Children: parent.Children},
+		Errors:  errs,
+		Package: file.Name.Name,
 	}, nil
 }
 
@@ -88,6 +94,16 @@ func getChildren(parent *flds.Field, fields map[string]flds.Field) []error {
 			continue
 		}
 
+		if child.Getter != "" {
+			errs = append(errs, fmt.Errorf("field %s is a struct, methods are only supported for primitive fields", child.Name))
+			continue
+		}
+
+		if shape := unsupportedShape(child.Type); shape != "" {
+			errs = append(errs, fmt.Errorf("field %s is %s (%s), which isn't supported", child.Name, shape, child.Type))
+			continue
+		}
+
 		f, ok := fields[child.Type]
 		if !ok {
 			f, ok = fields[child.Type]
@@ -99,6 +115,30 @@ func getChildren(parent *flds.Field, fields map[string]flds.Field) []error {
 
 		errs = append(errs, getChildren(&child, fields)...)
 
+		if !child.Embedded {
+			var chs []flds.Field
+			for _, ch := range child.Children {
+				if ch.Getter != "" {
+					errs = append(errs, fmt.Errorf("field %s of %s is bound to methods, which is only supported for fields of the top level struct", ch.Name, child.Name))
+					continue
+				}
+				if ch.Null != "" {
+					errs = append(errs, fmt.Errorf("field %s of %s is a %s, which is only supported for fields of the top level struct", ch.Name, child.Name, ch.Null))
+					continue
+				}
+				if ch.EmptyAsNull {
+					errs = append(errs, fmt.Errorf("field %s of %s has the empty_as_null option, which is only supported for fields of the top level struct", ch.Name, child.Name))
+					continue
+				}
+				if ch.NullSentinel != "" {
+					errs = append(errs, fmt.Errorf("field %s of %s has the null_sentinel option, which is only supported for fields of the top level struct", ch.Name, child.Name))
+					continue
+				}
+				chs = append(chs, ch)
+			}
+			child.Children = chs
+		}
+
 		f.Name = child.Name
 		f.Type = child.Type
 		f.ColumnName = child.ColumnName
@@ -139,22 +179,30 @@ func getFields(n map[string]ast.Node) (map[string]fields.Field, error) {
 			Type: k,
 		}
 
+		var err error
 		ast.Inspect(n, func(n ast.Node) bool {
-			if n == nil {
+			if n == nil || err != nil {
 				return false
 			}
 
 			switch x := n.(type) {
 			case *ast.Field:
-				if len(x.Names) == 1 && !isPrivate(x) {
-					f, skip := getField(x.Names[0].Name, x, nil)
-					if !skip {
+				var f flds.Field
+				var skip bool
+				if len(x.Names) == 1 {
+					f, skip, err = getField(x.Names[0].Name, x, nil)
+					// unexported fields are only used if they are
+					// bound to methods
+					if isPrivate(x) && f.Getter == "" {
+						skip = true
+					}
+					if !skip && err == nil {
 						parent.Children = append(parent.Children, f)
 					}
 				} else if len(x.Names) == 0 && !isPrivate(x) {
-					f, skip := getField(fmt.Sprintf("%s", x.Type), x, nil)
+					f, skip, err = getField(fmt.Sprintf("%s", x.Type), x, nil)
 					f.Embedded = true
-					if !skip {
+					if !skip && err == nil {
 						parent.Children = append(parent.Children, f)
 					}
 				}
@@ -162,6 +210,10 @@ func getFields(n map[string]ast.Node) (map[string]fields.Field, error) {
 			return true
 		})
 
+		if err != nil {
+			return nil, err
+		}
+
 		fields[k] = parent
 	}
 
@@ -173,16 +225,27 @@ func getType(typ string) string {
 	return parts[len(parts)-1]
 }
 
-func getField(name string, x ast.Node, parent *flds.Field) (flds.Field, bool) {
-	var typ, tag string
+func getField(name string, x ast.Node, parent *flds.Field) (flds.Field, bool, error) {
+	var typ, tag, null string
+	var opts []string
 	var optional, repeated bool
 	ast.Inspect(x, func(n ast.Node) bool {
 		switch t := n.(type) {
 		case *ast.Field:
 			if t.Tag != nil {
-				tag = parseTag(t.Tag.Value)
+				tag, opts = parseTag(t.Tag.Value)
 			}
 			typ = fmt.Sprintf("%s", t.Type)
+		case *ast.SelectorExpr:
+			if x, ok := t.X.(*ast.Ident); ok {
+				s := fmt.Sprintf("%s.%s", x.Name, t.Sel.Name)
+				if types[s] {
+					typ = s
+				} else if nt, ok := nullTypes[s]; ok {
+					typ = nt
+					null = s
+				}
+			}
 		case *ast.ArrayType:
 			at := n.(*ast.ArrayType)
 			s := fmt.Sprintf("%v", at.Elt)
@@ -201,6 +264,14 @@ func getField(name string, x ast.Node, parent *flds.Field) (flds.Field, bool) {
 		return true
 	})
 
+	// a slice of pointers and a pointer to a slice keep their whole
+	// type so that getChildren can say why they aren't supported
+	if t, ok := x.(*ast.Field); ok {
+		if s := gotypes.ExprString(t.Type); unsupportedShape(s) != "" {
+			typ = s
+		}
+	}
+
 	if tag == "" {
 		tag = name
 	}
@@ -208,25 +279,158 @@ func getField(name string, x ast.Node, parent *flds.Field) (flds.Field, bool) {
 	rt := fields.Required
 	if repeated {
 		rt = fields.Repeated
-	} else if optional {
+	} else if optional || null != "" {
 		rt = fields.Optional
 	}
 
-	return flds.Field{
+	f := flds.Field{
 		Type:           typ,
 		Name:           name,
 		ColumnName:     tag,
 		RepetitionType: rt,
-	}, tag == "-"
+		Null:           null,
+	}
+
+	for _, opt := range opts {
+		k, v := opt, ""
+		if i := strings.Index(opt, "="); i > -1 {
+			k, v = opt[:i], opt[i+1:]
+		}
+
+		switch {
+		case k == "unit" && typ == "time.Time":
+			if _, ok := flds.TimeUnits[v]; !ok {
+				return f, false, fmt.Errorf("invalid time unit %s on field %s (must be millis, micros, or nanos)", v, name)
+			}
+			f.TimeUnit = v
+		case k == "utc" && typ == "time.Time":
+			utc, err := strconv.ParseBool(v)
+			if err != nil {
+				return f, false, fmt.Errorf("invalid utc option %s on field %s", v, name)
+			}
+			f.LocalTime = !utc
+		case k == "unixtime" && typ == "time.Time":
+			if _, ok := flds.UnixTimeUnits[v]; !ok {
+				return f, false, fmt.Errorf("invalid unixtime unit %s on field %s (must be s, ms, us, or ns)", v, name)
+			}
+			f.UnixTime = v
+		case k == "empty_as_null" && v == "":
+			if typ != "string" || repeated {
+				return f, false, fmt.Errorf("field %s has the empty_as_null option, which is only supported for string and *string fields", name)
+			}
+			f.EmptyAsNull = true
+			if !optional && null == "" {
+				f.NoPointer = true
+				f.RepetitionType = fields.Optional
+			}
+		case k == "null_sentinel" && v != "":
+			if err := checkSentinel(typ, v); err != nil || repeated || optional || null != "" {
+				return f, false, fmt.Errorf("invalid null_sentinel option %s on field %s (it is only supported for int32, int64, uint32, uint64, float32, and float64 fields that aren't pointers or slices)", v, name)
+			}
+			f.NullSentinel = v
+			f.NoPointer = true
+			f.RepetitionType = fields.Optional
+		case k == "methods" && v == "":
+			f.Getter = strings.ToUpper(name[:1]) + name[1:]
+			f.Setter = "Set" + f.Getter
+		case k == "get" && v != "":
+			f.Getter = v
+		case k == "set" && v != "":
+			f.Setter = v
+		default:
+			return f, false, fmt.Errorf("unsupported tag option %s on field %s", opt, name)
+		}
+	}
+
+	if f.Getter != "" || f.Setter != "" {
+		if f.Getter == "" || f.Setter == "" {
+			return f, false, fmt.Errorf("field %s must have both a get and a set method", name)
+		}
+
+		if repeated {
+			return f, false, fmt.Errorf("field %s is repeated, methods are only supported for required and optional fields", name)
+		}
+	}
+
+	if null != "" {
+		if repeated || optional {
+			return f, false, fmt.Errorf("field %s is a slice or pointer of %s, which isn't supported", name, null)
+		}
+
+		if f.Getter != "" {
+			return f, false, fmt.Errorf("field %s is a %s, which can't be bound to methods", name, null)
+		}
+	}
+
+	if f.EmptyAsNull && f.Getter != "" {
+		return f, false, fmt.Errorf("field %s has the empty_as_null option, which can't be used with methods", name)
+	}
+
+	if f.UnixTime != "" && (f.TimeUnit != "" || f.LocalTime) {
+		return f, false, fmt.Errorf("field %s has the unixtime option, which can't be used with the unit or utc options", name)
+	}
+
+	if f.NullSentinel != "" && f.Getter != "" {
+		return f, false, fmt.Errorf("field %s has the null_sentinel option, which can't be used with methods", name)
+	}
+
+	return f, tag == "-", nil
+}
+
+// unsupportedShape describes typ if it is a slice of pointers or a
+// pointer to a slice.  The values of a repeated column can't be null
+// and a nil slice can't be told apart from an empty one, so neither
+// can be written to parquet.
+func unsupportedShape(typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "[]*"):
+		return "a slice of pointers"
+	case strings.HasPrefix(typ, "*[]"):
+		return "a pointer to a slice"
+	}
+	return ""
 }
 
-func parseTag(t string) string {
+// checkSentinel makes sure that the null_sentinel v
+// is a number that a field of type typ can hold.
+func checkSentinel(typ, v string) error {
+	var err error
+	switch typ {
+	case "int32", "int64":
+		_, err = strconv.ParseInt(v, 10, bits(typ))
+	case "uint32", "uint64":
+		_, err = strconv.ParseUint(v, 10, bits(typ))
+	case "float32", "float64":
+		var f float64
+		f, err = strconv.ParseFloat(v, bits(typ))
+		if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			// NaN isn't equal to itself and Inf isn't a go literal
+			err = fmt.Errorf("invalid sentinel %s", v)
+		}
+	default:
+		err = fmt.Errorf("%s fields can't have a null sentinel", typ)
+	}
+	return err
+}
+
+func bits(typ string) int {
+	if strings.HasSuffix(typ, "32") {
+		return 32
+	}
+	return 64
+}
+
+// parseTag returns the column name and options of a parquet tag,
+// for example: `parquet:"created,unit=millis,utc=false"` or
+// `parquet:"age,get=Age,set=SetAge"`.
+func parseTag(t string) (string, []string) {
 	i := strings.Index(t, `parquet:"`)
 	if i == -1 {
-		return ""
+		return "", nil
 	}
 	t = t[i+9:]
-	return t[:strings.Index(t, `"`)]
+	parts := strings.Split(t[:strings.Index(t, `"`)], ",")
+	return parts[0], parts[1:]
 }
 
 type visitorFunc func(n ast.Node) ast.Visitor
@@ -260,12 +464,26 @@ func (f *finder) findTypes(n ast.Node) ast.Visitor {
 }
 
 var types = map[string]bool{
-	"int32":   true,
-	"uint32":  true,
-	"int64":   true,
-	"uint64":  true,
-	"float32": true,
-	"float64": true,
-	"bool":    true,
-	"string":  true,
+	"int32":     true,
+	"uint32":    true,
+	"int64":     true,
+	"uint64":    true,
+	"float32":   true,
+	"float64":   true,
+	"bool":      true,
+	"string":    true,
+	"time.Time": true,
+	// parquet.Float16 is a FLOAT16 column
+	"parquet.Float16": true,
+}
+
+// nullTypes are the database/sql types of optional
+// values and the type of the value that each holds.
+var nullTypes = map[string]string{
+	"sql.NullBool":    "bool",
+	"sql.NullFloat64": "float64",
+	"sql.NullInt32":   "int32",
+	"sql.NullInt64":   "int64",
+	"sql.NullString":  "string",
+	"sql.NullTime":    "time.Time",
 }
@@ -1,6 +1,11 @@
 package parse_test
 
-import "time"
+import (
+	"database/sql"
+	"time"
+
+	"github.com/rclayton-godaddy/parquet"
+)
 
 type Being struct {
 	ID  int32
@@ -82,19 +87,61 @@ type Unsupported struct {
 	Being
 	// This field will be ignored because it's not one of the
 	// supported types.
-	Time time.Time
+	Time time.Duration
 }
 
 type SupportedAndUnsupported struct {
 	Happiness int64
 	x         int
-	T1        time.Time
+	T1        time.Duration
 	Being
 	y           int
-	T2          time.Time
+	T2          time.Duration
 	Anniversary *uint64
 }
 
+type Timestamps struct {
+	Created time.Time  `parquet:"created"`
+	Updated *time.Time `parquet:"updated,unit=millis,utc=false"`
+	Seen    []time.Time
+	Epoch   time.Time `parquet:"epoch,unixtime=s"`
+}
+
+type Float16s struct {
+	Weight parquet.Float16   `parquet:"weight"`
+	Bias   *parquet.Float16  `parquet:"bias"`
+	Vector []parquet.Float16 `parquet:"vector"`
+}
+
+type Methods struct {
+	ID       int32   `parquet:"id"`
+	code     string  `parquet:"code,methods"`
+	nickname *string `parquet:"nickname,get=Nickname,set=Rename"`
+}
+
+type Nulls struct {
+	ID   int32          `parquet:"id"`
+	Name sql.NullString `parquet:"name"`
+	Seen sql.NullTime   `parquet:"seen,unit=millis"`
+}
+
+type EmptyAsNull struct {
+	Name     string         `parquet:"name,empty_as_null"`
+	Nickname *string        `parquet:"nickname,empty_as_null"`
+	Code     sql.NullString `parquet:"code,empty_as_null"`
+}
+
+type Sentinels struct {
+	Rank  int64   `parquet:"rank,null_sentinel=-1"`
+	Count uint32  `parquet:"count,null_sentinel=4294967295"`
+	Ratio float64 `parquet:"ratio,null_sentinel=-0.5"`
+}
+
+type NestedNulls struct {
+	ID    int32 `parquet:"id"`
+	Nulls Nulls `parquet:"nulls"`
+}
+
 type Slice struct {
 	IDs []int32 `parquet:"ids"`
 }
@@ -138,6 +185,18 @@ type Slice7 struct {
 	Thing *Slice6 `parquet:"thing"`
 }
 
+type SlicePointers struct {
+	ID      int32     `parquet:"id"`
+	Hobbies []*Hobby  `parquet:"hobbies"`
+	Names   *[]string `parquet:"names"`
+	Codes   []*string `parquet:"codes"`
+}
+
+type NestedSlicePointers struct {
+	ID    int32         `parquet:"id"`
+	Thing SlicePointers `parquet:"thing"`
+}
+
 type Link struct {
 	Backward []int64
 	Forward  []int64
@@ -8,14 +8,17 @@ import (
 )
 
 // Struct generates a struct definition based on the
-// parquet schema.
+// parquet schema.  A column whose type isn't supported (and a group
+// without any supported columns) is left out of the struct, with a
+// comment that says so, since the generated reader and writer leave
+// it out too.
 func Struct(structName string, schema []*sch.SchemaElement) string {
 	if len(schema) == 0 {
 		return ""
 	}
 
 	schema[0].Name = structName
-	_, out := getStruct(schema[0], schema[1:])
+	_, out, _ := getStruct(schema[0], schema[1:])
 	if strings.Contains(out, "%s") {
 		out = fmt.Sprintf(out, "")
 	}
@@ -23,31 +26,49 @@ func Struct(structName string, schema []*sch.SchemaElement) string {
 	return out
 }
 
-func getStruct(parent *sch.SchemaElement, children []*sch.SchemaElement) (int, string) {
+// getStruct returns the number of elements (including grandchildren)
+// that are parent's children, the definitions of parent's struct and
+// the structs of its groups, and whether parent has any supported
+// columns.
+func getStruct(parent *sch.SchemaElement, children []*sch.SchemaElement) (int, string, bool) {
 	str := fmt.Sprintf(`type %s struct {
 	%%s
 }`, strings.Title(parent.Name))
 	var i, j int
-	var fields string
+	var fields []string
+	var supported bool
 	for i < int(*parent.NumChildren) {
 		ch := children[i+j]
-		fields = fmt.Sprintf("%s\n%s", fields, field(ch))
 		if ch.NumChildren != nil && int(*ch.NumChildren) > 0 {
-			n, s := getStruct(ch, children[i+j+1:])
+			n, s, ok := getStruct(ch, children[i+j+1:])
 			j += n
-			str += fmt.Sprintf("\n\n%s", s)
+			if ok {
+				fields = append(fields, field(ch))
+				str += fmt.Sprintf("\n\n%s", s)
+			} else {
+				fields = append(fields, fmt.Sprintf("// %s doesn't have any supported columns, so it's left out (read the file with IgnoreUnknownColumns)", ch.Name))
+			}
+			supported = supported || ok
+		} else if ch.Type == nil {
+			// an empty group
+			fields = append(fields, fmt.Sprintf("// %s doesn't have any supported columns, so it's left out (read the file with IgnoreUnknownColumns)", ch.Name))
+		} else if getType(ch) == "" {
+			fields = append(fields, fmt.Sprintf("// %s is an unsupported %s column, so it's left out (read the file with IgnoreUnknownColumns)", ch.Name, ch.Type))
+		} else {
+			fields = append(fields, field(ch))
+			supported = true
 		}
 		i++
 	}
 
-	return i + j, fmt.Sprintf(str, fields)
+	return i + j, fmt.Sprintf(str, strings.Join(fields, "\n")), supported
 }
 
 func field(elem *sch.SchemaElement) string {
 	n := strings.Title(elem.Name)
 	t := n
 	if elem.Type != nil {
-		t = getType(elem.Type.String())
+		t = getType(elem)
 	}
 	var ptr string
 	if elem.RepetitionType != nil && *elem.RepetitionType == sch.FieldRepetitionType_OPTIONAL {
@@ -56,8 +77,44 @@ func field(elem *sch.SchemaElement) string {
 	return fmt.Sprintf("%s %s%s `parquet:\"%s\"`", n, ptr, t, elem.Name)
 }
 
-func getType(t string) string {
-	return parquetTypes[t]
+// getType returns the go type of the column elem (or an empty
+// string if it isn't supported).  A FIXED_LEN_BYTE_ARRAY(2) with
+// the FLOAT16 logical type is a parquet.Float16, so a struct that
+// has one must import the parquet package.  An INT32 or INT64 that
+// is annotated as unsigned is a uint32 or uint64.
+func getType(elem *sch.SchemaElement) string {
+	if *elem.Type == sch.Type_FIXED_LEN_BYTE_ARRAY && elem.GetTypeLength() == 2 && elem.LogicalType != nil && elem.LogicalType.FLOAT16 != nil {
+		return "parquet.Float16"
+	}
+
+	if unsigned(elem) {
+		switch *elem.Type {
+		case sch.Type_INT32:
+			return "uint32"
+		case sch.Type_INT64:
+			return "uint64"
+		}
+	}
+	return parquetTypes[elem.Type.String()]
+}
+
+// unsigned is true if elem has one of the UINT_8, UINT_16, UINT_32,
+// and UINT_64 converted types or an unsigned INTEGER logical type (a
+// UINT_8 or UINT_16 is stored as an INT32, so it is a uint32 too).
+func unsigned(elem *sch.SchemaElement) bool {
+	if lt := elem.LogicalType; lt != nil && lt.INTEGER != nil {
+		return !lt.INTEGER.IsSigned
+	}
+
+	if elem.ConvertedType == nil {
+		return false
+	}
+
+	switch *elem.ConvertedType {
+	case sch.ConvertedType_UINT_8, sch.ConvertedType_UINT_16, sch.ConvertedType_UINT_32, sch.ConvertedType_UINT_64:
+		return true
+	}
+	return false
 }
 
 var parquetTypes = map[string]string{
@@ -61,6 +61,47 @@ func TestStruct(t *testing.T) {
 			},
 			expected: "type Root struct {\n	Hobby Hobby  `parquet:\"hobby\"`\n	Id    *int32 `parquet:\"id\"`\n}\n\ntype Hobby struct {\n	Name       *Name `parquet:\"name\"`\n	Difficulty int32 `parquet:\"difficulty\"`\n}\n\ntype Name struct {\n	First *string `parquet:\"first\"`\n	Last  string  `parquet:\"last\"`\n}",
 		},
+		{
+			name: "unsupported column",
+			schema: []*sch.SchemaElement{
+				{Name: "root", NumChildren: pint32(3)},
+				{Name: "id", Type: pt(sch.Type_INT32), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+				{Name: "created", Type: pt(sch.Type_INT96), RepetitionType: prt(sch.FieldRepetitionType_OPTIONAL)},
+				{Name: "name", Type: pt(sch.Type_BYTE_ARRAY), RepetitionType: prt(sch.FieldRepetitionType_OPTIONAL)},
+			},
+			expected: "type Root struct {\n	Id int32 `parquet:\"id\"`\n	// created is an unsupported INT96 column, so it's left out (read the file with IgnoreUnknownColumns)\n	Name *string `parquet:\"name\"`\n}",
+		},
+		{
+			name: "float16",
+			schema: []*sch.SchemaElement{
+				{Name: "root", NumChildren: pint32(2)},
+				{Name: "weight", Type: pt(sch.Type_FIXED_LEN_BYTE_ARRAY), TypeLength: pint32(2), LogicalType: &sch.LogicalType{FLOAT16: &sch.Float16Type{}}, RepetitionType: prt(sch.FieldRepetitionType_OPTIONAL)},
+				{Name: "md5", Type: pt(sch.Type_FIXED_LEN_BYTE_ARRAY), TypeLength: pint32(16), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+			},
+			expected: "type Root struct {\n	Weight *parquet.Float16 `parquet:\"weight\"`\n	// md5 is an unsupported FIXED_LEN_BYTE_ARRAY column, so it's left out (read the file with IgnoreUnknownColumns)\n}",
+		},
+		{
+			name: "unsigned",
+			schema: []*sch.SchemaElement{
+				{Name: "root", NumChildren: pint32(5)},
+				{Name: "a", Type: pt(sch.Type_INT32), ConvertedType: pct(sch.ConvertedType_UINT_8), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+				{Name: "b", Type: pt(sch.Type_INT32), ConvertedType: pct(sch.ConvertedType_UINT_16), RepetitionType: prt(sch.FieldRepetitionType_OPTIONAL)},
+				{Name: "c", Type: pt(sch.Type_INT64), ConvertedType: pct(sch.ConvertedType_UINT_64), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+				{Name: "d", Type: pt(sch.Type_INT64), LogicalType: &sch.LogicalType{INTEGER: &sch.IntType{BitWidth: 64}}, RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+				{Name: "e", Type: pt(sch.Type_INT32), ConvertedType: pct(sch.ConvertedType_INT_16), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+			},
+			expected: "type Root struct {\n	A uint32  `parquet:\"a\"`\n	B *uint32 `parquet:\"b\"`\n	C uint64  `parquet:\"c\"`\n	D uint64  `parquet:\"d\"`\n	E int32   `parquet:\"e\"`\n}",
+		},
+		{
+			name: "group without supported columns",
+			schema: []*sch.SchemaElement{
+				{Name: "root", NumChildren: pint32(2)},
+				{Name: "hash", RepetitionType: prt(sch.FieldRepetitionType_OPTIONAL), NumChildren: pint32(1)},
+				{Name: "md5", Type: pt(sch.Type_FIXED_LEN_BYTE_ARRAY), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+				{Name: "id", Type: pt(sch.Type_INT64), RepetitionType: prt(sch.FieldRepetitionType_REQUIRED)},
+			},
+			expected: "type Root struct {\n	// hash doesn't have any supported columns, so it's left out (read the file with IgnoreUnknownColumns)\n	Id int64 `parquet:\"id\"`\n}",
+		},
 	}
 
 	for i, tc := range testCases {
@@ -83,6 +124,10 @@ func prt(rt sch.FieldRepetitionType) *sch.FieldRepetitionType {
 	return &rt
 }
 
+func pct(ct sch.ConvertedType) *sch.ConvertedType {
+	return &ct
+}
+
 func pt(t sch.Type) *sch.Type {
 	return &t
 }
@@ -0,0 +1,119 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/rclayton-godaddy/parquet/internal/rle"
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// Dictionary collects the distinct values of a string column chunk
+// so that the chunk can be dictionary encoded.  All of the pages of
+// a column chunk share the same Dictionary, and it is written (as
+// the chunk's dictionary page) along with the chunk's first page.
+type Dictionary struct {
+	sorted  bool
+	index   map[string]uint32
+	vals    []string
+	written bool
+}
+
+// NewDictionary returns an empty Dictionary.  If sorted is true the
+// values are sorted before they are written, which tends to compress
+// better, and the dictionary page is marked as sorted.
+func NewDictionary(sorted bool) *Dictionary {
+	return &Dictionary{
+		sorted: sorted,
+		index:  map[string]uint32{},
+	}
+}
+
+// Add adds v to the dictionary if it isn't already in it.
+func (d *Dictionary) Add(v string) {
+	if _, ok := d.index[v]; ok {
+		return
+	}
+	d.index[v] = uint32(len(d.vals))
+	d.vals = append(d.vals, v)
+}
+
+// writePage writes the dictionary page the first time it is called.
+func (d *Dictionary) writePage(w io.Writer, meta *Metadata, pth []string, comp sch.CompressionCodec) error {
+	if d.written {
+		return nil
+	}
+	d.written = true
+
+	if d.sorted {
+		sort.Strings(d.vals)
+		for i, v := range d.vals {
+			d.index[v] = uint32(i)
+		}
+	}
+
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	bs := make([]byte, 4)
+	for _, v := range d.vals {
+		binary.LittleEndian.PutUint32(bs, uint32(len(v)))
+		buf.Write(bs)
+		buf.WriteString(v)
+	}
+
+	compressed := buffpool.Get()
+	defer buffpool.Put(compressed)
+
+	l, cl, data, err := compress(comp, compressed, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if err := meta.writeDictionaryPageHeader(w, pth, l, cl, len(d.vals), d.sorted, comp); err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// indices encodes the dictionary index of each of vals, preceded
+// by the bit width of the indices.
+func (d *Dictionary) indices(vals []string) []byte {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	width := bits.Len32(uint32(len(d.vals) - 1))
+	if width == 0 {
+		width = 1
+	}
+
+	idx := make([]uint32, len(vals))
+	for i, v := range vals {
+		idx[i] = d.index[v]
+	}
+	return append([]byte{byte(width)}, rle.EncodeUint32(idx, width)...)
+}
+
+// DoWriteDictionary writes a page of dictionary indices (and the
+// dictionary page if it hasn't been written yet).
+func (f *RequiredField) DoWriteDictionary(w io.Writer, meta *Metadata, d *Dictionary, vals []string, stats Stats) error {
+	if err := d.writePage(w, meta, f.pth, f.compression); err != nil {
+		return err
+	}
+	return f.doWrite(w, meta, d.indices(vals), len(vals), sch.Encoding_RLE_DICTIONARY, stats)
+}
+
+// DoWriteDictionary writes the levels and a page of dictionary indices
+// (and the dictionary page if it hasn't been written yet).  vals are
+// the values that aren't null.
+func (f *OptionalField) DoWriteDictionary(w io.Writer, meta *Metadata, d *Dictionary, vals []string, count int, stats Stats) error {
+	if err := d.writePage(w, meta, f.pth, f.compression); err != nil {
+		return err
+	}
+	return f.doWrite(w, meta, d.indices(vals), count, sch.Encoding_RLE_DICTIONARY, stats)
+}
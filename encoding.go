@@ -0,0 +1,268 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/rclayton-godaddy/parquet/internal/delta"
+	"github.com/rclayton-godaddy/parquet/internal/rle"
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// plainValues converts the n values of a page to the PLAIN
+// encoding, which is what the generated fields read.  typ is the
+// column's physical type and dict holds the entries of the column
+// chunk's dictionary page (if it has one).
+func plainValues(typ sch.Type, enc sch.Encoding, data []byte, n int, dict [][]byte) ([]byte, error) {
+	switch enc {
+	case sch.Encoding_PLAIN:
+		return data, nil
+	case sch.Encoding_DELTA_BINARY_PACKED:
+		return deltaBinaryPacked(typ, data, n)
+	case sch.Encoding_RLE:
+		if typ != sch.Type_BOOLEAN {
+			return nil, fmt.Errorf("unsupported type %s for encoding %s", typ, enc)
+		}
+		return rleBools(data, n)
+	case sch.Encoding_PLAIN_DICTIONARY, sch.Encoding_RLE_DICTIONARY:
+		return dictionaryValues(data, n, dict)
+	case sch.Encoding_DELTA_LENGTH_BYTE_ARRAY:
+		vals, _, err := deltaLengthByteArray(data, n)
+		if err != nil {
+			return nil, err
+		}
+		return plainByteArray(vals), nil
+	case sch.Encoding_DELTA_BYTE_ARRAY:
+		vals, err := deltaByteArray(data, n)
+		if err != nil {
+			return nil, err
+		}
+		return plainByteArray(vals), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %s", enc)
+	}
+}
+
+// deltaBinaryPacked decodes the DELTA_BINARY_PACKED values of
+// an INT32 or INT64 column.
+func deltaBinaryPacked(typ sch.Type, data []byte, n int) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	vals, _, err := delta.Decode(data, n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case sch.Type_INT32:
+		out := make([]byte, 4*len(vals))
+		for i, v := range vals {
+			binary.LittleEndian.PutUint32(out[4*i:], uint32(int32(v)))
+		}
+		return out, nil
+	case sch.Type_INT64:
+		out := make([]byte, 8*len(vals))
+		for i, v := range vals {
+			binary.LittleEndian.PutUint64(out[8*i:], uint64(v))
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s for encoding %s", typ, sch.Encoding_DELTA_BINARY_PACKED)
+	}
+}
+
+// rleBools decodes the RLE encoded values of a BOOLEAN column (their
+// length in 4 bytes followed by bit width 1 RLE/bit-packed runs) and
+// bit-packs them the way that PLAIN booleans are.
+func rleBools(data []byte, n int) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("RLE encoded booleans are missing their length")
+	}
+
+	l := binary.LittleEndian.Uint32(data)
+	if int64(l) > int64(len(data)-4) {
+		return nil, fmt.Errorf("RLE encoded booleans are %d bytes but there are only %d bytes left", l, len(data)-4)
+	}
+
+	vals, err := rle.DecodeUint32(data[4:4+l], 1, n)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, (len(vals)+7)/8)
+	for i, v := range vals {
+		if v == 1 {
+			out[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return out, nil
+}
+
+// deltaLengthByteArray decodes DELTA_LENGTH_BYTE_ARRAY data: the lengths
+// of the values are DELTA_BINARY_PACKED and then all the values follow.
+// It returns the number of bytes of data that were used.
+func deltaLengthByteArray(data []byte, n int) ([][]byte, int, error) {
+	lengths, pos, err := delta.Decode(data, n)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := make([][]byte, len(lengths))
+	for i, l := range lengths {
+		if l < 0 || l > int64(len(data)-pos) {
+			return nil, 0, fmt.Errorf("invalid length %d for value %d, %d bytes left", l, i, len(data)-pos)
+		}
+		out[i] = data[pos : pos+int(l)]
+		pos += int(l)
+	}
+	return out, pos, nil
+}
+
+// deltaLengthBytes encodes vals as DELTA_LENGTH_BYTE_ARRAY data.  Unlike
+// PLAIN, the lengths aren't mixed in with the values, so a column with
+// lots of empty (or same length) values takes up only a few bytes for
+// their lengths.
+func deltaLengthBytes(vals []string) []byte {
+	var size int
+	lengths := make([]int64, len(vals))
+	for i, v := range vals {
+		lengths[i] = int64(len(v))
+		size += len(v)
+	}
+
+	out := delta.Encode(lengths)
+	out = append(make([]byte, 0, len(out)+size), out...)
+	for _, v := range vals {
+		out = append(out, v...)
+	}
+	return out
+}
+
+// deltaByteArray decodes DELTA_BYTE_ARRAY data: the length of the
+// prefix that each value shares with the previous value is DELTA_BINARY_PACKED
+// and then the rest of each value is DELTA_LENGTH_BYTE_ARRAY.
+func deltaByteArray(data []byte, n int) ([][]byte, error) {
+	prefixes, pos, err := delta.Decode(data, n)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes, _, err := deltaLengthByteArray(data[pos:], n)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]byte, n)
+	var prev []byte
+	for i, p := range prefixes {
+		if p < 0 || p > int64(len(prev)) {
+			return nil, fmt.Errorf("invalid prefix length %d for value %d, the previous value has %d bytes", p, i, len(prev))
+		}
+
+		v := make([]byte, int(p)+len(suffixes[i]))
+		copy(v, prev[:p])
+		copy(v[p:], suffixes[i])
+		out[i] = v
+		prev = v
+	}
+	return out, nil
+}
+
+// plainByteArray encodes vals as PLAIN byte arrays (each
+// value is preceded by its 4 byte length).
+func plainByteArray(vals [][]byte) []byte {
+	var n int
+	for _, v := range vals {
+		n += 4 + len(v)
+	}
+
+	out := make([]byte, 0, n)
+	var l [4]byte
+	for _, v := range vals {
+		binary.LittleEndian.PutUint32(l[:], uint32(len(v)))
+		out = append(out, l[:]...)
+		out = append(out, v...)
+	}
+	return out
+}
+
+// dictionaryEntries splits the PLAIN values of a dictionary page
+// into entries that are still PLAIN encoded, so joining the entries
+// that a data page's indices point to gives the PLAIN values.  length
+// is the size of the values of a FIXED_LEN_BYTE_ARRAY column.
+func dictionaryEntries(typ sch.Type, length int32, data []byte, n int) ([][]byte, error) {
+	var size int
+	switch typ {
+	case sch.Type_INT32, sch.Type_FLOAT:
+		size = 4
+	case sch.Type_INT64, sch.Type_DOUBLE:
+		size = 8
+	case sch.Type_FIXED_LEN_BYTE_ARRAY:
+		if length < 1 {
+			return nil, fmt.Errorf("invalid FIXED_LEN_BYTE_ARRAY length %d", length)
+		}
+		size = int(length)
+	case sch.Type_BYTE_ARRAY:
+	default:
+		return nil, fmt.Errorf("unsupported dictionary type %s", typ)
+	}
+
+	out := make([][]byte, 0, min(n, len(data)/4))
+	for i := 0; i < n; i++ {
+		l := size
+		if typ == sch.Type_BYTE_ARRAY {
+			if len(data) < 4 {
+				return nil, fmt.Errorf("dictionary ended after %d of %d values", i, n)
+			}
+			l = 4 + int(binary.LittleEndian.Uint32(data))
+		}
+
+		if l < 0 || l > len(data) {
+			return nil, fmt.Errorf("dictionary ended after %d of %d values", i, n)
+		}
+		out = append(out, data[:l])
+		data = data[l:]
+	}
+	return out, nil
+}
+
+// dictionaryValues looks up the dictionary indices of a data page.  The
+// indices are preceded by their bit width.
+func dictionaryValues(data []byte, n int, dict [][]byte) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	if dict == nil {
+		return nil, fmt.Errorf("dictionary encoded page without a dictionary page")
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("dictionary encoded page has no data")
+	}
+
+	indices, err := rle.DecodeUint32(data[1:], int(data[0]), n)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int
+	for _, i := range indices {
+		if int(i) >= len(dict) {
+			return nil, fmt.Errorf("dictionary index %d out of range, the dictionary has %d values", i, len(dict))
+		}
+		size += len(dict[i])
+	}
+
+	out := make([]byte, 0, size)
+	for _, i := range indices {
+		out = append(out, dict[i]...)
+	}
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrWriterClosed is returned by a writer that is used after it has
+// been closed (the records that are added to it are dropped, since
+// the file's footer has already been written).
+var ErrWriterClosed = errors.New("the writer is closed")
+
+// UnknownColumnError is returned by a reader when a parquet file
+// has a column that isn't part of the reader's schema.
+type UnknownColumnError struct {
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown field: %s", e.Column)
+}
+
+// ReadColumnError is returned by a reader when the data of a column
+// chunk can't be read.  RowGroup is the index of the chunk's row group
+// and Offset is where the chunk starts in the file, so the bad bytes
+// of a corrupt file can be found.  Err is the underlying cause.
+type ReadColumnError struct {
+	Column   string
+	RowGroup int
+	Offset   int64
+	Err      error
+}
+
+func (e *ReadColumnError) Error() string {
+	return fmt.Sprintf("unable to read field %s (row group %d, offset %d), err: %s", e.Column, e.RowGroup, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *ReadColumnError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaMismatchError is returned when the columns of a file aren't
+// exactly the ones that were expected (see Metadata.RequireSchema).
+// Missing are the expected columns that the file doesn't have and
+// Unexpected are the ones that it has but that weren't expected (each
+// is a column's path joined by dots).  Different describes each column
+// whose type, repetition, or annotation isn't the expected one.
+type SchemaMismatchError struct {
+	Missing    []string
+	Unexpected []string
+	Different  []string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing columns "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, "unexpected columns "+strings.Join(e.Unexpected, ", "))
+	}
+	parts = append(parts, e.Different...)
+	return fmt.Sprintf("the file's schema isn't the expected schema: %s", strings.Join(parts, "; "))
+}
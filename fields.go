@@ -13,6 +13,7 @@ import (
 	"io"
 
 	"github.com/golang/snappy"
+	"github.com/rclayton-godaddy/parquet/internal/delta"
 	"github.com/rclayton-godaddy/parquet/internal/rle"
 	sch "github.com/rclayton-godaddy/parquet/schema"
 )
@@ -28,6 +29,22 @@ const (
 	Repeated RepetitionType = 2
 )
 
+// String returns the name of the repetition type (REQUIRED,
+// OPTIONAL, or REPEATED) as it is in a parquet schema.
+func (r RepetitionType) String() string {
+	switch r {
+	case Required:
+		return "REQUIRED"
+	case Optional:
+		return "OPTIONAL"
+	case Repeated:
+		return "REPEATED"
+	case Unseen:
+		return "UNSEEN"
+	}
+	return fmt.Sprintf("RepetitionType(%d)", int(r))
+}
+
 var (
 	buffpool = bytebufferpool.Pool{}
 )
@@ -56,10 +73,244 @@ func (r RepetitionTypes) MaxRep() uint8 {
 	return out
 }
 
+// Allocator provides the buffers that page data is decoded into.
+// Free is called with a buffer once its contents have been decoded
+// into a column's values.
+type Allocator interface {
+	Alloc(n int) []byte
+	Free([]byte)
+}
+
+type goAllocator struct{}
+
+func (goAllocator) Alloc(n int) []byte { return make([]byte, n) }
+func (goAllocator) Free([]byte)        {}
+
+// DefaultAllocator uses make and leaves freeing to the garbage collector.
+var DefaultAllocator Allocator = goAllocator{}
+
+// FieldBuffers holds the slices that a reader's fields decode their
+// values and levels into.  The fields of a reader that has
+// FieldBuffers truncate and refill the slices that the previous row
+// group's fields filled instead of allocating new ones, so FieldBuffers
+// that are passed to the readers of many files with the same schema
+// only grow when a column chunk is bigger than any before it.  The
+// slices are reused as soon as the next row group is read, so
+// FieldBuffers must only be used by one reader at a time.
+type FieldBuffers struct {
+	vals map[string]interface{}
+	defs map[string][]uint8
+	reps map[string][]uint8
+}
+
+// NewFieldBuffers returns empty FieldBuffers.
+func NewFieldBuffers() *FieldBuffers {
+	return &FieldBuffers{
+		vals: map[string]interface{}{},
+		defs: map[string][]uint8{},
+		reps: map[string][]uint8{},
+	}
+}
+
+// Values returns the slice of col's values in b, truncated to 0
+// (nil if b is nil or doesn't have a []T for col).  The truncated
+// slice is kept, so Column returns a []T even if none of col's
+// values are read.
+func Values[T any](b *FieldBuffers, col string) []T {
+	if b == nil {
+		return nil
+	}
+	vals, _ := b.vals[col].([]T)
+	b.vals[col] = vals[:0]
+	return vals[:0]
+}
+
+// Column returns the slice of col's values in b, which is a []T
+// for a column whose values are Ts (nil if b doesn't have col).
+func (b *FieldBuffers) Column(col string) interface{} {
+	return b.vals[col]
+}
+
+// KeepValues keeps vals (a slice of col's values) in b so that
+// Values returns it for the next row group.  It doesn't do
+// anything if b is nil.
+func (b *FieldBuffers) KeepValues(col string, vals interface{}) {
+	if b != nil {
+		b.vals[col] = vals
+	}
+}
+
+// levels returns the slices of col's definition and repetition
+// levels in b, truncated to 0.
+func (b *FieldBuffers) levels(col string) ([]uint8, []uint8) {
+	if b == nil {
+		return nil, nil
+	}
+	return b.defs[col][:0], b.reps[col][:0]
+}
+
+// keepLevels keeps col's levels in b (see KeepValues).
+func (b *FieldBuffers) keepLevels(col string, defs, reps []uint8) {
+	if b != nil {
+		b.defs[col] = defs
+		b.reps[col] = reps
+	}
+}
+
+// grow returns s with room for n more values, which reuses
+// s's backing array if it is big enough.
+func grow[T any](s []T, n int) []T {
+	if l := len(s) + n; l <= cap(s) {
+		return s[:l]
+	}
+
+	out := make([]T, len(s)+n)
+	copy(out, s)
+	return out
+}
+
+// Reserve returns s with room for n more values without changing its
+// length, so appending them doesn't reallocate s over and over.  It
+// reuses s's backing array if it is big enough.
+func Reserve[T any](s []T, n int) []T {
+	if len(s)+n <= cap(s) {
+		return s
+	}
+
+	out := make([]T, len(s), len(s)+n)
+	copy(out, s)
+	return out
+}
+
+// Interner makes the strings that are read from a file share their
+// memory, so that each distinct value is only allocated once.  It costs
+// a map lookup for each value, but a column with few distinct values
+// (categories, for example) no longer takes up a string for each row.
+// The strings stay in the Interner until it is Reset or dropped.
+type Interner struct {
+	m map[string]string
+}
+
+// NewInterner returns an empty Interner.
+func NewInterner() *Interner {
+	return &Interner{m: map[string]string{}}
+}
+
+// String returns b as a string, which is the same string that an
+// earlier call returned for the same bytes.  A nil Interner returns
+// a new string each time.
+func (i *Interner) String(b []byte) string {
+	if i == nil {
+		return string(b)
+	}
+
+	// looking up string(b) doesn't allocate
+	if s, ok := i.m[string(b)]; ok {
+		return s
+	}
+
+	s := string(b)
+	i.m[s] = s
+	return s
+}
+
+// Len returns the number of distinct strings in i.
+func (i *Interner) Len() int {
+	return len(i.m)
+}
+
+// Reset empties i (the strings that it returned aren't affected).
+func (i *Interner) Reset() {
+	i.m = map[string]string{}
+}
+
+// pageBuffers keeps track of the page data read by DoRead so it can
+// be handed back to the Allocator, and the FieldBuffers (if any) that
+// the values are decoded into.
+type pageBuffers struct {
+	alloc    Allocator
+	buf      []byte
+	buffers  *FieldBuffers
+	interner *Interner
+}
+
+// SetAllocator sets the Allocator that DoRead gets page data from.
+func (p *pageBuffers) SetAllocator(a Allocator) {
+	p.alloc = a
+}
+
+// SetInterner sets the Interner that Intern uses.
+func (p *pageBuffers) SetInterner(i *Interner) {
+	p.interner = i
+}
+
+// Intern returns the string value b, which is shared with the earlier
+// values that have the same bytes if the field has an Interner.
+func (p *pageBuffers) Intern(b []byte) string {
+	return p.interner.String(b)
+}
+
+// Buffers returns the field's FieldBuffers (nil if it doesn't have any).
+func (p *pageBuffers) Buffers() *FieldBuffers {
+	return p.buffers
+}
+
+// Release frees the data returned by the last call to DoRead.
+func (p *pageBuffers) Release() {
+	if p.buf != nil {
+		p.allocator().Free(p.buf)
+		p.buf = nil
+	}
+}
+
+func (p *pageBuffers) allocator() Allocator {
+	if p.alloc == nil {
+		return DefaultAllocator
+	}
+	return p.alloc
+}
+
+// concat joins the data from each page into one buffer. pages are
+// the buffers from the Allocator and parts are the sections of
+// them that hold values.
+func (p *pageBuffers) concat(pages, parts [][]byte) []byte {
+	if len(pages) == 1 {
+		p.buf = pages[0]
+		return parts[0]
+	}
+
+	var n int
+	for _, part := range parts {
+		n += len(part)
+	}
+
+	alloc := p.allocator()
+	p.buf = alloc.Alloc(n)
+	out := p.buf[:0]
+	for i, part := range parts {
+		out = append(out, part...)
+		alloc.Free(pages[i])
+	}
+	return out
+}
+
+func (p *pageBuffers) free(pages [][]byte) {
+	alloc := p.allocator()
+	for _, pg := range pages {
+		alloc.Free(pg)
+	}
+}
+
 // RequiredField writes the raw data for required columns
 type RequiredField struct {
+	pageBuffers
 	pth         []string
 	compression sch.CompressionCodec
+	dataPageV2  bool
+
+	// read is the number of values of the last
+	// column chunk that DoRead read
+	read int
 }
 
 // NewRequiredField creates a required field.
@@ -92,8 +343,40 @@ func RequiredFieldUncompressed(r *RequiredField) {
 	r.compression = sch.CompressionCodec_UNCOMPRESSED
 }
 
+// SetBuffers sets the FieldBuffers that the field's values are
+// decoded into.
+func (f *RequiredField) SetBuffers(b *FieldBuffers) {
+	f.buffers = b
+}
+
 // DoWrite writes the actual raw data.
 func (f *RequiredField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count int, stats Stats) error {
+	return f.doWrite(w, meta, vals, count, sch.Encoding_PLAIN, stats)
+}
+
+// DoWriteDelta writes vals with the DELTA_BINARY_PACKED encoding.
+// It is called by the int32 and int64 (and unsigned) fields.
+func (f *RequiredField) DoWriteDelta(w io.Writer, meta *Metadata, vals []int64, stats Stats) error {
+	return f.doWrite(w, meta, delta.Encode(vals), len(vals), sch.Encoding_DELTA_BINARY_PACKED, stats)
+}
+
+// DoWriteDelta32 writes vals, the values of an INT32 column, with the
+// DELTA_BINARY_PACKED encoding (see delta.Encode32).
+func (f *RequiredField) DoWriteDelta32(w io.Writer, meta *Metadata, vals []int64, stats Stats) error {
+	return f.doWrite(w, meta, delta.Encode32(vals), len(vals), sch.Encoding_DELTA_BINARY_PACKED, stats)
+}
+
+// DoWriteDeltaLength writes vals with the DELTA_LENGTH_BYTE_ARRAY
+// encoding.  It is called by the string fields.
+func (f *RequiredField) DoWriteDeltaLength(w io.Writer, meta *Metadata, vals []string, stats Stats) error {
+	return f.doWrite(w, meta, deltaLengthBytes(vals), len(vals), sch.Encoding_DELTA_LENGTH_BYTE_ARRAY, stats)
+}
+
+func (f *RequiredField) doWrite(w io.Writer, meta *Metadata, vals []byte, count int, enc sch.Encoding, stats Stats) error {
+	if f.dataPageV2 {
+		return f.writePageV2(w, meta, vals, count, enc, stats)
+	}
+
 	buff := buffpool.Get()
 	defer buffpool.Put(buff)
 
@@ -102,7 +385,7 @@ func (f *RequiredField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count
 		return err
 	}
 
-	if err := meta.WritePageHeader(w, f.pth, l, cl, count, count, 0, 0, f.compression, stats); err != nil {
+	if err := meta.writePageHeader(w, f.pth, l, cl, count, count, enc, f.compression, stats); err != nil {
 		return err
 	}
 
@@ -110,28 +393,135 @@ func (f *RequiredField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count
 	return err
 }
 
-// DoRead reads the actual raw data.
-func (f *RequiredField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, error) {
+// DoRead reads the actual raw data.  The data is valid until
+// Release is called.
+func (f *RequiredField) DoRead(r io.ReadSeeker, pg Page) (*bytes.Buffer, []int, error) {
+	f.Release()
+
+	max := pageLevels(pg, MaxLevel{})
+	if max.Rep > 0 {
+		return nil, nil, fmt.Errorf("column %s is repeated in the file's schema but not in the struct", f.Name())
+	}
+
+	// every page is read until the end of the column chunk, and the
+	// values that the page headers say they have are the ones that are
+	// read.  Some writers get the number of values in the footer wrong,
+	// so it isn't what the values are checked against: a required
+	// column has a value for each row, so it's the row group's rows.
 	var nRead int
-	var out []byte
+	var size int64
+	var pages, parts, dict [][]byte
 	var sizes []int
-	for nRead < pg.N {
-		ph, err := PageHeader(r)
+	for size < int64(pg.Size) {
+		ph, data, n, err := readPage(r, pg, int64(pg.Size)-size, f.allocator())
 		if err != nil {
+			f.free(pages)
 			return nil, nil, err
 		}
+		size += n
 
-		sizes = append(sizes, int(ph.DataPageHeader.NumValues))
+		if ph.DictionaryPageHeader != nil {
+			if dict, err = readDictionary(pg, ph, data, f.allocator()); err != nil {
+				f.free(pages)
+				return nil, nil, err
+			}
+			continue
+		}
+
+		pages = append(pages, data)
+		nVals, enc, l, err := f.readLevels(ph, data, max)
+		if err != nil {
+			f.free(pages)
+			return nil, nil, err
+		}
 
-		data, err := pageData(r, ph, pg)
+		vals, err := plainValues(pg.Type, enc, data[l:], nVals, dict)
 		if err != nil {
+			f.free(pages)
 			return nil, nil, err
 		}
 
-		out = append(out, data...)
-		nRead += int(ph.DataPageHeader.NumValues)
+		sizes = append(sizes, nVals)
+		parts = append(parts, vals)
+		nRead += nVals
 	}
-	return bytes.NewBuffer(out), sizes, nil
+
+	if int64(nRead) != pg.Rows {
+		f.free(pages)
+		return nil, nil, fmt.Errorf("column %s has %d values but its row group has %d rows (and its column chunk says it has %d values)", f.Name(), nRead, pg.Rows, pg.N)
+	}
+
+	if NumValuesOff(int64(nRead), int64(pg.N)) {
+		f.free(pages)
+		return nil, nil, numValuesError(f.Name(), nRead, pg.N)
+	}
+	f.read = nRead
+
+	if len(pages) == 0 {
+		return bytes.NewBuffer(nil), sizes, nil
+	}
+	return bytes.NewBuffer(f.concat(pages, parts)), sizes, nil
+}
+
+// NumValuesOff is true if numValues, the number of values that the
+// footer says a column chunk has, is too far from n, the number of
+// values in the chunk's pages, for the footer to be trusted.  Some
+// writers get the footer's count slightly wrong, so it can be off by
+// a tenth of n (or by 1 if that is more) before it is an error, and
+// the pages' count is the one that is read.
+func NumValuesOff(n, numValues int64) bool {
+	if numValues < 0 {
+		return true
+	}
+
+	d := n - numValues
+	if d < 0 {
+		d = -d
+	}
+
+	tolerance := n / 10
+	if tolerance < 1 {
+		tolerance = 1
+	}
+	return d > tolerance
+}
+
+// numValuesError is the error of a column chunk whose pages have n
+// values when the footer says it has numValues (see NumValuesOff).
+func numValuesError(col string, n, numValues int) error {
+	return fmt.Errorf("column %s has %d values but its column chunk says it has %d, which is too far off for the file to be trusted", col, n, numValues)
+}
+
+// ReadValues returns the number of values in the column chunk that was
+// read by the last call to DoRead, which is the number of values that
+// the field's Read decodes.  It is the number that the chunk's page
+// headers say it has, which is what the values are checked against,
+// rather than the number in the footer, which can be wrong.
+func (f *RequiredField) ReadValues() int {
+	return f.read
+}
+
+// readLevels skips the levels of a data page and returns the number
+// of values, their encoding, and the number of bytes the levels take
+// up.  A column that is optional in the file (see pageLevels) has
+// definition levels, which are fine as long as none of them are null.
+func (f *RequiredField) readLevels(ph *sch.PageHeader, data []byte, max MaxLevel) (int, sch.Encoding, int, error) {
+	n, enc, repLen, defLen := dataPage(ph)
+	if max.Def == 0 {
+		return n, enc, repLen + defLen, nil
+	}
+
+	_, _, defs, l, err := readLevels(ph, data, max)
+	if err != nil {
+		return 0, enc, 0, err
+	}
+
+	for _, d := range defs {
+		if d < max.Def {
+			return 0, enc, 0, fmt.Errorf("column %s has a null value but the field is required", f.Name())
+		}
+	}
+	return n, enc, l, nil
 }
 
 // Name returns the column name of this field
@@ -154,6 +544,7 @@ type MaxLevel struct {
 // OptionalField is any exported field in a
 // struct that is a pointer.
 type OptionalField struct {
+	pageBuffers
 	Defs           []uint8
 	Reps           []uint8
 	pth            []string
@@ -162,6 +553,11 @@ type OptionalField struct {
 	RepetitionType FieldFunc
 	Types          []int
 	repeated       bool
+	dataPageV2     bool
+
+	// read is the number of values (not counting the
+	// nulls) of the last column chunk that DoRead read
+	read int
 }
 
 func getRepetitionTypes(in []int) RepetitionTypes {
@@ -211,12 +607,93 @@ func OptionalFieldUncompressed(o *OptionalField) {
 	o.compression = sch.CompressionCodec_UNCOMPRESSED
 }
 
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *OptionalField) SetBuffers(b *FieldBuffers) {
+	f.buffers = b
+	f.Defs, f.Reps = b.levels(f.Name())
+}
+
 // Values reads the definition levels and uses them
 // to return the values from the page data.
 func (f *OptionalField) Values() int {
 	return f.valsFromDefs(f.Defs, uint8(f.MaxLevels.Def))
 }
 
+// ReadValues returns the number of values (not counting the nulls) in
+// the column chunk that was read by the last call to DoRead, which is
+// the number of values that the field's Read decodes.  It only counts
+// the chunk's own levels, so it doesn't depend on how many of the
+// levels and values of earlier chunks are still waiting to be scanned.
+func (f *OptionalField) ReadValues() int {
+	return f.read
+}
+
+// Rows returns the number of rows that the field has levels for
+// (a row of a repeated field starts with a repetition level of 0).
+func (f *OptionalField) Rows() int {
+	if !f.repeated {
+		return len(f.Defs)
+	}
+
+	var out int
+	for _, r := range f.Reps {
+		if r == 0 {
+			out++
+		}
+	}
+	return out
+}
+
+// RowValues returns the number of values (the definition levels that
+// are the max) in the field's next row.  A row of a repeated field can
+// have any number of them, the rest have 0 or 1.
+func (f *OptionalField) RowValues() int {
+	if len(f.Defs) == 0 {
+		return 0
+	}
+
+	n := 1
+	if f.repeated {
+		for n < len(f.Reps) && f.Reps[n] != 0 {
+			n++
+		}
+	}
+	return f.valsFromDefs(f.Defs[:n], f.MaxLevels.Def)
+}
+
+// OptionalValue returns the value of f's next row, where vals are
+// the field's values that haven't been scanned.  It is a slice of
+// the row's values if f is repeated.  ok is false if the row doesn't
+// have a value.
+func OptionalValue[T any](f *OptionalField, vals []T) (interface{}, bool) {
+	n := f.RowValues()
+	if n == 0 || n > len(vals) {
+		return nil, false
+	}
+
+	if f.repeated {
+		return append([]T{}, vals[:n]...), true
+	}
+	return vals[0], true
+}
+
+// AppendValues appends the values (nil for a null) of a column that
+// isn't repeated to vals and its definition levels to defs, where def
+// is the column's maximum definition level.  It is how the optional
+// fields add a column's values without records (see AddValues).
+func AppendValues[T any](vals []T, defs []uint8, in []*T, def uint8) ([]T, []uint8) {
+	for _, v := range in {
+		if v == nil {
+			defs = append(defs, 0)
+			continue
+		}
+		vals = append(vals, *v)
+		defs = append(defs, def)
+	}
+	return vals, defs
+}
+
 func (f *OptionalField) valsFromDefs(defs []uint8, max uint8) int {
 	var out int
 	for _, d := range defs {
@@ -230,29 +707,52 @@ func (f *OptionalField) valsFromDefs(defs []uint8, max uint8) int {
 // DoWrite is called by all optional field types to write the definition levels
 // and raw data to the io.Writer
 func (f *OptionalField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count int, stats Stats) error {
+	return f.doWrite(w, meta, vals, count, sch.Encoding_PLAIN, stats)
+}
+
+// DoWriteDelta writes the levels and vals (the values that aren't
+// null) with the DELTA_BINARY_PACKED encoding.
+func (f *OptionalField) DoWriteDelta(w io.Writer, meta *Metadata, vals []int64, count int, stats Stats) error {
+	return f.doWrite(w, meta, delta.Encode(vals), count, sch.Encoding_DELTA_BINARY_PACKED, stats)
+}
+
+// DoWriteDelta32 writes the levels and vals of an INT32 column with
+// the DELTA_BINARY_PACKED encoding (see delta.Encode32).
+func (f *OptionalField) DoWriteDelta32(w io.Writer, meta *Metadata, vals []int64, count int, stats Stats) error {
+	return f.doWrite(w, meta, delta.Encode32(vals), count, sch.Encoding_DELTA_BINARY_PACKED, stats)
+}
+
+// DoWriteDeltaLength writes the levels and vals (the values that
+// aren't null) with the DELTA_LENGTH_BYTE_ARRAY encoding.
+func (f *OptionalField) DoWriteDeltaLength(w io.Writer, meta *Metadata, vals []string, count int, stats Stats) error {
+	return f.doWrite(w, meta, deltaLengthBytes(vals), count, sch.Encoding_DELTA_LENGTH_BYTE_ARRAY, stats)
+}
+
+func (f *OptionalField) doWrite(w io.Writer, meta *Metadata, vals []byte, count int, enc sch.Encoding, stats Stats) error {
+	if f.dataPageV2 {
+		return f.writePageV2(w, meta, vals, count, enc, stats)
+	}
+
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
-	wc := &writeCounter{w: buf}
-
-	var repLen int64
 
 	if f.repeated {
-		err := writeLevels(wc, f.Reps, int32(bits.Len(uint(f.MaxLevels.Rep))))
+		err := WriteLevels(buf, f.Reps, f.MaxLevels.Rep)
 		if err != nil {
 			return err
 		}
-		repLen = wc.n
 	}
 
-	err := writeLevels(wc, f.Defs, int32(bits.Len(uint(f.MaxLevels.Def))))
+	err := WriteLevels(buf, f.Defs, f.MaxLevels.Def)
 	if err != nil {
 		return err
 	}
 
-	defLen := wc.n - repLen
-
-	if _, err = wc.Write(vals); err != nil {
-		return err
+	// a page where every value is null only has levels
+	if len(vals) > 0 {
+		if _, err = buf.Write(vals); err != nil {
+			return err
+		}
 	}
 
 	compressed := buffpool.Get()
@@ -263,7 +763,7 @@ func (f *OptionalField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count
 		return err
 	}
 
-	if err := meta.WritePageHeader(w, f.pth, l, cl, len(f.Defs), count, defLen, repLen, f.compression, stats); err != nil {
+	if err := meta.writePageHeader(w, f.pth, l, cl, count, f.Rows(), enc, f.compression, stats); err != nil {
 		return err
 	}
 	_, err = w.Write(vals)
@@ -271,49 +771,199 @@ func (f *OptionalField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count
 }
 
 // DoRead is called by all optional fields.  It reads the definition levels and uses
-// them to interpret the raw data.
-func (f *OptionalField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, error) {
-	var nRead int
-	var out []byte
+// them to interpret the raw data.  The data is valid until Release is called.
+func (f *OptionalField) DoRead(r io.ReadSeeker, pg Page) (*bytes.Buffer, []int, error) {
+	f.Release()
+
+	max := pageLevels(pg, f.MaxLevels)
+	var levels []int
+	if types := getRepetitionTypes(f.Types); pg.RepetitionTypes != nil && !sameRepetitionTypes(pg.RepetitionTypes, types) {
+		var err error
+		if levels, err = defLevels(f.pth, pg.RepetitionTypes, types); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	levels0, rows0 := len(f.Defs), f.Rows()
+	f.read = 0
+	var nRead int64
+	var pages, parts, dict [][]byte
 	var sizes []int
-	var rc *readCounter
 
-	for nRead < pg.Size {
-		rc = &readCounter{r: r}
-		ph, err := PageHeader(rc)
+	// the pages are read until the end of the column chunk, since
+	// the number of values (which counts every null and repeated
+	// value) isn't the number of rows of a repeated column, and the
+	// levels are checked against the row group's rows rather than
+	// the number of values in the footer, which can be wrong
+	for nRead < int64(pg.Size) {
+		ph, data, n, err := readPage(r, pg, int64(pg.Size)-nRead, f.allocator())
 		if err != nil {
+			f.free(pages)
 			return nil, nil, err
 		}
+		nRead += n
+
+		if ph.DictionaryPageHeader != nil {
+			if dict, err = readDictionary(pg, ph, data, f.allocator()); err != nil {
+				f.free(pages)
+				return nil, nil, err
+			}
+			continue
+		}
+		pages = append(pages, data)
 
-		data, err := pageData(rc, ph, pg)
+		enc, reps, defs, l, err := readLevels(ph, data, max)
 		if err != nil {
+			f.free(pages)
 			return nil, nil, err
 		}
 
-		var l int
-
-		if f.repeated {
-			reps, l2, err := readLevels(bytes.NewBuffer(data[l:]), int32(bits.Len(uint(f.MaxLevels.Rep))))
-			if err != nil {
+		if levels != nil {
+			if err := f.mapDefs(defs, levels); err != nil {
+				f.free(pages)
 				return nil, nil, err
 			}
-			f.Reps = append(f.Reps, reps[:int(ph.DataPageHeader.NumValues)]...)
-			l += l2
 		}
+		f.Reps = append(f.Reps, reps...)
+		f.Defs = append(f.Defs, defs...)
+
+		nVals := f.valsFromDefs(defs, uint8(f.MaxLevels.Def))
 
-		defs, l2, err := readLevels(bytes.NewBuffer(data[l:]), int32(bits.Len(uint(f.MaxLevels.Def))))
+		vals, err := plainValues(pg.Type, enc, data[l:], nVals, dict)
 		if err != nil {
+			f.free(pages)
 			return nil, nil, err
 		}
-		f.Defs = append(f.Defs, defs[:int(ph.DataPageHeader.NumValues)]...)
-		l += l2
 
-		n := f.valsFromDefs(defs, uint8(f.MaxLevels.Def))
-		sizes = append(sizes, n)
-		out = append(out, data[l:]...)
-		nRead += int(rc.n)
+		sizes = append(sizes, nVals)
+		parts = append(parts, vals)
+		f.read += nVals
 	}
-	return bytes.NewBuffer(out), sizes, nil
+	f.buffers.keepLevels(f.Name(), f.Defs, f.Reps)
+
+	if n := f.Rows() - rows0; int64(n) != pg.Rows {
+		f.free(pages)
+		return nil, nil, fmt.Errorf("column %s has %d rows but its row group has %d (its pages have %d values and its column chunk says it has %d)", f.Name(), n, pg.Rows, len(f.Defs)-levels0, pg.N)
+	}
+
+	if n := len(f.Defs) - levels0; NumValuesOff(int64(n), int64(pg.N)) {
+		f.free(pages)
+		return nil, nil, numValuesError(f.Name(), n, pg.N)
+	}
+
+	if len(pages) == 0 {
+		return bytes.NewBuffer(nil), sizes, nil
+	}
+	return bytes.NewBuffer(f.concat(pages, parts)), sizes, nil
+}
+
+// readLevels reads the repetition (if the column is repeated) and definition
+// levels of a data page.  It returns the encoding of the values and the
+// number of bytes of data the levels take up.
+func readLevels(ph *sch.PageHeader, data []byte, max MaxLevel) (sch.Encoding, []uint8, []uint8, int, error) {
+	n, enc, repLen, defLen := dataPage(ph)
+	if ph.DataPageHeaderV2 != nil {
+		var reps []uint8
+		if max.Rep > 0 {
+			var err error
+			if reps, err = readLevelsV2(data[:repLen], max.Rep, n); err != nil {
+				return enc, nil, nil, 0, err
+			}
+		}
+
+		defs, err := readLevelsV2(data[repLen:repLen+defLen], max.Def, n)
+		return enc, reps, defs, repLen + defLen, err
+	}
+
+	var l int
+	var reps []uint8
+	if max.Rep > 0 {
+		var err error
+		if reps, l, err = ReadLevels(bytes.NewBuffer(data), max.Rep, n); err != nil {
+			return enc, nil, nil, 0, err
+		}
+	}
+
+	// a column that is required in the file has no definition levels
+	if max.Def == 0 {
+		return enc, reps, make([]uint8, n), l, nil
+	}
+
+	defs, l2, err := ReadLevels(bytes.NewBuffer(data[l:]), max.Def, n)
+	return enc, reps, defs, l + l2, err
+}
+
+// mapDefs replaces the definition levels read from the file with the
+// field's definition levels (see defLevels).
+func (f *OptionalField) mapDefs(defs []uint8, levels []int) error {
+	for i, d := range defs {
+		def := levels[d]
+		if def < 0 {
+			return fmt.Errorf("column %s has a null value that can't be held by the struct", f.Name())
+		}
+		defs[i] = uint8(def)
+	}
+	return nil
+}
+
+// sameRepetitionTypes is true if a and b are equal.
+func sameRepetitionTypes(a, b RepetitionTypes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pageLevels returns the largest levels of the pages of a column.  They
+// come from the file's schema when pg has its repetition types, which
+// can differ from the field's if the file nests required and optional
+// elements differently than the struct does.
+func pageLevels(pg Page, field MaxLevel) MaxLevel {
+	if pg.RepetitionTypes == nil {
+		return field
+	}
+	return MaxLevel{
+		Def: pg.RepetitionTypes.MaxDef(),
+		Rep: pg.RepetitionTypes.MaxRep(),
+	}
+}
+
+// defLevels maps each definition level of a column whose path has the
+// repetition types from (in the file) to the definition level of the
+// same element in a path with the repetition types to (in the struct).
+// A level that is null in the file but can't be null in the struct
+// maps to -1.
+func defLevels(pth []string, from, to RepetitionTypes) ([]int, error) {
+	if len(from) != len(to) {
+		return nil, fmt.Errorf("column %s has %d elements in the file's schema but %d in the struct", strings.Join(pth, "."), len(from), len(to))
+	}
+
+	if from.MaxRep() != to.MaxRep() {
+		return nil, fmt.Errorf("column %s has a max repetition level of %d in the file's schema but %d in the struct", strings.Join(pth, "."), from.MaxRep(), to.MaxRep())
+	}
+
+	max := to.MaxDef()
+	out := make([]int, 0, from.MaxDef()+1)
+	for i, rt := range from {
+		if rt == Required {
+			continue
+		}
+
+		// element i is the first one that is null, so only the
+		// elements before it are defined.
+		def := to[:i].MaxDef()
+		if def == max {
+			out = append(out, -1)
+			continue
+		}
+		out = append(out, int(def))
+	}
+	return append(out, int(max)), nil
 }
 
 // Name returns the column name of this field
@@ -326,21 +976,6 @@ func (f *OptionalField) Path() []string {
 	return f.pth
 }
 
-// writeCounter keeps track of the number of bytes written
-// it is used for calls to binary.Write, which does not
-// return the number of bytes written.
-type writeCounter struct {
-	n int64
-	w io.Writer
-}
-
-// Write makes writeCounter an io.Writer
-func (w *writeCounter) Write(p []byte) (int, error) {
-	n, err := w.w.Write(p)
-	w.n += int64(n)
-	return n, err
-}
-
 // readCounter keeps track of the number of bytes written
 // it is used for calls to binary.Write.
 type readCounter struct {
@@ -355,50 +990,166 @@ func (r *readCounter) Read(p []byte) (int, error) {
 	return n, err
 }
 
-func pageData(r io.Reader, ph *sch.PageHeader, pg Page) ([]byte, error) {
-	var data []byte
-	switch pg.Codec {
-	case sch.CompressionCodec_SNAPPY:
-		compressed := make([]byte, ph.CompressedPageSize)
-		if _, err := r.Read(compressed); err != nil {
-			return nil, err
-		}
+// readPage reads the next page header and its data.  The page must
+// fit in the remaining bytes of the column chunk.  It returns the number
+// of bytes that were read.
+func readPage(r io.Reader, pg Page, remaining int64, alloc Allocator) (*sch.PageHeader, []byte, int64, error) {
+	rc := &readCounter{r: r}
+	ph, err := pageHeader(rc, remaining)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
-		var err error
-		data, err = snappy.Decode(nil, compressed)
-		if err != nil {
-			return nil, err
-		}
-	case sch.CompressionCodec_GZIP:
-		var buf bytes.Buffer
-		_, err := io.CopyN(&buf, r, int64(ph.CompressedPageSize))
-		if err != nil {
-			return nil, err
+	if int64(ph.CompressedPageSize) > remaining-rc.n {
+		return nil, nil, 0, fmt.Errorf("page size %d is larger than the %d bytes left in the column chunk", ph.CompressedPageSize, remaining-rc.n)
+	}
+
+	max := pg.MaxBytes
+	if max <= 0 {
+		max = DefaultMaxPageBytes
+	}
+	if ph.UncompressedPageSize > max {
+		return nil, nil, 0, fmt.Errorf("page size %d is larger than the maximum page size of %d bytes", ph.UncompressedPageSize, max)
+	}
+
+	data, err := pageData(rc, ph, pg, alloc)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return ph, data, rc.n, nil
+}
+
+// readDictionary returns the entries of a dictionary page.  They are
+// copied so that the page's data can be freed.
+func readDictionary(pg Page, ph *sch.PageHeader, data []byte, alloc Allocator) ([][]byte, error) {
+	defer alloc.Free(data)
+	return dictionaryEntries(pg.Type, pg.TypeLength, append([]byte(nil), data...), int(ph.DictionaryPageHeader.NumValues))
+}
+
+// pageData reads and decompresses the data of a page.  The returned
+// slice comes from alloc.
+func pageData(r io.Reader, ph *sch.PageHeader, pg Page, alloc Allocator) ([]byte, error) {
+	if ph.DataPageHeaderV2 != nil {
+		return pageDataV2(r, ph, pg, alloc)
+	}
+
+	if pg.Codec == sch.CompressionCodec_UNCOMPRESSED {
+		if ph.UncompressedPageSize != ph.CompressedPageSize {
+			return nil, fmt.Errorf("uncompressed page has compressed size %d and uncompressed size %d", ph.CompressedPageSize, ph.UncompressedPageSize)
 		}
 
-		zr, err := gzip.NewReader(&buf)
-		if err != nil {
+		data := alloc.Alloc(int(ph.UncompressedPageSize))
+		if _, err := io.ReadFull(r, data); err != nil {
+			alloc.Free(data)
 			return nil, err
 		}
+		return data, nil
+	}
 
-		data, err = io.ReadAll(zr)
+	compressed := alloc.Alloc(int(ph.CompressedPageSize))
+	defer alloc.Free(compressed)
+	if _, err := io.ReadFull(r, compressed); err != nil {
+		return nil, err
+	}
+
+	data := alloc.Alloc(int(ph.UncompressedPageSize))
+	if err := decompress(pg, compressed, data); err != nil {
+		alloc.Free(data)
+		return nil, err
+	}
+	return data, nil
+}
+
+// decompress decompresses a page (or, for a v2 page, its values)
+// into out, which must be exactly the size of the uncompressed data.
+func decompress(pg Page, compressed, out []byte) error {
+	switch codec := pageCodec(pg.Codec, len(out), compressed); codec {
+	case sch.CompressionCodec_SNAPPY:
+		err := decodeSnappy(compressed, out)
+		if err != nil && pg.Lenient && bytes.HasPrefix(compressed, snappyFrameMagic) {
+			// a page that was written with snappy's framing
+			// format instead of the block format
+			return decodeSnappyFramed(compressed, out)
+		}
+		if err != nil && pg.Lenient && len(compressed) == len(out) {
+			// a page that some writers leave uncompressed
+			// even though the column chunk is snappy
+			copy(out, compressed)
+			return nil
+		}
+		return err
+	case sch.CompressionCodec_GZIP:
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if err := zr.Close(); err != nil {
-			return nil, err
-		}
-	case sch.CompressionCodec_UNCOMPRESSED:
-		data = make([]byte, ph.UncompressedPageSize)
-		if _, err := r.Read(data); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(zr, out); err != nil {
+			return err
 		}
+		return zr.Close()
 	default:
-		return nil, fmt.Errorf("unsupported column chunk codec: %s", pg.Codec)
+		return fmt.Errorf("unsupported column chunk codec: %s", codec)
 	}
+}
 
-	return data, nil
+// decodeSnappy decodes a snappy page into out, which must
+// be exactly the size of the uncompressed data.
+func decodeSnappy(compressed, out []byte) error {
+	n, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return err
+	}
+
+	if n != len(out) {
+		return fmt.Errorf("snappy page decodes to %d bytes, expected %d", n, len(out))
+	}
+
+	_, err = snappy.Decode(out, compressed)
+	return err
+}
+
+// snappyFrameMagic is the stream identifier
+// that snappy's framing format starts with.
+var snappyFrameMagic = []byte("\xff\x06\x00\x00sNaPpY")
+
+// decodeSnappyFramed decodes a page that was compressed with snappy's
+// framing format into out, which must be exactly the size of the
+// uncompressed data.
+func decodeSnappyFramed(compressed, out []byte) error {
+	sr := snappy.NewReader(bytes.NewReader(compressed))
+	if _, err := io.ReadFull(sr, out); err != nil {
+		return fmt.Errorf("framed snappy page: %s", err)
+	}
+
+	if n, _ := sr.Read(make([]byte, 1)); n > 0 {
+		return fmt.Errorf("framed snappy page decodes to more than %d bytes", len(out))
+	}
+	return nil
+}
+
+// pageCodec returns the codec that a page was compressed with.  The
+// codec is only recorded once per column chunk, but some writers
+// change codecs from one page to the next, so a gzip page is
+// recognized by its magic number, and a page in a gzip chunk that
+// isn't gzipped is read as snappy if it looks like snappy.  A valid
+// snappy page can't start with the gzip magic number (it would be a
+// copy before any literal).
+func pageCodec(codec sch.CompressionCodec, size int, compressed []byte) sch.CompressionCodec {
+	if codec != sch.CompressionCodec_SNAPPY && codec != sch.CompressionCodec_GZIP {
+		return codec
+	}
+
+	if len(compressed) >= 2 && compressed[0] == 0x1f && compressed[1] == 0x8b {
+		return sch.CompressionCodec_GZIP
+	}
+
+	if codec == sch.CompressionCodec_GZIP {
+		if n, err := snappy.DecodedLen(compressed); err == nil && n == size {
+			return sch.CompressionCodec_SNAPPY
+		}
+	}
+	return codec
 }
 
 func compress(codec sch.CompressionCodec, buf *bytebufferpool.ByteBuffer, vals []byte) (int, int, []byte, error) {
@@ -434,24 +1185,72 @@ func compress(codec sch.CompressionCodec, buf *bytebufferpool.ByteBuffer, vals [
 	return l, len(vals), vals, err
 }
 
-// writeLevels writes vals to w as RLE/bitpack encoded data
-func writeLevels(w io.Writer, levels []uint8, width int32) error {
-	enc, _ := rle.New(width, len(levels)) //TODO: len(levels) is probably too big.  Chop it down a bit?
+// MaxLevelValue is the largest definition or repetition level
+// that can be encoded.
+const MaxLevelValue = 15
+
+// levelWidth is the number of bits needed to encode levels
+// from 0 to max.
+func levelWidth(max uint8) (int32, error) {
+	if max > MaxLevelValue {
+		return 0, fmt.Errorf("max level %d is greater than %d (highest supported)", max, MaxLevelValue)
+	}
+	return int32(bits.Len8(max)), nil
+}
+
+// WriteLevels writes definition or repetition levels to w as RLE/bitpack
+// encoded data.  max is the largest level of the column (for example,
+// a column nested in two optional groups has a max definition level
+// of 2) and it determines the bit width of the encoded levels.
+func WriteLevels(w io.Writer, levels []uint8, max uint8) error {
+	width, err := levelWidth(max)
+	if err != nil {
+		return err
+	}
+
+	enc, err := rle.New(width, len(levels)) //TODO: len(levels) is probably too big.  Chop it down a bit?
+	if err != nil {
+		return err
+	}
+
 	for _, l := range levels {
+		if l > max {
+			return fmt.Errorf("level %d is greater than max level %d", l, max)
+		}
 		enc.Write(l)
 	}
-	_, err := w.Write(enc.Bytes())
+	_, err = w.Write(enc.Bytes())
 	return err
 }
 
-// readLevels reads the RLE/bitpack encoded definition and repetition levels
-func readLevels(in io.Reader, width int32) ([]uint8, int, error) {
-	var out []uint8
-	dec, _ := rle.New(width, 0)
-	out, n, err := dec.Read(in)
+// ReadLevels reads n RLE/bitpack encoded definition or repetition levels
+// that were written with the max level.  It returns the levels and the
+// number of bytes that were read.
+func ReadLevels(in io.Reader, max uint8, n int) ([]uint8, int, error) {
+	width, err := levelWidth(max)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dec, err := rle.New(width, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out, l, err := dec.ReadN(in, n)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return out, n, nil
+	if len(out) < n {
+		return nil, 0, fmt.Errorf("expected %d levels, found %d", n, len(out))
+	}
+
+	for _, x := range out[:n] {
+		if x > max {
+			return nil, 0, fmt.Errorf("level %d is greater than max level %d", x, max)
+		}
+	}
+
+	return out[:n], l, nil
 }
@@ -0,0 +1,28 @@
+package parquet
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SchemaFingerprint returns a hex encoded SHA-256 hash of the schema
+// that fields are written with: each column's path, physical type,
+// repetition types, and converted and logical types, in order.  The
+// schema elements are hashed in their thrift (compact) encoding, which
+// doesn't depend on anything but the schema, so the fingerprint is the
+// same from one run (or one file) to the next as long as the schema is.
+func SchemaFingerprint(fields []Field) string {
+	_, elements := schemaElements(fields).schema()
+
+	ts := newThriftSerializer()
+	h := sha256.New()
+	for _, se := range elements {
+		// the serializer writes to memory, so it can't fail, and
+		// each element's encoding ends with a stop field so the
+		// elements can't run together
+		buf, _ := ts.Write(context.TODO(), se)
+		h.Write(buf)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
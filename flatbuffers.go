@@ -0,0 +1,183 @@
+package parquet
+
+import "encoding/binary"
+
+// fbBuilder builds a flatbuffer (the format of the metadata of an
+// Arrow IPC message) back to front, the way the flatbuffers library
+// does, so that every offset points forward.  An object is referred to
+// by its offset from the end of the buffer, which doesn't change as
+// more is prepended.  It only has what the Arrow messages need.
+type fbBuilder struct {
+	data     []byte
+	minAlign int
+
+	// the table that is being built (see startTable)
+	slots []int
+	start int
+}
+
+func newFBBuilder() *fbBuilder {
+	return &fbBuilder{minAlign: 1}
+}
+
+func (b *fbBuilder) offset() int {
+	return len(b.data)
+}
+
+func (b *fbBuilder) prepend(p []byte) {
+	out := make([]byte, len(p)+len(b.data))
+	copy(out, p)
+	copy(out[len(p):], b.data)
+	b.data = out
+}
+
+// prep pads the buffer so that it is aligned to size once
+// another n bytes are prepended.
+func (b *fbBuilder) prep(size, n int) {
+	if size > b.minAlign {
+		b.minAlign = size
+	}
+	if pad := (size - (len(b.data)+n)%size) % size; pad > 0 {
+		b.prepend(make([]byte, pad))
+	}
+}
+
+func (b *fbBuilder) prependUint8(v uint8) {
+	b.prep(1, 0)
+	b.prepend([]byte{v})
+}
+
+func (b *fbBuilder) prependUint16(v uint16) {
+	b.prep(2, 0)
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+	b.prepend(buf[:])
+}
+
+func (b *fbBuilder) prependUint32(v uint32) {
+	b.prep(4, 0)
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	b.prepend(buf[:])
+}
+
+func (b *fbBuilder) prependUint64(v uint64) {
+	b.prep(8, 0)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	b.prepend(buf[:])
+}
+
+// prependOffset prepends an offset to the object at off.
+func (b *fbBuilder) prependOffset(off int) {
+	b.prep(4, 0)
+	b.prependUint32(uint32(len(b.data) + 4 - off))
+}
+
+// createString returns the offset of the (null terminated) string s.
+func (b *fbBuilder) createString(s string) int {
+	b.prep(4, len(s)+1)
+	b.prepend(append([]byte(s), 0))
+	b.prependUint32(uint32(len(s)))
+	return b.offset()
+}
+
+// createOffsets returns the offset of a vector of the objects at offs.
+func (b *fbBuilder) createOffsets(offs []int) int {
+	b.prep(4, 4*len(offs))
+	for i := len(offs) - 1; i >= 0; i-- {
+		b.prependOffset(offs[i])
+	}
+	b.prependUint32(uint32(len(offs)))
+	return b.offset()
+}
+
+// createStructs returns the offset of a vector of n structs whose
+// (little endian) bytes are p.  The structs are aligned to align.
+func (b *fbBuilder) createStructs(p []byte, n, align int) int {
+	b.prep(4, len(p))
+	b.prep(align, len(p))
+	b.prepend(p)
+	b.prependUint32(uint32(n))
+	return b.offset()
+}
+
+// startTable starts a table, whose fields are prepended (with the
+// add methods) until endTable is called.  Strings, vectors, and other
+// tables can't be created until the table is ended.
+func (b *fbBuilder) startTable() {
+	b.slots = b.slots[:0]
+	b.start = len(b.data)
+}
+
+// slot records that the field that was just prepended is the table's
+// field with the id i.
+func (b *fbBuilder) slot(i int) {
+	for len(b.slots) <= i {
+		b.slots = append(b.slots, 0)
+	}
+	b.slots[i] = len(b.data)
+}
+
+func (b *fbBuilder) addBool(i int, v bool) {
+	var x uint8
+	if v {
+		x = 1
+	}
+	b.prependUint8(x)
+	b.slot(i)
+}
+
+func (b *fbBuilder) addUint8(i int, v uint8) {
+	b.prependUint8(v)
+	b.slot(i)
+}
+
+func (b *fbBuilder) addInt16(i int, v int16) {
+	b.prependUint16(uint16(v))
+	b.slot(i)
+}
+
+func (b *fbBuilder) addInt32(i int, v int32) {
+	b.prependUint32(uint32(v))
+	b.slot(i)
+}
+
+func (b *fbBuilder) addInt64(i int, v int64) {
+	b.prependUint64(uint64(v))
+	b.slot(i)
+}
+
+func (b *fbBuilder) addOffset(i int, off int) {
+	b.prependOffset(off)
+	b.slot(i)
+}
+
+// endTable prepends the table's vtable (which has the position of
+// each field in the table) and returns the offset of the table.
+func (b *fbBuilder) endTable() int {
+	b.prependUint32(0)
+	table := b.offset()
+
+	vtable := make([]byte, 4+2*len(b.slots))
+	binary.LittleEndian.PutUint16(vtable, uint16(len(vtable)))
+	binary.LittleEndian.PutUint16(vtable[2:], uint16(table-b.start))
+	for i, s := range b.slots {
+		if s > 0 {
+			binary.LittleEndian.PutUint16(vtable[4+2*i:], uint16(table-s))
+		}
+	}
+	b.prepend(vtable)
+
+	// the table starts with the distance back to its vtable
+	binary.LittleEndian.PutUint32(b.data[len(b.data)-table:], uint32(b.offset()-table))
+	return table
+}
+
+// finish prepends the offset of the root table and returns the
+// flatbuffer, whose size is a multiple of its largest alignment.
+func (b *fbBuilder) finish(root int) []byte {
+	b.prep(b.minAlign, 4)
+	b.prependOffset(root)
+	return b.data
+}
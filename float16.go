@@ -0,0 +1,73 @@
+package parquet
+
+import "math"
+
+// Float16 is an IEEE 754 half precision float, which is the go type
+// of a FLOAT16 column (a FIXED_LEN_BYTE_ARRAY(2) with the FLOAT16
+// logical type).  It holds the float's bits, and NewFloat16 and
+// Float32 convert it to and from a float32.
+type Float16 uint16
+
+// NewFloat16 returns the Float16 that is closest to f (ties are
+// rounded to even).  A float that is too large for a Float16 is
+// infinite and one that is too small is zero.
+func NewFloat16(f float32) Float16 {
+	b := math.Float32bits(f)
+	sign := uint32(b>>16) & 0x8000
+	exp := int32(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if exp == 0xff {
+		if mant != 0 {
+			// a quiet NaN
+			return Float16(sign | 0x7e00)
+		}
+		return Float16(sign | 0x7c00)
+	}
+
+	e := exp - 127 + 15
+	switch {
+	case e >= 0x1f:
+		return Float16(sign | 0x7c00)
+	case e <= 0:
+		// a subnormal Float16 (or zero) is its mantissa * 2^-24
+		if e < -10 {
+			return Float16(sign)
+		}
+		mant |= 0x800000
+		shift := uint32(14 - e)
+		half := mant >> shift
+		rem, halfway := mant&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return Float16(sign | half)
+	}
+
+	// rounding up can carry into the exponent
+	// (and make the Float16 infinite)
+	half := uint32(e)<<10 | mant>>13
+	if rem := mant & 0x1fff; rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return Float16(sign | half)
+}
+
+// Float32 returns h as a float32, which holds every Float16 exactly.
+func (h Float16) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0:
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package parquet_test
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzRead(f *testing.F) {
+	for _, comp := range []string{"uncompressed", "snappy"} {
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, MaxPageSize(2), compressionTest[comp])
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		for _, rowgroup := range getPeople(3, 5) {
+			for _, p := range rowgroup {
+				w.Add(p)
+			}
+			if err := w.Write(); err != nil {
+				f.Fatal(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// a malformed file is an error, not a panic
+		SafeRead(bytes.NewReader(data))
+	})
+}
@@ -1,6 +1,6 @@
 module github.com/rclayton-godaddy/parquet
 
-go 1.17
+go 1.18
 
 require (
 	github.com/apache/thrift v0.13.0
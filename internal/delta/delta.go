@@ -0,0 +1,265 @@
+// Package delta implements the DELTA_BINARY_PACKED encoding, which
+// is also used for the lengths and prefixes of the DELTA_LENGTH_BYTE_ARRAY
+// and DELTA_BYTE_ARRAY encodings.
+package delta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	blockSize     = 128
+	miniBlocks    = 4
+	miniBlockSize = blockSize / miniBlocks
+
+	// maxBlockSize keeps a corrupt header from
+	// causing huge allocations.
+	maxBlockSize = 1 << 16
+)
+
+// Encode encodes vals with DELTA_BINARY_PACKED.  Deltas (and the
+// first value) are signed, so descending and mixed-sign sequences
+// round-trip exactly (the arithmetic wraps the same way in Decode).
+func Encode(vals []int64) []byte {
+	return encode(vals, false)
+}
+
+// Encode32 encodes vals, which are the values of an INT32 column, like
+// Encode, but its deltas wrap like int32s do.  A delta between int32s
+// can need 33 bits, and other readers reject a bit width (or a min
+// delta) that doesn't fit in the column's type.  Decode's deltas wrap
+// the same way once its values are truncated to int32s.
+func Encode32(vals []int64) []byte {
+	return encode(vals, true)
+}
+
+func encode(vals []int64, wrap32 bool) []byte {
+	out := appendUvarint(nil, blockSize)
+	out = appendUvarint(out, miniBlocks)
+	out = appendUvarint(out, uint64(len(vals)))
+
+	var first int64
+	if len(vals) > 0 {
+		first = vals[0]
+	}
+	out = appendVarint(out, first)
+
+	deltas := make([]int64, 0, blockSize)
+	for i := 1; i < len(vals); i += blockSize {
+		deltas = deltas[:0]
+		for j := i; j < i+blockSize && j < len(vals); j++ {
+			d := vals[j] - vals[j-1]
+			if wrap32 {
+				d = int64(int32(vals[j]) - int32(vals[j-1]))
+			}
+			deltas = append(deltas, d)
+		}
+		out = encodeBlock(out, deltas)
+	}
+	return out
+}
+
+func encodeBlock(out []byte, deltas []int64) []byte {
+	min := deltas[0]
+	for _, d := range deltas {
+		if d < min {
+			min = d
+		}
+	}
+	out = appendVarint(out, min)
+
+	var widths [miniBlocks]int
+	for i := range widths {
+		for j := i * miniBlockSize; j < (i+1)*miniBlockSize && j < len(deltas); j++ {
+			if w := bits.Len64(uint64(deltas[j] - min)); w > widths[i] {
+				widths[i] = w
+			}
+		}
+		out = append(out, byte(widths[i]))
+	}
+
+	for i, w := range widths {
+		start := i * miniBlockSize
+		if start >= len(deltas) {
+			break
+		}
+
+		// the last miniblock is padded to the full size
+		var packed [miniBlockSize]uint64
+		for j := start; j < start+miniBlockSize && j < len(deltas); j++ {
+			packed[j-start] = uint64(deltas[j] - min)
+		}
+		out = pack(out, packed[:], w)
+	}
+	return out
+}
+
+// Decode decodes DELTA_BINARY_PACKED data that holds n values.  It
+// returns the values and the number of bytes of data that were used.
+func Decode(data []byte, n int) ([]int64, int, error) {
+	d := decoder{data: data}
+	size := d.uvarint()
+	blocks := d.uvarint()
+	count := d.uvarint()
+	first := d.varint()
+	if d.err != nil {
+		return nil, 0, d.err
+	}
+
+	if count != uint64(n) {
+		return nil, 0, fmt.Errorf("delta: header has %d values, expected %d", count, n)
+	}
+
+	if size == 0 || size > maxBlockSize || blocks == 0 || size%blocks != 0 || size/blocks%8 != 0 {
+		return nil, 0, fmt.Errorf("delta: invalid block size %d with %d miniblocks", size, blocks)
+	}
+
+	if n == 0 {
+		return nil, d.pos, nil
+	}
+
+	// don't trust n for the capacity (a miniblock with a
+	// bit width of 0 holds values without using any data)
+	c := n
+	if c > 8*len(data) {
+		c = 8 * len(data)
+	}
+
+	perMini := int(size / blocks)
+	out := make([]int64, 1, c+1)
+	out[0] = first
+	widths := make([]int, blocks)
+	for len(out) < n {
+		min := d.varint()
+		for i := range widths {
+			widths[i] = int(d.byte())
+			if widths[i] > 64 {
+				return nil, 0, fmt.Errorf("delta: invalid bit width %d", widths[i])
+			}
+		}
+		if d.err != nil {
+			return nil, 0, d.err
+		}
+
+		for _, w := range widths {
+			if len(out) == n {
+				break
+			}
+
+			b := d.bytes(perMini * w / 8)
+			if d.err != nil {
+				return nil, 0, d.err
+			}
+
+			for i := 0; i < perMini && len(out) < n; i++ {
+				v := out[len(out)-1] + min + int64(unpack(b, i, w))
+				out = append(out, v)
+			}
+		}
+	}
+	return out, d.pos, nil
+}
+
+// pack appends vals, bit packed with width bits each
+// (least significant bit first).
+func pack(out []byte, vals []uint64, width int) []byte {
+	if width == 0 {
+		return out
+	}
+
+	start := len(out)
+	out = append(out, make([]byte, len(vals)*width/8)...)
+	b := out[start:]
+	for i, v := range vals {
+		for j := 0; j < width; j++ {
+			if v&(1<<uint(j)) != 0 {
+				bit := i*width + j
+				b[bit/8] |= 1 << uint(bit%8)
+			}
+		}
+	}
+	return out
+}
+
+// unpack returns the i'th value of b, bit packed with width bits.
+func unpack(b []byte, i, width int) uint64 {
+	var v uint64
+	for j := 0; j < width; j++ {
+		bit := i*width + j
+		if b[bit/8]&(1<<uint(bit%8)) != 0 {
+			v |= 1 << uint(j)
+		}
+	}
+	return v
+}
+
+func appendUvarint(out []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(out, buf[:n]...)
+}
+
+// appendVarint appends v with zigzag encoding.
+func appendVarint(out []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	return append(out, buf[:n]...)
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		d.err = fmt.Errorf("delta: invalid varint at byte %d", d.pos)
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		d.err = fmt.Errorf("delta: invalid zigzag varint at byte %d", d.pos)
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *decoder) byte() byte {
+	b := d.bytes(1)
+	if d.err != nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n > len(d.data)-d.pos {
+		d.err = fmt.Errorf("delta: need %d bytes at byte %d, only %d left", n, d.pos, len(d.data)-d.pos)
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
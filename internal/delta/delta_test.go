@@ -0,0 +1,153 @@
+package delta_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/rclayton-godaddy/parquet/internal/delta"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		in       []byte
+		expected []int64
+	}{
+		{
+			// example 1 from the parquet encodings doc
+			name:     "ascending",
+			in:       []byte{0x80, 0x01, 0x04, 0x05, 0x02, 0x02, 0x00, 0x00, 0x00, 0x00},
+			expected: []int64{1, 2, 3, 4, 5},
+		},
+		{
+			// example 2 from the parquet encodings doc
+			name: "descending then ascending",
+			in: []byte{
+				0x80, 0x01, 0x04, 0x08, 0x0e,
+				0x03, 0x02, 0x00, 0x00, 0x00,
+				0xc0, 0x3f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			expected: []int64{7, 5, 3, 1, 2, 3, 4, 5},
+		},
+		{
+			name:     "one value",
+			in:       []byte{0x80, 0x01, 0x04, 0x01, 0x09},
+			expected: []int64{-5},
+		},
+		{
+			// the first value and the min delta are zigzag varints
+			name:     "negative deltas",
+			in:       []byte{0x80, 0x01, 0x04, 0x03, 0x0a, 0x0f, 0x00, 0x00, 0x00, 0x00},
+			expected: []int64{5, -3, -11},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, n, err := delta.Decode(tc.in, len(tc.expected))
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, out)
+				assert.Equal(t, len(tc.in), n)
+			}
+		})
+	}
+}
+
+func TestEncodeAndDecode(t *testing.T) {
+	testCases := [][]int64{
+		{},
+		{42},
+		{5, 5, 6, 6},
+		{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		{math.MaxInt64, math.MinInt64, 0, math.MaxInt64},
+		seq(1000, func(i int) int64 { return int64(i * i % 317) }),
+		seq(129, func(i int) int64 { return int64(i) }),
+		// negative and mixed-sign deltas
+		{5, -3, 100, -100, 0},
+		{-1, -2, -3, -4, -5, -6},
+		{math.MinInt32, math.MaxInt32, math.MinInt32, 0, -1},
+		seq(300, func(i int) int64 { return int64((i%2)*2-1) * int64(i*i) }),
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			b := delta.Encode(tc)
+
+			// trailing bytes belong to whatever comes next
+			out, n, err := delta.Decode(append(b, 0xff, 0xff), len(tc))
+			if assert.NoError(t, err) {
+				if len(tc) == 0 {
+					assert.Nil(t, out)
+				} else {
+					assert.Equal(t, tc, out)
+				}
+				assert.Equal(t, len(b), n)
+			}
+		})
+	}
+}
+
+func TestEncode32(t *testing.T) {
+	vals := []int64{math.MinInt32, math.MaxInt32, math.MinInt32, 0, -1}
+
+	// the deltas of an INT32 column wrap, so they fit in 32 bits
+	// and so does the min delta (Encode's need 33)
+	for _, tc := range []struct {
+		b     []byte
+		width byte
+	}{
+		{b: delta.Encode32(vals), width: 32},
+		{b: delta.Encode(vals), width: 33},
+	} {
+		pos := 4 // the block size (2 bytes), number of miniblocks, and count
+		_, n := binary.Varint(tc.b[pos:])
+		pos += n
+		min, n := binary.Varint(tc.b[pos:])
+		pos += n
+		assert.Equal(t, tc.width, tc.b[pos])
+		if tc.width == 32 {
+			assert.True(t, min >= math.MinInt32 && min <= math.MaxInt32, min)
+		}
+	}
+
+	// the values that are decoded wrap the same way
+	out, _, err := delta.Decode(delta.Encode32(vals), len(vals))
+	if assert.NoError(t, err) {
+		for i, v := range out {
+			assert.Equal(t, int32(vals[i]), int32(v))
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   []byte
+		n    int
+		err  string
+	}{
+		{name: "empty", in: nil, n: 1, err: "delta: invalid varint at byte 0"},
+		{name: "wrong count", in: []byte{0x80, 0x01, 0x04, 0x05, 0x02}, n: 4, err: "delta: header has 5 values, expected 4"},
+		{name: "bad block size", in: []byte{0x07, 0x04, 0x01, 0x02}, n: 1, err: "delta: invalid block size 7 with 4 miniblocks"},
+		{name: "truncated", in: []byte{0x80, 0x01, 0x04, 0x02, 0x02, 0x00, 0x08, 0x00, 0x00, 0x00, 0x01}, n: 2, err: "delta: need 32 bytes at byte 10, only 1 left"},
+		{name: "bit width", in: []byte{0x80, 0x01, 0x04, 0x02, 0x02, 0x00, 0x41, 0x00, 0x00, 0x00}, n: 2, err: "delta: invalid bit width 65"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := delta.Decode(tc.in, tc.n)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func seq(n int, f func(int) int64) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = f(i)
+	}
+	return out
+}
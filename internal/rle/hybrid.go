@@ -0,0 +1,144 @@
+package rle
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// EncodeUint32 encodes vals (dictionary indices) with the RLE/bit-packing
+// hybrid encoding.  Unlike Bytes, the output isn't preceded by its length
+// and width can be up to 32.  Runs of 8 or more of the same value are
+// run length encoded and everything else is bit packed.
+func EncodeUint32(vals []uint32, width int) []byte {
+	var out []byte
+	var packed []uint32
+	flush := func() {
+		if len(packed) == 0 {
+			return
+		}
+		for len(packed)%8 != 0 {
+			packed = append(packed, 0)
+		}
+		out = appendUvarint(out, uint64(len(packed)/8)<<1|1)
+		out = packUint32(out, packed, width)
+		packed = packed[:0]
+	}
+
+	for i := 0; i < len(vals); {
+		j := i + 1
+		for j < len(vals) && vals[j] == vals[i] {
+			j++
+		}
+
+		// a run can only start where a bit packed
+		// group of 8 ends
+		if j-i >= 8 && len(packed)%8 == 0 {
+			flush()
+			out = appendUvarint(out, uint64(j-i)<<1)
+			var v [4]byte
+			binary.LittleEndian.PutUint32(v[:], vals[i])
+			out = append(out, v[:(width+7)/8]...)
+			i = j
+			continue
+		}
+
+		packed = append(packed, vals[i])
+		i++
+	}
+	flush()
+	return out
+}
+
+// DecodeUint32 decodes n values that were encoded by EncodeUint32
+// (or any other writer of the hybrid encoding).
+func DecodeUint32(data []byte, width, n int) ([]uint32, error) {
+	if width < 0 || width > 32 {
+		return nil, fmt.Errorf("invalid bit width %d", width)
+	}
+
+	// the capacity isn't taken from n since it isn't trusted
+	out := make([]uint32, 0, minInt(n, 8*len(data)+8))
+	for len(out) < n {
+		header, l := binary.Uvarint(data)
+		if l <= 0 {
+			return nil, fmt.Errorf("invalid rle header with %d of %d values left", n-len(out), n)
+		}
+		data = data[l:]
+
+		if header&1 == 0 {
+			count := header >> 1
+			if count > uint64(n-len(out)) {
+				return nil, fmt.Errorf("rle run of %d values is more than the %d expected", count, n)
+			}
+
+			size := (width + 7) / 8
+			if len(data) < size {
+				return nil, fmt.Errorf("rle run is missing its value")
+			}
+
+			var v [4]byte
+			copy(v[:], data[:size])
+			data = data[size:]
+			x := binary.LittleEndian.Uint32(v[:])
+			for i := uint64(0); i < count; i++ {
+				out = append(out, x)
+			}
+			continue
+		}
+
+		count := (header >> 1) * 8
+		if count > uint64(n-len(out)+7) {
+			return nil, fmt.Errorf("bitpacked run of %d values is more than the %d expected", count, n)
+		}
+
+		size := int(count) * width / 8
+		if len(data) < size {
+			return nil, fmt.Errorf("bitpacked run of %d values is longer than the remaining data", count)
+		}
+
+		for i := 0; i < int(count) && len(out) < n; i++ {
+			out = append(out, unpackUint32(data, i, width))
+		}
+		data = data[size:]
+	}
+	return out, nil
+}
+
+func packUint32(out []byte, vals []uint32, width int) []byte {
+	start := len(out)
+	out = append(out, make([]byte, len(vals)*width/8)...)
+	b := out[start:]
+	for i, v := range vals {
+		for j := 0; j < width; j++ {
+			if v&(1<<uint(j)) != 0 {
+				bit := i*width + j
+				b[bit/8] |= 1 << uint(bit%8)
+			}
+		}
+	}
+	return out
+}
+
+func unpackUint32(b []byte, i, width int) uint32 {
+	var v uint32
+	for j := 0; j < width; j++ {
+		bit := i*width + j
+		if b[bit/8]&(1<<uint(bit%8)) != 0 {
+			v |= 1 << uint(j)
+		}
+	}
+	return v
+}
+
+func appendUvarint(out []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(out, buf[:n]...)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -157,26 +157,52 @@ func (r *RLE) Bytes() []byte {
 
 // Read reads the RLE encoded definition levels
 func (r *RLE) Read(in io.Reader) ([]uint8, int, error) {
+	return r.ReadN(in, -1)
+}
+
+// ReadN reads the RLE encoded definition levels and returns an
+// error if the data holds more than n values (bitpacked runs may be
+// padded by up to 7 values).  n < 0 means there is no limit.
+func (r *RLE) ReadN(in io.Reader, n int) ([]uint8, int, error) {
 	var out []uint8
 	var length int32
 	if err := binary.Read(in, binary.LittleEndian, &length); err != nil {
 		return out, 0, err
 	}
 
-	buf := make([]byte, length)
-	if _, err := in.Read(buf); err != nil {
+	if length < 0 {
+		return nil, 0, fmt.Errorf("invalid rle length %d", length)
+	}
+
+	// the length isn't trusted, so the buffer only grows
+	// as large as the data that is actually there.
+	buf, err := io.ReadAll(io.LimitReader(in, int64(length)))
+	if err != nil {
 		return nil, 0, err
 	}
 
+	if len(buf) < int(length) {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
 	rr := bytes.NewReader(buf)
 	var header uint64
 	var vals []uint8
-	var err error
 	for rr.Len() > 0 {
 		header, err = readLEB128(rr)
 		if err != nil {
 			return nil, 0, err
 		}
+
+		count := header >> 1
+		if header&1 == 1 {
+			count *= 8
+		}
+
+		if n >= 0 && count > uint64(n-len(out)+7) {
+			return nil, 0, fmt.Errorf("rle run of %d values is more than the %d expected", count, n)
+		}
+
 		if header&1 == 0 {
 			vals, err = readRLE(rr, header, uint64(r.bitWidth))
 			if err != nil {
@@ -184,6 +210,10 @@ func (r *RLE) Read(in io.Reader) ([]uint8, int, error) {
 			}
 			out = append(out, vals...)
 		} else {
+			if uint64(r.bitWidth)*count/8 > uint64(rr.Len()) {
+				return nil, 0, fmt.Errorf("bitpacked run of %d values is longer than the remaining data", count)
+			}
+
 			vals, err = readRLEBitPacked(rr, header, uint8(r.bitWidth))
 			if err != nil {
 				return nil, 0, err
@@ -123,3 +123,48 @@ func repeat(v uint8, c int) []uint8 {
 	}
 	return out
 }
+
+func TestUint32(t *testing.T) {
+	testCases := []struct {
+		name  string
+		width int
+		in    []uint32
+	}{
+		{name: "empty", width: 1, in: []uint32{}},
+		{name: "bit packed", width: 3, in: []uint32{0, 1, 2, 3, 4, 5, 6, 7, 6, 5}},
+		{name: "run", width: 2, in: []uint32{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3}},
+		{name: "packed then run", width: 4, in: []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 9, 9, 9, 9, 9, 9, 9, 9, 1}},
+		{name: "run that doesn't start a group", width: 2, in: []uint32{1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 0}},
+		{name: "wide", width: 20, in: []uint32{1 << 19, 7, 1<<20 - 1, 0}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := rle.EncodeUint32(tc.in, tc.width)
+			out, err := rle.DecodeUint32(b, tc.width, len(tc.in))
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.in, out)
+			}
+		})
+	}
+}
+
+func TestDecodeUint32(t *testing.T) {
+	// a run of 8 threes with a width of 2
+	out, err := rle.DecodeUint32([]byte{0x10, 0x03}, 2, 8)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []uint32{3, 3, 3, 3, 3, 3, 3, 3}, out)
+	}
+
+	_, err = rle.DecodeUint32([]byte{0x10, 0x03}, 2, 4)
+	assert.EqualError(t, err, "rle run of 8 values is more than the 4 expected")
+
+	_, err = rle.DecodeUint32([]byte{0x03, 0x01}, 3, 8)
+	assert.EqualError(t, err, "bitpacked run of 8 values is longer than the remaining data")
+
+	_, err = rle.DecodeUint32(nil, 2, 1)
+	assert.EqualError(t, err, "invalid rle header with 1 of 1 values left")
+
+	_, err = rle.DecodeUint32(nil, 33, 1)
+	assert.EqualError(t, err, "invalid bit width 33")
+}
@@ -0,0 +1,627 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// Number is the go types of the numeric columns.
+type Number interface {
+	int32 | uint32 | int64 | uint64 | float32 | float64 | Float16
+}
+
+// NumericField is a required numeric column of the type R.  The
+// generated code uses it (instead of generating a field for each
+// type) along with the functions that read and write the column's
+// value of an R.
+type NumericField[T Number, R any] struct {
+	vals []T
+	RequiredField
+	read  func(r R) T
+	write func(r *R, vals []T)
+	stats *numericStats[T]
+	delta bool
+
+	// statsOnWrite is true if the stats are computed by Write
+	statsOnWrite bool
+}
+
+// NewNumericField returns a NumericField that gets each value from
+// a record with read and sets them with write.
+func NewNumericField[T Number, R any](read func(r R) T, write func(r *R, vals []T), path []string, opts ...func(*RequiredField)) *NumericField[T, R] {
+	return &NumericField[T, R]{
+		read:          read,
+		write:         write,
+		RequiredField: NewRequiredField(path, opts...),
+		stats:         newNumericStats[T](),
+	}
+}
+
+// Schema returns the parquet schema of the field.
+func (f *NumericField[T, R]) Schema() Field {
+	return numericSchema[T](f.Name(), f.Path(), RepetitionRequired, []int{0})
+}
+
+// Read reads a page of values.
+func (f *NumericField[T, R]) Read(r io.ReadSeeker, pg Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n > rr.Len()/valueSize[T](pg) {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	start := len(f.vals)
+	f.vals = grow(f.vals, n)
+	err = readNumbers(rr, pg, f.vals[start:])
+	f.buffers.KeepValues(f.Name(), f.vals)
+	return err
+}
+
+// SetBuffers sets the FieldBuffers that the field's values are
+// decoded into.
+func (f *NumericField[T, R]) SetBuffers(b *FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = Values[T](b, f.Name())
+}
+
+// SetDelta makes the field DELTA_BINARY_PACKED encoded.  Float
+// fields can't be delta encoded so it doesn't change them.
+func (f *NumericField[T, R]) SetDelta() {
+	f.delta = isInteger[T]()
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *NumericField[T, R]) SetStatsMode(m StatsMode) {
+	f.statsOnWrite = m == StatsOnWrite
+}
+
+// Write writes the field's values as a page.
+func (f *NumericField[T, R]) Write(w io.Writer, meta *Metadata) error {
+	if f.statsOnWrite {
+		for _, v := range f.vals {
+			f.stats.add(v)
+		}
+	}
+
+	if f.delta && is32Bit[T]() {
+		return f.DoWriteDelta32(w, meta, deltaValues(f.vals), f.stats)
+	}
+	if f.delta {
+		return f.DoWriteDelta(w, meta, deltaValues(f.vals), f.stats)
+	}
+
+	return f.DoWrite(w, meta, plainNumbers(f.vals), len(f.vals), f.stats)
+}
+
+// Scan sets the field of r to the next value.
+func (f *NumericField[T, R]) Scan(r *R) {
+	if len(f.vals) == 0 {
+		return
+	}
+
+	f.write(r, f.vals)
+	f.vals = f.vals[1:]
+}
+
+// Value returns the value of the field's next row.
+func (f *NumericField[T, R]) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
+}
+
+// Add adds the field's value of r.
+func (f *NumericField[T, R]) Add(r R) {
+	f.add(f.read(r))
+}
+
+// AddValues adds the values of rows without records.
+func (f *NumericField[T, R]) AddValues(vals []T) {
+	for _, v := range vals {
+		f.add(v)
+	}
+}
+
+func (f *NumericField[T, R]) add(v T) {
+	if !f.statsOnWrite {
+		f.stats.add(v)
+	}
+	f.vals = append(f.vals, v)
+}
+
+// Levels returns the field's definition and repetition levels
+// (a required field doesn't have any).
+func (f *NumericField[T, R]) Levels() ([]uint8, []uint8) {
+	return nil, nil
+}
+
+// Rows returns the number of rows that have been added.
+func (f *NumericField[T, R]) Rows() int {
+	return len(f.vals)
+}
+
+// OptionalNumericField is an optional or repeated numeric column
+// of the type R.
+type OptionalNumericField[T Number, R any] struct {
+	OptionalField
+	vals  []T
+	read  func(r R, vals []T, defs, reps []uint8) ([]T, []uint8, []uint8)
+	write func(r *R, vals []T, defs, reps []uint8) (int, int)
+	stats *optionalNumericStats[T]
+	delta bool
+
+	// statsOnWrite is true if the stats are computed by Write
+	statsOnWrite bool
+}
+
+// NewOptionalNumericField returns an OptionalNumericField that adds
+// the values and levels of a record with read and sets them with write.
+func NewOptionalNumericField[T Number, R any](read func(r R, vals []T, defs, reps []uint8) ([]T, []uint8, []uint8), write func(r *R, vals []T, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*OptionalField)) *OptionalNumericField[T, R] {
+	return &OptionalNumericField[T, R]{
+		read:          read,
+		write:         write,
+		OptionalField: NewOptionalField(path, types, opts...),
+		stats:         newOptionalNumericStats[T](maxDef(types)),
+	}
+}
+
+// Schema returns the parquet schema of the field.
+func (f *OptionalNumericField[T, R]) Schema() Field {
+	return numericSchema[T](f.Name(), f.Path(), f.RepetitionType, f.Types)
+}
+
+// SetDelta makes the field DELTA_BINARY_PACKED encoded.  Float
+// fields can't be delta encoded so it doesn't change them.
+func (f *OptionalNumericField[T, R]) SetDelta() {
+	f.delta = isInteger[T]()
+}
+
+// SetStatsMode sets when the field computes its stats.
+func (f *OptionalNumericField[T, R]) SetStatsMode(m StatsMode) {
+	f.statsOnWrite = m == StatsOnWrite
+}
+
+// Write writes the field's levels and values as a page.
+func (f *OptionalNumericField[T, R]) Write(w io.Writer, meta *Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.delta && is32Bit[T]() {
+		return f.DoWriteDelta32(w, meta, deltaValues(f.vals), len(f.Defs), f.stats)
+	}
+	if f.delta {
+		return f.DoWriteDelta(w, meta, deltaValues(f.vals), len(f.Defs), f.stats)
+	}
+
+	return f.DoWrite(w, meta, plainNumbers(f.vals), len(f.Defs), f.stats)
+}
+
+// Read reads a page of levels and values.
+func (f *OptionalNumericField[T, R]) Read(r io.ReadSeeker, pg Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n == 0 {
+		// every value in the column chunk is null
+		return nil
+	}
+
+	if n > rr.Len()/valueSize[T](pg) {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	start := len(f.vals)
+	f.vals = grow(f.vals, n)
+	err = readNumbers(rr, pg, f.vals[start:])
+	f.buffers.KeepValues(f.Name(), f.vals)
+	return err
+}
+
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *OptionalNumericField[T, R]) SetBuffers(b *FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = Values[T](b, f.Name())
+}
+
+// Add adds the field's values and levels of r.
+func (f *OptionalNumericField[T, R]) Add(r R) {
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *OptionalNumericField[T, R]) AddValues(vals []*T) {
+	v, defs := AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+// add keeps the field's values and levels after new ones
+// have been appended to them.
+func (f *OptionalNumericField[T, R]) add(vals []T, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	f.vals = vals
+	f.Defs = defs
+	f.Reps = reps
+}
+
+// Scan sets the field of r to its next value (or values).
+func (f *OptionalNumericField[T, R]) Scan(r *R) {
+	if len(f.Defs) == 0 {
+		return
+	}
+
+	v, l := f.write(r, f.vals, f.Defs, f.Reps)
+	f.vals = f.vals[v:]
+	f.Defs = f.Defs[l:]
+	if len(f.Reps) > 0 {
+		f.Reps = f.Reps[l:]
+	}
+}
+
+// Value returns the value of the field's next row (see OptionalValue).
+func (f *OptionalNumericField[T, R]) Value() (interface{}, bool) {
+	return OptionalValue(&f.OptionalField, f.vals)
+}
+
+// Levels returns the field's definition and repetition levels.
+func (f *OptionalNumericField[T, R]) Levels() ([]uint8, []uint8) {
+	return f.Defs, f.Reps
+}
+
+type numericStats[T Number] struct {
+	min T
+	max T
+}
+
+func newNumericStats[T Number]() *numericStats[T] {
+	return &numericStats[T]{min: maxNumber[T]()}
+}
+
+func (s *numericStats[T]) add(v T) {
+	if less(v, s.min) {
+		s.min = v
+	}
+	if less(s.max, v) {
+		s.max = v
+	}
+}
+
+func (s *numericStats[T]) NullCount() *int64 {
+	return nil
+}
+
+func (s *numericStats[T]) DistinctCount() *int64 {
+	return nil
+}
+
+func (s *numericStats[T]) Min() []byte {
+	return plainNumbers([]T{s.min})
+}
+
+func (s *numericStats[T]) Max() []byte {
+	return plainNumbers([]T{s.max})
+}
+
+type optionalNumericStats[T Number] struct {
+	min     T
+	max     T
+	nils    int64
+	nonNils int64
+	maxDef  uint8
+}
+
+func newOptionalNumericStats[T Number](d uint8) *optionalNumericStats[T] {
+	return &optionalNumericStats[T]{
+		min:    maxNumber[T](),
+		maxDef: d,
+	}
+}
+
+func (s *optionalNumericStats[T]) add(vals []T, defs []uint8) {
+	var i int
+	for _, def := range defs {
+		if def < s.maxDef {
+			s.nils++
+			continue
+		}
+
+		v := vals[i]
+		i++
+
+		s.nonNils++
+		if less(v, s.min) {
+			s.min = v
+		}
+		if less(s.max, v) {
+			s.max = v
+		}
+	}
+}
+
+func (s *optionalNumericStats[T]) NullCount() *int64 {
+	return &s.nils
+}
+
+func (s *optionalNumericStats[T]) DistinctCount() *int64 {
+	return nil
+}
+
+func (s *optionalNumericStats[T]) Min() []byte {
+	if s.nonNils == 0 {
+		return nil
+	}
+	return plainNumbers([]T{s.min})
+}
+
+func (s *optionalNumericStats[T]) Max() []byte {
+	if s.nonNils == 0 {
+		return nil
+	}
+	return plainNumbers([]T{s.max})
+}
+
+// The rest of this file is the type specific code that can't be
+// written for any T (binary.Read handles a []T on its own since
+// the dynamic type of the slice is one of its fast paths, except
+// for a []Float16).
+
+func numericSchema[T Number](name string, pth []string, rt FieldFunc, types []int) Field {
+	var typ sch.Type
+	out := Field{Name: name, Path: pth, RepetitionType: rt, Types: types}
+
+	var v T
+	switch any(v).(type) {
+	case int32:
+		typ = sch.Type_INT32
+	case uint32:
+		typ = sch.Type_INT32
+		out.ConvertedType = convertedType(sch.ConvertedType_UINT_32)
+		out.LogicalType = &sch.LogicalType{INTEGER: &sch.IntType{BitWidth: 32}}
+	case int64:
+		typ = sch.Type_INT64
+	case uint64:
+		typ = sch.Type_INT64
+		out.ConvertedType = convertedType(sch.ConvertedType_UINT_64)
+		out.LogicalType = &sch.LogicalType{INTEGER: &sch.IntType{BitWidth: 64}}
+	case float32:
+		typ = sch.Type_FLOAT
+	case float64:
+		typ = sch.Type_DOUBLE
+	case Float16:
+		typ = sch.Type_FIXED_LEN_BYTE_ARRAY
+		out.LogicalType = &sch.LogicalType{FLOAT16: &sch.Float16Type{}}
+	}
+
+	out.Type = func(se *sch.SchemaElement) {
+		t := typ
+		se.Type = &t
+		if typ == sch.Type_FIXED_LEN_BYTE_ARRAY {
+			l := int32(2)
+			se.TypeLength = &l
+		}
+	}
+	return out
+}
+
+func convertedType(c sch.ConvertedType) *sch.ConvertedType {
+	return &c
+}
+
+// numberSize is the number of bytes of a PLAIN encoded T.
+func numberSize[T Number]() int {
+	var v T
+	switch any(v).(type) {
+	case Float16:
+		return 2
+	case int32, uint32, float32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// decimal is true if the values of pg are the unscaled INT32 or
+// INT64 values of a DECIMAL column that are read into a float field
+// (see Page.DecimalAsFloat).
+func decimal[T Number](pg Page) bool {
+	if !pg.DecimalAsFloat || isInteger[T]() || isFloat16[T]() || (pg.Type != sch.Type_INT32 && pg.Type != sch.Type_INT64) {
+		return false
+	}
+	if pg.LogicalType != nil && pg.LogicalType.DECIMAL != nil {
+		return true
+	}
+	return pg.ConvertedType != nil && *pg.ConvertedType == sch.ConvertedType_DECIMAL
+}
+
+// valueSize is the size of each of the PLAIN encoded values of pg
+// that are read into a T.
+func valueSize[T Number](pg Page) int {
+	if !decimal[T](pg) {
+		return numberSize[T]()
+	}
+	if pg.Type == sch.Type_INT32 {
+		return 4
+	}
+	return 8
+}
+
+// readNumbers reads the PLAIN encoded values of pg into vals.  The
+// unscaled values of a DECIMAL column are divided by 10^scale.
+func readNumbers[T Number](r io.Reader, pg Page, vals []T) error {
+	if h, ok := any(vals).([]Float16); ok {
+		return readFloat16s(r, h)
+	}
+
+	if !decimal[T](pg) {
+		return binary.Read(r, binary.LittleEndian, vals)
+	}
+
+	scale := pg.Scale
+	if pg.LogicalType != nil && pg.LogicalType.DECIMAL != nil {
+		scale = pg.LogicalType.DECIMAL.Scale
+	}
+	div := math.Pow10(int(scale))
+
+	if pg.Type == sch.Type_INT32 {
+		unscaled := make([]int32, len(vals))
+		if err := binary.Read(r, binary.LittleEndian, unscaled); err != nil {
+			return err
+		}
+		for i, v := range unscaled {
+			vals[i] = T(float64(v) / div)
+		}
+		return nil
+	}
+
+	unscaled := make([]int64, len(vals))
+	if err := binary.Read(r, binary.LittleEndian, unscaled); err != nil {
+		return err
+	}
+	for i, v := range unscaled {
+		vals[i] = T(float64(v) / div)
+	}
+	return nil
+}
+
+// readFloat16s reads the PLAIN encoded values (2 little endian
+// bytes each) of a FLOAT16 column into vals.
+func readFloat16s(r io.Reader, vals []Float16) error {
+	buf := make([]byte, 2*len(vals))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	for i := range vals {
+		vals[i] = Float16(binary.LittleEndian.Uint16(buf[2*i:]))
+	}
+	return nil
+}
+
+// maxNumber is the largest T (it is where the min stat starts).
+func maxNumber[T Number]() T {
+	var v T
+	var out interface{}
+	switch any(v).(type) {
+	case int32:
+		out = int32(math.MaxInt32)
+	case uint32:
+		out = uint32(math.MaxUint32)
+	case int64:
+		out = int64(math.MaxInt64)
+	case uint64:
+		out = uint64(math.MaxUint64)
+	case float32:
+		out = float32(math.MaxFloat32)
+	case float64:
+		out = float64(math.MaxFloat64)
+	case Float16:
+		out = Float16(0x7bff)
+	}
+	return out.(T)
+}
+
+func isInteger[T Number]() bool {
+	var v T
+	switch any(v).(type) {
+	case float32, float64, Float16:
+		return false
+	default:
+		return true
+	}
+}
+
+// is32Bit is true if T is stored as an INT32.
+func is32Bit[T Number]() bool {
+	var v T
+	switch any(v).(type) {
+	case int32, uint32:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFloat16[T Number]() bool {
+	var v T
+	_, ok := any(v).(Float16)
+	return ok
+}
+
+// less is true if a is less than b (a Float16 is compared
+// as a float, not by its bits).
+func less[T Number](a, b T) bool {
+	if isFloat16[T]() {
+		return Float16(a).Float32() < Float16(b).Float32()
+	}
+	return a < b
+}
+
+// plainNumbers PLAIN encodes vals.
+func plainNumbers[T Number](vals []T) []byte {
+	size := numberSize[T]()
+	out := make([]byte, size*len(vals))
+	for i, v := range vals {
+		bs := out[i*size:]
+		switch x := any(v).(type) {
+		case int32:
+			binary.LittleEndian.PutUint32(bs, uint32(x))
+		case uint32:
+			binary.LittleEndian.PutUint32(bs, x)
+		case int64:
+			binary.LittleEndian.PutUint64(bs, uint64(x))
+		case uint64:
+			binary.LittleEndian.PutUint64(bs, x)
+		case float32:
+			binary.LittleEndian.PutUint32(bs, math.Float32bits(x))
+		case float64:
+			binary.LittleEndian.PutUint64(bs, math.Float64bits(x))
+		case Float16:
+			binary.LittleEndian.PutUint16(bs, uint16(x))
+		}
+	}
+	return out
+}
+
+// deltaValues converts vals to the int64s that are delta encoded
+// (32 bit values are sign extended from their INT32 value).
+func deltaValues[T Number](vals []T) []int64 {
+	out := make([]int64, len(vals))
+	for i, v := range vals {
+		switch x := any(v).(type) {
+		case uint32:
+			out[i] = int64(int32(x))
+		default:
+			out[i] = int64(v)
+		}
+	}
+	return out
+}
+
+// maxDef is the max definition level of a column
+// with the repetition types types.
+func maxDef(types []int) uint8 {
+	var out uint8
+	for _, typ := range types {
+		if typ > 0 {
+			out++
+		}
+	}
+	return out
+}
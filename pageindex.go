@@ -0,0 +1,290 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// IndexedPage is a data page of a column chunk as it is described
+// by the chunk's offset index and column index.  Unlike the footer,
+// the indexes have an entry for each page, so a reader can decide
+// which pages to skip without reading their headers.
+type IndexedPage struct {
+	// Offset is where the page (starting with its header) is in
+	// the file and Size is the size of the page and its header.
+	Offset int64
+	Size   int32
+	// FirstRow is the index of the page's first row in the row group.
+	FirstRow int64
+
+	// The rest of the fields are from the column index, which is
+	// optional (only the offset index is needed to find the pages).
+	// Null is true if every value of the page is null, Min and Max
+	// are the page's PLAIN encoded bounds (nil for a null page), and
+	// NullCount is the number of nulls (if the writer recorded it).
+	Null      bool
+	Min       []byte
+	Max       []byte
+	NullCount *int64
+}
+
+// PageIndex returns the pages of the column chunk col of row group
+// rg.  It returns nil if the chunk doesn't have an offset index.
+func (m *Metadata) PageIndex(r io.ReadSeeker, rg int, col string) ([]IndexedPage, error) {
+	ch, err := m.columnChunk(rg, col)
+	if err != nil {
+		return nil, err
+	}
+
+	if ch.OffsetIndexOffset == nil || ch.OffsetIndexLength == nil {
+		return nil, nil
+	}
+
+	oi := sch.NewOffsetIndex()
+	if err := readIndex(r, *ch.OffsetIndexOffset, *ch.OffsetIndexLength, oi); err != nil {
+		return nil, fmt.Errorf("unable to read the offset index of %s: %s", col, err)
+	}
+
+	out := make([]IndexedPage, len(oi.PageLocations))
+	for i, loc := range oi.PageLocations {
+		out[i] = IndexedPage{
+			Offset:   loc.Offset,
+			Size:     loc.CompressedPageSize,
+			FirstRow: loc.FirstRowIndex,
+		}
+	}
+
+	if ch.ColumnIndexOffset == nil || ch.ColumnIndexLength == nil {
+		return out, nil
+	}
+
+	ci := sch.NewColumnIndex()
+	if err := readIndex(r, *ch.ColumnIndexOffset, *ch.ColumnIndexLength, ci); err != nil {
+		return nil, fmt.Errorf("unable to read the column index of %s: %s", col, err)
+	}
+
+	n := len(out)
+	if len(ci.NullPages) != n || len(ci.MinValues) != n || len(ci.MaxValues) != n || (ci.NullCounts != nil && len(ci.NullCounts) != n) {
+		return nil, fmt.Errorf("the column index of %s doesn't have an entry for each of its %d pages", col, n)
+	}
+
+	for i := range out {
+		out[i].Null = ci.NullPages[i]
+		if !out[i].Null {
+			out[i].Min = ci.MinValues[i]
+			out[i].Max = ci.MaxValues[i]
+		}
+		if ci.NullCounts != nil {
+			out[i].NullCount = &ci.NullCounts[i]
+		}
+	}
+	return out, nil
+}
+
+// PageSkip calls skip with each page of col (in every row group that
+// has a page index) and returns the pages that it returned true for,
+// by row group.  The row groups without a page index have no pages
+// that can be skipped.
+func (m *Metadata) PageSkip(r io.ReadSeeker, col string, skip func(IndexedPage) bool) ([][]IndexedPage, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	out := make([][]IndexedPage, len(m.metadata.RowGroups))
+	for i := range m.metadata.RowGroups {
+		pages, err := m.PageIndex(r, i, col)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pg := range pages {
+			if skip(pg) {
+				out[i] = append(out[i], pg)
+			}
+		}
+	}
+	return out, nil
+}
+
+// readIndex reads the size byte offset index or column index at offset.
+func readIndex(r io.ReadSeeker, offset int64, size int32, index interface {
+	Read(thrift.TProtocol) error
+}) error {
+	if size < 0 {
+		return fmt.Errorf("invalid size %d", size)
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	return index.Read(newThriftReader(bytes.NewReader(buf), int64(size)))
+}
+
+// WritePageIndex makes Footer write the offset index and column index
+// of each column chunk (before the footer).  The column index is left
+// out for a column chunk with a page that doesn't have a min and max.
+func (m *Metadata) WritePageIndex() {
+	m.pageIndex = true
+}
+
+// pageIndex holds the pages of a column chunk that has been
+// written, until they are written as its page index.
+type pageIndex struct {
+	// the offsets of locations are relative to
+	// the start of the column chunk
+	locations []*sch.PageLocation
+	rows      int64
+
+	nulls      []bool
+	mins       [][]byte
+	maxes      [][]byte
+	nullCounts []int64
+
+	// noStats is set if a page doesn't have a min and max (so the
+	// chunk can't have a column index) and noNullCounts if a page
+	// doesn't have a null count
+	noStats      bool
+	noNullCounts bool
+}
+
+// addPage adds a data page, which is size bytes including its header,
+// to the page index of its column chunk.  It must be called before the
+// page is added to the row group.
+func (m *Metadata) addPage(pth []string, size, count, rows int, stats Stats) {
+	if !m.pageIndex || len(m.rowGroups) == 0 {
+		return
+	}
+
+	rg := m.rowGroups[len(m.rowGroups)-1]
+	col := strings.Join(pth, ".")
+	pi, ok := rg.pages[col]
+	if !ok {
+		pi = &pageIndex{}
+		rg.pages[col] = pi
+	}
+
+	var offset int64
+	if ch, ok := rg.columns[col]; ok {
+		offset = ch.MetaData.TotalCompressedSize
+	}
+
+	pi.locations = append(pi.locations, &sch.PageLocation{
+		Offset:             offset,
+		CompressedPageSize: int32(size),
+		FirstRowIndex:      pi.rows,
+	})
+	pi.rows += int64(rows)
+
+	nulls := stats.NullCount()
+	if nulls == nil {
+		pi.noNullCounts = true
+	} else {
+		pi.nullCounts = append(pi.nullCounts, *nulls)
+	}
+
+	min, max := stats.Min(), stats.Max()
+	switch {
+	case min != nil && max != nil:
+		pi.nulls = append(pi.nulls, false)
+		pi.mins = append(pi.mins, min)
+		pi.maxes = append(pi.maxes, max)
+	case nulls != nil && *nulls == int64(count):
+		// a page of nulls has empty bounds
+		pi.nulls = append(pi.nulls, true)
+		pi.mins = append(pi.mins, []byte{})
+		pi.maxes = append(pi.maxes, []byte{})
+	default:
+		pi.noStats = true
+	}
+}
+
+// writePageIndexes writes the column indexes and then the offset indexes
+// of the column chunks of fmd at pos (where the row groups end) and sets
+// their locations in fmd.
+func (m *Metadata) writePageIndexes(w io.Writer, fmd *sch.FileMetaData, pos int64) error {
+	type chunk struct {
+		ch *sch.ColumnChunk
+		pi *pageIndex
+	}
+
+	var chunks []chunk
+	var i int
+	for _, mrg := range m.rowGroups {
+		if mrg.rowGroup.NumRows == 0 {
+			continue
+		}
+
+		for _, ch := range fmd.RowGroups[i].Columns {
+			if pi, ok := mrg.pages[m.column(ch.MetaData.PathInSchema)]; ok {
+				chunks = append(chunks, chunk{ch: ch, pi: pi})
+			}
+		}
+		i++
+	}
+
+	write := func(s thrift.TStruct) (int64, int32, error) {
+		buf, err := m.ts.Write(context.TODO(), s)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if _, err := w.Write(buf); err != nil {
+			return 0, 0, err
+		}
+
+		offset := pos
+		pos += int64(len(buf))
+		return offset, int32(len(buf)), nil
+	}
+
+	for _, c := range chunks {
+		if c.pi.noStats {
+			continue
+		}
+
+		ci := &sch.ColumnIndex{
+			NullPages:     c.pi.nulls,
+			MinValues:     c.pi.mins,
+			MaxValues:     c.pi.maxes,
+			BoundaryOrder: sch.BoundaryOrder_UNORDERED,
+		}
+		if !c.pi.noNullCounts {
+			ci.NullCounts = c.pi.nullCounts
+		}
+
+		offset, size, err := write(ci)
+		if err != nil {
+			return err
+		}
+		c.ch.ColumnIndexOffset = &offset
+		c.ch.ColumnIndexLength = &size
+	}
+
+	for _, c := range chunks {
+		locations := make([]*sch.PageLocation, len(c.pi.locations))
+		for i, loc := range c.pi.locations {
+			l := *loc
+			l.Offset += c.ch.FileOffset
+			locations[i] = &l
+		}
+
+		offset, size, err := write(&sch.OffsetIndex{PageLocations: locations})
+		if err != nil {
+			return err
+		}
+		c.ch.OffsetIndexOffset = &offset
+		c.ch.OffsetIndexLength = &size
+	}
+	return nil
+}
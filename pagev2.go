@@ -0,0 +1,231 @@
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rclayton-godaddy/parquet/internal/rle"
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// SetDataPageV2 makes the field write DATA_PAGE_V2 pages.  The
+// header of a v2 page has the number of nulls and rows in the page
+// (along with the page's statistics) and the levels aren't compressed,
+// so a reader can skip a page without decompressing it.
+func (f *RequiredField) SetDataPageV2() {
+	f.dataPageV2 = true
+}
+
+// SetDataPageV2 makes the field write DATA_PAGE_V2 pages.
+func (f *OptionalField) SetDataPageV2() {
+	f.dataPageV2 = true
+}
+
+// pageV2 holds the sizes and counts of a DATA_PAGE_V2 page.
+type pageV2 struct {
+	count int
+	nulls int
+	rows  int
+
+	repLen int
+	defLen int
+
+	// dataLen and compressedLen are the size of the values
+	dataLen       int
+	compressedLen int
+}
+
+func (f *RequiredField) writePageV2(w io.Writer, meta *Metadata, vals []byte, count int, enc sch.Encoding, stats Stats) error {
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	l, cl, vals, err := compress(f.compression, buf, vals)
+	if err != nil {
+		return err
+	}
+
+	pg := pageV2{count: count, rows: count, dataLen: l, compressedLen: cl}
+	if err := meta.writePageHeaderV2(w, f.pth, pg, enc, f.compression, stats); err != nil {
+		return err
+	}
+
+	_, err = w.Write(vals)
+	return err
+}
+
+func (f *OptionalField) writePageV2(w io.Writer, meta *Metadata, vals []byte, count int, enc sch.Encoding, stats Stats) error {
+	var reps []byte
+	if f.repeated {
+		var err error
+		if reps, err = levelsV2(f.Reps, f.MaxLevels.Rep); err != nil {
+			return err
+		}
+	}
+
+	defs, err := levelsV2(f.Defs, f.MaxLevels.Def)
+	if err != nil {
+		return err
+	}
+
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	l, cl, vals, err := compress(f.compression, buf, vals)
+	if err != nil {
+		return err
+	}
+
+	pg := pageV2{
+		count:         count,
+		nulls:         len(f.Defs) - f.Values(),
+		rows:          f.Rows(),
+		repLen:        len(reps),
+		defLen:        len(defs),
+		dataLen:       l,
+		compressedLen: cl,
+	}
+
+	if err := meta.writePageHeaderV2(w, f.pth, pg, enc, f.compression, stats); err != nil {
+		return err
+	}
+
+	for _, b := range [][]byte{reps, defs, vals} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Metadata) writePageHeaderV2(w io.Writer, pth []string, pg pageV2, enc sch.Encoding, comp sch.CompressionCodec, stats Stats) error {
+	levels := pg.repLen + pg.defLen
+	compressed := comp != sch.CompressionCodec_UNCOMPRESSED
+	ph := &sch.PageHeader{
+		Type:                 sch.PageType_DATA_PAGE_V2,
+		UncompressedPageSize: int32(levels + pg.dataLen),
+		CompressedPageSize:   int32(levels + pg.compressedLen),
+		DataPageHeaderV2: &sch.DataPageHeaderV2{
+			NumValues:                  int32(pg.count),
+			NumNulls:                   int32(pg.nulls),
+			NumRows:                    int32(pg.rows),
+			Encoding:                   enc,
+			DefinitionLevelsByteLength: int32(pg.defLen),
+			RepetitionLevelsByteLength: int32(pg.repLen),
+			IsCompressed:               compressed,
+			Statistics: &sch.Statistics{
+				NullCount:     stats.NullCount(),
+				DistinctCount: stats.DistinctCount(),
+				MinValue:      stats.Min(),
+				MaxValue:      stats.Max(),
+			},
+		},
+	}
+
+	m.pageDocs = 0
+
+	buf, err := m.ts.Write(context.TODO(), ph)
+	if err != nil {
+		return err
+	}
+
+	m.addRows(pth, pg.rows)
+	m.addPage(pth, levels+pg.compressedLen+len(buf), pg.count, pg.rows, stats)
+	if err := m.updateRowGroup(pth, levels+pg.dataLen, levels+pg.compressedLen, len(buf), pg.count, enc, comp); err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// levelsV2 encodes the levels of a v2 page, which (unlike
+// the levels of a v1 page) aren't preceded by their length.
+func levelsV2(levels []uint8, max uint8) ([]byte, error) {
+	width, err := levelWidth(max)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]uint32, len(levels))
+	for i, l := range levels {
+		if l > max {
+			return nil, fmt.Errorf("level %d is greater than max level %d", l, max)
+		}
+		vals[i] = uint32(l)
+	}
+	return rle.EncodeUint32(vals, int(width)), nil
+}
+
+// readLevelsV2 decodes the n levels of a v2 page.
+func readLevelsV2(data []byte, max uint8, n int) ([]uint8, error) {
+	width, err := levelWidth(max)
+	if err != nil {
+		return nil, err
+	}
+
+	if width == 0 {
+		return make([]uint8, n), nil
+	}
+
+	vals, err := rle.DecodeUint32(data, int(width), n)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]uint8, n)
+	for i, v := range vals {
+		if v > uint32(max) {
+			return nil, fmt.Errorf("level %d is greater than max level %d", v, max)
+		}
+		out[i] = uint8(v)
+	}
+	return out, nil
+}
+
+// dataPage returns the number of values and the encoding of a data page
+// (v1 or v2) and, for a v2 page, the size of its repetition and definition
+// levels.
+func dataPage(ph *sch.PageHeader) (n int, enc sch.Encoding, repLen, defLen int) {
+	if v2 := ph.DataPageHeaderV2; v2 != nil {
+		return int(v2.NumValues), v2.Encoding, int(v2.RepetitionLevelsByteLength), int(v2.DefinitionLevelsByteLength)
+	}
+	return int(ph.DataPageHeader.NumValues), ph.DataPageHeader.Encoding, 0, 0
+}
+
+// pageDataV2 reads and decompresses the data of a v2 page.  Only the
+// values are compressed, the levels before them are copied as is.
+func pageDataV2(r io.Reader, ph *sch.PageHeader, pg Page, alloc Allocator) ([]byte, error) {
+	v2 := ph.DataPageHeaderV2
+	levels := int(v2.RepetitionLevelsByteLength) + int(v2.DefinitionLevelsByteLength)
+	if levels > int(ph.CompressedPageSize) || levels > int(ph.UncompressedPageSize) {
+		return nil, fmt.Errorf("page levels are %d bytes, the page is only %d bytes", levels, ph.CompressedPageSize)
+	}
+
+	if pg.Codec == sch.CompressionCodec_UNCOMPRESSED || !v2.GetIsCompressed() {
+		if ph.UncompressedPageSize != ph.CompressedPageSize {
+			return nil, fmt.Errorf("uncompressed page has compressed size %d and uncompressed size %d", ph.CompressedPageSize, ph.UncompressedPageSize)
+		}
+
+		data := alloc.Alloc(int(ph.UncompressedPageSize))
+		if _, err := io.ReadFull(r, data); err != nil {
+			alloc.Free(data)
+			return nil, err
+		}
+		return data, nil
+	}
+
+	compressed := alloc.Alloc(int(ph.CompressedPageSize))
+	defer alloc.Free(compressed)
+	if _, err := io.ReadFull(r, compressed); err != nil {
+		return nil, err
+	}
+
+	data := alloc.Alloc(int(ph.UncompressedPageSize))
+	copy(data, compressed[:levels])
+	if err := decompress(pg, compressed[levels:], data[levels:]); err != nil {
+		alloc.Free(data)
+		return nil, err
+	}
+	return data, nil
+}
@@ -4,6 +4,7 @@
 package parquet
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -22,53 +23,191 @@ type Field struct {
 	Types          []int
 	Type           FieldFunc
 	RepetitionType FieldFunc
+	// ConvertedType and LogicalType annotate Type so that
+	// readers know how to interpret it (for example, an INT32
+	// that holds unsigned values).  Either may be nil.
+	ConvertedType *sch.ConvertedType
+	LogicalType   *sch.LogicalType
+}
+
+// annotate sets the type information of the field on se.
+func (f Field) annotate(se *sch.SchemaElement) {
+	f.Type(se)
+	f.RepetitionType(se)
+	if f.ConvertedType != nil {
+		se.ConvertedType = f.ConvertedType
+	}
+	if f.LogicalType != nil {
+		se.LogicalType = f.LogicalType
+	}
+}
+
+// String returns the field's name, physical type, repetition type,
+// and annotation (if it has one), for example "age INT32 OPTIONAL" or
+// "born INT64 REQUIRED TIMESTAMP(MILLIS, utc)".
+func (f Field) String() string {
+	var se sch.SchemaElement
+	if f.Type != nil {
+		f.Type(&se)
+	}
+	if f.RepetitionType != nil {
+		f.RepetitionType(&se)
+	}
+
+	out := f.Name
+	if se.Type != nil {
+		out += " " + se.Type.String()
+	}
+	if se.RepetitionType != nil {
+		out += " " + se.RepetitionType.String()
+	}
+
+	ct := se.ConvertedType
+	if f.ConvertedType != nil {
+		ct = f.ConvertedType
+	}
+	lt := se.LogicalType
+	if f.LogicalType != nil {
+		lt = f.LogicalType
+	}
+
+	if a := annotation(lt, ct); a != "" {
+		out += " " + a
+	}
+	return out
+}
+
+// annotation renders a logical type (or, if there isn't
+// one that it knows of, a converted type).
+func annotation(lt *sch.LogicalType, ct *sch.ConvertedType) string {
+	switch {
+	case lt != nil && lt.TIMESTAMP != nil:
+		unit := "NANOS"
+		switch u := lt.TIMESTAMP.Unit; {
+		case u == nil:
+			unit = "UNKNOWN"
+		case u.IsSetMILLIS():
+			unit = "MILLIS"
+		case u.IsSetMICROS():
+			unit = "MICROS"
+		}
+
+		zone := "local"
+		if lt.TIMESTAMP.IsAdjustedToUTC {
+			zone = "utc"
+		}
+		return fmt.Sprintf("TIMESTAMP(%s, %s)", unit, zone)
+	case lt != nil && lt.INTEGER != nil:
+		sign := "unsigned"
+		if lt.INTEGER.IsSigned {
+			sign = "signed"
+		}
+		return fmt.Sprintf("INTEGER(%d, %s)", lt.INTEGER.BitWidth, sign)
+	case lt != nil && lt.STRING != nil:
+		return "STRING"
+	case lt != nil && lt.FLOAT16 != nil:
+		return "FLOAT16"
+	case ct != nil:
+		return ct.String()
+	}
+	return ""
 }
 
 // Page keeps track of metadata for each ColumnChunk
 type Page struct {
-	// N is the number of values in the ColumnChunk
-	N      int
-	Size   int
+	// N is the number of values in the ColumnChunk, which counts
+	// each null and each of a repeated column's values, so it is
+	// the number of levels (not rows) in the chunk.
+	N int
+	// Rows is the number of rows in the ColumnChunk (the number
+	// of rows of its row group).
+	Rows int64
+	// RowGroup is the index of the ColumnChunk's row group.
+	RowGroup int
+	Size     int
+	// Offset is where the column chunk's first page starts.
 	Offset int64
 	Codec  sch.CompressionCodec
+	// Type is the column's physical type.
+	Type sch.Type
+	// ConvertedType and LogicalType are the column's annotations
+	// in the file's schema.
+	ConvertedType *sch.ConvertedType
+	LogicalType   *sch.LogicalType
+	// MaxBytes is the size of the largest (decompressed) page
+	// that is read.  It is DefaultMaxPageBytes if it is 0.
+	MaxBytes int32
+	// Lenient reads a page of a snappy column chunk that was
+	// written with snappy's framing format, or that isn't valid
+	// snappy but is the size of the uncompressed page (as though it
+	// wasn't compressed).  See Metadata.SetLenient.
+	Lenient bool
+	// Scale is the scale of a DECIMAL column, and DecimalAsFloat
+	// reads the unscaled INT32 or INT64 values of a DECIMAL column
+	// into float fields divided by 10^Scale (see
+	// Metadata.SetDecimalAsFloat).
+	Scale          int32
+	DecimalAsFloat bool
+	// TypeLength is the size of each value of a
+	// FIXED_LEN_BYTE_ARRAY column.
+	TypeLength int32
+	// RepetitionTypes are the repetition types of every element
+	// of the column's path in the file's schema.  The definition
+	// and repetition levels of the column's pages are decoded
+	// with the levels they add up to.  If it is nil the levels
+	// of the field that reads the column are used.
+	RepetitionTypes RepetitionTypes
 }
 
+// DefaultMaxPageBytes is the size of the largest (decompressed) page
+// that is read unless Metadata.SetMaxPageBytes is called.  Pages are
+// usually much smaller, so it only keeps a page that claims to be huge
+// (a decompression bomb, for example) from being allocated.
+const DefaultMaxPageBytes int32 = 256 << 20
+
 type schema struct {
 	fields []Field
 	lookup map[string]sch.SchemaElement
+	// name is the name of the root of the schema
+	// ("root" if it is empty, see SetSchemaName)
+	name string
 }
 
 func (s schema) schema() (int64, []*sch.SchemaElement) {
+	name := s.name
+	if name == "" {
+		name = "root"
+	}
+
+	var children int32
 	out := make([]*sch.SchemaElement, 0, len(s.fields)+1)
 	out = append(out, &sch.SchemaElement{
-		Name: "root",
+		Name:        name,
+		NumChildren: &children,
 	})
 
-	var children int32
 	var z int32
 	m := map[string]*sch.SchemaElement{}
 	for _, f := range s.fields {
-		if len(f.Path) > 1 {
-			for i, name := range f.Path[:len(f.Path)-1] {
-				par, ok := m[name]
-				if !ok {
-					children++
-					parts := strings.Split(name, ".")
-					rt := sch.FieldRepetitionType(f.Types[i])
-					par = &sch.SchemaElement{
-						Name:           parts[len(parts)-1],
-						RepetitionType: &rt,
-						NumChildren:    &z,
-					}
-					out = append(out, par)
+		// groups are keyed by their full path so that groups with
+		// the same name under different parents stay separate and
+		// each group only counts its direct children.
+		parent := out[0]
+		for i := range f.Path[:len(f.Path)-1] {
+			key := strings.Join(f.Path[:i+1], ".")
+			par, ok := m[key]
+			if !ok {
+				rt := sch.FieldRepetitionType(f.Types[i])
+				par = &sch.SchemaElement{
+					Name:           f.Path[i],
+					RepetitionType: &rt,
+					NumChildren:    &z,
 				}
-				n := *par.NumChildren
-				n++
-				par.NumChildren = &n
-				m[name] = par
+				addChild(parent)
+				out = append(out, par)
+				m[key] = par
 			}
-		} else if len(f.Path) == 1 {
-			children++
+			parent = par
 		}
 
 		se := &sch.SchemaElement{
@@ -79,15 +218,19 @@ func (s schema) schema() (int64, []*sch.SchemaElement) {
 			FieldID:    &z,
 		}
 
-		f.Type(se)
-		f.RepetitionType(se)
+		f.annotate(se)
+		addChild(parent)
 		out = append(out, se)
 	}
 
-	out[0].NumChildren = &children
 	return int64(len(s.fields)), out
 }
 
+func addChild(se *sch.SchemaElement) {
+	n := se.GetNumChildren() + 1
+	se.NumChildren = &n
+}
+
 // Metadata keeps track of the things that need to
 // be kept track of in order to write the FileMetaData
 // at the end of the parquet file.
@@ -99,6 +242,35 @@ type Metadata struct {
 	rowGroupDocs int64
 	rowGroups    []RowGroup
 
+	// pageIndex makes Footer write the offset index and
+	// column index of each column chunk
+	pageIndex bool
+
+	// maxPageBytes is the MaxBytes of the pages from Pages
+	maxPageBytes int32
+
+	// lenient is the Lenient of the pages from Pages
+	lenient bool
+
+	// decimalAsFloat is the DecimalAsFloat of the pages from Pages
+	decimalAsFloat bool
+
+	// written is the number of bytes of pages (and padding, see
+	// Align) that have been written after the leading marker
+	written int64
+
+	// overrides replace the schema of columns in the
+	// footer (see SetSchemaOverride)
+	overrides map[string]Field
+
+	// sortingColumns are the columns that the rows of each
+	// row group are sorted by (see SetSortingColumns)
+	sortingColumns []*sch.SortingColumn
+
+	// footerProtocol is the thrift protocol
+	// of the footer (see SetFooterProtocol)
+	footerProtocol ThriftProtocol
+
 	metadata *sch.FileMetaData
 }
 
@@ -110,13 +282,24 @@ type Stats interface {
 	Max() []byte
 }
 
+// StatsMode is when a field computes the Stats of its pages.
+type StatsMode int
+
+const (
+	// StatsOnAdd updates the stats as each value is added,
+	// which is the default.
+	StatsOnAdd StatsMode = iota
+	// StatsOnWrite computes the stats in one pass over the
+	// page's values when the page is written, which makes
+	// adding a value cheaper.
+	StatsOnWrite
+)
+
 // New returns a Metadata struct and reads the first row group
 // into memory.
 func New(fields ...Field) *Metadata {
-	ts := thrift.NewTSerializer()
-	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
 	m := &Metadata{
-		ts:     ts,
+		ts:     newThriftSerializer(),
 		schema: schemaElements(fields),
 	}
 
@@ -124,15 +307,49 @@ func New(fields ...Field) *Metadata {
 	return m
 }
 
+// SetSchemaName sets the name of the root of the schema (the message
+// name) that is written in the footer, which is "root" by default.
+func (m *Metadata) SetSchemaName(name string) {
+	m.schema.name = name
+}
+
 // StartRowGroup is called when starting a new row group
 func (m *Metadata) StartRowGroup(fields ...Field) {
 	m.rowGroupDocs = 0
 	m.rowGroups = append(m.rowGroups, RowGroup{
-		fields:  schemaElements(fields),
-		columns: make(map[string]sch.ColumnChunk),
+		fields:       schemaElements(fields),
+		columns:      make(map[string]sch.ColumnChunk),
+		dictionaries: make(map[string]int64),
+		pages:        make(map[string]*pageIndex),
+		rows:         make(map[string]int64),
+		padding:      make(map[string]int64),
 	})
 }
 
+// EndRowGroup is called once each column chunk of the current row group
+// has been written.  It makes sure that every chunk has rows rows (the
+// pages of a chunk can come from different writers, so a writer that
+// is missing a value would leave the chunks misaligned) and sets the
+// row group's number of rows to rows.
+func (m *Metadata) EndRowGroup(rows int64) error {
+	i := len(m.rowGroups)
+	if i == 0 {
+		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
+	}
+
+	rg := m.rowGroups[i-1]
+	for _, f := range rg.fields.fields {
+		col := strings.Join(f.Path, ".")
+		if n := rg.rows[col]; n != rows {
+			return fmt.Errorf("the column chunk of %s in row group %d has %d rows but the row group has %d rows", col, i-1, n, rows)
+		}
+	}
+
+	rg.rowGroup.NumRows = rows
+	m.rowGroups[i-1] = rg
+	return nil
+}
+
 // NextDoc keeps track of how many documents have been
 // added to this parquet file.  The final value of m.docs
 // is used for the FileMetaData.NumRows
@@ -156,13 +373,19 @@ func (m *Metadata) RowGroups() []RowGroup {
 
 // WritePageHeader is called in order to finish writing to a column chunk.
 func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compressedLen, defCount, count int, defLen, repLen int64, comp sch.CompressionCodec, stats Stats) error {
+	return m.writePageHeader(w, pth, dataLen, compressedLen, count, count, sch.Encoding_PLAIN, comp, stats)
+}
+
+// writePageHeader writes the header of a data page with count values
+// (including nulls) of rows rows.
+func (m *Metadata) writePageHeader(w io.Writer, pth []string, dataLen, compressedLen, count, rows int, enc sch.Encoding, comp sch.CompressionCodec, stats Stats) error {
 	ph := &sch.PageHeader{
 		Type:                 sch.PageType_DATA_PAGE,
 		UncompressedPageSize: int32(dataLen),
 		CompressedPageSize:   int32(compressedLen),
 		DataPageHeader: &sch.DataPageHeader{
 			NumValues:               int32(count),
-			Encoding:                sch.Encoding_PLAIN,
+			Encoding:                enc,
 			DefinitionLevelEncoding: sch.Encoding_RLE,
 			RepetitionLevelEncoding: sch.Encoding_RLE,
 			Statistics: &sch.Statistics{
@@ -181,15 +404,55 @@ func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compresse
 		return err
 	}
 
-	if err := m.updateRowGroup(pth, dataLen, compressedLen, len(buf), count, comp); err != nil {
+	m.addRows(pth, rows)
+	m.addPage(pth, compressedLen+len(buf), count, rows, stats)
+	if err := m.updateRowGroup(pth, dataLen, compressedLen, len(buf), count, enc, comp); err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// writeDictionaryPageHeader writes the header of a column chunk's
+// dictionary page, which must come before any of its data pages.
+func (m *Metadata) writeDictionaryPageHeader(w io.Writer, pth []string, dataLen, compressedLen, count int, sorted bool, comp sch.CompressionCodec) error {
+	ph := &sch.PageHeader{
+		Type:                 sch.PageType_DICTIONARY_PAGE,
+		UncompressedPageSize: int32(dataLen),
+		CompressedPageSize:   int32(compressedLen),
+		DictionaryPageHeader: &sch.DictionaryPageHeader{
+			NumValues: int32(count),
+			Encoding:  sch.Encoding_PLAIN,
+			IsSorted:  &sorted,
+		},
+	}
+
+	buf, err := m.ts.Write(context.TODO(), ph)
+	if err != nil {
+		return err
+	}
+
+	if err := m.updateRowGroup(pth, dataLen, compressedLen, len(buf), 0, sch.Encoding_PLAIN, comp); err != nil {
 		return err
 	}
 
+	rg := m.rowGroups[len(m.rowGroups)-1]
+	rg.dictionaries[strings.Join(pth, ".")] = int64(compressedLen + len(buf))
+
 	_, err = w.Write(buf)
 	return err
 }
 
-func (m *Metadata) updateRowGroup(pth []string, dataLen, compressedLen, headerLen, count int, comp sch.CompressionCodec) error {
+// addRows adds the rows of a data page to its column chunk.
+func (m *Metadata) addRows(pth []string, rows int) {
+	if len(m.rowGroups) == 0 {
+		return
+	}
+	m.rowGroups[len(m.rowGroups)-1].rows[strings.Join(pth, ".")] += int64(rows)
+}
+
+func (m *Metadata) updateRowGroup(pth []string, dataLen, compressedLen, headerLen, count int, enc sch.Encoding, comp sch.CompressionCodec) error {
 	i := len(m.rowGroups)
 	if i == 0 {
 		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
@@ -198,11 +461,43 @@ func (m *Metadata) updateRowGroup(pth []string, dataLen, compressedLen, headerLe
 	rg := m.rowGroups[i-1]
 
 	rg.rowGroup.NumRows = m.rowGroupDocs
-	err := rg.updateColumnChunk(pth, dataLen+headerLen, compressedLen+headerLen, count, m.schema, comp)
+	err := rg.updateColumnChunk(pth, dataLen+headerLen, compressedLen+headerLen, count, m.schema, enc, comp)
 	m.rowGroups[i-1] = rg
+	if err == nil {
+		m.written += int64(compressedLen + headerLen)
+	}
 	return err
 }
 
+// Align writes zeros to w so that the column chunk of col (the
+// column's path joined by dots), which must be written next, starts
+// at a multiple of n bytes.  The padding is left out of the column
+// chunk's size and skipped over by its offset in the footer.
+func (m *Metadata) Align(w io.Writer, col string, n int) error {
+	i := len(m.rowGroups)
+	if i == 0 {
+		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
+	}
+
+	if n <= 1 {
+		return nil
+	}
+
+	pos := int64(len(magic)) + m.written
+	pad := (int64(n) - pos%int64(n)) % int64(n)
+	if pad == 0 {
+		return nil
+	}
+
+	if _, err := w.Write(make([]byte, pad)); err != nil {
+		return err
+	}
+
+	m.written += pad
+	m.rowGroups[i-1].padding[col] += pad
+	return nil
+}
+
 func columnType(col string, fields schema) (sch.Type, error) {
 	f, ok := fields.lookup[col]
 	if !ok {
@@ -217,9 +512,76 @@ func (m *Metadata) Rows() int64 {
 	return m.metadata.NumRows
 }
 
-// Footer writes the FileMetaData at the end of the file.
+// magic is the marker that every parquet file starts and ends with.
+var magic = []byte("PAR1")
+
+// WriteHeader writes the marker that a parquet file starts with.
+func WriteHeader(w io.Writer) error {
+	_, err := w.Write(magic)
+	return err
+}
+
+// WriteTrailer writes the end of a parquet file: the footer
+// followed by the same marker that the file starts with.  They are
+// built in memory and written with a single call to w.Write, so if the
+// footer can't be built nothing is written and the file is clearly
+// incomplete (rather than ending with part of a footer).
+func (m *Metadata) WriteTrailer(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := m.Footer(&buf); err != nil {
+		return err
+	}
+	buf.Write(magic)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Footer writes the FileMetaData at the end of the file (but
+// not the trailing marker, see WriteTrailer).
 func (m *Metadata) Footer(w io.Writer) error {
-	_, s := m.schema.schema()
+	fmd, pos := m.fileMetaData()
+	if m.pageIndex {
+		if err := m.writePageIndexes(w, fmd, pos); err != nil {
+			return err
+		}
+	}
+
+	ts := m.ts
+	if m.footerProtocol == BinaryProtocol {
+		ts = newBinarySerializer()
+	}
+
+	buf, err := ts.Write(context.TODO(), fmd)
+	if err != nil {
+		return err
+	}
+
+	n, err := w.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, uint32(n))
+}
+
+// SetFooterProtocol sets the thrift protocol that Footer writes the
+// FileMetaData with.  It is CompactProtocol by default, which the spec
+// requires.  The page headers, page indexes, and sync markers are
+// always written with the compact protocol.
+func (m *Metadata) SetFooterProtocol(proto ThriftProtocol) error {
+	switch proto {
+	case CompactProtocol, BinaryProtocol:
+		m.footerProtocol = proto
+		return nil
+	default:
+		return fmt.Errorf("invalid footer protocol %s", proto)
+	}
+}
+
+// fileMetaData returns the FileMetaData of the row groups that have
+// been written and the offset of the end of the last one.
+func (m *Metadata) fileMetaData() (*sch.FileMetaData, int64) {
+	_, s := m.overridden().schema()
 	fmd := &sch.FileMetaData{
 		Version:   1,
 		Schema:    s,
@@ -227,7 +589,7 @@ func (m *Metadata) Footer(w io.Writer) error {
 		RowGroups: make([]*sch.RowGroup, 0, len(m.rowGroups)),
 	}
 
-	pos := int64(4)
+	pos := int64(len(magic))
 	for _, mrg := range m.rowGroups {
 		rg := mrg.rowGroup
 		if rg.NumRows == 0 {
@@ -235,6 +597,7 @@ func (m *Metadata) Footer(w io.Writer) error {
 		}
 
 		for _, col := range mrg.fields.fields {
+			pos += mrg.padding[strings.Join(col.Path, ".")]
 			ch, ok := mrg.columns[strings.Join(col.Path, ".")]
 			if !ok {
 				continue
@@ -242,25 +605,23 @@ func (m *Metadata) Footer(w io.Writer) error {
 
 			ch.FileOffset = pos
 			ch.MetaData.DataPageOffset = pos
-			rg.TotalByteSize += ch.MetaData.TotalCompressedSize
+			if size, ok := mrg.dictionaries[strings.Join(col.Path, ".")]; ok {
+				offset := pos
+				ch.MetaData.DictionaryPageOffset = &offset
+				ch.MetaData.DataPageOffset = pos + size
+			}
+			m.overridePath(strings.Join(col.Path, "."), &ch)
+			rg.TotalByteSize += ch.MetaData.TotalUncompressedSize
 			rg.Columns = append(rg.Columns, &ch)
 			pos += ch.MetaData.TotalCompressedSize
 		}
+		pos += mrg.sync
+		rg.SortingColumns = m.sortingColumns
 
+		fmd.KeyValueMetadata = append(fmd.KeyValueMetadata, rowGroupMetadata(len(fmd.RowGroups), mrg.meta)...)
 		fmd.RowGroups = append(fmd.RowGroups, &rg)
 	}
-
-	buf, err := m.ts.Write(context.TODO(), fmd)
-	if err != nil {
-		return err
-	}
-
-	n, err := w.Write(buf)
-	if err != nil {
-		return err
-	}
-
-	return binary.Write(w, binary.LittleEndian, uint32(n))
+	return fmd, pos
 }
 
 // RowGroup wraps schema.RowGroup and adds accounting functions
@@ -272,6 +633,30 @@ type RowGroup struct {
 	columns  map[string]sch.ColumnChunk
 	child    *RowGroup
 
+	// dictionaries is the size of each column
+	// chunk's dictionary page (if it has one)
+	dictionaries map[string]int64
+
+	// pages are the pages of each column chunk (if page
+	// indexes are written)
+	pages map[string]*pageIndex
+
+	// meta is the row group's key/value metadata (see
+	// SetRowGroupMetadata)
+	meta map[string]string
+
+	// rows is the number of rows in each column
+	// chunk's data pages (see EndRowGroup)
+	rows map[string]int64
+
+	// padding is the number of zeros that are written
+	// before each column chunk (see Metadata.Align)
+	padding map[string]int64
+
+	// sync is the size of the sync marker that is
+	// written after the row group (see WriteSyncMarker)
+	sync int64
+
 	Rows int64
 }
 
@@ -280,7 +665,7 @@ func (r *RowGroup) Columns() []*sch.ColumnChunk {
 	return r.rowGroup.Columns
 }
 
-func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count int, fields schema, comp sch.CompressionCodec) error {
+func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count int, fields schema, enc sch.Encoding, comp sch.CompressionCodec) error {
 	col := strings.Join(pth, ".")
 
 	ch, ok := r.columns[col]
@@ -300,6 +685,10 @@ func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count
 		}
 	}
 
+	if !hasEncoding(ch.MetaData.Encodings, enc) {
+		ch.MetaData.Encodings = append(ch.MetaData.Encodings, enc)
+	}
+
 	ch.MetaData.NumValues += int64(count)
 	ch.MetaData.TotalUncompressedSize += int64(dataLen)
 	ch.MetaData.TotalCompressedSize += int64(compressedLen)
@@ -307,6 +696,15 @@ func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count
 	return nil
 }
 
+func hasEncoding(encs []sch.Encoding, enc sch.Encoding) bool {
+	for _, e := range encs {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
+
 func schemaElements(fields []Field) schema {
 	m := make(map[string]sch.SchemaElement)
 	for _, f := range fields {
@@ -319,8 +717,7 @@ func schemaElements(fields []Field) schema {
 			FieldID:    &z,
 		}
 
-		f.Type(&se)
-		f.RepetitionType(&se)
+		f.annotate(&se)
 		m[strings.Join(f.Path, ".")] = se
 	}
 
@@ -333,42 +730,227 @@ func (m *Metadata) Pages() (map[string][]Page, error) {
 		return nil, nil
 	}
 	out := map[string][]Page{}
-	for _, rg := range m.metadata.RowGroups {
+	leaves := m.leaves()
+	for i, rg := range m.metadata.RowGroups {
 		for _, ch := range rg.Columns {
 			pth := ch.MetaData.PathInSchema
-			_, ok := m.schema.lookup[strings.Join(pth, ".")]
-			if !ok {
-				return nil, fmt.Errorf("could not find schema for %v", pth)
-			}
-
+			k := strings.Join(pth, ".")
 			pg := Page{
-				N:      int(ch.MetaData.NumValues),
-				Offset: ch.FileOffset,
-				Size:   int(ch.MetaData.TotalCompressedSize),
-				Codec:  ch.MetaData.Codec,
+				N:        int(ch.MetaData.NumValues),
+				Rows:     rg.NumRows,
+				RowGroup: i,
+				Offset:   chunkOffset(ch),
+				Size:     int(chunkSize(ch)),
+				Codec:    ch.MetaData.Codec,
+				Type:     ch.MetaData.Type,
+				MaxBytes: m.maxPageBytes,
+				Lenient:  m.lenient,
+
+				DecimalAsFloat: m.decimalAsFloat,
+			}
+			if leaf, ok := leaves[k]; ok {
+				pg.ConvertedType = leaf.ConvertedType
+				pg.LogicalType = leaf.LogicalType
+				pg.Scale = leaf.Scale
+				pg.TypeLength = leaf.TypeLength
+				pg.RepetitionTypes = leaf.types
 			}
-			k := strings.Join(pth, ".")
 			out[k] = append(out[k], pg)
 		}
 	}
 	return out, nil
 }
 
+// SetMaxPageBytes sets the size of the largest (decompressed) page
+// that can be read from the pages returned by Pages.  A page that is
+// larger returns an error before any memory is allocated for it.
+func (m *Metadata) SetMaxPageBytes(n int32) {
+	m.maxPageBytes = n
+}
+
+// SetLenient makes the pages returned by Pages read a page that
+// claims to be snappy compressed but isn't (some writers leave a
+// page uncompressed without saying so) as though it wasn't
+// compressed, as long as it is the size of the uncompressed page.
+// It also reads a page that was compressed with snappy's framing
+// format (the format of snappy.NewWriter) instead of the block format
+// that parquet uses.
+func (m *Metadata) SetLenient(lenient bool) {
+	m.lenient = lenient
+}
+
+// SetDecimalAsFloat makes the pages returned by Pages read the
+// unscaled INT32 or INT64 values of a DECIMAL column into a float
+// field as approximate values (the unscaled value divided by
+// 10^scale) instead of reading their bytes as floats.
+func (m *Metadata) SetDecimalAsFloat(decimalAsFloat bool) {
+	m.decimalAsFloat = decimalAsFloat
+}
+
+// leaf is a column of the file's schema along with the
+// repetition types of every element of its path.
+type leaf struct {
+	*SchemaNode
+	types RepetitionTypes
+}
+
+// leaves maps the columns of the file's schema to their
+// SchemaNode.  It is empty if the schema isn't a valid tree.
+func (m *Metadata) leaves() map[string]leaf {
+	out := map[string]leaf{}
+	root, err := SchemaTree(m.metadata.Schema)
+	if err != nil {
+		return out
+	}
+
+	var walk func(*SchemaNode, RepetitionTypes)
+	walk = func(n *SchemaNode, types RepetitionTypes) {
+		if n.Leaf() {
+			out[strings.Join(n.Path, ".")] = leaf{SchemaNode: n, types: types}
+		}
+		for _, ch := range n.Children {
+			rt := RepetitionType(ch.RepetitionType)
+			walk(ch, append(append(RepetitionTypes{}, types...), rt))
+		}
+	}
+	walk(root, nil)
+	return out
+}
+
+// ColumnChunkLocation returns the offset and length (in bytes) of
+// the column chunk of col in row group rg.  col is the column's
+// path joined by dots.  The length doesn't include a bloom filter
+// (see chunkSize).
+func (m *Metadata) ColumnChunkLocation(rg int, col string) (int64, int64, error) {
+	ch, err := m.columnChunk(rg, col)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return chunkOffset(ch), chunkSize(ch), nil
+}
+
+// ColumnCompressedSize returns the size (in bytes) of the pages of
+// col's column chunks in every row group, which is how much of the file
+// reading the column reads.  col is the column's path joined by dots.
+// A row group that doesn't have the column (the row groups of a file
+// don't all have to have the same columns) adds nothing.
+func (m *Metadata) ColumnCompressedSize(col string) (int64, error) {
+	if m.metadata == nil {
+		return 0, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	if _, ok := m.leaves()[col]; !ok {
+		return 0, &UnknownColumnError{Column: col}
+	}
+
+	var out int64
+	for _, rg := range m.metadata.RowGroups {
+		for _, ch := range rg.Columns {
+			if strings.Join(ch.MetaData.PathInSchema, ".") == col {
+				out += chunkSize(ch)
+			}
+		}
+	}
+	return out, nil
+}
+
+// chunkOffset returns the offset of the first page of a column
+// chunk, which is its dictionary page if it has one.
+func chunkOffset(ch *sch.ColumnChunk) int64 {
+	offset := ch.MetaData.DataPageOffset
+	if ch.MetaData.DictionaryPageOffset != nil && *ch.MetaData.DictionaryPageOffset < offset {
+		offset = *ch.MetaData.DictionaryPageOffset
+	}
+	return offset
+}
+
+// chunkSize returns the size of the pages of the column chunk ch.  A
+// bloom filter is never part of the pages, but some writers count the
+// chunk's bloom filter in its size when it follows the pages, so the
+// pages end where the bloom filter starts (and its bytes aren't read
+// as a page).  A bloom filter anywhere else is ignored.
+func chunkSize(ch *sch.ColumnChunk) int64 {
+	offset, size := chunkOffset(ch), ch.MetaData.TotalCompressedSize
+	if b := ch.MetaData.BloomFilterOffset; b != nil && *b > offset && *b < offset+size {
+		return *b - offset
+	}
+	return size
+}
+
+// columnChunk returns the column chunk col of row group rg.
+func (m *Metadata) columnChunk(rg int, col string) (*sch.ColumnChunk, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	if rg < 0 || rg >= len(m.metadata.RowGroups) {
+		return nil, fmt.Errorf("row group %d out of range, there are %d row groups", rg, len(m.metadata.RowGroups))
+	}
+
+	for _, ch := range m.metadata.RowGroups[rg].Columns {
+		if strings.Join(ch.MetaData.PathInSchema, ".") == col {
+			return ch, nil
+		}
+	}
+	return nil, &UnknownColumnError{Column: col}
+}
+
 // ReadMetaData reads the FileMetaData from the end of a parquet file
 func ReadMetaData(r io.ReadSeeker) (*sch.FileMetaData, error) {
-	p := thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: r})
+	if err := checkHeader(r); err != nil {
+		return nil, err
+	}
+
 	size, err := getMetaDataSize(r)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = r.Seek(-int64(size+8), io.SeekEnd)
+	start, err := r.Seek(-int64(size+8), io.SeekEnd)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid footer size %d: %s", size, err)
+	}
+
+	if start < int64(len(magic)) {
+		return nil, fmt.Errorf("invalid footer size %d: larger than the file", size)
+	}
+
+	// the whole footer is read up front (a reader doesn't have to
+	// fill p on each call to Read, and a large footer with thousands
+	// of row groups would otherwise mean thousands of tiny reads)
+	footer := make([]byte, size)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, fmt.Errorf("unable to read the %d byte footer: %s", size, err)
 	}
 
 	m := sch.NewFileMetaData()
-	return m, m.Read(p)
+	if err := m.Read(newThriftReader(bytes.NewReader(footer), int64(size))); err != nil {
+		return nil, err
+	}
+	return m, checkMetaData(m)
+}
+
+// checkMetaData makes sure that the parts of the footer that
+// are optional in the thrift definition, but required in order
+// to read the file, are present.
+func checkMetaData(m *sch.FileMetaData) error {
+	for i, rg := range m.RowGroups {
+		if rg.NumRows < 0 {
+			return fmt.Errorf("row group %d has %d rows", i, rg.NumRows)
+		}
+
+		for _, ch := range rg.Columns {
+			if ch.MetaData == nil {
+				return fmt.Errorf("row group %d has a column chunk without metadata", i)
+			}
+
+			if ch.MetaData.NumValues < 0 || ch.MetaData.TotalCompressedSize < 0 {
+				return fmt.Errorf("column %s has %d values and a size of %d", strings.Join(ch.MetaData.PathInSchema, "."), ch.MetaData.NumValues, ch.MetaData.TotalCompressedSize)
+			}
+		}
+	}
+	return nil
 }
 
 // ReadFooter reads the parquet metadata
@@ -378,12 +960,273 @@ func (m *Metadata) ReadFooter(r io.ReadSeeker) error {
 	return err
 }
 
+// SchemaTree returns the hierarchical schema of the parquet file
+// that was read by ReadFooter.
+func (m *Metadata) SchemaTree() (*SchemaNode, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+	return SchemaTree(m.metadata.Schema)
+}
+
+// SchemaFields returns the columns of the schema of the file that was
+// read by ReadFooter as Fields, so that New writes them with the same
+// types and annotations (such as a STRING column's UTF8 converted type
+// and STRING logical type) when a file is copied or merged.  The
+// groups of the schema are only kept as the Paths and Types of their
+// columns, so a group's annotations (such as LIST) aren't kept.
+func (m *Metadata) SchemaFields() ([]Field, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	elems := m.metadata.Schema
+	if _, err := SchemaTree(elems); err != nil {
+		return nil, err
+	}
+
+	var out []Field
+	i := 1
+	var walk func(pth []string, types []int, n int) error
+	walk = func(pth []string, types []int, n int) error {
+		for j := 0; j < n; j++ {
+			se := elems[i]
+			i++
+
+			rt := se.GetRepetitionType()
+			if rt < 0 || int(rt) >= len(fieldFuncs) {
+				return fmt.Errorf("schema element %s has an invalid repetition type %d", se.Name, rt)
+			}
+
+			p := append(append([]string{}, pth...), se.Name)
+			t := append(append([]int{}, types...), int(rt))
+			if se.Type == nil {
+				if err := walk(p, t, int(se.GetNumChildren())); err != nil {
+					return err
+				}
+				continue
+			}
+			out = append(out, schemaField(se, p, t))
+		}
+		return nil
+	}
+	return out, walk(nil, nil, int(elems[0].GetNumChildren()))
+}
+
+// schemaField returns the Field of the column se, which
+// sets the same type (and type length, etc.) as se.
+func schemaField(se *sch.SchemaElement, pth []string, types []int) Field {
+	return Field{
+		Name:  strings.Join(pth, "."),
+		Path:  pth,
+		Types: types,
+		Type: func(out *sch.SchemaElement) {
+			out.Type = se.Type
+			if se.TypeLength != nil {
+				out.TypeLength = se.TypeLength
+			}
+			if se.Scale != nil {
+				out.Scale = se.Scale
+			}
+			if se.Precision != nil {
+				out.Precision = se.Precision
+			}
+			if se.FieldID != nil {
+				out.FieldID = se.FieldID
+			}
+		},
+		RepetitionType: fieldFuncs[se.GetRepetitionType()],
+		ConvertedType:  se.ConvertedType,
+		LogicalType:    se.LogicalType,
+	}
+}
+
+// SchemaNode is a single node of a parquet schema.  Group nodes
+// have Children and a nil Type, leaf nodes (columns) have a Type
+// and no Children.
+type SchemaNode struct {
+	Name           string
+	Path           []string
+	Type           *sch.Type
+	ConvertedType  *sch.ConvertedType
+	LogicalType    *sch.LogicalType
+	RepetitionType sch.FieldRepetitionType
+	// Scale is the scale of a DECIMAL node.
+	Scale int32
+	// TypeLength is the size of each value of a
+	// FIXED_LEN_BYTE_ARRAY node.
+	TypeLength int32
+	// MaxDef and MaxRep are the largest definition and
+	// repetition levels of the node.
+	MaxDef   uint8
+	MaxRep   uint8
+	Children []*SchemaNode
+}
+
+// Leaf is true if the node is a column.
+func (n *SchemaNode) Leaf() bool {
+	return n.Type != nil
+}
+
+// SchemaTree turns the flattened (depth first) list of SchemaElements
+// found in the FileMetaData into a tree.  The first element is the
+// root of the schema.
+func SchemaTree(elems []*sch.SchemaElement) (*SchemaNode, error) {
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("empty schema")
+	}
+
+	root := &SchemaNode{
+		Name:           elems[0].Name,
+		RepetitionType: sch.FieldRepetitionType_REQUIRED,
+	}
+
+	n, err := schemaChildren(root, elems[0], elems[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	if n != len(elems)-1 {
+		return nil, fmt.Errorf("schema has %d elements but the root only accounts for %d", len(elems)-1, n)
+	}
+	return root, nil
+}
+
+// schemaChildren adds the children of se to parent and returns the
+// number of elements (including grandchildren) that were consumed.
+func schemaChildren(parent *SchemaNode, se *sch.SchemaElement, elems []*sch.SchemaElement) (int, error) {
+	var i int
+	for j := 0; j < int(se.GetNumChildren()); j++ {
+		if i >= len(elems) {
+			return 0, fmt.Errorf("schema element %s is missing children", se.Name)
+		}
+
+		ch := elems[i]
+		i++
+
+		node := &SchemaNode{
+			Name:           ch.Name,
+			Path:           append(append([]string{}, parent.Path...), ch.Name),
+			Type:           ch.Type,
+			ConvertedType:  ch.ConvertedType,
+			LogicalType:    ch.LogicalType,
+			RepetitionType: ch.GetRepetitionType(),
+			Scale:          ch.GetScale(),
+			TypeLength:     ch.GetTypeLength(),
+			MaxDef:         parent.MaxDef,
+			MaxRep:         parent.MaxRep,
+		}
+
+		switch node.RepetitionType {
+		case sch.FieldRepetitionType_OPTIONAL:
+			node.MaxDef++
+		case sch.FieldRepetitionType_REPEATED:
+			node.MaxDef++
+			node.MaxRep++
+		}
+
+		n, err := schemaChildren(node, ch, elems[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n
+		parent.Children = append(parent.Children, node)
+	}
+	return i, nil
+}
+
 // PageHeader reads the page header from a column page
 func PageHeader(r io.Reader) (*sch.PageHeader, error) {
-	p := thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: r})
+	return pageHeader(r, -1)
+}
+
+// pageHeader reads a page header that is no larger than n
+// bytes (n < 0 means there is no limit).
+func pageHeader(r io.Reader, n int64) (*sch.PageHeader, error) {
+	pg, err := anyPageHeader(r, n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case pg.DataPageHeader != nil:
+		if pg.DataPageHeader.NumValues < 0 {
+			return nil, fmt.Errorf("invalid number of values in page: %d", pg.DataPageHeader.NumValues)
+		}
+	case pg.DataPageHeaderV2 != nil:
+		v2 := pg.DataPageHeaderV2
+		if v2.NumValues < 0 {
+			return nil, fmt.Errorf("invalid number of values in page: %d", v2.NumValues)
+		}
+		if v2.DefinitionLevelsByteLength < 0 || v2.RepetitionLevelsByteLength < 0 {
+			return nil, fmt.Errorf("invalid level sizes in page: %d and %d", v2.RepetitionLevelsByteLength, v2.DefinitionLevelsByteLength)
+		}
+	case pg.DictionaryPageHeader != nil:
+		if pg.DictionaryPageHeader.NumValues < 0 {
+			return nil, fmt.Errorf("invalid number of values in dictionary page: %d", pg.DictionaryPageHeader.NumValues)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported page type %s", pg.Type)
+	}
+	return pg, nil
+}
+
+// anyPageHeader is like pageHeader, but the page doesn't have
+// to be a data page.
+func anyPageHeader(r io.Reader, n int64) (*sch.PageHeader, error) {
 	pg := &sch.PageHeader{}
-	err := pg.Read(p)
-	return pg, err
+	if err := pg.Read(newThriftReader(r, n)); err != nil {
+		return nil, err
+	}
+
+	if pg.CompressedPageSize < 0 || pg.UncompressedPageSize < 0 {
+		return nil, fmt.Errorf("invalid page size, compressed: %d, uncompressed: %d", pg.CompressedPageSize, pg.UncompressedPageSize)
+	}
+	return pg, nil
+}
+
+// ForEachPage calls fn with the header of each page (including
+// dictionary pages) of col in every row group, without reading the
+// pages' data.  col is the column's path joined by dots.  It stops
+// and returns the error if fn returns one.
+func (m *Metadata) ForEachPage(r io.ReadSeeker, col string, fn func(sch.PageHeader) error) error {
+	if m.metadata == nil {
+		return fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	for i := range m.metadata.RowGroups {
+		offset, length, err := m.ColumnChunkLocation(i, col)
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to offset %d, err: %s", offset, err)
+		}
+
+		for nRead := int64(0); nRead < length; {
+			rc := &readCounter{r: r}
+			ph, err := anyPageHeader(rc, length-nRead)
+			if err != nil {
+				return fmt.Errorf("unable to read page header: %s", err)
+			}
+			nRead += rc.n
+
+			if int64(ph.CompressedPageSize) > length-nRead {
+				return fmt.Errorf("page size %d is larger than the %d bytes left in the column chunk", ph.CompressedPageSize, length-nRead)
+			}
+
+			if err := fn(*ph); err != nil {
+				return err
+			}
+
+			if _, err := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent); err != nil {
+				return fmt.Errorf("unable to seek to next page: %s", err)
+			}
+			nRead += int64(ph.CompressedPageSize)
+		}
+	}
+	return nil
 }
 
 // PageHeaders reads all the page headers without reading the actual
@@ -392,7 +1235,11 @@ func PageHeaders(footer *sch.FileMetaData, r io.ReadSeeker) ([]sch.PageHeader, e
 	var pageHeaders []sch.PageHeader
 	for _, rg := range footer.RowGroups {
 		for _, col := range rg.Columns {
-			h, err := PageHeadersAtOffset(r, col.MetaData.DataPageOffset, col.MetaData.NumValues)
+			offset := col.MetaData.DataPageOffset
+			if col.MetaData.DictionaryPageOffset != nil && *col.MetaData.DictionaryPageOffset < offset {
+				offset = *col.MetaData.DictionaryPageOffset
+			}
+			h, err := PageHeadersAtOffset(r, offset, col.MetaData.NumValues)
 			if err != nil {
 				return nil, err
 			}
@@ -432,7 +1279,12 @@ func PageHeadersAtOffset(r io.ReadSeeker, o, n int64) ([]sch.PageHeader, error)
 			return nil, fmt.Errorf("unable to seek to next page: %s", err)
 		}
 
-		nRead += int64(ph.DataPageHeader.NumValues)
+		if ph.DataPageHeader != nil {
+			nRead += int64(ph.DataPageHeader.NumValues)
+		}
+		if ph.DataPageHeaderV2 != nil {
+			nRead += int64(ph.DataPageHeaderV2.NumValues)
+		}
 	}
 	return out, nil
 }
@@ -464,7 +1316,7 @@ var fieldFuncs = []FieldFunc{RepetitionRequired, RepetitionOptional, RepetitionR
 func GetBools(r io.Reader, n int, pageSizes []int) ([]bool, error) {
 	var vals [8]bool
 	data, _ := ioutil.ReadAll(r)
-	out := make([]bool, 0, n)
+	out := make([]bool, 0, min(n, len(data)*8))
 	for _, nVals := range pageSizes {
 
 		if nVals == 0 {
@@ -476,6 +1328,10 @@ func GetBools(r io.Reader, n int, pageSizes []int) ([]bool, error) {
 			l++
 		}
 
+		if l > len(data) {
+			return nil, fmt.Errorf("not enough data for %d bools", nVals)
+		}
+
 		var i int
 		chunk := data[:l]
 		data = data[l:]
@@ -492,6 +1348,41 @@ func GetBools(r io.Reader, n int, pageSizes []int) ([]bool, error) {
 	return out, nil
 }
 
+// ByteBools returns vals as one byte (0 or 1) per value instead of
+// one bit, which isn't what the PLAIN encoding of a BOOLEAN is, so it
+// is only for readers that expect it (see GetByteBools).
+func ByteBools(vals []bool) []byte {
+	out := make([]byte, len(vals))
+	for i, v := range vals {
+		if v {
+			out[i] = 1
+		}
+	}
+	return out
+}
+
+// GetByteBools reads the bools that ByteBools wrote.  It returns an
+// error if a byte isn't 0 or 1 (a column that is bit packed, for
+// example).
+func GetByteBools(r io.Reader, n int, pageSizes []int) ([]bool, error) {
+	data, _ := ioutil.ReadAll(r)
+	out := make([]bool, 0, min(n, len(data)))
+	for _, nVals := range pageSizes {
+		if nVals > len(data) {
+			return nil, fmt.Errorf("not enough data for %d bools", nVals)
+		}
+
+		for _, b := range data[:nVals] {
+			if b > 1 {
+				return nil, fmt.Errorf("invalid bool byte %d, it must be 0 or 1", b)
+			}
+			out = append(out, b == 1)
+		}
+		data = data[nVals:]
+	}
+	return out, nil
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -513,12 +1404,36 @@ func unpackBools(data byte) [8]bool {
 	}
 }
 
+// checkHeader makes sure that r starts with the parquet marker.
+func checkHeader(r io.ReadSeeker) error {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	b := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return fmt.Errorf("not a parquet file, unable to read the header: %s", err)
+	}
+
+	if !bytes.Equal(b, magic) {
+		return fmt.Errorf("not a parquet file, it starts with %q instead of %q", b, magic)
+	}
+	return nil
+}
+
 func getMetaDataSize(r io.ReadSeeker) (int, error) {
 	_, err := r.Seek(-8, io.SeekEnd)
 	if err != nil {
 		return 0, err
 	}
 
-	var size uint32
-	return int(size), binary.Read(r, binary.LittleEndian, &size)
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+
+	if !bytes.Equal(b[4:], magic) {
+		return 0, fmt.Errorf("not a parquet file, it ends with %q instead of %q", b[4:], magic)
+	}
+	return int(binary.LittleEndian.Uint32(b[:4])), nil
 }
@@ -3,18 +3,27 @@ package parquet_test
 // Code generated by github.com/rclayton-godaddy/parquet.  DO NOT EDIT.
 
 import (
+	"bufio"
+	"container/heap"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rclayton-godaddy/parquet"
 	sch "github.com/rclayton-godaddy/parquet/schema"
 	"github.com/valyala/bytebufferpool"
 )
 
-var _ = math.MaxInt32 // to avoid unused import
+var _ = math.MaxInt32       // to avoid unused import
+var _ = time.Second         // to avoid unused import
+var _ = binary.LittleEndian // to avoid unused import
 
 type compression int
 
@@ -43,6 +52,90 @@ type ParquetWriter struct {
 	meta        *parquet.Metadata
 	w           io.Writer
 	compression compression
+
+	// dictionary (and sorted) make the string columns dictionary
+	// encoded.  dicts holds the current row group's dictionaries,
+	// which are shared by the row group's pages.
+	dictionary bool
+	sorted     bool
+	dicts      map[string]*parquet.Dictionary
+
+	// delta makes the integer columns DELTA_BINARY_PACKED
+	delta bool
+
+	// deltaLength holds the string columns that are
+	// DELTA_LENGTH_BYTE_ARRAY encoded
+	deltaLength map[string]bool
+
+	// byteBools holds the bool columns that are written
+	// with one byte per value (see ByteBools)
+	byteBools map[string]bool
+
+	// dataPageV2 makes the pages DATA_PAGE_V2
+	dataPageV2 bool
+
+	// truncate is the max length of the string
+	// columns' min and max statistics
+	truncate int
+
+	// statsMode is when the columns compute their statistics
+	statsMode parquet.StatsMode
+
+	// pageIndex writes the offset and column indexes
+	pageIndex bool
+
+	// footerProtocol is the thrift protocol
+	// of the footer (see FooterProtocol)
+	footerProtocol parquet.ThriftProtocol
+
+	// syncMarkers writes a sync marker after
+	// each row group (see SyncMarkers)
+	syncMarkers bool
+
+	// alignment pads the file so that each column
+	// chunk starts at a multiple of it (see PageAlignment)
+	alignment int
+
+	// schemaName is the name of the root of the
+	// footer's schema (see SchemaName)
+	schemaName string
+
+	// schemaOverride replaces the schema of columns
+	// in the footer (see SchemaOverride)
+	schemaOverride map[string]parquet.Field
+
+	// sortingColumns are the columns that the rows are
+	// sorted by in the footer (see SortingColumns)
+	sortingColumns []parquet.SortingColumn
+
+	// onAdd checks (and can change) each record
+	// before it is added (see OnAdd)
+	onAdd func(*Person) error
+
+	// rejected is the number of records that onAdd has rejected
+	// since the last call to Write (or WriteWithMeta), and
+	// rejectedErr is the error of the first of them
+	rejected    int
+	rejectedErr error
+
+	// single holds every row until Close, which writes
+	// them as one row group (see SingleRowGroup)
+	single bool
+
+	// interval is how often the rows that have been added are
+	// written as a row group (see FlushInterval).  mu is held by
+	// Add, Write, and the goroutine that writes the row groups,
+	// stop ends that goroutine (stopOnce makes sure that only one
+	// Close closes it), and flushErr is the error it got.
+	mu       sync.Mutex
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	flushErr error
+
+	// closed is true once Close has been called
+	closed bool
 }
 
 func Fields(compression compression) []Field {
@@ -69,7 +162,30 @@ func Fields(compression compression) []Field {
 		NewStringOptionalField(readFriendsName, writeFriendsName, []string{"friends", "name"}, []int{2, 0}, optionalFieldCompression(compression)),
 		NewInt32OptionalField(readFriendsAge, writeFriendsAge, []string{"friends", "age"}, []int{2, 1}, optionalFieldCompression(compression)),
 		NewBoolField(readSleepy, writeSleepy, []string{"Sleepy"}, fieldCompression(compression)),
+		NewTimeField(readBorn, writeBorn, []string{"born"}, parquet.Timestamp{Unit: parquet.Micros, AdjustedToUTC: true}, fieldCompression(compression)),
+		NewTimeOptionalField(readDied, writeDied, []string{"died"}, []int{1}, parquet.Timestamp{Unit: parquet.Millis, AdjustedToUTC: false}, optionalFieldCompression(compression)),
+	}
+}
+
+// fieldSchema is the schema of the Fields.  It never changes, so it is
+// only built once and is shared (and must not be modified) by every
+// reader and writer.
+var fieldSchema = newFieldSchema()
+
+func newFieldSchema() []parquet.Field {
+	ff := Fields(compressionUnknown)
+	schema := make([]parquet.Field, len(ff))
+	for i, f := range ff {
+		schema[i] = f.Schema()
 	}
+	return schema
+}
+
+// SchemaJSON returns a JSON document that describes the columns of
+// Person (their paths, physical types, nullability, and
+// annotations) for a data dictionary (see parquet.SchemaJSON).
+func SchemaJSON() string {
+	return parquet.SchemaJSON(fieldSchema)
 }
 
 func readID(x Person) int32 {
@@ -566,6 +682,37 @@ func writeSleepy(x *Person, vals []bool) {
 	x.Sleepy = vals[0]
 }
 
+func readBorn(x Person) time.Time {
+	return x.Born
+}
+
+func writeBorn(x *Person, vals []time.Time) {
+	x.Born = vals[0]
+}
+
+func readDied(x Person, vals []time.Time, defs, reps []uint8) ([]time.Time, []uint8, []uint8) {
+	switch {
+	case x.Died == nil:
+		defs = append(defs, 0)
+		return vals, defs, reps
+	default:
+		vals = append(vals, *x.Died)
+		defs = append(defs, 1)
+		return vals, defs, reps
+	}
+}
+
+func writeDied(x *Person, vals []time.Time, defs, reps []uint8) (int, int) {
+	def := defs[0]
+	switch def {
+	case 1:
+		x.Died = ptimeTime(vals[0])
+		return 1, 1
+	}
+
+	return 0, 1
+}
+
 func fieldCompression(c compression) func(*parquet.RequiredField) {
 	switch c {
 	case compressionUncompressed:
@@ -593,7 +740,17 @@ func optionalFieldCompression(c compression) func(*parquet.OptionalField) {
 }
 
 func NewParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*ParquetWriter, error) {
-	return newParquetWriter(w, append(opts, begin)...)
+	p, err := newParquetWriter(w, append(opts, begin)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.interval > 0 {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.flush()
+	}
+	return p, nil
 }
 
 func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*ParquetWriter, error) {
@@ -601,6 +758,7 @@ func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*Parquet
 		max:         1000,
 		w:           w,
 		compression: compressionSnappy,
+		schemaName:  "Person",
 	}
 
 	for _, opt := range opts {
@@ -609,679 +767,2823 @@ func newParquetWriter(w io.Writer, opts ...func(*ParquetWriter) error) (*Parquet
 		}
 	}
 
+	if p.single && p.interval > 0 {
+		return nil, fmt.Errorf("SingleRowGroup can't be used with FlushInterval, which writes a row group every %s", p.interval)
+	}
+
 	p.fields = Fields(p.compression)
+	p.setDictionaries()
+	p.setDelta()
+	p.setDeltaLength()
+	p.setByteBools()
+	p.setDataPageV2()
+	p.setStatsTruncateLength()
+	p.setStatsMode()
 	if p.meta == nil {
-		ff := Fields(p.compression)
-		schema := make([]parquet.Field, len(ff))
-		for i, f := range ff {
-			schema[i] = f.Schema()
+		p.meta = parquet.New(fieldSchema...)
+		p.meta.SetSchemaName(p.schemaName)
+	}
+
+	if p.schemaOverride != nil {
+		if err := p.meta.SetSchemaOverride(p.schemaOverride); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.sortingColumns) > 0 {
+		if err := p.meta.SetSortingColumns(p.sortingColumns...); err != nil {
+			return nil, err
 		}
-		p.meta = parquet.New(schema...)
 	}
 
+	if p.pageIndex {
+		p.meta.WritePageIndex()
+	}
+
+	if err := p.meta.SetFooterProtocol(p.footerProtocol); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
 // MaxPageSize is the maximum number of rows in each row groups' page.
+// It must be at least 1.
 func MaxPageSize(m int) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
+		if m < 1 {
+			return fmt.Errorf("invalid max page size %d, it must be at least 1", m)
+		}
 		p.max = m
 		return nil
 	}
 }
 
-var par1 = []byte("PAR1")
-
-func begin(p *ParquetWriter) error {
-	_, err := p.w.Write(par1)
-	return err
+// PageAlignment pads the file with zeros so that each column chunk
+// starts at a multiple of n bytes (4096 lines them up with the pages
+// of a memory mapped file, for example).  The footer has the chunks'
+// true offsets and sizes, so readers skip the padding.  n must be at
+// least 1 (1 doesn't pad anything).
+func PageAlignment(n int) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if n < 1 {
+			return fmt.Errorf("invalid page alignment %d, it must be at least 1", n)
+		}
+		p.alignment = n
+		return nil
+	}
 }
 
-func withMeta(m *parquet.Metadata) func(*ParquetWriter) error {
+// SchemaName sets the name of the root of the schema in the footer
+// (the message name), which some tools show or check.  It is the name
+// of the type (Person) by default.
+func SchemaName(name string) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
-		p.meta = m
+		if name == "" {
+			return fmt.Errorf("invalid schema name, it can't be empty")
+		}
+		p.schemaName = name
 		return nil
 	}
 }
 
-func Uncompressed(p *ParquetWriter) error {
-	p.compression = compressionUncompressed
-	return nil
+// SchemaOverride replaces the schema of the columns (keyed by their
+// dotted paths) in the footer with the fields of overrides, for a file
+// that has to match a schema that the generated one doesn't (an int64
+// that is annotated as a TIMESTAMP, or a column with another name, for
+// example).  The values are written the same way, so an override can't
+// change a column's physical type or repetition types.  The fields of
+// an override that are left empty are the column's own (see
+// parquet.Metadata.SetSchemaOverride).  A file with a renamed column
+// can't be read by the generated reader.
+func SchemaOverride(overrides map[string]parquet.Field) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.schemaOverride = overrides
+		return nil
+	}
 }
 
-func Snappy(p *ParquetWriter) error {
-	p.compression = compressionSnappy
-	return nil
+// SortingColumns records in the footer that the rows of each row group
+// are sorted by cols (see parquet.Metadata.SetSortingColumns), so that
+// readers can rely on the order.  It doesn't sort the rows, which must
+// be added in that order (see SortedWriter, which parquetgen generates
+// with -sorted).
+func SortingColumns(cols ...parquet.SortingColumn) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.sortingColumns = cols
+		return nil
+	}
 }
 
-func Gzip(p *ParquetWriter) error {
-	p.compression = compressionGzip
-	return nil
+// OnAdd calls fn with each record that is passed to Add before the
+// record is added, so that records can be checked (or changed, since
+// the record that fn changes is the one that is added) in one place.  A
+// record that fn returns an error for isn't added.  Add returns the
+// error, and so that it isn't lost if Add's error isn't checked, the
+// next call to Write, WriteWithMeta, or Close returns it (with the
+// number of records that were rejected) once the records that were
+// added have been written.  The rows that Commit adds aren't records, so fn isn't
+// called for them.
+func OnAdd(fn func(*Person) error) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.onAdd = fn
+		return nil
+	}
 }
 
-func withCompression(c compression) func(*ParquetWriter) error {
+// FlushInterval writes the rows that have been added as a row group
+// every d, so a slow stream of records doesn't sit in memory (and out
+// of the file) until enough of them have been added.  Nothing is written
+// if no rows have been added since the last row group.  The writing
+// happens in a goroutine that is stopped by Close, and Add and Write
+// are safe to call while it runs.  An error that the goroutine gets
+// is returned by the next call to Write or Close.
+func FlushInterval(d time.Duration) func(*ParquetWriter) error {
 	return func(p *ParquetWriter) error {
-		p.compression = c
+		if d <= 0 {
+			return fmt.Errorf("invalid flush interval %s, it must be greater than 0", d)
+		}
+		p.interval = d
 		return nil
 	}
 }
 
-func (p *ParquetWriter) Write() error {
-	for i, f := range p.fields {
-		if err := f.Write(p.w, p.meta); err != nil {
-			return err
-		}
+// SingleRowGroup writes every row as one row group when the writer is
+// closed, for readers that are slow with many small row groups (or to
+// make sure that a sorted column is sorted across the whole file).
+// Write doesn't write anything (WriteWithMeta only sets the row
+// group's metadata), so every row is held in memory until Close.  It
+// can't be used with FlushInterval.
+func SingleRowGroup(p *ParquetWriter) error {
+	p.single = true
+	return nil
+}
 
-		for child := p.child; child != nil; child = child.child {
-			if err := child.fields[i].Write(p.w, p.meta); err != nil {
-				return err
+func (p *ParquetWriter) flush() {
+	defer close(p.done)
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.mu.Lock()
+			if p.len > 0 && p.flushErr == nil {
+				p.flushErr = p.write()
 			}
+			p.mu.Unlock()
 		}
 	}
+}
 
-	p.fields = Fields(p.compression)
-	p.child = nil
-	p.len = 0
+func begin(p *ParquetWriter) error {
+	return parquet.WriteHeader(p.w)
+}
 
-	schema := make([]parquet.Field, len(p.fields))
-	for i, f := range p.fields {
-		schema[i] = f.Schema()
-	}
-	p.meta.StartRowGroup(schema...)
+// Dictionary dictionary encodes the string columns.  The distinct
+// values of each column chunk are written once, in the chunk's
+// dictionary page, and the data pages only hold indices into it.
+func Dictionary(p *ParquetWriter) error {
+	p.dictionary = true
 	return nil
 }
 
-func (p *ParquetWriter) Close() error {
-	if err := p.meta.Footer(p.w); err != nil {
-		return err
-	}
+// SortedDictionary is like Dictionary but the values in each
+// dictionary page are sorted, and the page is marked as sorted.
+func SortedDictionary(p *ParquetWriter) error {
+	p.dictionary = true
+	p.sorted = true
+	return nil
+}
 
-	_, err := p.w.Write(par1)
-	return err
+func withDictionaries(dicts map[string]*parquet.Dictionary, sorted bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.dictionary = true
+		p.sorted = sorted
+		p.dicts = dicts
+		return nil
+	}
 }
 
-func (p *ParquetWriter) Add(rec Person) {
-	if p.len == p.max {
-		if p.child == nil {
-			// an error can't happen here
-			p.child, _ = newParquetWriter(p.w, MaxPageSize(p.max), withMeta(p.meta), withCompression(p.compression))
-		}
+type dictionaryField interface {
+	SetDictionary(*parquet.Dictionary)
+}
 
-		p.child.Add(rec)
+// setDictionaries gives each string field the dictionary of its
+// column (every page of a column chunk shares one dictionary).
+func (p *ParquetWriter) setDictionaries() {
+	if !p.dictionary {
 		return
 	}
 
-	p.meta.NextDoc()
-	for _, f := range p.fields {
-		f.Add(rec)
+	if p.dicts == nil {
+		p.dicts = map[string]*parquet.Dictionary{}
 	}
 
-	p.len++
+	for _, f := range p.fields {
+		df, ok := f.(dictionaryField)
+		if !ok {
+			continue
+		}
+
+		d, ok := p.dicts[f.Name()]
+		if !ok {
+			d = parquet.NewDictionary(p.sorted)
+			p.dicts[f.Name()] = d
+		}
+		df.SetDictionary(d)
+	}
 }
 
-type Field interface {
-	Add(r Person)
-	Write(w io.Writer, meta *parquet.Metadata) error
-	Schema() parquet.Field
-	Scan(r *Person)
-	Read(r io.ReadSeeker, pg parquet.Page) error
-	Name() string
-	Levels() ([]uint8, []uint8)
+// Delta writes the int32, int64, uint32, and uint64 columns with the
+// DELTA_BINARY_PACKED encoding, which is much smaller than PLAIN for
+// sorted or slowly changing values (like ids or counters).
+func Delta(p *ParquetWriter) error {
+	p.delta = true
+	return nil
 }
 
-func getFields(ff []Field) map[string]Field {
-	m := make(map[string]Field, len(ff))
-	for _, f := range ff {
-		m[f.Name()] = f
-	}
-	return m
+type deltaField interface {
+	SetDelta()
 }
 
-func NewParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
-	ff := Fields(compressionUnknown)
-	pr := &ParquetReader{
-		r: r,
+func (p *ParquetWriter) setDelta() {
+	if !p.delta {
+		return
 	}
 
-	for _, opt := range opts {
-		opt(pr)
+	for _, f := range p.fields {
+		if df, ok := f.(deltaField); ok {
+			df.SetDelta()
+		}
 	}
+}
 
-	schema := make([]parquet.Field, len(ff))
-	for i, f := range ff {
-		pr.fieldNames = append(pr.fieldNames, f.Name())
-		schema[i] = f.Schema()
-	}
+// DeltaLength writes the string columns cols (or every string column
+// if there are none) with the DELTA_LENGTH_BYTE_ARRAY encoding.  The
+// lengths of the values are written together instead of before each
+// value, which is much smaller for columns that are often empty.  These
+// columns aren't dictionary encoded.
+func DeltaLength(cols ...string) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		strs := map[string]bool{}
+		for _, f := range Fields(compressionUnknown) {
+			if _, ok := f.(deltaLengthField); ok {
+				strs[f.Name()] = true
+			}
+		}
 
-	meta := parquet.New(schema...)
-	if err := meta.ReadFooter(r); err != nil {
-		return nil, err
-	}
-	pr.rows = meta.Rows()
-	var err error
-	pr.pages, err = meta.Pages()
-	if err != nil {
-		return nil, err
-	}
+		if len(cols) == 0 {
+			p.deltaLength = strs
+			return nil
+		}
 
-	pr.rowGroups = meta.RowGroups()
-	_, err = r.Seek(4, io.SeekStart)
-	if err != nil {
-		return nil, err
+		p.deltaLength = map[string]bool{}
+		for _, col := range cols {
+			if !strs[col] {
+				return fmt.Errorf("%s isn't a string column", col)
+			}
+			p.deltaLength[col] = true
+		}
+		return nil
 	}
-	pr.meta = meta
-
-	return pr, pr.readRowGroup()
 }
 
-func readerIndex(i int) func(*ParquetReader) {
-	return func(p *ParquetReader) {
-		p.index = i
+func withDeltaLength(cols map[string]bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.deltaLength = cols
+		return nil
 	}
 }
 
-// ParquetReader reads one page from a row group.
-type ParquetReader struct {
-	fields         map[string]Field
-	fieldNames     []string
-	index          int
-	cursor         int64
-	rows           int64
-	rowGroupCursor int64
-	rowGroupCount  int64
-	pages          map[string][]parquet.Page
-	meta           *parquet.Metadata
-	err            error
+type deltaLengthField interface {
+	SetDeltaLength()
+}
 
-	r         io.ReadSeeker
-	rowGroups []parquet.RowGroup
+func (p *ParquetWriter) setDeltaLength() {
+	for _, f := range p.fields {
+		if df, ok := f.(deltaLengthField); ok && p.deltaLength[f.Name()] {
+			df.SetDeltaLength()
+		}
+	}
 }
 
-type Levels struct {
-	Name string
-	Defs []uint8
-	Reps []uint8
+// ByteBools writes the bool columns cols (or every bool column if
+// there are none) with one byte (0 or 1) per value instead of one bit,
+// for a reader that can't read bit packed bools.  This isn't the PLAIN
+// encoding of a BOOLEAN (even though the pages say that it is), so
+// other readers can't read these columns, and this package's readers
+// only can with the ReadByteBools option.
+func ByteBools(cols ...string) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		var err error
+		p.byteBools, err = byteBoolColumns(cols)
+		return err
+	}
 }
 
-func (p *ParquetReader) Levels() []Levels {
-	var out []Levels
-	//for {
-	for _, name := range p.fieldNames {
-		f := p.fields[name]
-		d, r := f.Levels()
-		out = append(out, Levels{Name: f.Name(), Defs: d, Reps: r})
+func withByteBools(cols map[string]bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.byteBools = cols
+		return nil
 	}
-	//	if err := p.readRowGroup(); err != nil {
-	//		break
-	//	}
-	//}
-	return out
 }
 
-func (p *ParquetReader) Error() error {
-	return p.err
+type byteBoolField interface {
+	SetByteBools()
 }
 
-func (p *ParquetReader) readRowGroup() error {
-	p.rowGroupCursor = 0
+// byteBoolColumns returns the columns of ByteBools (or ReadByteBools),
+// which must be bool columns.
+func byteBoolColumns(cols []string) (map[string]bool, error) {
+	bools := map[string]bool{}
+	for _, f := range Fields(compressionUnknown) {
+		if _, ok := f.(byteBoolField); ok {
+			bools[f.Name()] = true
+		}
+	}
 
-	if len(p.rowGroups) == 0 {
-		p.rowGroupCount = 0
-		return nil
+	if len(cols) == 0 {
+		return bools, nil
 	}
 
-	rg := p.rowGroups[0]
-	p.fields = getFields(Fields(compressionUnknown))
-	p.rowGroupCount = rg.Rows
-	p.rowGroupCursor = 0
-	for _, col := range rg.Columns() {
-		name := strings.Join(col.MetaData.PathInSchema, ".")
-		f, ok := p.fields[name]
-		if !ok {
-			return fmt.Errorf("unknown field: %s", name)
-		}
-		pages := p.pages[name]
-		if len(pages) <= p.index {
-			break
+	out := map[string]bool{}
+	for _, col := range cols {
+		if !bools[col] {
+			return nil, fmt.Errorf("%s isn't a bool column", col)
 		}
+		out[col] = true
+	}
+	return out, nil
+}
 
-		pg := pages[0]
-		if err := f.Read(p.r, pg); err != nil {
-			return fmt.Errorf("unable to read field %s, err: %s", f.Name(), err)
+// setByteBools makes f write (or read) one byte per value if it
+// is one of cols.
+func setByteBools(f Field, cols map[string]bool) {
+	if bf, ok := f.(byteBoolField); ok && cols[f.Name()] {
+		bf.SetByteBools()
+	}
+}
+
+func (p *ParquetWriter) setByteBools() {
+	for _, f := range p.fields {
+		setByteBools(f, p.byteBools)
+	}
+}
+
+// StatsTruncateLength truncates the min and max statistics of the
+// string columns to at most n bytes, which keeps the page headers of
+// columns with long values small.  A truncated max is incremented so
+// that it is still greater than or equal to every value of the page.
+func StatsTruncateLength(n int) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if n < 1 {
+			return fmt.Errorf("invalid stats truncate length %d, it must be at least 1", n)
 		}
-		p.pages[name] = p.pages[name][1:]
+		p.truncate = n
+		return nil
 	}
-	p.rowGroups = p.rowGroups[1:]
-	return nil
 }
 
-func (p *ParquetReader) Rows() int64 {
-	return p.rows
+type statsTruncateField interface {
+	SetStatsTruncateLength(int)
 }
 
-func (p *ParquetReader) Next() bool {
-	if p.err == nil && p.cursor >= p.rows {
-		return false
+func (p *ParquetWriter) setStatsTruncateLength() {
+	if p.truncate == 0 {
+		return
 	}
-	if p.rowGroupCursor >= p.rowGroupCount {
-		p.err = p.readRowGroup()
-		if p.err != nil {
-			return false
+
+	for _, f := range p.fields {
+		if sf, ok := f.(statsTruncateField); ok {
+			sf.SetStatsTruncateLength(p.truncate)
+		}
+	}
+}
+
+// Statistics sets when the columns compute the statistics (min, max,
+// and null count) of their pages.  With parquet.StatsOnAdd (the
+// default) they are updated as each row is added.  parquet.StatsOnWrite
+// computes them in one pass over a page's values when it is written,
+// which makes Add cheaper.
+func Statistics(mode parquet.StatsMode) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		if mode != parquet.StatsOnAdd && mode != parquet.StatsOnWrite {
+			return fmt.Errorf("invalid stats mode %d", mode)
 		}
+		p.statsMode = mode
+		return nil
 	}
+}
 
-	p.cursor++
-	p.rowGroupCursor++
-	return true
+type statsModeField interface {
+	SetStatsMode(parquet.StatsMode)
 }
 
-func (p *ParquetReader) Scan(x *Person) {
-	if p.err != nil {
+func (p *ParquetWriter) setStatsMode() {
+	if p.statsMode == parquet.StatsOnAdd {
 		return
 	}
 
-	for _, name := range p.fieldNames {
-		f := p.fields[name]
-		f.Scan(x)
+	for _, f := range p.fields {
+		if sf, ok := f.(statsModeField); ok {
+			sf.SetStatsMode(p.statsMode)
+		}
 	}
 }
 
-type Int32Field struct {
-	vals []int32
-	parquet.RequiredField
-	read  func(r Person) int32
-	write func(r *Person, vals []int32)
-	stats *int32stats
+// PageIndex writes (if enabled is true) the offset index and column
+// index of each column chunk between the last row group and the footer.
+// The indexes have the location, first row, min, and max of every page,
+// so a reader can skip the pages it doesn't need.
+func PageIndex(enabled bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.pageIndex = enabled
+		return nil
+	}
 }
 
-func NewInt32Field(read func(r Person) int32, write func(r *Person, vals []int32), path []string, opts ...func(*parquet.RequiredField)) *Int32Field {
-	return &Int32Field{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newInt32stats(),
+// FooterProtocol sets the thrift protocol that the footer is written
+// with.  The default, parquet.CompactProtocol, is the one the spec
+// requires.  parquet.BinaryProtocol is only for debugging: other
+// readers (and ParquetReader) can't read a file whose footer uses it.
+func FooterProtocol(proto parquet.ThriftProtocol) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.footerProtocol = proto
+		return nil
 	}
 }
 
-func (f *Int32Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int32Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+// SyncMarkers writes (if enabled is true) a sync marker after each row
+// group, which is a copy of the footer of the row groups written so far.
+// If the writer never gets to Close (the process crashes, for example),
+// parquet.Recover rebuilds the footer from the last sync marker so the
+// row groups that were written can still be read.
+func SyncMarkers(enabled bool) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.syncMarkers = enabled
+		return nil
+	}
 }
 
-func (f *Int32Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
-	}
+// DataPageV2 writes DATA_PAGE_V2 pages instead of DATA_PAGE pages.
+// The header of a v2 page has the number of nulls and rows in the
+// page, along with the page's statistics (min, max, and null count),
+// and the page's levels aren't compressed.
+func DataPageV2(p *ParquetWriter) error {
+	p.dataPageV2 = true
+	return nil
+}
 
-	v := make([]int32, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+type dataPageV2Field interface {
+	SetDataPageV2()
 }
 
-func (f *Int32Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+func (p *ParquetWriter) setDataPageV2() {
+	if !p.dataPageV2 {
+		return
+	}
 
-	bs := make([]byte, 4)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint32(bs, uint32(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
+	for _, f := range p.fields {
+		if df, ok := f.(dataPageV2Field); ok {
+			df.SetDataPageV2()
 		}
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
 }
 
-func (f *Int32Field) Scan(r *Person) {
-	if len(f.vals) == 0 {
-		return
+func withMeta(m *parquet.Metadata) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.meta = m
+		return nil
 	}
+}
 
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
+func Uncompressed(p *ParquetWriter) error {
+	p.compression = compressionUncompressed
+	return nil
 }
 
-func (f *Int32Field) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
+func Snappy(p *ParquetWriter) error {
+	p.compression = compressionSnappy
+	return nil
 }
 
-func (f *Int32Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+func Gzip(p *ParquetWriter) error {
+	p.compression = compressionGzip
+	return nil
 }
 
-type StringField struct {
-	parquet.RequiredField
-	vals  []string
-	read  func(r Person) string
-	write func(r *Person, vals []string)
-	stats *stringStats
+func withCompression(c compression) func(*ParquetWriter) error {
+	return func(p *ParquetWriter) error {
+		p.compression = c
+		return nil
+	}
 }
 
-func NewStringField(read func(r Person) string, write func(r *Person, vals []string), path []string, opts ...func(*parquet.RequiredField)) *StringField {
-	return &StringField{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newStringStats(),
+// Write writes the rows that have been added as a row group.  The
+// column chunks are written one at a time, each page is written to
+// the io.Writer as soon as it is encoded, and a column's values are
+// let go of once its chunk is written.  So, while the row group is
+// written, the memory that is used shrinks with each column instead
+// of holding every column until the end.
+// Nothing is written (and an error is returned) if a column doesn't
+// have the same number of rows as the others.
+func (p *ParquetWriter) Write() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if !p.single {
+		if err := p.write(); err != nil {
+			return err
+		}
 	}
+	return p.rejection()
 }
 
-func (f *StringField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+// WriteWithMeta writes the rows that have been added as a row group
+// (see Write) with the key/value metadata meta, which can be read with
+// ParquetReader.RowGroupMetadata.  See parquet.RowGroupMetadataPrefix
+// for how it is stored.
+func (p *ParquetWriter) WriteWithMeta(meta map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if err := p.checkRows(); err != nil {
+		return err
+	}
+
+	if p.len > 0 {
+		p.meta.SetRowGroupMetadata(meta)
+		if !p.single {
+			if err := p.write(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.rejection()
 }
 
-func (f *StringField) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+// rejection returns the error of the records that OnAdd rejected
+// (if it rejected any), which it only returns once.
+func (p *ParquetWriter) rejection() error {
+	if p.rejected == 0 {
+		return nil
+	}
 
-	bs := make([]byte, 4)
-	for _, s := range f.vals {
-		binary.LittleEndian.PutUint32(bs, uint32(len(s)))
-		if _, err := buf.Write(bs); err != nil {
+	err := fmt.Errorf("%d record(s) rejected by OnAdd, the first with: %w", p.rejected, p.rejectedErr)
+	p.rejected, p.rejectedErr = 0, nil
+	return err
+}
+
+func (p *ParquetWriter) write() error {
+	if err := p.checkRows(); err != nil {
+		return err
+	}
+
+	// the footer leaves out a row group without rows,
+	// so its (empty) pages mustn't be written
+	if p.len == 0 {
+		return nil
+	}
+
+	// the writer is reset before the columns are written so that
+	// nothing but chunks refers to the values that are written
+	chunks := [][]Field{p.fields}
+	rows := p.len
+	for child := p.child; child != nil; child = child.child {
+		chunks = append(chunks, child.fields)
+		rows += child.len
+	}
+
+	p.fields = Fields(p.compression)
+	p.child = nil
+	p.len = 0
+	if p.dictionary {
+		p.dicts = nil
+		p.setDictionaries()
+	}
+	p.setDelta()
+	p.setDeltaLength()
+	p.setByteBools()
+	p.setDataPageV2()
+	p.setStatsTruncateLength()
+	p.setStatsMode()
+
+	for i := range chunks[0] {
+		if err := p.meta.Align(p.w, chunks[0][i].Name(), p.alignment); err != nil {
 			return err
 		}
-		buf.WriteString(s)
+
+		for _, fields := range chunks {
+			if err := fields[i].Write(p.w, p.meta); err != nil {
+				return err
+			}
+			fields[i] = nil
+		}
 	}
 
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+	if err := p.meta.EndRowGroup(int64(rows)); err != nil {
+		return err
+	}
+
+	if p.syncMarkers {
+		if err := p.meta.WriteSyncMarker(p.w); err != nil {
+			return err
+		}
+	}
+
+	p.meta.StartRowGroup(fieldSchema...)
+	return nil
 }
 
-func (f *StringField) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+// checkRows makes sure that every column has a value (or levels) for
+// each of the rows that have been added, so a row group isn't written
+// with columns of different lengths.
+func (p *ParquetWriter) checkRows() error {
+	for w := p; w != nil; w = w.child {
+		for _, f := range w.fields {
+			if n := f.Rows(); n != w.len {
+				return fmt.Errorf("column %s has %d rows but %d rows have been added", f.Name(), n, w.len)
+			}
+		}
 	}
+	return nil
+}
 
-	for j := 0; j < pg.N; j++ {
-		var x int32
-		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
+// Close writes the footer (it doesn't write the rows that have been
+// added since the last row group was written, unless the writer was
+// made with SingleRowGroup) and stops the goroutine started by
+// FlushInterval.  Once it has been called the writer can't be used:
+// Add, Write, WriteWithMeta, and Close return parquet.ErrWriterClosed.
+func (p *ParquetWriter) Close() error {
+	// the goroutine is stopped before mu is held, because it
+	// holds mu while it writes a row group
+	p.stopOnce.Do(func() {
+		if p.stop != nil {
+			close(p.stop)
+			<-p.done
+		}
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return parquet.ErrWriterClosed
+	}
+	p.closed = true
+
+	if p.flushErr != nil {
+		return p.flushErr
+	}
+
+	if p.single {
+		if err := p.write(); err != nil {
 			return err
 		}
-		s := make([]byte, x)
-		if _, err := rr.Read(s); err != nil {
+	}
+
+	if err := p.meta.WriteTrailer(p.w); err != nil {
+		return err
+	}
+	return p.rejection()
+}
+
+// Add adds rec to the row group that is being written (see
+// MaxPageSize).  After Write has written a row group, the records that
+// are added go into the next one.  After Close, rec isn't added and Add
+// returns parquet.ErrWriterClosed.  It returns the error of a record
+// that OnAdd rejects too, which the next call to Write, WriteWithMeta,
+// or Close also returns.
+func (p *ParquetWriter) Add(rec Person) error {
+	err := p.tryAdd(rec)
+	if err != nil && err != parquet.ErrWriterClosed {
+		p.mu.Lock()
+		p.reject(err)
+		p.mu.Unlock()
+	}
+	return err
+}
+
+// reject records that OnAdd rejected a record with err.
+func (p *ParquetWriter) reject(err error) {
+	if p.rejected == 0 {
+		p.rejectedErr = err
+	}
+	p.rejected++
+}
+
+// tryAdd adds rec unless OnAdd rejects it, and returns OnAdd's error
+// (or parquet.ErrWriterClosed if the writer is closed).
+func (p *ParquetWriter) tryAdd(rec Person) error {
+	if p.onAdd != nil {
+		if err := p.onAdd(&rec); err != nil {
 			return err
 		}
+	}
 
-		f.vals = append(f.vals, string(s))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return parquet.ErrWriterClosed
 	}
+
+	p.add(rec)
 	return nil
 }
 
-func (f *StringField) Scan(r *Person) {
-	if len(f.vals) == 0 {
+func (p *ParquetWriter) add(rec Person) {
+	if p.len == p.max {
+		if p.child == nil {
+			p.child = p.newChild()
+		}
+
+		p.child.add(rec)
 		return
 	}
 
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
-}
+	p.meta.NextDoc()
+	for _, f := range p.fields {
+		f.Add(rec)
+	}
 
-func (f *StringField) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
+	p.len++
 }
 
-func (f *StringField) Levels() ([]uint8, []uint8) {
-	return nil, nil
+// newChild returns the writer of the page of rows that comes after
+// p's (see MaxPageSize), which has the same options as p.
+func (p *ParquetWriter) newChild() *ParquetWriter {
+	// an error can't happen here
+	opts := []func(*ParquetWriter) error{MaxPageSize(p.max), withMeta(p.meta), withCompression(p.compression)}
+	if p.dictionary {
+		opts = append(opts, withDictionaries(p.dicts, p.sorted))
+	}
+	if p.delta {
+		opts = append(opts, Delta)
+	}
+	if p.deltaLength != nil {
+		opts = append(opts, withDeltaLength(p.deltaLength))
+	}
+	if p.byteBools != nil {
+		opts = append(opts, withByteBools(p.byteBools))
+	}
+	if p.dataPageV2 {
+		opts = append(opts, DataPageV2)
+	}
+	if p.truncate > 0 {
+		opts = append(opts, StatsTruncateLength(p.truncate))
+	}
+	if p.statsMode != parquet.StatsOnAdd {
+		opts = append(opts, Statistics(p.statsMode))
+	}
+	child, _ := newParquetWriter(p.w, opts...)
+	return child
 }
 
-type Int32OptionalField struct {
-	parquet.OptionalField
-	vals  []int32
-	read  func(r Person, vals []int32, defs, reps []uint8) ([]int32, []uint8, []uint8)
-	write func(r *Person, vals []int32, defs, reps []uint8) (int, int)
-	stats *int32optionalStats
+// SuggestRowGroupRows returns the number of rows of a row group that
+// is about targetBytes bytes.  It writes sample with opts (which
+// should be the options that the row groups will be written with,
+// since compression and encodings change how big they are) and scales
+// the sample's size per row up to targetBytes.  The sample should be
+// big enough (and varied enough) to look like the real data.  The
+// number of rows is at least 1.  It returns an error if the sample
+// isn't written as a row group by Write, as with SingleRowGroup.
+func SuggestRowGroupRows(sample []Person, targetBytes int64, opts ...func(*ParquetWriter) error) (int, error) {
+	if len(sample) == 0 {
+		return 0, fmt.Errorf("the sample must have at least 1 row")
+	}
+
+	if targetBytes < 1 {
+		return 0, fmt.Errorf("invalid target size %d, it must be at least 1", targetBytes)
+	}
+
+	cw := &countWriter{w: io.Discard}
+	pw, err := NewParquetWriter(cw, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	// the leading marker isn't part of the row group
+	start := cw.n
+	for _, rec := range sample {
+		pw.Add(rec)
+	}
+
+	if err := pw.Write(); err != nil {
+		return 0, err
+	}
+
+	size := cw.n - start
+	if err := pw.Close(); err != nil {
+		return 0, err
+	}
+
+	if size == 0 {
+		return 0, fmt.Errorf("the sample wasn't written by Write, so its size is unknown (SingleRowGroup can't be used)")
+	}
+
+	n := int(float64(targetBytes) * float64(len(sample)) / float64(size))
+	if n < 1 {
+		return 1, nil
+	}
+	return n, nil
 }
 
-func NewInt32OptionalField(read func(r Person, vals []int32, defs, reps []uint8) ([]int32, []uint8, []uint8), write func(r *Person, vals []int32, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int32OptionalField {
-	return &Int32OptionalField{
-		read:          read,
-		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newint32optionalStats(maxDef(types)),
+// jsonRowGroupRows is the number of records that
+// WriteJSONArray writes as each row group.
+const jsonRowGroupRows = 100000
+
+// WriteJSONArray writes a parquet file to w of the records in r, which
+// is a JSON array of objects that each decode into a Person.
+// The array is decoded one record at a time and the records are written
+// as a row group every jsonRowGroupRows, so a large array isn't held in
+// memory.  The opts are passed to the ParquetWriter.
+func WriteJSONArray(w io.Writer, r io.Reader, opts ...func(*ParquetWriter) error) error {
+	pw, err := NewParquetWriter(w, opts...)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r)
+	if err := jsonDelim(dec, '['); err != nil {
+		return err
+	}
+
+	var n int
+	for dec.More() {
+		var rec Person
+		if err := dec.Decode(&rec); err != nil {
+			return fmt.Errorf("unable to decode record %d of the JSON array: %s", n, err)
+		}
+
+		pw.Add(rec)
+		n++
+		if n%jsonRowGroupRows == 0 {
+			if err := pw.Write(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := jsonDelim(dec, ']'); err != nil {
+		return err
+	}
+
+	if err := pw.Write(); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// jsonDelim reads the next token of dec, which must be d.
+func jsonDelim(dec *json.Decoder, d json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON array: %s", err)
+	}
+
+	if tok != d {
+		return fmt.Errorf("invalid JSON array, expected %s but got %v", d, tok)
+	}
+	return nil
+}
+
+// SplitWriter writes to a series of parquet files so that
+// each file is roughly size bytes.  The size of the current file
+// is checked each time a row group is written, and once it is at
+// least size bytes the file is closed and the next row group is
+// written to a new file from next.  If an io.Writer returned by next
+// is also an io.Closer it is closed after the file's footer is written.
+type SplitWriter struct {
+	next func() (io.Writer, error)
+	size int64
+	opts []func(*ParquetWriter) error
+
+	pw  *ParquetWriter
+	cw  *countWriter
+	err error
+}
+
+// NewSplitWriter returns a SplitWriter.  The opts are passed to
+// each file's ParquetWriter.
+func NewSplitWriter(next func() (io.Writer, error), size int64, opts ...func(*ParquetWriter) error) *SplitWriter {
+	return &SplitWriter{
+		next: next,
+		size: size,
+		opts: opts,
+	}
+}
+
+// Add adds a record to the current row group, starting a new
+// file if needed.  An error starting a file is returned by
+// the next call to Write or Close.
+func (s *SplitWriter) Add(rec Person) {
+	if s.err != nil {
+		return
+	}
+
+	if s.pw == nil {
+		if s.err = s.open(); s.err != nil {
+			return
+		}
+	}
+
+	s.pw.Add(rec)
+}
+
+// Write writes the current row group and closes the current file
+// if it has reached the target size.
+func (s *SplitWriter) Write() error {
+	if s.err != nil || s.pw == nil {
+		return s.err
+	}
+
+	if err := s.pw.Write(); err != nil {
+		return err
+	}
+
+	if s.cw.n >= s.size {
+		return s.closeFile()
+	}
+	return nil
+}
+
+// Close closes the current file.  Like ParquetWriter.Close, it doesn't
+// write records that were added since the last call to Write.
+func (s *SplitWriter) Close() error {
+	if s.err != nil || s.pw == nil {
+		return s.err
+	}
+	return s.closeFile()
+}
+
+func (s *SplitWriter) open() error {
+	w, err := s.next()
+	if err != nil {
+		return err
+	}
+
+	s.cw = &countWriter{w: w}
+	s.pw, err = NewParquetWriter(s.cw, s.opts...)
+	return err
+}
+
+func (s *SplitWriter) closeFile() error {
+	err := s.pw.Close()
+	if c, ok := s.cw.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	s.pw = nil
+	s.cw = nil
+	return err
+}
+
+// countWriter keeps track of the number of bytes
+// written to a file.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sortedRunRows is the number of records in each row group of the
+// runs of a SortedWriter, which is as many records of each run as are
+// held in memory while the runs are merged.
+const sortedRunRows = 1000
+
+// SortedWriter writes the records that are added to it to a parquet
+// file in the order of less (records that are neither less than the
+// other stay in the order they were added).  Up to maxRows records are
+// held in memory.  Once there are more, each maxRows records are sorted
+// and written to a temporary file (a run), and Close merges the runs.
+// The runs are parquet files, so a field that isn't written to the
+// file isn't kept either.  The file's row groups have maxRows rows.
+//
+// Less is opaque to the writer, so the order is only recorded in the
+// footer if the opts include SortingColumns with the columns that less
+// compares.  OnAdd is called by Add (before the record is sorted), and
+// the error of the records that it rejects is returned by Close.
+type SortedWriter struct {
+	w       io.Writer
+	less    func(a, b Person) bool
+	maxRows int
+	opts    []func(*ParquetWriter) error
+
+	// check is a ParquetWriter with the opts, whose
+	// OnAdd (and rejected records) Add uses
+	check *ParquetWriter
+
+	recs []Person
+	runs []*os.File
+	err  error
+}
+
+// NewSortedWriter returns a SortedWriter that writes to w.  The opts
+// are passed to the ParquetWriter that writes the sorted records.
+func NewSortedWriter(w io.Writer, less func(a, b Person) bool, maxRows int, opts ...func(*ParquetWriter) error) (*SortedWriter, error) {
+	if maxRows < 1 {
+		return nil, fmt.Errorf("invalid max rows %d, it must be at least 1", maxRows)
+	}
+
+	// the options are checked now rather than
+	// after all of the records have been added
+	check, err := newParquetWriter(io.Discard, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SortedWriter{
+		w:       w,
+		less:    less,
+		maxRows: maxRows,
+		opts:    opts,
+		check:   check,
+	}, nil
+}
+
+// Add adds a record, which writes a run if there are
+// too many records in memory.  An error writing the run
+// is returned by Close.
+func (s *SortedWriter) Add(rec Person) {
+	if s.err != nil {
+		return
+	}
+
+	if s.check.onAdd != nil {
+		if err := s.check.onAdd(&rec); err != nil {
+			s.check.reject(err)
+			return
+		}
+	}
+
+	s.recs = append(s.recs, rec)
+	if len(s.recs) >= s.maxRows {
+		s.err = s.spill()
+	}
+}
+
+// Close writes the sorted records (and the footer) and
+// removes the runs.  It doesn't close the io.Writer.
+func (s *SortedWriter) Close() error {
+	defer s.removeRuns()
+	if s.err != nil {
+		return s.err
+	}
+
+	pw, err := NewParquetWriter(s.w, s.opts...)
+	if err != nil {
+		return err
+	}
+	// the records were checked by Add
+	pw.onAdd = nil
+
+	var n int
+	add := func(rec Person) error {
+		pw.Add(rec)
+		n++
+		if n%s.maxRows == 0 {
+			return pw.Write()
+		}
+		return nil
+	}
+
+	if len(s.runs) == 0 {
+		sort.SliceStable(s.recs, func(i, j int) bool { return s.less(s.recs[i], s.recs[j]) })
+		for _, rec := range s.recs {
+			if err := add(rec); err != nil {
+				return err
+			}
+		}
+	} else {
+		if len(s.recs) > 0 {
+			if err := s.spill(); err != nil {
+				return err
+			}
+		}
+		if err := s.merge(add); err != nil {
+			return err
+		}
+	}
+	s.recs = nil
+
+	if err := pw.Write(); err != nil {
+		return err
+	}
+
+	if err := pw.Close(); err != nil {
+		return err
+	}
+	return s.check.rejection()
+}
+
+// spill sorts the records in memory and writes them to a run.
+func (s *SortedWriter) spill() error {
+	sort.SliceStable(s.recs, func(i, j int) bool { return s.less(s.recs[i], s.recs[j]) })
+
+	f, err := os.CreateTemp("", "parquet-sort-")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	pw, err := NewParquetWriter(f, Uncompressed)
+	if err != nil {
+		return err
+	}
+
+	for i, rec := range s.recs {
+		pw.Add(rec)
+		if (i+1)%sortedRunRows == 0 {
+			if err := pw.Write(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := pw.Write(); err != nil {
+		return err
+	}
+
+	if err := pw.Close(); err != nil {
+		return err
+	}
+
+	s.recs = s.recs[:0]
+	return nil
+}
+
+// merge passes the records of the runs to add in order.
+func (s *SortedWriter) merge(add func(Person) error) error {
+	m := &sortedMerge{less: s.less}
+	for i, f := range s.runs {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		r, err := NewParquetReader(f)
+		if err != nil {
+			return err
+		}
+
+		run := &sortedRun{r: r, i: i}
+		if run.next() {
+			m.runs = append(m.runs, run)
+		} else if err := r.Error(); err != nil {
+			return err
+		}
+	}
+	heap.Init(m)
+
+	for len(m.runs) > 0 {
+		run := m.runs[0]
+		if err := add(run.rec); err != nil {
+			return err
+		}
+
+		if run.next() {
+			heap.Fix(m, 0)
+			continue
+		}
+
+		if err := run.r.Error(); err != nil {
+			return err
+		}
+		heap.Pop(m)
+	}
+	return nil
+}
+
+func (s *SortedWriter) removeRuns() {
+	for _, f := range s.runs {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	s.runs = nil
+}
+
+// sortedRun is a run of a SortedWriter that is being merged.
+type sortedRun struct {
+	r   *ParquetReader
+	rec Person
+
+	// i is the index of the run, which breaks ties so that
+	// the records of earlier runs come first
+	i int
+}
+
+// next reads the run's next record.
+func (r *sortedRun) next() bool {
+	if !r.r.Next() {
+		return false
+	}
+
+	var rec Person
+	r.r.Scan(&rec)
+	r.rec = rec
+	return true
+}
+
+// sortedMerge is a heap of the runs of a SortedWriter
+// whose first run has the smallest record.
+type sortedMerge struct {
+	runs []*sortedRun
+	less func(a, b Person) bool
+}
+
+func (m *sortedMerge) Len() int {
+	return len(m.runs)
+}
+
+func (m *sortedMerge) Less(i, j int) bool {
+	a, b := m.runs[i], m.runs[j]
+	if m.less(a.rec, b.rec) {
+		return true
+	}
+	if m.less(b.rec, a.rec) {
+		return false
+	}
+	return a.i < b.i
+}
+
+func (m *sortedMerge) Swap(i, j int) {
+	m.runs[i], m.runs[j] = m.runs[j], m.runs[i]
+}
+
+func (m *sortedMerge) Push(x interface{}) {
+	m.runs = append(m.runs, x.(*sortedRun))
+}
+
+func (m *sortedMerge) Pop() interface{} {
+	run := m.runs[len(m.runs)-1]
+	m.runs = m.runs[:len(m.runs)-1]
+	return run
+}
+
+type Field interface {
+	Add(r Person)
+	Write(w io.Writer, meta *parquet.Metadata) error
+	Schema() parquet.Field
+	Scan(r *Person)
+	Read(r io.ReadSeeker, pg parquet.Page) error
+	Name() string
+	Levels() ([]uint8, []uint8)
+	Rows() int
+	SetAllocator(parquet.Allocator)
+	SetBuffers(*parquet.FieldBuffers)
+	SetInterner(*parquet.Interner)
+	Value() (interface{}, bool)
+}
+
+func getFields(ff []Field) map[string]Field {
+	m := make(map[string]Field, len(ff))
+	for _, f := range ff {
+		m[f.Name()] = f
+	}
+	return m
+}
+
+func NewParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	pr, err := newParquetReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return pr, pr.readRowGroup()
+}
+
+// newParquetReader reads the footer (but none of the row groups).
+func newParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	ff := Fields(compressionUnknown)
+	pr := &ParquetReader{
+		r: r,
+	}
+
+	for _, opt := range opts {
+		opt(pr)
+	}
+
+	if pr.readByteBools {
+		var err error
+		if pr.byteBools, err = byteBoolColumns(pr.byteBoolCols); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range ff {
+		pr.fieldNames = append(pr.fieldNames, f.Name())
+	}
+
+	meta := parquet.New(fieldSchema...)
+	if err := meta.ReadFooter(r); err != nil {
+		return nil, err
+	}
+	if pr.requireExact {
+		if err := meta.RequireSchema(pr.exactSchema); err != nil {
+			return nil, err
+		}
+	}
+	if pr.maxPageBytes > 0 {
+		meta.SetMaxPageBytes(pr.maxPageBytes)
+	}
+	meta.SetLenient(pr.lenient)
+	meta.SetDecimalAsFloat(pr.decimalAsFloat)
+	pr.rows = meta.Rows()
+	if pr.limited && pr.limit < pr.rows {
+		pr.rows = pr.limit
+		if pr.rows < 0 {
+			pr.rows = 0
+		}
+	}
+	var err error
+	pr.pages, err = meta.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pr.rowGroups = meta.RowGroups()
+	_, err = r.Seek(4, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	pr.meta = meta
+	return pr, nil
+}
+
+// SafeRead reads every record from r.  It is meant for files that
+// come from an untrusted source: the sizes and counts in a malformed
+// file are checked before they are used, so it returns an error
+// instead of causing a panic (or a huge allocation).
+func SafeRead(r io.ReadSeeker, opts ...func(*ParquetReader)) ([]Person, error) {
+	pr, err := NewParquetReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Person
+	for pr.Next() {
+		var x Person
+		pr.Scan(&x)
+		out = append(out, x)
+	}
+	return out, pr.Error()
+}
+
+// WithAllocator sets the parquet.Allocator that page data is
+// decoded into.
+func WithAllocator(a parquet.Allocator) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.alloc = a
+	}
+}
+
+// WithBuffers sets the parquet.FieldBuffers that each row group's
+// values and levels are decoded into.  Passing the same FieldBuffers
+// to the readers of files that have the same schema (one after
+// another) reuses the slices instead of allocating them for each
+// file.  The buffers aren't used by the reader's ColumnReaders.
+func WithBuffers(b *parquet.FieldBuffers) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.buffers = b
+	}
+}
+
+// Limit caps the number of rows that are read.  Row groups
+// past the limit are never read.  Limit(0) (or a negative n)
+// reads no rows, so a limit that is computed can be 0.
+func Limit(n int64) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.limit = n
+		p.limited = true
+	}
+}
+
+// MaxPageBytes sets the size of the largest (decompressed) page that
+// is read.  A page that is larger (a decompression bomb in a file from
+// an untrusted source, for example) returns an error before it is
+// allocated.  It is parquet.DefaultMaxPageBytes if n isn't at least 1.
+func MaxPageBytes(n int32) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.maxPageBytes = n
+	}
+}
+
+// Lenient reads a page that claims to be snappy compressed, but
+// isn't valid snappy and is the size of the uncompressed page, as
+// though it wasn't compressed.  Some writers leave pages uncompressed
+// without saying so, and without Lenient those files can't be read.
+// It also reads a page that was compressed with snappy's framing
+// format instead of the block format that parquet uses.
+func Lenient(p *ParquetReader) {
+	p.lenient = true
+}
+
+// InternStrings makes the values of string columns that are the same
+// share their memory (see parquet.Interner), which saves a lot of heap
+// when a column only has a few distinct values.  Each reader has its
+// own table of strings, which is dropped along with the reader.
+func InternStrings(p *ParquetReader) {
+	p.interner = parquet.NewInterner()
+}
+
+// DecimalAsFloat reads the INT32 and INT64 columns that are annotated
+// as DECIMAL into float fields as the unscaled value divided by
+// 10^scale (the column's scale in the file's schema).  The values are
+// approximate, so a DECIMAL column that needs to be exact shouldn't
+// be read this way.
+func DecimalAsFloat(p *ParquetReader) {
+	p.decimalAsFloat = true
+}
+
+// IgnoreUnknownColumns skips the columns of a parquet file that
+// aren't part of the reader's type instead of returning a
+// parquet.UnknownColumnError.  This allows a file to be read
+// into a struct that only has a subset of its columns.
+func IgnoreUnknownColumns(p *ParquetReader) {
+	p.ignoreUnknown = true
+}
+
+// ReadByteBools reads the bool columns cols (or every bool column if
+// there are none) of a file that was written with the ByteBools option,
+// which stores one byte per value instead of one bit.  Nothing in the
+// file says that it was, so the same columns must be given here.
+func ReadByteBools(cols ...string) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.byteBoolCols = cols
+		p.readByteBools = true
+	}
+}
+
+// RequireExactSchema makes NewParquetReader return a
+// *parquet.SchemaMismatchError, before it reads anything but the
+// footer, unless the file's columns are exactly fields (see
+// parquet.Metadata.RequireSchema).  Without it a file can leave out
+// some of the reader's columns (they are zero when scanned), and with
+// IgnoreUnknownColumns it can have others, so this is for files that
+// must have nothing but the expected columns.
+func RequireExactSchema(fields []parquet.Field) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.exactSchema = fields
+		p.requireExact = true
+	}
+}
+
+func readerIndex(i int) func(*ParquetReader) {
+	return func(p *ParquetReader) {
+		p.index = i
+	}
+}
+
+// ParquetReader reads one page from a row group.
+type ParquetReader struct {
+	fields         map[string]Field
+	fieldNames     []string
+	index          int
+	cursor         int64
+	rows           int64
+	rowGroupCursor int64
+	rowGroupCount  int64
+	pages          map[string][]parquet.Page
+	meta           *parquet.Metadata
+	err            error
+	alloc          parquet.Allocator
+	buffers        *parquet.FieldBuffers
+	interner       *parquet.Interner
+	limit          int64
+	limited        bool
+	maxPageBytes   int32
+	ignoreUnknown  bool
+	lenient        bool
+	requireExact   bool
+	exactSchema    []parquet.Field
+	decimalAsFloat bool
+
+	// byteBools holds the bool columns that are read with one
+	// byte per value (see ReadByteBools)
+	readByteBools bool
+	byteBoolCols  []string
+	byteBools     map[string]bool
+
+	// unscanned is true if the row that Next read hasn't
+	// been scanned, so the fields' next values are its values.
+	unscanned bool
+
+	// scanned is the cursor of the row that Scan
+	// set last (0 before the first one, see Pos)
+	scanned int64
+
+	r         io.ReadSeeker
+	rowGroups []parquet.RowGroup
+}
+
+type Levels struct {
+	Name string
+	Defs []uint8
+	Reps []uint8
+}
+
+func (p *ParquetReader) Levels() []Levels {
+	var out []Levels
+	//for {
+	for _, name := range p.fieldNames {
+		f := p.fields[name]
+		d, r := f.Levels()
+		out = append(out, Levels{Name: f.Name(), Defs: d, Reps: r})
+	}
+	//	if err := p.readRowGroup(); err != nil {
+	//		break
+	//	}
+	//}
+	return out
+}
+
+func (p *ParquetReader) Error() error {
+	return p.err
+}
+
+// ColumnChunkLocation returns the byte offset and length of a
+// column chunk within the parquet file.
+func (p *ParquetReader) ColumnChunkLocation(rg int, col string) (offset, length int64, err error) {
+	return p.meta.ColumnChunkLocation(rg, col)
+}
+
+// ColumnCompressedSize returns the size (in bytes) of col's pages in
+// every row group, which is how much of the file reading the column
+// reads (see parquet.Metadata.ColumnCompressedSize).
+func (p *ParquetReader) ColumnCompressedSize(col string) (int64, error) {
+	return p.meta.ColumnCompressedSize(col)
+}
+
+// PageIndex returns the pages of col in row group rg as they are
+// described by the file's offset and column indexes (nil if the file
+// doesn't have them).  It seeks back to where it was, so it can be
+// used while the ParquetReader is being read.
+func (p *ParquetReader) PageIndex(rg int, col string) ([]parquet.IndexedPage, error) {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := p.meta.PageIndex(p.r, rg, col)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return pages, err
+}
+
+// PageSkip calls skip with each of col's pages (in the row groups
+// that have a page index) and returns the pages that can be skipped
+// (the ones skip returned true for) by row group.  For example, skip
+// could compare each page's Min and Max to the values of a query.
+func (p *ParquetReader) PageSkip(col string, skip func(parquet.IndexedPage) bool) ([][]parquet.IndexedPage, error) {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := p.meta.PageSkip(p.r, col, skip)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return pages, err
+}
+
+// RowGroupMetadata returns the key/value metadata that
+// was written with row group rg by WriteWithMeta.
+func (p *ParquetReader) RowGroupMetadata(rg int) (map[string]string, error) {
+	return p.meta.RowGroupMetadata(rg)
+}
+
+// SortingColumns returns the columns that the rows of row group rg
+// are sorted by, if the writer recorded them (see SortingColumns).
+func (p *ParquetReader) SortingColumns(rg int) ([]parquet.SortingColumn, error) {
+	return p.meta.SortingColumns(rg)
+}
+
+// SchemaTree returns the hierarchical schema of the parquet file.
+func (p *ParquetReader) SchemaTree() (*parquet.SchemaNode, error) {
+	return p.meta.SchemaTree()
+}
+
+// SchemaFields returns the columns of the parquet file's schema with
+// their types and annotations (see parquet.Metadata.SchemaFields).
+func (p *ParquetReader) SchemaFields() ([]parquet.Field, error) {
+	return p.meta.SchemaFields()
+}
+
+// ForEachPage calls fn with the header of each of col's pages without
+// reading the pages' data.  col is the column's path joined by dots.
+// It can be called between calls to Next.
+func (p *ParquetReader) ForEachPage(col string, fn func(sch.PageHeader) error) error {
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	err = p.meta.ForEachPage(p.r, col, fn)
+	if _, serr := p.r.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// OptionalColumn returns the values and definition levels of col (the
+// column's path joined by dots) in every row group without scanning any
+// records.  col must be optional and not repeated.  The values are a
+// slice of the column's type (for example, []int32 for a *int32 field)
+// that only holds the values that aren't null, and a row's value is
+// null if its definition level is less than the column's maximum
+// definition level.  It seeks back to where it was, so it can be used
+// while the ParquetReader is being read.
+func (p *ParquetReader) OptionalColumn(col string) (interface{}, []int64, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return nil, nil, &parquet.UnknownColumnError{Column: col}
+	}
+
+	var optional bool
+	for _, t := range f.Schema().Types {
+		switch parquet.RepetitionType(t) {
+		case parquet.Optional:
+			optional = true
+		case parquet.Repeated:
+			return nil, nil, fmt.Errorf("column %s is repeated", col)
+		}
+	}
+	if !optional {
+		return nil, nil, fmt.Errorf("column %s isn't optional", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	if err := p.readColumnChunks(f, b); err != nil {
+		return nil, nil, err
+	}
+
+	levels, _ := f.Levels()
+	defs := make([]int64, len(levels))
+	for i, d := range levels {
+		defs[i] = int64(d)
+	}
+	return b.Column(col), defs, nil
+}
+
+// ReadInt32Column appends the values of col (the path of a
+// required int32 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadInt32Column(col string, dst []int32) ([]int32, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Int32Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required int32 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]int32), nil
+}
+
+// ReadStringColumn appends the values of col (the path of a
+// required string column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadStringColumn(col string, dst []string) ([]string, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*StringField); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required string column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]string), nil
+}
+
+// ReadInt64Column appends the values of col (the path of a
+// required int64 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadInt64Column(col string, dst []int64) ([]int64, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Int64Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required int64 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]int64), nil
+}
+
+// ReadFloat32Column appends the values of col (the path of a
+// required float32 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadFloat32Column(col string, dst []float32) ([]float32, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Float32Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required float32 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]float32), nil
+}
+
+// ReadFloat64Column appends the values of col (the path of a
+// required float64 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadFloat64Column(col string, dst []float64) ([]float64, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Float64Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required float64 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]float64), nil
+}
+
+// ReadUint32Column appends the values of col (the path of a
+// required uint32 column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadUint32Column(col string, dst []uint32) ([]uint32, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*Uint32Field); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required uint32 column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]uint32), nil
+}
+
+// ReadBoolColumn appends the values of col (the path of a
+// required bool column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadBoolColumn(col string, dst []bool) ([]bool, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*BoolField); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required bool column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]bool), nil
+}
+
+// ReadTimeColumn appends the values of col (the path of a
+// required time.Time column joined by dots) in every row group to
+// dst[:0], which grows if it isn't big enough, and returns it.  Like
+// OptionalColumn it doesn't scan any records and it seeks back to where
+// it was.
+func (p *ParquetReader) ReadTimeColumn(col string, dst []time.Time) ([]time.Time, error) {
+	f, ok := getFields(Fields(compressionUnknown))[col]
+	if !ok {
+		return dst[:0], &parquet.UnknownColumnError{Column: col}
+	}
+
+	if _, ok := f.(*TimeField); !ok {
+		return dst[:0], fmt.Errorf("column %s isn't a required time.Time column", col)
+	}
+
+	b := parquet.NewFieldBuffers()
+	b.KeepValues(col, dst[:0])
+	if err := p.readColumnChunks(f, b); err != nil {
+		return dst[:0], err
+	}
+	return b.Column(col).([]time.Time), nil
+}
+
+// readColumnChunks reads f's column chunks in every row group into b
+// (the values and levels of each one are appended to the ones before
+// it) and then seeks back to where it was.
+func (p *ParquetReader) readColumnChunks(f Field, b *parquet.FieldBuffers) error {
+	pages, err := p.meta.Pages()
+	if err != nil {
+		return err
+	}
+
+	pos, err := p.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	f.SetBuffers(b)
+	f.SetInterner(p.interner)
+	setByteBools(f, p.byteBools)
+	if p.alloc != nil {
+		f.SetAllocator(p.alloc)
+	}
+	for _, pg := range pages[f.Name()] {
+		if _, err := p.r.Seek(pg.Offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(p.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: f.Name(), RowGroup: pg.RowGroup, Offset: pg.Offset, Err: err}
+		}
+	}
+
+	_, err = p.r.Seek(pos, io.SeekStart)
+	return err
+}
+
+// ColumnReader reads one column, row group by row group, without
+// reading the file's other columns.  It is much faster than
+// ParquetReader when only one column of a wide file is needed.
+type ColumnReader struct {
+	r         io.ReadSeeker
+	meta      *parquet.Metadata
+	cols      []string
+	alloc     parquet.Allocator
+	interner  *parquet.Interner
+	byteBools map[string]bool
+	fields    []Field
+	pages     map[string][]parquet.Page
+	rowGroups []parquet.RowGroup
+	rowGroup  int
+
+	cursor         int64
+	rows           int64
+	rowGroupCursor int64
+	rowGroupCount  int64
+	err            error
+}
+
+// ReadColumn returns a ColumnReader for col (the column's path joined
+// by dots).  A column of a nested struct can only be scanned after
+// the columns before it in the struct, so those columns are read too.
+// The ColumnReader seeks to each of the column chunks and then back to
+// where it was, so it can be used while the ParquetReader is being read.
+func (p *ParquetReader) ReadColumn(col string) (*ColumnReader, error) {
+	var cols []string
+	for _, f := range Fields(compressionUnknown) {
+		if f.Schema().Path[0] != strings.Split(col, ".")[0] {
+			continue
+		}
+
+		cols = append(cols, f.Name())
+		if f.Name() == col {
+			break
+		}
+	}
+
+	if len(cols) == 0 || cols[len(cols)-1] != col {
+		return nil, &parquet.UnknownColumnError{Column: col}
+	}
+
+	return p.readColumns(cols)
+}
+
+// readColumns returns a ColumnReader for cols, which must be in the
+// order of Fields.
+func (p *ParquetReader) readColumns(cols []string) (*ColumnReader, error) {
+	pages, err := p.meta.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	rowGroups := p.meta.RowGroups()
+	return &ColumnReader{
+		r:         p.r,
+		meta:      p.meta,
+		cols:      cols,
+		alloc:     p.alloc,
+		interner:  p.interner,
+		byteBools: p.byteBools,
+		pages:     pages,
+		rowGroups: rowGroups,
+		rows:      p.rows,
+	}, nil
+}
+
+func (c *ColumnReader) readRowGroup() error {
+	pos, err := c.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	rg := c.rowGroups[c.rowGroup]
+	fields := getFields(Fields(compressionUnknown))
+	c.fields = c.fields[:0]
+	for _, col := range c.cols {
+		// a row group doesn't have to have every column (see
+		// ParquetReader.Scan), and the field of a column that
+		// it doesn't have isn't read, so Scan leaves it zero
+		if len(c.pages[col]) == 0 || c.pages[col][0].RowGroup != c.rowGroup {
+			continue
+		}
+
+		pg := c.pages[col][0]
+		c.pages[col] = c.pages[col][1:]
+		if int64(pg.N) < rg.Rows && parquet.NumValuesOff(rg.Rows, int64(pg.N)) {
+			// see ParquetReader.readRowGroup
+			return fmt.Errorf("column %s has %d values but the row group has %d rows", col, pg.N, rg.Rows)
+		}
+
+		f := fields[col]
+		f.SetInterner(c.interner)
+		setByteBools(f, c.byteBools)
+		if c.alloc != nil {
+			f.SetAllocator(c.alloc)
+		}
+
+		offset, _, err := c.meta.ColumnChunkLocation(c.rowGroup, col)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(c.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: col, RowGroup: c.rowGroup, Offset: offset, Err: err}
+		}
+		c.fields = append(c.fields, f)
+	}
+
+	if _, err := c.r.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+
+	c.rowGroupCount = rg.Rows
+	c.rowGroupCursor = 0
+	c.rowGroup++
+	return nil
+}
+
+// Next reads the next row of the column.  It returns false
+// when there are no more rows or there was an error.
+func (c *ColumnReader) Next() bool {
+	if c.err != nil || c.cursor >= c.rows {
+		return false
+	}
+
+	for c.rowGroupCursor >= c.rowGroupCount {
+		if c.rowGroup >= len(c.rowGroups) {
+			c.err = fmt.Errorf("expected %d rows, only found %d", c.rows, c.cursor)
+			return false
+		}
+
+		c.err = c.readRowGroup()
+		if c.err != nil {
+			return false
+		}
+	}
+
+	c.cursor++
+	c.rowGroupCursor++
+	return true
+}
+
+// Scan sets the column's field of x (and the fields of the other
+// columns that were read).  The rest of x is left as is.
+func (c *ColumnReader) Scan(x *Person) {
+	if c.err != nil {
+		return
+	}
+
+	resetColumn(x, c.cols[0])
+	c.scan(x)
+}
+
+func (c *ColumnReader) scan(x *Person) {
+	for _, f := range c.fields {
+		f.Scan(x)
+	}
+}
+
+// Error returns the error (if any) that stopped Next.
+func (c *ColumnReader) Error() error {
+	return c.err
+}
+
+// DumpColumn writes the values of col (the column's path joined by
+// dots) in the file r to w as text, one row per line, so a column can
+// be looked at without writing a program to read it.  Only col (and
+// the columns before it in its struct, see ReadColumn) is read.  A
+// null, or a repeated column without any values in the row, is written
+// as null, and the values of a repeated column are written as a list.
+func DumpColumn(r io.ReadSeeker, col string, w io.Writer) error {
+	pr, err := newParquetReader(r)
+	if err != nil {
+		return err
+	}
+
+	c, err := pr.ReadColumn(col)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	var x Person
+	for c.Next() {
+		// the column's field is the last one that is read (unless
+		// the row group doesn't have the column)
+		var v interface{}
+		var ok bool
+		if n := len(c.fields); n > 0 && c.fields[n-1].Name() == col {
+			v, ok = c.fields[n-1].Value()
+		}
+
+		if ok {
+			fmt.Fprintln(bw, v)
+		} else {
+			bw.WriteString("null\n")
+		}
+		c.Scan(&x)
+	}
+
+	if err := c.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Summary is a projection of Person (the id, age, hobby, friends, born columns).
+type Summary struct {
+	ID      int32     `parquet:"id"`
+	Age     *int32    `parquet:"age"`
+	Hobby   *Hobby    `parquet:"hobby"`
+	Friends []Being   `parquet:"friends"`
+	Born    time.Time `parquet:"born"`
+}
+
+// SummaryParquetReader reads the Summary projection of each
+// record.  Only the columns of Summary's fields are read.
+type SummaryParquetReader struct {
+	c   *ColumnReader
+	rec Person
+}
+
+// NewSummaryParquetReader returns a SummaryParquetReader.  The
+// opts are the same as NewParquetReader's.
+func NewSummaryParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*SummaryParquetReader, error) {
+	p, err := newParquetReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := map[string]bool{"id": true, "age": true, "hobby": true, "friends": true, "born": true}
+	var names []string
+	for _, f := range Fields(compressionUnknown) {
+		if cols[f.Schema().Path[0]] {
+			names = append(names, f.Name())
+		}
+	}
+
+	c, err := p.readColumns(names)
+	if err != nil {
+		return nil, err
+	}
+	return &SummaryParquetReader{c: c}, nil
+}
+
+// Next reads the next row.  It returns false when
+// there are no more rows or there was an error.
+func (p *SummaryParquetReader) Next() bool {
+	return p.c.Next()
+}
+
+// Scan sets the fields of x to the current row.
+func (p *SummaryParquetReader) Scan(x *Summary) {
+	if p.c.err != nil {
+		return
+	}
+
+	resetRecord(&p.rec)
+	p.c.scan(&p.rec)
+	rec := &p.rec
+	*x = Summary{
+		ID:      rec.ID,
+		Age:     rec.Age,
+		Hobby:   rec.Hobby,
+		Friends: rec.Friends,
+		Born:    rec.Born,
+	}
+}
+
+// Error returns the error (if any) that stopped Next.
+func (p *SummaryParquetReader) Error() error {
+	return p.c.Error()
+}
+
+func (p *ParquetReader) readRowGroup() error {
+	p.rowGroupCursor = 0
+
+	if len(p.rowGroups) == 0 {
+		p.rowGroupCount = 0
+		return nil
+	}
+
+	rg := p.rowGroups[0]
+	p.fields = getFields(Fields(compressionUnknown))
+	if p.alloc != nil {
+		for _, f := range p.fields {
+			f.SetAllocator(p.alloc)
+		}
+	}
+	if p.buffers != nil {
+		for _, f := range p.fields {
+			f.SetBuffers(p.buffers)
+		}
+	}
+	if p.interner != nil {
+		for _, f := range p.fields {
+			f.SetInterner(p.interner)
+		}
+	}
+	for _, f := range p.fields {
+		setByteBools(f, p.byteBools)
+	}
+	p.rowGroupCount = rg.Rows
+	p.rowGroupCursor = 0
+	for _, col := range rg.Columns() {
+		name := strings.Join(col.MetaData.PathInSchema, ".")
+		if col.MetaData.NumValues < rg.Rows && parquet.NumValuesOff(rg.Rows, col.MetaData.NumValues) {
+			// every row has at least one value (or null) in each
+			// column, so the footer is too far off to be trusted
+			// (a footer that is only a little off is read with the
+			// number of values in the pages, see parquet.NumValuesOff)
+			return fmt.Errorf("column %s has %d values but the row group has %d rows", name, col.MetaData.NumValues, rg.Rows)
+		}
+
+		f, ok := p.fields[name]
+		if !ok {
+			if !p.ignoreUnknown {
+				return &parquet.UnknownColumnError{Column: name}
+			}
+			continue
+		}
+		pages := p.pages[name]
+		if len(pages) <= p.index {
+			break
+		}
+
+		// the column chunks aren't always next to each
+		// other (see PageAlignment)
+		pg := pages[0]
+		if _, err := p.r.Seek(pg.Offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := f.Read(p.r, pg); err != nil {
+			return &parquet.ReadColumnError{Column: f.Name(), RowGroup: pg.RowGroup, Offset: pg.Offset, Err: err}
+		}
+		p.pages[name] = p.pages[name][1:]
+	}
+	p.rowGroups = p.rowGroups[1:]
+	return nil
+}
+
+func (p *ParquetReader) Rows() int64 {
+	return p.rows
+}
+
+func (p *ParquetReader) Next() bool {
+	if p.unscanned {
+		p.skip()
+	}
+
+	if p.err == nil && p.cursor >= p.rows {
+		return false
+	}
+	for p.rowGroupCursor >= p.rowGroupCount {
+		if len(p.rowGroups) == 0 {
+			p.err = fmt.Errorf("expected %d rows, only found %d", p.rows, p.cursor)
+			return false
+		}
+
+		p.err = p.readRowGroup()
+		if p.err != nil {
+			return false
+		}
+	}
+
+	p.cursor++
+	p.rowGroupCursor++
+	p.unscanned = true
+	return true
+}
+
+// Scan sets every field of x that is read from parquet, so the
+// same x can be reused for each record.  A field that is null (or
+// empty) in the record is set to its zero value, and so is the field
+// of a column that the record's row group doesn't have (the row groups
+// of a file that was put together from other files don't always have
+// the same columns).
+func (p *ParquetReader) Scan(x *Person) {
+	if p.err != nil {
+		return
+	}
+
+	resetRecord(x)
+	for _, name := range p.fieldNames {
+		f := p.fields[name]
+		f.Scan(x)
+	}
+	p.unscanned = false
+	p.scanned = p.cursor
+}
+
+// Pos returns the index (in the file, starting at 0) of the row that
+// the most recent call to Scan set, or -1 if Scan hasn't been called.
+// A row that Next read but that wasn't scanned doesn't change it, so
+// Pos is the last row that was processed (to checkpoint, for example).
+func (p *ParquetReader) Pos() int64 {
+	return p.scanned - 1
+}
+
+// skip moves the fields past the row that Next read, which keeps
+// them in step with Next when a row isn't scanned.
+func (p *ParquetReader) skip() {
+	var x Person
+	for _, name := range p.fieldNames {
+		p.fields[name].Scan(&x)
+	}
+	p.unscanned = false
+}
+
+// ReadTail scans the last n rows of the file (all of them if it has
+// fewer) into dst, which must have room for them, and returns how many
+// it scanned.  The row groups before the first of those rows are
+// skipped without being read, so only the end of a large file is read.
+// The reader can't go back to a row it has already passed, and once
+// ReadTail returns it is at the end of the file.
+func (p *ParquetReader) ReadTail(n int, dst []Person) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+
+	start := p.rows - int64(n)
+	if start < 0 {
+		start = 0
+	}
+
+	if start < p.cursor {
+		return 0, fmt.Errorf("can't read the last %d rows, the reader has already passed row %d", n, start)
+	}
+
+	if int64(len(dst)) < p.rows-start {
+		return 0, fmt.Errorf("can't read the last %d rows into a slice of length %d", p.rows-start, len(dst))
+	}
+
+	if err := p.seekToRow(start); err != nil {
+		return 0, err
+	}
+
+	var i int
+	for p.Next() {
+		p.Scan(&dst[i])
+		i++
+	}
+	return i, p.err
+}
+
+// seekToRow moves the reader forward so that the next call to Next
+// reads row (the index of the row in the file).  The row groups that
+// end before it are dropped without being read, and the rows before it
+// in its own row group are skipped.
+func (p *ParquetReader) seekToRow(row int64) error {
+	if p.unscanned {
+		p.skip()
+	}
+
+	// the rest of the row group that has been read
+	if left := p.rowGroupCount - p.rowGroupCursor; p.cursor+left <= row {
+		p.cursor += left
+		p.rowGroupCursor = p.rowGroupCount
+	}
+
+	for len(p.rowGroups) > 0 && p.rowGroupCursor >= p.rowGroupCount && p.cursor+p.rowGroups[0].Rows <= row {
+		rg := p.rowGroups[0]
+		for _, col := range rg.Columns() {
+			name := strings.Join(col.MetaData.PathInSchema, ".")
+			if len(p.pages[name]) > 0 {
+				p.pages[name] = p.pages[name][1:]
+			}
+		}
+		p.rowGroups = p.rowGroups[1:]
+		p.cursor += rg.Rows
+	}
+
+	for p.cursor < row {
+		if !p.Next() {
+			break
+		}
+		p.skip()
+	}
+	return p.err
+}
+
+// Value returns the value of col (the column's path joined by dots)
+// in the row that Next read without scanning the rest of the row.
+// The value of a repeated column is a slice of the row's values.  ok
+// is false if the value is null (or there aren't any values), col
+// isn't one of the columns that are read, or the row has already been
+// scanned.
+func (p *ParquetReader) Value(col string) (interface{}, bool) {
+	if p.err != nil || !p.unscanned {
+		return nil, false
+	}
+
+	f, ok := p.fields[col]
+	if !ok {
+		return nil, false
+	}
+	return f.Value()
+}
+
+// resetRecord sets each of x's fields that are read from parquet
+// back to its zero value so that a reused struct doesn't keep the
+// values of the previous record.
+func resetRecord(x *Person) {
+	var zero Person
+	x.ID = zero.ID
+	x.Name = zero.Name
+	x.Age = zero.Age
+	x.Happiness = zero.Happiness
+	x.Sadness = zero.Sadness
+	x.Code = zero.Code
+	x.Funkiness = zero.Funkiness
+	x.Boldness = zero.Boldness
+	x.Lameness = zero.Lameness
+	x.Keen = zero.Keen
+	x.Birthday = zero.Birthday
+	x.Anniversary = zero.Anniversary
+	x.BFF = zero.BFF
+	x.Hungry = zero.Hungry
+	x.Hobby = zero.Hobby
+	x.Friends = zero.Friends
+	x.Sleepy = zero.Sleepy
+	x.Born = zero.Born
+	x.Died = zero.Died
+}
+
+// resetColumn is like resetRecord but it only resets the
+// field that holds col (or, for a nested column, its struct).
+func resetColumn(x *Person, col string) {
+	var zero Person
+	switch strings.Split(col, ".")[0] {
+	case "id":
+		x.ID = zero.ID
+	case "name":
+		x.Name = zero.Name
+	case "age":
+		x.Age = zero.Age
+	case "happiness":
+		x.Happiness = zero.Happiness
+	case "sadness":
+		x.Sadness = zero.Sadness
+	case "code":
+		x.Code = zero.Code
+	case "funkiness":
+		x.Funkiness = zero.Funkiness
+	case "boldness":
+		x.Boldness = zero.Boldness
+	case "lameness":
+		x.Lameness = zero.Lameness
+	case "keen":
+		x.Keen = zero.Keen
+	case "birthday":
+		x.Birthday = zero.Birthday
+	case "anniversary":
+		x.Anniversary = zero.Anniversary
+	case "bff":
+		x.BFF = zero.BFF
+	case "hungry":
+		x.Hungry = zero.Hungry
+	case "hobby":
+		x.Hobby = zero.Hobby
+	case "friends":
+		x.Friends = zero.Friends
+	case "Sleepy":
+		x.Sleepy = zero.Sleepy
+	case "born":
+		x.Born = zero.Born
+	case "died":
+		x.Died = zero.Died
+	}
+}
+
+// Equal is true if r and other have the same values in each of the
+// fields that are written to parquet (the other fields aren't
+// compared).  Times are compared with time.Time.Equal and a nil
+// slice is equal to an empty one, so a record that has been written
+// and read back is equal to the original.
+func (r Person) Equal(other Person) bool {
+	if r.ID != other.ID {
+		return false
+	}
+	if r.Name != other.Name {
+		return false
+	}
+	if (r.Age == nil) != (other.Age == nil) || (r.Age != nil && *r.Age != *other.Age) {
+		return false
+	}
+	if r.Happiness != other.Happiness {
+		return false
+	}
+	if (r.Sadness == nil) != (other.Sadness == nil) || (r.Sadness != nil && *r.Sadness != *other.Sadness) {
+		return false
+	}
+	if (r.Code == nil) != (other.Code == nil) || (r.Code != nil && *r.Code != *other.Code) {
+		return false
+	}
+	if r.Funkiness != other.Funkiness {
+		return false
+	}
+	if r.Boldness != other.Boldness {
+		return false
+	}
+	if (r.Lameness == nil) != (other.Lameness == nil) || (r.Lameness != nil && *r.Lameness != *other.Lameness) {
+		return false
+	}
+	if (r.Keen == nil) != (other.Keen == nil) || (r.Keen != nil && *r.Keen != *other.Keen) {
+		return false
+	}
+	if r.Birthday != other.Birthday {
+		return false
+	}
+	if (r.Anniversary == nil) != (other.Anniversary == nil) || (r.Anniversary != nil && *r.Anniversary != *other.Anniversary) {
+		return false
+	}
+	if r.BFF != other.BFF {
+		return false
+	}
+	if r.Hungry != other.Hungry {
+		return false
+	}
+	if (r.Hobby == nil) != (other.Hobby == nil) {
+		return false
+	}
+	if r.Hobby != nil {
+		if r.Hobby.Name != other.Hobby.Name {
+			return false
+		}
+		if (r.Hobby.Difficulty == nil) != (other.Hobby.Difficulty == nil) || (r.Hobby.Difficulty != nil && *r.Hobby.Difficulty != *other.Hobby.Difficulty) {
+			return false
+		}
+		if len(r.Hobby.Skills) != len(other.Hobby.Skills) {
+			return false
+		}
+		for i1 := range r.Hobby.Skills {
+			if r.Hobby.Skills[i1].Name != other.Hobby.Skills[i1].Name {
+				return false
+			}
+			if r.Hobby.Skills[i1].Difficulty != other.Hobby.Skills[i1].Difficulty {
+				return false
+			}
+		}
+	}
+	if len(r.Friends) != len(other.Friends) {
+		return false
+	}
+	for i0 := range r.Friends {
+		if r.Friends[i0].ID != other.Friends[i0].ID {
+			return false
+		}
+		if r.Friends[i0].Name != other.Friends[i0].Name {
+			return false
+		}
+		if (r.Friends[i0].Age == nil) != (other.Friends[i0].Age == nil) || (r.Friends[i0].Age != nil && *r.Friends[i0].Age != *other.Friends[i0].Age) {
+			return false
+		}
+	}
+	if r.Sleepy != other.Sleepy {
+		return false
+	}
+	if !r.Born.Equal(other.Born) {
+		return false
+	}
+	if (r.Died == nil) != (other.Died == nil) || (r.Died != nil && !r.Died.Equal(*other.Died)) {
+		return false
 	}
-}
 
-func (f *Int32OptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int32Type, RepetitionType: f.RepetitionType, Types: f.Types}
+	return true
 }
 
-func (f *Int32OptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
-
-	bs := make([]byte, 4)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint32(bs, uint32(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
+// Clone returns a copy of r that doesn't share the pointers
+// and slices of the fields that are written to parquet with r.
+func (r Person) Clone() Person {
+	out := r
+	if r.Age != nil {
+		v0 := *r.Age
+		out.Age = &v0
+	}
+	if r.Sadness != nil {
+		v0 := *r.Sadness
+		out.Sadness = &v0
+	}
+	if r.Code != nil {
+		v0 := *r.Code
+		out.Code = &v0
+	}
+	if r.Lameness != nil {
+		v0 := *r.Lameness
+		out.Lameness = &v0
+	}
+	if r.Keen != nil {
+		v0 := *r.Keen
+		out.Keen = &v0
+	}
+	if r.Anniversary != nil {
+		v0 := *r.Anniversary
+		out.Anniversary = &v0
+	}
+	if r.Hobby != nil {
+		v0 := *r.Hobby
+		if r.Hobby.Difficulty != nil {
+			v1 := *r.Hobby.Difficulty
+			v0.Difficulty = &v1
+		}
+		if r.Hobby.Skills != nil {
+			v0.Skills = make([]Skill, len(r.Hobby.Skills))
+			copy(v0.Skills, r.Hobby.Skills)
+		}
+		out.Hobby = &v0
+	}
+	if r.Friends != nil {
+		out.Friends = make([]Being, len(r.Friends))
+		copy(out.Friends, r.Friends)
+		for i0 := range r.Friends {
+			if r.Friends[i0].Age != nil {
+				v1 := *r.Friends[i0].Age
+				out.Friends[i0].Age = &v1
+			}
 		}
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
-}
-
-func (f *Int32OptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+	if r.Died != nil {
+		v0 := *r.Died
+		out.Died = &v0
 	}
 
-	v := make([]int32, f.Values()-len(f.vals))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
-}
-
-func (f *Int32OptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
+	return out
 }
 
-func (f *Int32OptionalField) Scan(r *Person) {
-	if len(f.Defs) == 0 {
-		return
+// ArrowWriter writes Person records to an Arrow IPC stream (see
+// parquet.ArrowStream) as record batches, using the same functions that
+// read the values of each column for the ParquetWriter.  A column that
+// is repeated isn't written (the stream only has scalar columns), and
+// a nested column is named after its dotted path.  It isn't safe to
+// use from more than one goroutine.
+type ArrowWriter struct {
+	s    *parquet.ArrowStream
+	cols []*parquet.ArrowColumn
+	defs []uint8
+}
+
+// NewArrowWriter returns an ArrowWriter that writes to w.
+func NewArrowWriter(w io.Writer) *ArrowWriter {
+	cols := []*parquet.ArrowColumn{
+		parquet.NewArrowColumn[int32]("id", false),
+		parquet.NewArrowColumn[string]("name", false),
+		parquet.NewArrowColumn[int32]("age", true),
+		parquet.NewArrowColumn[int64]("happiness", false),
+		parquet.NewArrowColumn[int64]("sadness", true),
+		parquet.NewArrowColumn[string]("code", true),
+		parquet.NewArrowColumn[float32]("funkiness", false),
+		parquet.NewArrowColumn[float64]("boldness", false),
+		parquet.NewArrowColumn[float32]("lameness", true),
+		parquet.NewArrowColumn[bool]("keen", true),
+		parquet.NewArrowColumn[uint32]("birthday", false),
+		parquet.NewArrowColumn[uint64]("anniversary", true),
+		parquet.NewArrowColumn[string]("bff", false),
+		parquet.NewArrowColumn[bool]("hungry", false),
+		parquet.NewArrowColumn[string]("hobby.name", true),
+		parquet.NewArrowColumn[int32]("hobby.difficulty", true),
+		parquet.NewArrowColumn[bool]("Sleepy", false),
+		parquet.NewArrowTimeColumn("born", false, parquet.Timestamp{Unit: parquet.Micros, AdjustedToUTC: true}),
+		parquet.NewArrowTimeColumn("died", true, parquet.Timestamp{Unit: parquet.Millis, AdjustedToUTC: false}),
+	}
+	return &ArrowWriter{s: parquet.NewArrowStream(w, cols...), cols: cols, defs: make([]uint8, 0, 1)}
+}
+
+// Add appends the values of rec to the record batch that is
+// written by the next call to Write.
+func (a *ArrowWriter) Add(rec Person) {
+	parquet.AppendArrow(a.cols[0], readID(rec))
+	parquet.AppendArrow(a.cols[1], readName(rec))
+	if vals, defs, _ := readAge(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[2], vals[0])
+	} else {
+		a.cols[2].AppendNull()
 	}
-
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
+	parquet.AppendArrow(a.cols[3], readHappiness(rec))
+	if vals, defs, _ := readSadness(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[4], vals[0])
+	} else {
+		a.cols[4].AppendNull()
 	}
-}
-
-func (f *Int32OptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
-}
-
-type Int64Field struct {
-	vals []int64
-	parquet.RequiredField
-	read  func(r Person) int64
-	write func(r *Person, vals []int64)
-	stats *int64stats
-}
-
-func NewInt64Field(read func(r Person) int64, write func(r *Person, vals []int64), path []string, opts ...func(*parquet.RequiredField)) *Int64Field {
-	return &Int64Field{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newInt64stats(),
+	if vals, defs, _ := readCode(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[5], vals[0])
+	} else {
+		a.cols[5].AppendNull()
 	}
-}
-
-func (f *Int64Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int64Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
-}
-
-func (f *Int64Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+	parquet.AppendArrow(a.cols[6], readFunkiness(rec))
+	parquet.AppendArrow(a.cols[7], readBoldness(rec))
+	if vals, defs, _ := readLameness(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[8], vals[0])
+	} else {
+		a.cols[8].AppendNull()
+	}
+	if vals, defs, _ := readKeen(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[9], vals[0])
+	} else {
+		a.cols[9].AppendNull()
+	}
+	parquet.AppendArrow(a.cols[10], readBirthday(rec))
+	if vals, defs, _ := readAnniversary(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[11], vals[0])
+	} else {
+		a.cols[11].AppendNull()
+	}
+	parquet.AppendArrow(a.cols[12], readBFF(rec))
+	parquet.AppendArrow(a.cols[13], readHungry(rec))
+	if vals, defs, _ := readHobbyName(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		parquet.AppendArrow(a.cols[14], vals[0])
+	} else {
+		a.cols[14].AppendNull()
+	}
+	if vals, defs, _ := readHobbyDifficulty(rec, nil, a.defs[:0], nil); defs[0] == 2 {
+		parquet.AppendArrow(a.cols[15], vals[0])
+	} else {
+		a.cols[15].AppendNull()
+	}
+	parquet.AppendArrow(a.cols[16], readSleepy(rec))
+	a.cols[17].AppendTime(readBorn(rec))
+	if vals, defs, _ := readDied(rec, nil, a.defs[:0], nil); defs[0] == 1 {
+		a.cols[18].AppendTime(vals[0])
+	} else {
+		a.cols[18].AppendNull()
 	}
-
-	v := make([]int64, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
 }
 
-func (f *Int64Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
-
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, uint64(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
-		}
-	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+// Write writes the records that have been added since the
+// last call to Write as a record batch.
+func (a *ArrowWriter) Write() error {
+	return a.s.WriteBatch()
 }
 
-func (f *Int64Field) Scan(r *Person) {
-	if len(f.vals) == 0 {
-		return
+// Close writes the records that haven't been written yet
+// and the end of the stream.  It doesn't close the io.Writer.
+func (a *ArrowWriter) Close() error {
+	if err := a.s.WriteBatch(); err != nil {
+		return err
 	}
-
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
+	return a.s.Close()
 }
 
-func (f *Int64Field) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
-}
+type Int32Field = parquet.NumericField[int32, Person]
 
-func (f *Int64Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+func NewInt32Field(read func(r Person) int32, write func(r *Person, vals []int32), path []string, opts ...func(*parquet.RequiredField)) *Int32Field {
+	return parquet.NewNumericField(read, write, path, opts...)
 }
 
-type Int64OptionalField struct {
-	parquet.OptionalField
-	vals  []int64
-	read  func(r Person, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8)
-	write func(r *Person, vals []int64, defs, reps []uint8) (int, int)
-	stats *int64optionalStats
+type StringField struct {
+	parquet.RequiredField
+	vals         []string
+	read         func(r Person) string
+	write        func(r *Person, vals []string)
+	stats        *stringStats
+	dict         *parquet.Dictionary
+	deltaLength  bool
+	statsOnWrite bool
 }
 
-func NewInt64OptionalField(read func(r Person, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8), write func(r *Person, vals []int64, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int64OptionalField {
-	return &Int64OptionalField{
+func NewStringField(read func(r Person) string, write func(r *Person, vals []string), path []string, opts ...func(*parquet.RequiredField)) *StringField {
+	return &StringField{
 		read:          read,
 		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newint64optionalStats(maxDef(types)),
-	}
-}
-
-func (f *Int64OptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Int64Type, RepetitionType: f.RepetitionType, Types: f.Types}
-}
-
-func (f *Int64OptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
-
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, uint64(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
-		}
+		RequiredField: parquet.NewRequiredField(path, opts...),
+		stats:         newStringStats(),
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
 }
 
-func (f *Int64OptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
-	}
-
-	v := make([]int64, f.Values()-len(f.vals))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+func (f *StringField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, ConvertedType: pconvertedType(sch.ConvertedType_UTF8), LogicalType: &sch.LogicalType{STRING: &sch.StringType{}}, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
 }
 
-func (f *Int64OptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
+// SetDictionary makes the field dictionary encoded.
+func (f *StringField) SetDictionary(d *parquet.Dictionary) {
+	f.dict = d
 }
 
-func (f *Int64OptionalField) Scan(r *Person) {
-	if len(f.Defs) == 0 {
-		return
-	}
-
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
-	}
+// SetDeltaLength makes the field DELTA_LENGTH_BYTE_ARRAY encoded
+// instead of dictionary encoded.
+func (f *StringField) SetDeltaLength() {
+	f.deltaLength = true
+	f.dict = nil
 }
 
-func (f *Int64OptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
+// SetStatsTruncateLength truncates the field's min and max
+// statistics to at most n bytes.
+func (f *StringField) SetStatsTruncateLength(n int) {
+	f.stats.truncate = n
 }
 
-type StringOptionalField struct {
-	parquet.OptionalField
-	vals  []string
-	read  func(r Person, vals []string, def, rep []uint8) ([]string, []uint8, []uint8)
-	write func(r *Person, vals []string, def, rep []uint8) (int, int)
-	stats *stringOptionalStats
+// SetStatsMode sets when the field computes its stats.
+func (f *StringField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
 }
 
-func NewStringOptionalField(read func(r Person, vals []string, def, rep []uint8) ([]string, []uint8, []uint8), write func(r *Person, vals []string, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *StringOptionalField {
-	return &StringOptionalField{
-		read:          read,
-		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newStringOptionalStats(maxDef(types)),
+func (f *StringField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		for _, v := range f.vals {
+			f.stats.add(v)
+		}
 	}
-}
-
-func (f *StringOptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, RepetitionType: f.RepetitionType, Types: f.Types}
-}
-
-func (f *StringOptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
-}
 
-func (f *StringOptionalField) Scan(r *Person) {
-	if len(f.Defs) == 0 {
-		return
+	if f.deltaLength {
+		return f.DoWriteDeltaLength(w, meta, f.vals, f.stats)
 	}
 
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
+	if f.dict != nil {
+		return f.DoWriteDictionary(w, meta, f.dict, f.vals, f.stats)
 	}
-}
 
-func (f *StringOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
 
@@ -1294,244 +3596,295 @@ func (f *StringOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
 		buf.WriteString(s)
 	}
 
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
+	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
 }
 
-func (f *StringOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
+func (f *StringField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	rr, _, err := f.DoRead(r, pg)
 	if err != nil {
 		return err
 	}
+	defer f.Release()
+
+	// each value has at least its length, so n (which comes from
+	// the file) is checked before the values are allocated at once
+	n := f.ReadValues()
+	if n > rr.Len()/4 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
 
-	for j := 0; j < f.Values(); j++ {
+	f.vals = parquet.Reserve(f.vals, n)
+	for j := 0; j < n; j++ {
 		var x int32
 		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
 			return err
 		}
-		s := make([]byte, x)
-		if _, err := rr.Read(s); err != nil {
-			return err
+		if x < 0 || int(x) > rr.Len() {
+			return fmt.Errorf("invalid string length %d", x)
 		}
-
-		f.vals = append(f.vals, string(s))
+		f.vals = append(f.vals, f.Intern(rr.Next(int(x))))
 	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return nil
 }
 
-func (f *StringOptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *StringField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[string](b, f.Name())
 }
 
-type Float32Field struct {
-	vals []float32
-	parquet.RequiredField
-	read  func(r Person) float32
-	write func(r *Person, vals []float32)
-	stats *float32stats
+func (f *StringField) Scan(r *Person) {
+	if len(f.vals) == 0 {
+		return
+	}
+
+	f.write(r, f.vals)
+	f.vals = f.vals[1:]
 }
 
-func NewFloat32Field(read func(r Person) float32, write func(r *Person, vals []float32), path []string, opts ...func(*parquet.RequiredField)) *Float32Field {
-	return &Float32Field{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newFloat32stats(),
+// Value returns the value of the field's next row.
+func (f *StringField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
 	}
+	return f.vals[0], true
 }
 
-func (f *Float32Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Float32Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+func (f *StringField) Add(r Person) {
+	f.add(f.read(r))
 }
 
-func (f *Float32Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
+// AddValues adds the values of rows without records.
+func (f *StringField) AddValues(vals []string) {
+	for _, v := range vals {
+		f.add(v)
 	}
+}
 
-	v := make([]float32, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+func (f *StringField) add(v string) {
+	if !f.statsOnWrite {
+		f.stats.add(v)
+	}
+	if f.dict != nil {
+		f.dict.Add(v)
+	}
+	f.vals = append(f.vals, v)
 }
 
-func (f *Float32Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+func (f *StringField) Levels() ([]uint8, []uint8) {
+	return nil, nil
+}
 
-	bs := make([]byte, 4)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint32(bs, math.Float32bits(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
-		}
-	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+// Rows returns the number of rows that have been added.
+func (f *StringField) Rows() int {
+	return len(f.vals)
 }
 
-func (f *Float32Field) Scan(r *Person) {
-	if len(f.vals) == 0 {
-		return
-	}
+type Int32OptionalField = parquet.OptionalNumericField[int32, Person]
 
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
+func NewInt32OptionalField(read func(r Person, vals []int32, defs, reps []uint8) ([]int32, []uint8, []uint8), write func(r *Person, vals []int32, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int32OptionalField {
+	return parquet.NewOptionalNumericField(read, write, path, types, opts...)
 }
 
-func (f *Float32Field) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
+type Int64Field = parquet.NumericField[int64, Person]
+
+func NewInt64Field(read func(r Person) int64, write func(r *Person, vals []int64), path []string, opts ...func(*parquet.RequiredField)) *Int64Field {
+	return parquet.NewNumericField(read, write, path, opts...)
 }
 
-func (f *Float32Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+type Int64OptionalField = parquet.OptionalNumericField[int64, Person]
+
+func NewInt64OptionalField(read func(r Person, vals []int64, defs, reps []uint8) ([]int64, []uint8, []uint8), write func(r *Person, vals []int64, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Int64OptionalField {
+	return parquet.NewOptionalNumericField(read, write, path, types, opts...)
 }
 
-type Float64Field struct {
-	vals []float64
-	parquet.RequiredField
-	read  func(r Person) float64
-	write func(r *Person, vals []float64)
-	stats *float64stats
+type StringOptionalField struct {
+	parquet.OptionalField
+	vals         []string
+	read         func(r Person, vals []string, def, rep []uint8) ([]string, []uint8, []uint8)
+	write        func(r *Person, vals []string, def, rep []uint8) (int, int)
+	stats        *stringOptionalStats
+	dict         *parquet.Dictionary
+	deltaLength  bool
+	statsOnWrite bool
 }
 
-func NewFloat64Field(read func(r Person) float64, write func(r *Person, vals []float64), path []string, opts ...func(*parquet.RequiredField)) *Float64Field {
-	return &Float64Field{
+func NewStringOptionalField(read func(r Person, vals []string, def, rep []uint8) ([]string, []uint8, []uint8), write func(r *Person, vals []string, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *StringOptionalField {
+	return &StringOptionalField{
 		read:          read,
 		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newFloat64stats(),
+		OptionalField: parquet.NewOptionalField(path, types, opts...),
+		stats:         newStringOptionalStats(maxDef(types)),
 	}
 }
 
-func (f *Float64Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Float64Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
+func (f *StringOptionalField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: StringType, ConvertedType: pconvertedType(sch.ConvertedType_UTF8), LogicalType: &sch.LogicalType{STRING: &sch.StringType{}}, RepetitionType: f.RepetitionType, Types: f.Types}
 }
 
-func (f *Float64Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
-	}
-
-	v := make([]float64, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+func (f *StringOptionalField) Add(r Person) {
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
 }
 
-func (f *Float64Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *StringOptionalField) AddValues(vals []*string) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
 
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, math.Float64bits(v))
-		if _, err := buf.Write(bs); err != nil {
-			return err
+func (f *StringOptionalField) add(vals []string, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	if f.dict != nil {
+		for _, v := range vals[len(f.vals):] {
+			f.dict.Add(v)
 		}
 	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
+	f.vals = vals
+	f.Defs = defs
+	f.Reps = reps
 }
 
-func (f *Float64Field) Scan(r *Person) {
-	if len(f.vals) == 0 {
+func (f *StringOptionalField) Scan(r *Person) {
+	if len(f.Defs) == 0 {
 		return
 	}
 
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
+	v, l := f.write(r, f.vals, f.Defs, f.Reps)
+	f.vals = f.vals[v:]
+	f.Defs = f.Defs[l:]
+	if len(f.Reps) > 0 {
+		f.Reps = f.Reps[l:]
+	}
 }
 
-func (f *Float64Field) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *StringOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
 }
 
-func (f *Float64Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+// SetDictionary makes the field dictionary encoded.
+func (f *StringOptionalField) SetDictionary(d *parquet.Dictionary) {
+	f.dict = d
 }
 
-type Float32OptionalField struct {
-	parquet.OptionalField
-	vals  []float32
-	read  func(r Person, vals []float32, defs, reps []uint8) ([]float32, []uint8, []uint8)
-	write func(r *Person, vals []float32, defs, reps []uint8) (int, int)
-	stats *float32optionalStats
+// SetDeltaLength makes the field DELTA_LENGTH_BYTE_ARRAY encoded
+// instead of dictionary encoded.
+func (f *StringOptionalField) SetDeltaLength() {
+	f.deltaLength = true
+	f.dict = nil
 }
 
-func NewFloat32OptionalField(read func(r Person, vals []float32, defs, reps []uint8) ([]float32, []uint8, []uint8), write func(r *Person, vals []float32, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Float32OptionalField {
-	return &Float32OptionalField{
-		read:          read,
-		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newfloat32optionalStats(maxDef(types)),
-	}
+// SetStatsTruncateLength truncates the field's min and max
+// statistics to at most n bytes.
+func (f *StringOptionalField) SetStatsTruncateLength(n int) {
+	f.stats.truncate = n
 }
 
-func (f *Float32OptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Float32Type, RepetitionType: f.RepetitionType, Types: f.Types}
+// SetStatsMode sets when the field computes its stats.
+func (f *StringOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
 }
 
-func (f *Float32OptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+func (f *StringOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.deltaLength {
+		return f.DoWriteDeltaLength(w, meta, f.vals, len(f.Defs), f.stats)
+	}
+
+	if f.dict != nil {
+		return f.DoWriteDictionary(w, meta, f.dict, f.vals, len(f.Defs), f.stats)
+	}
+
 	buf := buffpool.Get()
 	defer buffpool.Put(buf)
 
 	bs := make([]byte, 4)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint32(bs, math.Float32bits(v))
+	for _, s := range f.vals {
+		binary.LittleEndian.PutUint32(bs, uint32(len(s)))
 		if _, err := buf.Write(bs); err != nil {
 			return err
 		}
+		buf.WriteString(s)
 	}
+
 	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
 }
 
-func (f *Float32OptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
+func (f *StringOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	rr, _, err := f.DoRead(r, pg)
 	if err != nil {
 		return err
 	}
+	defer f.Release()
 
-	v := make([]float32, f.Values()-len(f.vals))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
+	// each value has at least its length, so the number of values
+	// (which comes from the file) is checked before they are allocated
+	n := f.ReadValues()
+	if n > rr.Len()/4 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
+
+	f.vals = parquet.Reserve(f.vals, n)
+	for j := 0; j < n; j++ {
+		var x int32
+		if err := binary.Read(rr, binary.LittleEndian, &x); err != nil {
+			return err
+		}
+		if x < 0 || int(x) > rr.Len() {
+			return fmt.Errorf("invalid string length %d", x)
+		}
+		f.vals = append(f.vals, f.Intern(rr.Next(int(x))))
+	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
+	return nil
 }
 
-func (f *Float32OptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *StringOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[string](b, f.Name())
 }
 
-func (f *Float32OptionalField) Scan(r *Person) {
-	if len(f.Defs) == 0 {
-		return
-	}
+func (f *StringOptionalField) Levels() ([]uint8, []uint8) {
+	return f.Defs, f.Reps
+}
 
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
-	}
+type Float32Field = parquet.NumericField[float32, Person]
+
+func NewFloat32Field(read func(r Person) float32, write func(r *Person, vals []float32), path []string, opts ...func(*parquet.RequiredField)) *Float32Field {
+	return parquet.NewNumericField(read, write, path, opts...)
 }
 
-func (f *Float32OptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
+type Float64Field = parquet.NumericField[float64, Person]
+
+func NewFloat64Field(read func(r Person) float64, write func(r *Person, vals []float64), path []string, opts ...func(*parquet.RequiredField)) *Float64Field {
+	return parquet.NewNumericField(read, write, path, opts...)
+}
+
+type Float32OptionalField = parquet.OptionalNumericField[float32, Person]
+
+func NewFloat32OptionalField(read func(r Person, vals []float32, defs, reps []uint8) ([]float32, []uint8, []uint8), write func(r *Person, vals []float32, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Float32OptionalField {
+	return parquet.NewOptionalNumericField(read, write, path, types, opts...)
 }
 
 type BoolOptionalField struct {
 	parquet.OptionalField
-	vals  []bool
-	read  func(r Person, vals []bool, defs, reps []uint8) ([]bool, []uint8, []uint8)
-	write func(r *Person, vals []bool, defs, reps []uint8) (int, int)
-	stats *boolOptionalStats
+	vals         []bool
+	read         func(r Person, vals []bool, defs, reps []uint8) ([]bool, []uint8, []uint8)
+	write        func(r *Person, vals []bool, defs, reps []uint8) (int, int)
+	stats        *boolOptionalStats
+	statsOnWrite bool
+	byteBools    bool
 }
 
 func NewBoolOptionalField(read func(r Person, vals []bool, defs, reps []uint8) ([]bool, []uint8, []uint8), write func(r *Person, vals []bool, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *BoolOptionalField {
@@ -1552,12 +3905,32 @@ func (f *BoolOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	if err != nil {
 		return err
 	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n == 0 {
+		// every value in the column chunk is null
+		return nil
+	}
+
+	getBools := parquet.GetBools
+	if f.byteBools {
+		getBools = parquet.GetByteBools
+	}
 
-	v, err := parquet.GetBools(rr, f.Values()-len(f.vals), sizes)
+	v, err := getBools(rr, n, sizes)
 	f.vals = append(f.vals, v...)
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return err
 }
 
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *BoolOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[bool](b, f.Name())
+}
+
 func (f *BoolOptionalField) Scan(r *Person) {
 	if len(f.Defs) == 0 {
 		return
@@ -1571,15 +3944,46 @@ func (f *BoolOptionalField) Scan(r *Person) {
 	}
 }
 
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *BoolOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
+}
+
 func (f *BoolOptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
+}
+
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *BoolOptionalField) AddValues(vals []*bool) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
+}
+
+func (f *BoolOptionalField) add(vals []bool, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
+	}
 	f.vals = vals
 	f.Defs = defs
 	f.Reps = reps
 }
 
+// SetStatsMode sets when the field computes its stats.
+func (f *BoolOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
+}
+
 func (f *BoolOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.vals, f.Defs)
+	}
+
+	if f.byteBools {
+		return f.DoWrite(w, meta, parquet.ByteBools(f.vals), len(f.Defs), f.stats)
+	}
+
 	ln := len(f.vals)
 	byteNum := (ln + 7) / 8
 	rawBuf := make([]byte, byteNum)
@@ -1593,154 +3997,35 @@ func (f *BoolOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
 	return f.DoWrite(w, meta, rawBuf, len(f.Defs), f.stats)
 }
 
+// SetByteBools makes the field write (or read) one byte per
+// value instead of one bit (see ByteBools).
+func (f *BoolOptionalField) SetByteBools() {
+	f.byteBools = true
+}
+
 func (f *BoolOptionalField) Levels() ([]uint8, []uint8) {
 	return f.Defs, f.Reps
 }
 
-type Uint32Field struct {
-	vals []uint32
-	parquet.RequiredField
-	read  func(r Person) uint32
-	write func(r *Person, vals []uint32)
-	stats *uint32stats
-}
+type Uint32Field = parquet.NumericField[uint32, Person]
 
 func NewUint32Field(read func(r Person) uint32, write func(r *Person, vals []uint32), path []string, opts ...func(*parquet.RequiredField)) *Uint32Field {
-	return &Uint32Field{
-		read:          read,
-		write:         write,
-		RequiredField: parquet.NewRequiredField(path, opts...),
-		stats:         newUint32stats(),
-	}
-}
-
-func (f *Uint32Field) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Uint32Type, RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
-}
-
-func (f *Uint32Field) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
-	}
-
-	v := make([]uint32, int(pg.N))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
-}
-
-func (f *Uint32Field) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
-
-	bs := make([]byte, 4)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint32(bs, v)
-		if _, err := buf.Write(bs); err != nil {
-			return err
-		}
-	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
-}
-
-func (f *Uint32Field) Scan(r *Person) {
-	if len(f.vals) == 0 {
-		return
-	}
-
-	f.write(r, f.vals)
-	f.vals = f.vals[1:]
-}
-
-func (f *Uint32Field) Add(r Person) {
-	v := f.read(r)
-	f.stats.add(v)
-	f.vals = append(f.vals, v)
-}
-
-func (f *Uint32Field) Levels() ([]uint8, []uint8) {
-	return nil, nil
+	return parquet.NewNumericField(read, write, path, opts...)
 }
 
-type Uint64OptionalField struct {
-	parquet.OptionalField
-	vals  []uint64
-	read  func(r Person, vals []uint64, defs, reps []uint8) ([]uint64, []uint8, []uint8)
-	write func(r *Person, vals []uint64, defs, reps []uint8) (int, int)
-	stats *uint64optionalStats
-}
+type Uint64OptionalField = parquet.OptionalNumericField[uint64, Person]
 
 func NewUint64OptionalField(read func(r Person, vals []uint64, defs, reps []uint8) ([]uint64, []uint8, []uint8), write func(r *Person, vals []uint64, defs, reps []uint8) (int, int), path []string, types []int, opts ...func(*parquet.OptionalField)) *Uint64OptionalField {
-	return &Uint64OptionalField{
-		read:          read,
-		write:         write,
-		OptionalField: parquet.NewOptionalField(path, types, opts...),
-		stats:         newuint64optionalStats(maxDef(types)),
-	}
-}
-
-func (f *Uint64OptionalField) Schema() parquet.Field {
-	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: Uint64Type, RepetitionType: f.RepetitionType, Types: f.Types}
-}
-
-func (f *Uint64OptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
-	buf := buffpool.Get()
-	defer buffpool.Put(buf)
-
-	bs := make([]byte, 8)
-	for _, v := range f.vals {
-		binary.LittleEndian.PutUint64(bs, v)
-		if _, err := buf.Write(bs); err != nil {
-			return err
-		}
-	}
-	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
-}
-
-func (f *Uint64OptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
-	rr, _, err := f.DoRead(r, pg)
-	if err != nil {
-		return err
-	}
-
-	v := make([]uint64, f.Values()-len(f.vals))
-	err = binary.Read(rr, binary.LittleEndian, &v)
-	f.vals = append(f.vals, v...)
-	return err
-}
-
-func (f *Uint64OptionalField) Add(r Person) {
-	vals, defs, reps := f.read(r, f.vals, f.Defs, f.Reps)
-	f.stats.add(vals[len(f.vals):], defs[len(f.Defs):])
-	f.vals = vals
-	f.Defs = defs
-	f.Reps = reps
-}
-
-func (f *Uint64OptionalField) Scan(r *Person) {
-	if len(f.Defs) == 0 {
-		return
-	}
-
-	v, l := f.write(r, f.vals, f.Defs, f.Reps)
-	f.vals = f.vals[v:]
-	f.Defs = f.Defs[l:]
-	if len(f.Reps) > 0 {
-		f.Reps = f.Reps[l:]
-	}
-}
-
-func (f *Uint64OptionalField) Levels() ([]uint8, []uint8) {
-	return f.Defs, f.Reps
+	return parquet.NewOptionalNumericField(read, write, path, types, opts...)
 }
 
 type BoolField struct {
 	parquet.RequiredField
-	vals  []bool
-	read  func(r Person) bool
-	write func(r *Person, vals []bool)
-	stats *boolStats
+	vals      []bool
+	read      func(r Person) bool
+	write     func(r *Person, vals []bool)
+	stats     *boolStats
+	byteBools bool
 }
 
 func NewBoolField(read func(r Person) bool, write func(r *Person, vals []bool), path []string, opts ...func(*parquet.RequiredField)) *BoolField {
@@ -1756,6 +4041,10 @@ func (f *BoolField) Schema() parquet.Field {
 }
 
 func (f *BoolField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.byteBools {
+		return f.DoWrite(w, meta, parquet.ByteBools(f.vals), len(f.vals), newBoolStats())
+	}
+
 	ln := len(f.vals)
 	n := (ln + 7) / 8
 	rawBuf := make([]byte, n)
@@ -1769,16 +4058,37 @@ func (f *BoolField) Write(w io.Writer, meta *parquet.Metadata) error {
 	return f.DoWrite(w, meta, rawBuf, len(f.vals), newBoolStats())
 }
 
+// SetByteBools makes the field write (or read) one byte per
+// value instead of one bit (see ByteBools).
+func (f *BoolField) SetByteBools() {
+	f.byteBools = true
+}
+
 func (f *BoolField) Read(r io.ReadSeeker, pg parquet.Page) error {
 	rr, sizes, err := f.DoRead(r, pg)
 	if err != nil {
 		return err
 	}
+	defer f.Release()
+
+	getBools := parquet.GetBools
+	if f.byteBools {
+		getBools = parquet.GetByteBools
+	}
 
-	f.vals, err = parquet.GetBools(rr, int(pg.N), sizes)
+	v, err := getBools(rr, f.ReadValues(), sizes)
+	f.vals = append(f.vals, v...)
+	f.Buffers().KeepValues(f.Name(), f.vals)
 	return err
 }
 
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *BoolField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[bool](b, f.Name())
+}
+
 func (f *BoolField) Scan(r *Person) {
 	if len(f.vals) == 0 {
 		return
@@ -1788,285 +4098,359 @@ func (f *BoolField) Scan(r *Person) {
 	f.vals = f.vals[1:]
 }
 
+// Value returns the value of the field's next row.
+func (f *BoolField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
+}
+
 func (f *BoolField) Add(r Person) {
 	v := f.read(r)
 	f.vals = append(f.vals, v)
 }
 
+// AddValues adds the values of rows without records.
+func (f *BoolField) AddValues(vals []bool) {
+	f.vals = append(f.vals, vals...)
+}
+
 func (f *BoolField) Levels() ([]uint8, []uint8) {
 	return nil, nil
 }
 
-type int32stats struct {
-	min int32
-	max int32
+// Rows returns the number of rows that have been added.
+func (f *BoolField) Rows() int {
+	return len(f.vals)
 }
 
-func newInt32stats() *int32stats {
-	return &int32stats{
-		min: int32(math.MaxInt32),
-	}
+type TimeField struct {
+	vals []time.Time
+	parquet.RequiredField
+	read         func(r Person) time.Time
+	write        func(r *Person, vals []time.Time)
+	ts           parquet.Timestamp
+	stats        *timeStats
+	statsOnWrite bool
 }
 
-func (i *int32stats) add(val int32) {
-	if val < i.min {
-		i.min = val
-	}
-	if val > i.max {
-		i.max = val
+func NewTimeField(read func(r Person) time.Time, write func(r *Person, vals []time.Time), path []string, ts parquet.Timestamp, opts ...func(*parquet.RequiredField)) *TimeField {
+	return &TimeField{
+		read:          read,
+		write:         write,
+		ts:            ts,
+		RequiredField: parquet.NewRequiredField(path, opts...),
+		stats:         newTimeStats(),
 	}
 }
 
-func (f *int32stats) bytes(v int32) []byte {
-	bs := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bs, uint32(v))
-	return bs
+func (f *TimeField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: TimeType, ConvertedType: f.ts.ConvertedType(), LogicalType: f.ts.LogicalType(), RepetitionType: parquet.RepetitionRequired, Types: []int{0}}
 }
 
-func (f *int32stats) NullCount() *int64 {
-	return nil
-}
+func (f *TimeField) Read(r io.ReadSeeker, pg parquet.Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
 
-func (f *int32stats) DistinctCount() *int64 {
-	return nil
-}
+	n := f.ReadValues()
+	if n > rr.Len()/8 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
 
-func (f *int32stats) Min() []byte {
-	return f.bytes(f.min)
-}
+	v := make([]int64, n)
+	if err := binary.Read(rr, binary.LittleEndian, &v); err != nil {
+		return err
+	}
 
-func (f *int32stats) Max() []byte {
-	return f.bytes(f.max)
-}
+	// the file's TIMESTAMP annotation wins over the field's
+	ts, ok := parquet.TimestampOf(pg.LogicalType, pg.ConvertedType)
+	if !ok {
+		ts = f.ts
+	}
 
-const nilString = "__#NIL#__"
+	f.vals = parquet.Reserve(f.vals, len(v))
+	for _, x := range v {
+		f.vals = append(f.vals, ts.Time(x))
+	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
+	return nil
+}
 
-type stringStats struct {
-	min string
-	max string
+// SetBuffers sets the FieldBuffers that the field's values
+// are decoded into.
+func (f *TimeField) SetBuffers(b *parquet.FieldBuffers) {
+	f.RequiredField.SetBuffers(b)
+	f.vals = parquet.Values[time.Time](b, f.Name())
 }
 
-func newStringStats() *stringStats {
-	return &stringStats{
-		min: nilString,
-		max: nilString,
-	}
+// SetStatsMode sets when the field computes its stats.
+func (f *TimeField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
 }
 
-func (s *stringStats) add(val string) {
-	if s.min == nilString {
-		s.min = val
-	} else {
-		if val < s.min {
-			s.min = val
+func (f *TimeField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		for _, v := range f.vals {
+			f.stats.add(f.ts.Int64(v))
 		}
 	}
-	if s.max == nilString {
-		s.max = val
-	} else {
-		if val > s.max {
-			s.max = val
+
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	bs := make([]byte, 8)
+	for _, v := range f.vals {
+		binary.LittleEndian.PutUint64(bs, uint64(f.ts.Int64(v)))
+		if _, err := buf.Write(bs); err != nil {
+			return err
 		}
 	}
+	return f.DoWrite(w, meta, buf.Bytes(), len(f.vals), f.stats)
 }
 
-func (s *stringStats) NullCount() *int64 {
-	return nil
+func (f *TimeField) Scan(r *Person) {
+	if len(f.vals) == 0 {
+		return
+	}
+
+	f.write(r, f.vals)
+	f.vals = f.vals[1:]
 }
 
-func (s *stringStats) DistinctCount() *int64 {
-	return nil
+// Value returns the value of the field's next row.
+func (f *TimeField) Value() (interface{}, bool) {
+	if len(f.vals) == 0 {
+		return nil, false
+	}
+	return f.vals[0], true
 }
 
-func (s *stringStats) Min() []byte {
-	if s.min == nilString {
-		return nil
+func (f *TimeField) Add(r Person) {
+	f.add(f.read(r))
+}
+
+// AddValues adds the values of rows without records.
+func (f *TimeField) AddValues(vals []time.Time) {
+	for _, v := range vals {
+		f.add(v)
 	}
-	return []byte(s.min)
 }
 
-func (s *stringStats) Max() []byte {
-	if s.max == nilString {
-		return nil
+func (f *TimeField) add(v time.Time) {
+	if !f.statsOnWrite {
+		f.stats.add(f.ts.Int64(v))
 	}
-	return []byte(s.max)
+	f.vals = append(f.vals, v)
 }
 
-type int32optionalStats struct {
-	min     int32
-	max     int32
-	nils    int64
-	nonNils int64
-	maxDef  uint8
+func (f *TimeField) Levels() ([]uint8, []uint8) {
+	return nil, nil
 }
 
-func newint32optionalStats(d uint8) *int32optionalStats {
-	return &int32optionalStats{
-		min:    int32(math.MaxInt32),
-		maxDef: d,
-	}
+// Rows returns the number of rows that have been added.
+func (f *TimeField) Rows() int {
+	return len(f.vals)
 }
 
-func (f *int32optionalStats) add(vals []int32, defs []uint8) {
-	var i int
-	for _, def := range defs {
-		if def < f.maxDef {
-			f.nils++
-		} else {
-			val := vals[i]
-			i++
+type TimeOptionalField struct {
+	parquet.OptionalField
+	vals         []time.Time
+	read         func(r Person, vals []time.Time, defs, reps []uint8) ([]time.Time, []uint8, []uint8)
+	write        func(r *Person, vals []time.Time, defs, reps []uint8) (int, int)
+	ts           parquet.Timestamp
+	stats        *timeOptionalStats
+	statsOnWrite bool
+}
 
-			f.nonNils++
-			if val < f.min {
-				f.min = val
-			}
-			if val > f.max {
-				f.max = val
-			}
-		}
+func NewTimeOptionalField(read func(r Person, vals []time.Time, defs, reps []uint8) ([]time.Time, []uint8, []uint8), write func(r *Person, vals []time.Time, defs, reps []uint8) (int, int), path []string, types []int, ts parquet.Timestamp, opts ...func(*parquet.OptionalField)) *TimeOptionalField {
+	return &TimeOptionalField{
+		read:          read,
+		write:         write,
+		ts:            ts,
+		OptionalField: parquet.NewOptionalField(path, types, opts...),
+		stats:         newTimeOptionalStats(maxDef(types)),
 	}
 }
 
-func (f *int32optionalStats) bytes(v int32) []byte {
-	bs := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bs, uint32(v))
-	return bs
+func (f *TimeOptionalField) Schema() parquet.Field {
+	return parquet.Field{Name: f.Name(), Path: f.Path(), Type: TimeType, ConvertedType: f.ts.ConvertedType(), LogicalType: f.ts.LogicalType(), RepetitionType: f.RepetitionType, Types: f.Types}
 }
 
-func (f *int32optionalStats) NullCount() *int64 {
-	return &f.nils
+// SetStatsMode sets when the field computes its stats.
+func (f *TimeOptionalField) SetStatsMode(m parquet.StatsMode) {
+	f.statsOnWrite = m == parquet.StatsOnWrite
 }
 
-func (f *int32optionalStats) DistinctCount() *int64 {
-	return nil
-}
+func (f *TimeOptionalField) Write(w io.Writer, meta *parquet.Metadata) error {
+	if f.statsOnWrite {
+		f.stats.add(f.ts, f.vals, f.Defs)
+	}
 
-func (f *int32optionalStats) Min() []byte {
-	if f.nonNils == 0 {
-		return nil
+	buf := buffpool.Get()
+	defer buffpool.Put(buf)
+
+	bs := make([]byte, 8)
+	for _, v := range f.vals {
+		binary.LittleEndian.PutUint64(bs, uint64(f.ts.Int64(v)))
+		if _, err := buf.Write(bs); err != nil {
+			return err
+		}
 	}
-	return f.bytes(f.min)
+	return f.DoWrite(w, meta, buf.Bytes(), len(f.Defs), f.stats)
 }
 
-func (f *int32optionalStats) Max() []byte {
-	if f.nonNils == 0 {
+func (f *TimeOptionalField) Read(r io.ReadSeeker, pg parquet.Page) error {
+	rr, _, err := f.DoRead(r, pg)
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+
+	n := f.ReadValues()
+	if n == 0 {
+		// every value in the column chunk is null
 		return nil
 	}
-	return f.bytes(f.max)
-}
 
-type int64stats struct {
-	min int64
-	max int64
-}
+	if n > rr.Len()/8 {
+		return fmt.Errorf("not enough data for %d values", n)
+	}
 
-func newInt64stats() *int64stats {
-	return &int64stats{
-		min: int64(math.MaxInt64),
+	v := make([]int64, n)
+	if err := binary.Read(rr, binary.LittleEndian, &v); err != nil {
+		return err
 	}
-}
 
-func (i *int64stats) add(val int64) {
-	if val < i.min {
-		i.min = val
+	// the file's TIMESTAMP annotation wins over the field's
+	ts, ok := parquet.TimestampOf(pg.LogicalType, pg.ConvertedType)
+	if !ok {
+		ts = f.ts
 	}
-	if val > i.max {
-		i.max = val
+
+	f.vals = parquet.Reserve(f.vals, len(v))
+	for _, x := range v {
+		f.vals = append(f.vals, ts.Time(x))
 	}
+	f.Buffers().KeepValues(f.Name(), f.vals)
+	return nil
 }
 
-func (f *int64stats) bytes(v int64) []byte {
-	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, uint64(v))
-	return bs
+// SetBuffers sets the FieldBuffers that the field's values and
+// levels are decoded into.
+func (f *TimeOptionalField) SetBuffers(b *parquet.FieldBuffers) {
+	f.OptionalField.SetBuffers(b)
+	f.vals = parquet.Values[time.Time](b, f.Name())
 }
 
-func (f *int64stats) NullCount() *int64 {
-	return nil
+func (f *TimeOptionalField) Add(r Person) {
+	f.add(f.read(r, f.vals, f.Defs, f.Reps))
 }
 
-func (f *int64stats) DistinctCount() *int64 {
-	return nil
+// AddValues adds the values (nil for a null) of rows without
+// records.  The field mustn't be repeated.
+func (f *TimeOptionalField) AddValues(vals []*time.Time) {
+	v, defs := parquet.AppendValues(f.vals, f.Defs, vals, f.MaxLevels.Def)
+	f.add(v, defs, f.Reps)
 }
 
-func (f *int64stats) Min() []byte {
-	return f.bytes(f.min)
+func (f *TimeOptionalField) add(vals []time.Time, defs, reps []uint8) {
+	if !f.statsOnWrite {
+		f.stats.add(f.ts, vals[len(f.vals):], defs[len(f.Defs):])
+	}
+	f.vals = vals
+	f.Defs = defs
+	f.Reps = reps
 }
 
-func (f *int64stats) Max() []byte {
-	return f.bytes(f.max)
+func (f *TimeOptionalField) Scan(r *Person) {
+	if len(f.Defs) == 0 {
+		return
+	}
+
+	v, l := f.write(r, f.vals, f.Defs, f.Reps)
+	f.vals = f.vals[v:]
+	f.Defs = f.Defs[l:]
+	if len(f.Reps) > 0 {
+		f.Reps = f.Reps[l:]
+	}
 }
 
-type int64optionalStats struct {
-	min     int64
-	max     int64
-	nils    int64
-	nonNils int64
-	maxDef  uint8
+// Value returns the value of the field's next row (see
+// parquet.OptionalValue).
+func (f *TimeOptionalField) Value() (interface{}, bool) {
+	return parquet.OptionalValue(&f.OptionalField, f.vals)
 }
 
-func newint64optionalStats(d uint8) *int64optionalStats {
-	return &int64optionalStats{
-		min:    int64(math.MaxInt64),
-		maxDef: d,
-	}
+func (f *TimeOptionalField) Levels() ([]uint8, []uint8) {
+	return f.Defs, f.Reps
 }
 
-func (f *int64optionalStats) add(vals []int64, defs []uint8) {
-	var i int
-	for _, def := range defs {
-		if def < f.maxDef {
-			f.nils++
-		} else {
-			val := vals[i]
-			i++
+const nilString = "__#NIL#__"
 
-			f.nonNils++
-			if val < f.min {
-				f.min = val
-			}
-			if val > f.max {
-				f.max = val
-			}
-		}
+type stringStats struct {
+	min      string
+	max      string
+	truncate int
+}
+
+func newStringStats() *stringStats {
+	return &stringStats{
+		min: nilString,
+		max: nilString,
 	}
 }
 
-func (f *int64optionalStats) bytes(v int64) []byte {
-	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, uint64(v))
-	return bs
+func (s *stringStats) add(val string) {
+	if s.min == nilString {
+		s.min = val
+	} else {
+		if val < s.min {
+			s.min = val
+		}
+	}
+	if s.max == nilString {
+		s.max = val
+	} else {
+		if val > s.max {
+			s.max = val
+		}
+	}
 }
 
-func (f *int64optionalStats) NullCount() *int64 {
-	return &f.nils
+func (s *stringStats) NullCount() *int64 {
+	return nil
 }
 
-func (f *int64optionalStats) DistinctCount() *int64 {
+func (s *stringStats) DistinctCount() *int64 {
 	return nil
 }
 
-func (f *int64optionalStats) Min() []byte {
-	if f.nonNils == 0 {
+func (s *stringStats) Min() []byte {
+	if s.min == nilString {
 		return nil
 	}
-	return f.bytes(f.min)
+	return parquet.TruncateMin([]byte(s.min), s.truncate)
 }
 
-func (f *int64optionalStats) Max() []byte {
-	if f.nonNils == 0 {
+func (s *stringStats) Max() []byte {
+	if s.max == nilString {
 		return nil
 	}
-	return f.bytes(f.max)
+	return parquet.TruncateMax([]byte(s.max), s.truncate)
 }
 
 const nilOptString = "__#NIL#__"
 
 type stringOptionalStats struct {
-	min    string
-	max    string
-	nils   int64
-	maxDef uint8
+	min      string
+	max      string
+	nils     int64
+	maxDef   uint8
+	truncate int
 }
 
 func newStringOptionalStats(d uint8) *stringOptionalStats {
@@ -2115,161 +4499,14 @@ func (s *stringOptionalStats) Min() []byte {
 	if s.min == nilOptString {
 		return nil
 	}
-	return []byte(s.min)
+	return parquet.TruncateMin([]byte(s.min), s.truncate)
 }
 
 func (s *stringOptionalStats) Max() []byte {
 	if s.max == nilOptString {
 		return nil
 	}
-	return []byte(s.max)
-}
-
-type float32stats struct {
-	min float32
-	max float32
-}
-
-func newFloat32stats() *float32stats {
-	return &float32stats{
-		min: float32(math.MaxFloat32),
-	}
-}
-
-func (i *float32stats) add(val float32) {
-	if val < i.min {
-		i.min = val
-	}
-	if val > i.max {
-		i.max = val
-	}
-}
-
-func (f *float32stats) bytes(v float32) []byte {
-	bs := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bs, math.Float32bits(v))
-	return bs
-}
-
-func (f *float32stats) NullCount() *int64 {
-	return nil
-}
-
-func (f *float32stats) DistinctCount() *int64 {
-	return nil
-}
-
-func (f *float32stats) Min() []byte {
-	return f.bytes(f.min)
-}
-
-func (f *float32stats) Max() []byte {
-	return f.bytes(f.max)
-}
-
-type float64stats struct {
-	min float64
-	max float64
-}
-
-func newFloat64stats() *float64stats {
-	return &float64stats{
-		min: float64(math.MaxFloat64),
-	}
-}
-
-func (i *float64stats) add(val float64) {
-	if val < i.min {
-		i.min = val
-	}
-	if val > i.max {
-		i.max = val
-	}
-}
-
-func (f *float64stats) bytes(v float64) []byte {
-	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, math.Float64bits(v))
-	return bs
-}
-
-func (f *float64stats) NullCount() *int64 {
-	return nil
-}
-
-func (f *float64stats) DistinctCount() *int64 {
-	return nil
-}
-
-func (f *float64stats) Min() []byte {
-	return f.bytes(f.min)
-}
-
-func (f *float64stats) Max() []byte {
-	return f.bytes(f.max)
-}
-
-type float32optionalStats struct {
-	min     float32
-	max     float32
-	nils    int64
-	nonNils int64
-	maxDef  uint8
-}
-
-func newfloat32optionalStats(d uint8) *float32optionalStats {
-	return &float32optionalStats{
-		min:    float32(math.MaxFloat32),
-		maxDef: d,
-	}
-}
-
-func (f *float32optionalStats) add(vals []float32, defs []uint8) {
-	var i int
-	for _, def := range defs {
-		if def < f.maxDef {
-			f.nils++
-		} else {
-			val := vals[i]
-			i++
-
-			f.nonNils++
-			if val < f.min {
-				f.min = val
-			}
-			if val > f.max {
-				f.max = val
-			}
-		}
-	}
-}
-
-func (f *float32optionalStats) bytes(v float32) []byte {
-	bs := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bs, math.Float32bits(v))
-	return bs
-}
-
-func (f *float32optionalStats) NullCount() *int64 {
-	return &f.nils
-}
-
-func (f *float32optionalStats) DistinctCount() *int64 {
-	return nil
-}
-
-func (f *float32optionalStats) Min() []byte {
-	if f.nonNils == 0 {
-		return nil
-	}
-	return f.bytes(f.min)
-}
-
-func (f *float32optionalStats) Max() []byte {
-	if f.nonNils == 0 {
-		return nil
-	}
-	return f.bytes(f.max)
+	return parquet.TruncateMax([]byte(s.max), s.truncate)
 }
 
 type boolOptionalStats struct {
@@ -2305,127 +4542,132 @@ func (b *boolOptionalStats) Max() []byte {
 	return nil
 }
 
-type uint32stats struct {
-	min uint32
-	max uint32
+type boolStats struct{}
+
+func newBoolStats() *boolStats             { return &boolStats{} }
+func (b *boolStats) NullCount() *int64     { return nil }
+func (b *boolStats) DistinctCount() *int64 { return nil }
+func (b *boolStats) Min() []byte           { return nil }
+func (b *boolStats) Max() []byte           { return nil }
+
+type timeStats struct {
+	min int64
+	max int64
 }
 
-func newUint32stats() *uint32stats {
-	return &uint32stats{
-		min: uint32(math.MaxUint32),
+func newTimeStats() *timeStats {
+	return &timeStats{
+		min: math.MaxInt64,
+		max: math.MinInt64,
 	}
 }
 
-func (i *uint32stats) add(val uint32) {
-	if val < i.min {
-		i.min = val
+func (t *timeStats) add(val int64) {
+	if val < t.min {
+		t.min = val
 	}
-	if val > i.max {
-		i.max = val
+	if val > t.max {
+		t.max = val
 	}
 }
 
-func (f *uint32stats) bytes(v uint32) []byte {
-	bs := make([]byte, 4)
-	binary.LittleEndian.PutUint32(bs, v)
+func (t *timeStats) bytes(v int64) []byte {
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, uint64(v))
 	return bs
 }
 
-func (f *uint32stats) NullCount() *int64 {
+func (t *timeStats) NullCount() *int64 {
 	return nil
 }
 
-func (f *uint32stats) DistinctCount() *int64 {
+func (t *timeStats) DistinctCount() *int64 {
 	return nil
 }
 
-func (f *uint32stats) Min() []byte {
-	return f.bytes(f.min)
+func (t *timeStats) Min() []byte {
+	return t.bytes(t.min)
 }
 
-func (f *uint32stats) Max() []byte {
-	return f.bytes(f.max)
+func (t *timeStats) Max() []byte {
+	return t.bytes(t.max)
 }
 
-type uint64optionalStats struct {
-	min     uint64
-	max     uint64
+type timeOptionalStats struct {
+	min     int64
+	max     int64
 	nils    int64
 	nonNils int64
 	maxDef  uint8
 }
 
-func newuint64optionalStats(d uint8) *uint64optionalStats {
-	return &uint64optionalStats{
-		min:    uint64(math.MaxUint64),
+func newTimeOptionalStats(d uint8) *timeOptionalStats {
+	return &timeOptionalStats{
+		min:    math.MaxInt64,
+		max:    math.MinInt64,
 		maxDef: d,
 	}
 }
 
-func (f *uint64optionalStats) add(vals []uint64, defs []uint8) {
+func (t *timeOptionalStats) add(ts parquet.Timestamp, vals []time.Time, defs []uint8) {
 	var i int
 	for _, def := range defs {
-		if def < f.maxDef {
-			f.nils++
+		if def < t.maxDef {
+			t.nils++
 		} else {
-			val := vals[i]
+			val := ts.Int64(vals[i])
 			i++
 
-			f.nonNils++
-			if val < f.min {
-				f.min = val
+			t.nonNils++
+			if val < t.min {
+				t.min = val
 			}
-			if val > f.max {
-				f.max = val
+			if val > t.max {
+				t.max = val
 			}
 		}
 	}
 }
 
-func (f *uint64optionalStats) bytes(v uint64) []byte {
+func (t *timeOptionalStats) bytes(v int64) []byte {
 	bs := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bs, v)
+	binary.LittleEndian.PutUint64(bs, uint64(v))
 	return bs
 }
 
-func (f *uint64optionalStats) NullCount() *int64 {
-	return &f.nils
+func (t *timeOptionalStats) NullCount() *int64 {
+	return &t.nils
 }
 
-func (f *uint64optionalStats) DistinctCount() *int64 {
+func (t *timeOptionalStats) DistinctCount() *int64 {
 	return nil
 }
 
-func (f *uint64optionalStats) Min() []byte {
-	if f.nonNils == 0 {
+func (t *timeOptionalStats) Min() []byte {
+	if t.nonNils == 0 {
 		return nil
 	}
-	return f.bytes(f.min)
+	return t.bytes(t.min)
 }
 
-func (f *uint64optionalStats) Max() []byte {
-	if f.nonNils == 0 {
+func (t *timeOptionalStats) Max() []byte {
+	if t.nonNils == 0 {
 		return nil
 	}
-	return f.bytes(f.max)
+	return t.bytes(t.max)
 }
 
-type boolStats struct{}
-
-func newBoolStats() *boolStats             { return &boolStats{} }
-func (b *boolStats) NullCount() *int64     { return nil }
-func (b *boolStats) DistinctCount() *int64 { return nil }
-func (b *boolStats) Min() []byte           { return nil }
-func (b *boolStats) Max() []byte           { return nil }
-
-func pint32(i int32) *int32       { return &i }
-func puint32(i uint32) *uint32    { return &i }
-func pint64(i int64) *int64       { return &i }
-func puint64(i uint64) *uint64    { return &i }
-func pbool(b bool) *bool          { return &b }
-func pstring(s string) *string    { return &s }
-func pfloat32(f float32) *float32 { return &f }
-func pfloat64(f float64) *float64 { return &f }
+func pint32(i int32) *int32                                 { return &i }
+func puint32(i uint32) *uint32                              { return &i }
+func pint64(i int64) *int64                                 { return &i }
+func puint64(i uint64) *uint64                              { return &i }
+func pbool(b bool) *bool                                    { return &b }
+func pstring(s string) *string                              { return &s }
+func pfloat32(f float32) *float32                           { return &f }
+func pfloat64(f float64) *float64                           { return &f }
+func ptimeTime(t time.Time) *time.Time                      { return &t }
+func pparquetFloat16(f parquet.Float16) *parquet.Float16    { return &f }
+func pconvertedType(c sch.ConvertedType) *sch.ConvertedType { return &c }
 
 // keeps track of the indices of repeated fields
 // that have already been handled by a previous field
@@ -2459,8 +4701,6 @@ func Int32Type(se *sch.SchemaElement) {
 func Uint32Type(se *sch.SchemaElement) {
 	t := sch.Type_INT32
 	se.Type = &t
-	ct := sch.ConvertedType_UINT_32
-	se.ConvertedType = &ct
 }
 
 func Int64Type(se *sch.SchemaElement) {
@@ -2471,8 +4711,6 @@ func Int64Type(se *sch.SchemaElement) {
 func Uint64Type(se *sch.SchemaElement) {
 	t := sch.Type_INT64
 	se.Type = &t
-	ct := sch.ConvertedType_UINT_64
-	se.ConvertedType = &ct
 }
 
 func Float32Type(se *sch.SchemaElement) {
@@ -2494,3 +4732,8 @@ func StringType(se *sch.SchemaElement) {
 	t := sch.Type_BYTE_ARRAY
 	se.Type = &t
 }
+
+func TimeType(se *sch.SchemaElement) {
+	t := sch.Type_INT64
+	se.Type = &t
+}
@@ -2,21 +2,35 @@ package parquet_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/golang/snappy"
 	"github.com/rclayton-godaddy/parquet"
+	"github.com/rclayton-godaddy/parquet/parquettest"
 	sch "github.com/rclayton-godaddy/parquet/schema"
 	"github.com/stretchr/testify/assert"
 )
 
-//go:generate parquetgen -input parquet_test.go -type Person -package parquet_test -output parquet_generated_test.go
+//go:generate parquetgen -input parquet_test.go -type Person -package parquet_test -output parquet_generated_test.go -helpers -arrow -split-writer -json -dump -sorted -projection Summary:id,age,hobby,friends,born
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
@@ -26,6 +40,8 @@ func init() {
 }
 
 var (
+	update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
 	letterRunes      = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	compressionCases = []string{"uncompressed", "snappy"}
 )
@@ -415,6 +431,24 @@ func TestParquet(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "optional columns all nil multiple pages",
+			pageSize: 2,
+			input: [][]Person{
+				{
+					{Being: Being{ID: 1}},
+					{Being: Being{ID: 2}},
+					{Being: Being{ID: 3}},
+					{Being: Being{ID: 4}},
+					{Being: Being{ID: 5}},
+				},
+				{
+					{Being: Being{ID: 6}},
+					{Being: Being{ID: 7}},
+					{Being: Being{ID: 8}},
+				},
+			},
+		},
 		{
 			name:     "repeated two pages",
 			pageSize: 2,
@@ -532,326 +566,5819 @@ func TestPageHeaders(t *testing.T) {
 		return
 	}
 
-	assert.Equal(t, 88, len(pageHeaders))
+	assert.Equal(t, 96, len(pageHeaders))
 }
 
-func TestStats(t *testing.T) {
-	type stats struct {
-		min      []byte
-		max      []byte
-		nilCount *int64
+func TestSchemaTree(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	type testCase struct {
-		name     string
-		input    [][]Person
-		pageSize int
-		stats    []stats
-		col      string
+	w.Add(Person{})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	testCases := []testCase{
-		{
-			name: "int64 single stat",
-			col:  "happiness",
-			input: [][]Person{
-				{
-					{Happiness: 1},
-					{Happiness: 2},
-					{Happiness: 22},
-				},
-			},
-			stats: []stats{
-				{min: writeInt64(1), max: writeInt64(22)},
-			},
-		},
-		{
-			name:     "int64 two pages",
-			col:      "happiness",
-			pageSize: 2,
-			input: [][]Person{
-				{
-					{Happiness: 1},
-					{Happiness: 2},
-					{Happiness: 22},
-				},
-			},
-			stats: []stats{
-				{min: writeInt64(1), max: writeInt64(2)},
-				{min: writeInt64(22), max: writeInt64(22)},
-			},
-		},
-		{
-			name: "int64 no stats",
-			col:  "happiness",
-			input: [][]Person{
-				{
-					{Being: Being{ID: 1}},
-					{Being: Being{ID: 2}},
-					{Being: Being{ID: 3}},
-				},
-			},
-			stats: []stats{
-				{min: writeInt64(0), max: writeInt64(0)},
-			},
-		},
-		{
-			name: "optional int64 no stats",
-			col:  "sadness",
-			input: [][]Person{
-				{
-					{Being: Being{ID: 1}},
-					{Being: Being{ID: 2}},
-					{Being: Being{ID: 3}},
-				},
-			},
-			stats: []stats{
-				{min: nil, max: nil, nilCount: pint64(3)},
-			},
-		},
-		{
-			name: "int32 stats",
-			col:  "birthday",
-			input: [][]Person{
-				{
-					{Birthday: 10},
-					{Birthday: 20},
-					{Birthday: 30},
-				},
-			},
-			stats: []stats{
-				{min: writeInt32(10), max: writeInt32(30)},
-			},
-		},
-		{
-			name: "float64 stats",
-			col:  "boldness",
-			input: [][]Person{
-				{
-					{Boldness: 0.5},
-					{Boldness: 500.0},
-					{Boldness: -50.5},
-				},
-			},
-			stats: []stats{
-				{min: writeFloat64(-50.5), max: writeFloat64(500.0)},
-			},
-		},
-		{
-			name: "float32 optional stats",
-			col:  "lameness",
-			input: [][]Person{
-				{
-					{Lameness: pfloat32(0.5)},
-					{Lameness: pfloat32(500.0)},
-					{Lameness: pfloat32(50.5)},
-					{Lameness: nil},
-				},
-			},
-			stats: []stats{
-				{min: writeFloat32(0.5), max: writeFloat32(500.0), nilCount: pint64(1)},
-			},
-		},
-		{
-			name: "bool stats",
-			col:  "hungry",
-			input: [][]Person{
-				{
-					{Hungry: true},
-					{Hungry: false},
-					{Hungry: true},
-				},
-			},
-			stats: []stats{
-				{},
-			},
-		},
-		{
-			name: "optional bool stats",
-			col:  "keen",
-			input: [][]Person{
-				{
-					{Keen: pbool(true)},
-					{Keen: nil},
-					{Keen: nil},
-				},
-			},
-			stats: []stats{
-				{nilCount: pint64(2)},
-			},
-		},
-		{
-			name: "string stats",
-			col:  "bff",
-			input: [][]Person{
-				{
-					{BFF: "Fred"},
-					{BFF: "Val"},
-					{BFF: "Miranda"},
-				},
-			},
-			stats: []stats{
-				{min: []byte("Fred"), max: []byte("Val")},
-			},
-		},
-		{
-			name: "string optional stats",
-			col:  "code",
-			input: [][]Person{
-				{
-					{Code: pstring("Fred")},
-					{Code: nil},
-					{Code: pstring("Miranda")},
-				},
-			},
-			stats: []stats{
-				{min: []byte("Fred"), max: []byte("Miranda"), nilCount: pint64(1)},
-			},
-		},
+	root, err := r.SchemaTree()
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	for i, tc := range testCases {
-		for j, comp := range []string{"uncompressed", "snappy"} {
-			t.Run(fmt.Sprintf("%02d %s %s", 2*i+j, tc.name, comp), func(t *testing.T) {
-				if tc.pageSize == 0 {
-					tc.pageSize = 100
-				}
-				var buf bytes.Buffer
-				w, err := NewParquetWriter(&buf, MaxPageSize(tc.pageSize), compressionTest[comp])
-				assert.Nil(t, err, tc.name)
-				for _, rowgroup := range tc.input {
-					for _, p := range rowgroup {
-						w.Add(p)
-					}
-					assert.Nil(t, w.Write(), tc.name)
-				}
+	assert.Equal(t, "Person", root.Name)
+	assert.Equal(t, 19, len(root.Children))
 
-				err = w.Close()
-				assert.Nil(t, err, tc.name)
+	hobby := root.Children[14]
+	assert.Equal(t, "hobby", hobby.Name)
+	assert.False(t, hobby.Leaf())
+	assert.Equal(t, 3, len(hobby.Children))
 
-				r := bytes.NewReader(buf.Bytes())
-				footer, err := parquet.ReadMetaData(r)
-				if !assert.NoError(t, err) {
-					return
-				}
+	skills := hobby.Children[2]
+	assert.Equal(t, "skills", skills.Name)
+	assert.Equal(t, sch.FieldRepetitionType_REPEATED, skills.RepetitionType)
+	assert.Equal(t, 2, len(skills.Children))
 
-				pages, err := getPageHeaders(r, tc.col, footer)
-				if !assert.NoError(t, err) {
-					return
-				}
+	difficulty := skills.Children[1]
+	assert.True(t, difficulty.Leaf())
+	assert.Equal(t, []string{"hobby", "skills", "difficulty"}, difficulty.Path)
+	assert.Equal(t, uint8(2), difficulty.MaxDef)
+	assert.Equal(t, uint8(1), difficulty.MaxRep)
 
-				if !assert.Equal(t, len(tc.stats), len(pages), tc.name) {
-					return
-				}
-				for i, st := range tc.stats {
-					ph := pages[i]
-					assert.Equal(t, st.min, ph.DataPageHeader.Statistics.MinValue)
-					assert.Equal(t, st.max, ph.DataPageHeader.Statistics.MaxValue)
-					if st.nilCount == nil {
-						assert.Equal(t, st.nilCount, ph.DataPageHeader.Statistics.NullCount)
-					} else {
-						assert.Equal(t, *st.nilCount, *ph.DataPageHeader.Statistics.NullCount)
-					}
-				}
-			})
-		}
-	}
+	friends := root.Children[15]
+	assert.Equal(t, "friends", friends.Name)
+	assert.Equal(t, 3, len(friends.Children))
+	assert.Equal(t, uint8(2), friends.Children[2].MaxDef)
 }
 
-func getPageHeaders(r io.ReadSeeker, name string, footer *sch.FileMetaData) ([]sch.PageHeader, error) {
-	var out []sch.PageHeader
-	for _, rg := range footer.RowGroups {
-		for _, col := range rg.Columns {
-			pth := col.MetaData.PathInSchema
-			if pth[len(pth)-1] == name {
-				h, err := parquet.PageHeadersAtOffset(r, col.MetaData.DataPageOffset, col.MetaData.NumValues)
-				if err != nil {
-					return nil, err
-				}
-				out = append(out, h...)
-			}
-		}
+// TestStringAnnotation checks that string columns are annotated
+// as UTF8 so that other readers (Hive and Presto, for example)
+// don't treat them as binary.
+func TestStringAnnotation(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
 	}
-	return out, nil
-}
 
-var compressionTest = map[string]func(*ParquetWriter) error{
-	"uncompressed": Uncompressed,
-	"snappy":       Snappy,
-	"gzip":         Gzip,
+	w.Add(Person{})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	root, err := r.SchemaTree()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var n int
+	var check func(*parquet.SchemaNode)
+	check = func(node *parquet.SchemaNode) {
+		for _, ch := range node.Children {
+			check(ch)
+		}
+
+		if !node.Leaf() || *node.Type != sch.Type_BYTE_ARRAY {
+			return
+		}
+
+		n++
+		if assert.NotNil(t, node.ConvertedType, node.Name) {
+			assert.Equal(t, sch.ConvertedType_UTF8, *node.ConvertedType, node.Name)
+		}
+		if assert.NotNil(t, node.LogicalType, node.Name) {
+			assert.NotNil(t, node.LogicalType.STRING, node.Name)
+		}
+	}
+	check(root)
+	assert.Equal(t, 7, n)
+}
+
+// TestSchemaFields checks that a file's schema, with its types and
+// annotations, is the same after it's read and written again.
+func TestSchemaFields(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w.Add(Person{})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fields, err := r.SchemaFields()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, f := range fields {
+		if f.Name == "code" {
+			assert.Equal(t, "code BYTE_ARRAY OPTIONAL STRING", f.String())
+		}
+	}
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var copied bytes.Buffer
+	meta := parquet.New(fields...)
+	meta.SetSchemaName(footer.Schema[0].Name)
+	assert.NoError(t, parquet.WriteHeader(&copied))
+	assert.NoError(t, meta.WriteTrailer(&copied))
+
+	copiedFooter, err := parquet.ReadMetaData(bytes.NewReader(copied.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	want, err := parquet.SchemaTree(footer.Schema)
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, err := parquet.SchemaTree(copiedFooter.Schema)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, got)
+	}
+
+	_, err = parquet.New().SchemaFields()
+	assert.EqualError(t, err, "no footer, you must call ReadFooter first")
+}
+
+func TestSchemaName(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, SchemaName("people"), MaxPageSize(1))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w.Add(Person{})
+	w.Add(Person{})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "people", footer.Schema[0].Name)
+	}
+
+	_, err = NewParquetWriter(&buf, SchemaName(""))
+	assert.EqualError(t, err, "invalid schema name, it can't be empty")
+}
+
+func TestSchemaOverride(t *testing.T) {
+	ts := parquet.Timestamp{Unit: parquet.Millis, AdjustedToUTC: true}
+	overrides := map[string]parquet.Field{
+		"happiness":  {LogicalType: ts.LogicalType(), ConvertedType: ts.ConvertedType()},
+		"name":       {Name: "full_name"},
+		"hobby.name": {Path: []string{"pastime", "title"}},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, SchemaOverride(overrides))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w.Add(Person{Being: Being{ID: 1, Name: "a"}, Happiness: 1000, Hobby: &Hobby{Name: "golf"}})
+	w.Add(Person{Being: Being{ID: 2, Name: "b"}, Happiness: 2000})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var paths []string
+	for _, ch := range footer.RowGroups[0].Columns {
+		paths = append(paths, strings.Join(ch.MetaData.PathInSchema, "."))
+	}
+	assert.Contains(t, paths, "full_name")
+	assert.Contains(t, paths, "pastime.title")
+	assert.NotContains(t, paths, "name")
+
+	// the values are written the same way, so
+	// they read back with the new schema
+	meta := parquet.New()
+	r := bytes.NewReader(buf.Bytes())
+	if !assert.NoError(t, meta.ReadFooter(r)) {
+		return
+	}
+	fields, err := meta.SchemaFields()
+	if !assert.NoError(t, err) {
+		return
+	}
+	schema := map[string]parquet.Field{}
+	for _, f := range fields {
+		schema[strings.Join(f.Path, ".")] = f
+	}
+	assert.NotContains(t, schema, "name")
+	assert.NotContains(t, schema, "hobby.name")
+	if assert.Contains(t, schema, "happiness") {
+		tm, ok := parquet.TimestampOf(schema["happiness"].LogicalType, schema["happiness"].ConvertedType)
+		assert.True(t, ok)
+		assert.Equal(t, ts, tm)
+	}
+
+	// the renamed columns are still strings
+	for _, col := range []string{"full_name", "pastime.title"} {
+		if assert.Contains(t, schema, col) {
+			assert.NotNil(t, schema[col].LogicalType.GetSTRING())
+		}
+	}
+
+	pages, err := meta.Pages()
+	if assert.NoError(t, err) {
+		assert.Len(t, pages["full_name"], 1)
+		assert.Len(t, pages["pastime.title"], 1)
+	}
+
+	testCases := []struct {
+		name      string
+		overrides map[string]parquet.Field
+		err       string
+	}{
+		{
+			name:      "no such column",
+			overrides: map[string]parquet.Field{"nope": {Name: "yep"}},
+			err:       "invalid schema override, there is no column nope",
+		},
+		{
+			name:      "physical type",
+			overrides: map[string]parquet.Field{"happiness": {Type: Int32Type}},
+			err:       "invalid schema override for column happiness: its physical type is INT32, not INT64",
+		},
+		{
+			name:      "repetition type",
+			overrides: map[string]parquet.Field{"happiness": {RepetitionType: parquet.RepetitionOptional, Types: []int{1}}},
+			err:       "invalid schema override for column happiness: the repetition type of happiness is OPTIONAL, not REQUIRED",
+		},
+		{
+			name:      "path length",
+			overrides: map[string]parquet.Field{"hobby.name": {Path: []string{"hobby_name"}}},
+			err:       "invalid schema override for column hobby.name: it has 2 repetition types for a path of length 1",
+		},
+		{
+			name:      "same path",
+			overrides: map[string]parquet.Field{"name": {Name: "code"}},
+			err:       "invalid schema override, columns name and code would both have the path code",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewParquetWriter(&bytes.Buffer{}, SchemaOverride(tc.overrides))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestFieldSchemaShared(t *testing.T) {
+	want := make([]string, len(fieldSchema))
+	for i, f := range fieldSchema {
+		want[i] = f.String()
+	}
+
+	ts := parquet.Timestamp{Unit: parquet.Millis}
+	overrides := map[string]parquet.Field{
+		"happiness": {LogicalType: ts.LogicalType()},
+	}
+
+	// the writers and readers share the schema, so it must
+	// be the same after they have all been used at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var opts []func(*ParquetWriter) error
+			if i%2 == 0 {
+				opts = append(opts, SchemaOverride(overrides))
+			}
+
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, append(opts, MaxPageSize(2))...)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, p := range getPeople(5, 5)[0] {
+				w.Add(p)
+			}
+			if err := w.Write(); err != nil {
+				errs <- err
+				return
+			}
+			if err := w.Close(); err != nil {
+				errs <- err
+				return
+			}
+
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				errs <- err
+				return
+			}
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+			}
+			errs <- r.Error()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	got := make([]string, len(fieldSchema))
+	for i, f := range fieldSchema {
+		got[i] = f.String()
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestEqualClone(t *testing.T) {
+	input := getPeople(10, 5)[0]
+	input[1].Hobby = &Hobby{Name: "golf", Difficulty: pint32(3), Skills: []Skill{{Name: "putting", Difficulty: "hard"}}}
+	input[2].Friends = []Being{{ID: 1, Name: "a", Age: pint32(30)}, {ID: 2}}
+	input[3].Secret = "shh"
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, p := range input {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// Secret isn't written, so it isn't compared
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Len(t, out, len(input)) {
+		for i, p := range out {
+			assert.True(t, input[i].Equal(p), i)
+		}
+	}
+
+	c := input[1].Clone()
+	assert.True(t, input[1].Equal(c))
+	*c.Hobby.Difficulty = 4
+	c.Hobby.Skills[0].Name = "driving"
+	assert.False(t, input[1].Equal(c))
+	assert.Equal(t, int32(3), *input[1].Hobby.Difficulty)
+	assert.Equal(t, "putting", input[1].Hobby.Skills[0].Name)
+
+	c = input[2].Clone()
+	*c.Friends[0].Age = 31
+	assert.False(t, input[2].Equal(c))
+	assert.Equal(t, int32(30), *input[2].Friends[0].Age)
+}
+
+func TestColumnChunkLocation(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, rowgroup := range getPeople(5, 10) {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	rd := bytes.NewReader(buf.Bytes())
+	r, err := NewParquetReader(rd)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for rg := 0; rg < 2; rg++ {
+		offset, length, err := r.ColumnChunkLocation(rg, "happiness")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.True(t, length > 0)
+		pages, err := parquet.PageHeadersAtOffset(rd, offset, 5)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int32(5), pages[0].DataPageHeader.NumValues)
+		}
+	}
+
+	_, _, err = r.ColumnChunkLocation(2, "happiness")
+	assert.Error(t, err)
+
+	_, _, err = r.ColumnChunkLocation(0, "bogus")
+	var unknown *parquet.UnknownColumnError
+	assert.True(t, errors.As(err, &unknown))
+}
+
+func TestColumnCompressedSize(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, rowgroup := range getPeople(5, 15) {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, col := range []string{"happiness", "hobby.name", "friends.age"} {
+		var expected int64
+		for rg := 0; rg < 3; rg++ {
+			_, length, err := r.ColumnChunkLocation(rg, col)
+			if assert.NoError(t, err) {
+				expected += length
+			}
+		}
+
+		size, err := r.ColumnCompressedSize(col)
+		if assert.NoError(t, err, col) {
+			assert.True(t, size > 0, col)
+			assert.Equal(t, expected, size, col)
+		}
+	}
+
+	_, err = r.ColumnCompressedSize("bogus")
+	var unknown *parquet.UnknownColumnError
+	assert.True(t, errors.As(err, &unknown))
+
+	_, err = parquet.New().ColumnCompressedSize("happiness")
+	assert.EqualError(t, err, "no footer, you must call ReadFooter first")
+}
+
+func TestLevels(t *testing.T) {
+	testCases := []struct {
+		name   string
+		max    uint8
+		levels []uint8
+		err    bool
+	}{
+		{name: "max 1", max: 1, levels: []uint8{0, 1, 1, 0, 1}},
+		{name: "max 2", max: 2, levels: []uint8{0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 0}},
+		{name: "max 3", max: 3, levels: []uint8{3, 0, 2, 1, 3, 3, 0, 1, 2}},
+		{name: "max 7", max: 7, levels: []uint8{7, 6, 5, 4, 3, 2, 1, 0, 7}},
+		{name: "max 15", max: 15, levels: []uint8{15, 8, 0, 15, 15, 15, 15, 15, 15, 15, 15, 15, 1}},
+		{name: "level greater than max", max: 2, levels: []uint8{0, 3}, err: true},
+		{name: "max too large", max: 16, levels: []uint8{0}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := parquet.WriteLevels(&buf, tc.levels, tc.max)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			l := buf.Len()
+			levels, n, err := parquet.ReadLevels(&buf, tc.max, len(tc.levels))
+			if assert.NoError(t, err) {
+				assert.Equal(t, l, n)
+				assert.Equal(t, tc.levels, levels)
+			}
+		})
+	}
+}
+
+func TestConvertedTypes(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the values don't fit in an int32 or int64, so they
+	// are only right if they are read as unsigned
+	people := []Person{
+		{Birthday: 1, Anniversary: puint64(2)},
+		{Birthday: math.MaxUint32, Anniversary: puint64(math.MaxUint64)},
+	}
+	for _, p := range people {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, people, out)
+	}
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	elems := map[string]*sch.SchemaElement{}
+	for _, se := range footer.Schema {
+		elems[se.Name] = se
+	}
+
+	birthday := elems["birthday"]
+	assert.Equal(t, sch.ConvertedType_UINT_32, birthday.GetConvertedType())
+	assert.Equal(t, &sch.IntType{BitWidth: 32}, birthday.GetLogicalType().INTEGER)
+
+	anniversary := elems["anniversary"]
+	assert.Equal(t, sch.ConvertedType_UINT_64, anniversary.GetConvertedType())
+	assert.Equal(t, &sch.IntType{BitWidth: 64}, anniversary.GetLogicalType().INTEGER)
+
+	happiness := elems["happiness"]
+	assert.False(t, happiness.IsSetConvertedType())
+	assert.False(t, happiness.IsSetLogicalType())
+}
+
+func TestTimestamps(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	died := time.Date(2050, 1, 2, 3, 4, 5, 6e6, time.Local)
+	w.Add(Person{Born: time.Date(1980, 1, 2, 3, 4, 5, 6e3, time.UTC), Died: &died})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	elems := map[string]*sch.SchemaElement{}
+	for _, se := range footer.Schema {
+		elems[se.Name] = se
+	}
+
+	born := elems["born"].GetLogicalType().TIMESTAMP
+	assert.True(t, born.IsAdjustedToUTC)
+	assert.NotNil(t, born.Unit.MICROS)
+	assert.Equal(t, sch.ConvertedType_TIMESTAMP_MICROS, elems["born"].GetConvertedType())
+
+	d := elems["died"].GetLogicalType().TIMESTAMP
+	assert.False(t, d.IsAdjustedToUTC)
+	assert.NotNil(t, d.Unit.MILLIS)
+	assert.False(t, elems["died"].IsSetConvertedType())
+
+	// the reader uses the unit from the file, not the generated field
+	buf.Reset()
+	buf.Write([]byte("PAR1"))
+	ts := parquet.Timestamp{Unit: parquet.Millis, AdjustedToUTC: true}
+	fld := parquet.Field{Name: "born", Path: []string{"born"}, Types: []int{0}, Type: TimeType, RepetitionType: parquet.RepetitionRequired, LogicalType: ts.LogicalType()}
+	meta := parquet.New(fld)
+	meta.NextDoc()
+
+	col := parquet.NewRequiredField(fld.Path, parquet.RequiredFieldUncompressed)
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt64(1500), 1, valueStats(writeInt64(1500)))) {
+		return
+	}
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	people, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Equal(t, 1, len(people)) {
+		assert.Equal(t, time.Unix(1, 5e8).UTC(), people[0].Born)
+	}
+}
+
+func TestMagic(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	w.Add(Person{Being: Being{ID: 1}})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	b := buf.Bytes()
+	assert.Equal(t, "PAR1", string(b[:4]))
+	assert.Equal(t, "PAR1", string(b[len(b)-4:]))
+
+	testCases := []struct {
+		name string
+		b    []byte
+		err  string
+	}{
+		{
+			name: "no header",
+			b:    append([]byte("PAR0"), b[4:]...),
+			err:  `not a parquet file, it starts with "PAR0" instead of "PAR1"`,
+		},
+		{
+			name: "no trailer",
+			b:    append(append([]byte{}, b[:len(b)-4]...), "PARX"...),
+			err:  `not a parquet file, it ends with "PARX" instead of "PAR1"`,
+		},
+		{
+			name: "too short",
+			b:    []byte("PA"),
+			err:  "not a parquet file, unable to read the header: unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewParquetReader(bytes.NewReader(tc.b))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+// dripReader returns at most n bytes from each call to Read.
+type dripReader struct {
+	*bytes.Reader
+	n int
+}
+
+func (d *dripReader) Read(p []byte) (int, error) {
+	if len(p) > d.n {
+		p = p[:d.n]
+	}
+	return d.Reader.Read(p)
+}
+
+func TestLargeFooter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// a row group per row makes a footer that is much
+	// larger than any single Read of dripReader
+	input := getPeople(1, 500)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	b := buf.Bytes()
+	size := binary.LittleEndian.Uint32(b[len(b)-8:])
+	assert.True(t, size > 100000, "footer is only %d bytes", size)
+
+	footer, err := parquet.ReadMetaData(&dripReader{Reader: bytes.NewReader(b), n: 3})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, footer.RowGroups, 500)
+	assert.Equal(t, int64(500), footer.NumRows)
+
+	r, err := NewParquetReader(&dripReader{Reader: bytes.NewReader(b), n: 3})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 500, i)
+}
+
+// TestFooterProtocol decodes the footer with thrift's protocols instead
+// of the package's own reader.  The compact protocol (which the spec
+// requires) is the default.
+func TestFooterProtocol(t *testing.T) {
+	write := func(opts ...func(*ParquetWriter) error) []byte {
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range goldenPeople()[0] {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+		assert.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	compact := write()
+	expected, err := parquet.ReadMetaData(bytes.NewReader(compact))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	testCases := []struct {
+		name     string
+		opts     []func(*ParquetWriter) error
+		protocol func(thrift.TTransport) thrift.TProtocol
+	}{
+		{
+			name:     "default",
+			protocol: func(tt thrift.TTransport) thrift.TProtocol { return thrift.NewTCompactProtocol(tt) },
+		},
+		{
+			name:     "compact",
+			opts:     []func(*ParquetWriter) error{FooterProtocol(parquet.CompactProtocol)},
+			protocol: func(tt thrift.TTransport) thrift.TProtocol { return thrift.NewTCompactProtocol(tt) },
+		},
+		{
+			name:     "binary",
+			opts:     []func(*ParquetWriter) error{FooterProtocol(parquet.BinaryProtocol)},
+			protocol: func(tt thrift.TTransport) thrift.TProtocol { return thrift.NewTBinaryProtocolTransport(tt) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := write(tc.opts...)
+			size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+			footer := b[len(b)-8-size : len(b)-8]
+
+			tb := thrift.NewTMemoryBufferLen(size)
+			tb.Write(footer)
+			var m sch.FileMetaData
+			if !assert.NoError(t, m.Read(tc.protocol(tb))) {
+				return
+			}
+
+			// the whole footer is used
+			assert.Equal(t, 0, tb.Len())
+			assert.Equal(t, *expected, m)
+		})
+	}
+
+	// the reader only reads compact footers
+	_, err = parquet.ReadMetaData(bytes.NewReader(write(FooterProtocol(parquet.BinaryProtocol))))
+	assert.Error(t, err)
+
+	_, err = NewParquetWriter(&bytes.Buffer{}, FooterProtocol(2))
+	assert.EqualError(t, err, "invalid footer protocol ThriftProtocol(2)")
+}
+
+func TestPageIndex(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		w.Add(Person{Happiness: int64(i)})
+		if i == 4 {
+			assert.NoError(t, w.Write())
+		}
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// the writer doesn't write page indexes
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	pages, err := r.PageIndex(0, "happiness")
+	assert.NoError(t, err)
+	assert.Nil(t, pages)
+
+	b, err := addPageIndex(buf.Bytes(), "happiness")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r, err = NewParquetReader(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	type page struct {
+		firstRow int64
+		min, max int64
+	}
+
+	expected := [][]page{
+		{{firstRow: 0, min: 0, max: 2}, {firstRow: 3, min: 3, max: 4}},
+		{{firstRow: 0, min: 5, max: 7}, {firstRow: 3, min: 8, max: 9}},
+	}
+
+	for rg, exp := range expected {
+		pages, err := r.PageIndex(rg, "happiness")
+		if !assert.NoError(t, err) || !assert.Len(t, pages, len(exp)) {
+			return
+		}
+
+		for i, pg := range pages {
+			assert.Equal(t, exp[i].firstRow, pg.FirstRow)
+			assert.Equal(t, writeInt64(exp[i].min), pg.Min)
+			assert.Equal(t, writeInt64(exp[i].max), pg.Max)
+
+			// the offset is where the page's header is
+			br := bytes.NewReader(b)
+			br.Seek(pg.Offset, io.SeekStart)
+			ph, err := parquet.PageHeader(br)
+			if assert.NoError(t, err) {
+				assert.Equal(t, writeInt64(exp[i].max), ph.DataPageHeader.Statistics.MaxValue)
+			}
+		}
+	}
+
+	// the pages that can't have a happiness of 6 can be skipped
+	skipped, err := r.PageSkip("happiness", func(pg parquet.IndexedPage) bool {
+		return int64(binary.LittleEndian.Uint64(pg.Max)) < 6 || int64(binary.LittleEndian.Uint64(pg.Min)) > 6
+	})
+	if assert.NoError(t, err) && assert.Len(t, skipped, 2) {
+		assert.Len(t, skipped[0], 2)
+		if assert.Len(t, skipped[1], 1) {
+			assert.Equal(t, int64(3), skipped[1][0].FirstRow)
+		}
+	}
+
+	_, err = r.PageIndex(0, "nope")
+	assert.EqualError(t, err, "unknown field: nope")
+
+	_, err = r.PageIndex(2, "happiness")
+	assert.EqualError(t, err, "row group 2 out of range, there are 2 row groups")
+
+	// the file can still be read
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, int64(i), p.Happiness)
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 10, i)
+}
+
+func TestSuggestRowGroupRows(t *testing.T) {
+	people := getPeople(20000, 20000)[0]
+	for _, target := range []int64{16 << 10, 256 << 10} {
+		t.Run(fmt.Sprintf("%d bytes", target), func(t *testing.T) {
+			n, err := SuggestRowGroupRows(people[:500], target, Gzip)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// a row group of the suggested size is close to the target
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, Gzip)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, p := range people[:n] {
+				w.Add(p)
+			}
+			assert.NoError(t, w.Write())
+
+			size := int64(buf.Len() - 4)
+			assert.True(t, size > target*3/4 && size < target*5/4, "%d rows are %d bytes", n, size)
+		})
+	}
+
+	n, err := SuggestRowGroupRows(people[:10], 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = SuggestRowGroupRows(nil, 1<<20)
+	assert.EqualError(t, err, "the sample must have at least 1 row")
+
+	_, err = SuggestRowGroupRows(people[:10], 0)
+	assert.EqualError(t, err, "invalid target size 0, it must be at least 1")
+
+	_, err = SuggestRowGroupRows(people[:10], 1<<20, MaxPageSize(0))
+	assert.Error(t, err)
+
+	// Write doesn't write a row group, so the sample has no size
+	_, err = SuggestRowGroupRows(people[:10], 1<<20, SingleRowGroup)
+	assert.EqualError(t, err, "the sample wasn't written by Write, so its size is unknown (SingleRowGroup can't be used)")
+}
+
+func TestPageAlignment(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []func(*ParquetWriter) error
+	}{
+		{name: "v1", opts: []func(*ParquetWriter) error{Uncompressed}},
+		{name: "v2", opts: []func(*ParquetWriter) error{DataPageV2, Gzip}},
+		{name: "dictionary with page index", opts: []func(*ParquetWriter) error{Dictionary, PageIndex(true)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, append(tc.opts, PageAlignment(512), MaxPageSize(3))...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := getPeople(5, 12)
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			b := buf.Bytes()
+			r, err := NewParquetReader(bytes.NewReader(b))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			footer, err := parquet.ReadMetaData(bytes.NewReader(b))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// every chunk starts at a multiple of the alignment
+			// and only zeros come between one chunk and the next
+			end := int64(4)
+			for i, rg := range footer.RowGroups {
+				for _, ch := range rg.Columns {
+					col := strings.Join(ch.MetaData.PathInSchema, ".")
+					offset, length, err := r.ColumnChunkLocation(i, col)
+					if !assert.NoError(t, err, col) {
+						return
+					}
+
+					assert.Equal(t, int64(0), offset%512, col)
+					assert.Equal(t, make([]byte, offset-end), b[end:offset], col)
+					end = offset + length
+
+					// the pages of the offset index are in the chunk
+					if ch.OffsetIndexOffset != nil {
+						pages, err := r.PageIndex(i, col)
+						if assert.NoError(t, err, col) {
+							last := pages[len(pages)-1]
+							assert.Equal(t, end, last.Offset+int64(last.Size), col)
+						}
+					}
+				}
+			}
+
+			var i int
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				assert.Equal(t, *getExpected(input, i), p)
+				i++
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, 12, i)
+
+			c, err := r.ReadColumn("happiness")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			i = 0
+			for c.Next() {
+				var p Person
+				c.Scan(&p)
+				assert.Equal(t, getExpected(input, i).Happiness, p.Happiness)
+				i++
+			}
+			assert.NoError(t, c.Error())
+			assert.Equal(t, 12, i)
+		})
+	}
+
+	_, err := NewParquetWriter(&bytes.Buffer{}, PageAlignment(0))
+	assert.EqualError(t, err, "invalid page alignment 0, it must be at least 1")
+}
+
+func TestSyncMarkers(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, SyncMarkers(true), PageIndex(true))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var input []Person
+	for i := 0; i < 9; i++ {
+		p := Person{Being: Being{ID: int32(i), Age: pint32(int32(i))}, Code: pstring(fmt.Sprint(i))}
+		input = append(input, p)
+		w.Add(p)
+		if i%3 == 2 {
+			assert.NoError(t, w.Write())
+		}
+	}
+	assert.NoError(t, w.Close())
+
+	// the sync markers are skipped by the reader
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, input, out)
+	}
+
+	meta := parquet.New()
+	if !assert.NoError(t, meta.ReadFooter(bytes.NewReader(buf.Bytes()))) {
+		return
+	}
+	offset, _, err := meta.ColumnChunkLocation(2, "id")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the writer stopped while it was writing the last row group
+	b := append([]byte{}, buf.Bytes()[:offset+10]...)
+	_, err = SafeRead(bytes.NewReader(b))
+	assert.Error(t, err)
+
+	recovered, err := parquet.Recover(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(6), recovered.Rows())
+
+	fixed := bytes.NewBuffer(b)
+	assert.NoError(t, recovered.WriteFooter(fixed))
+	out, err = SafeRead(bytes.NewReader(fixed.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, input[:6], out)
+	}
+
+	// a file without sync markers can't be recovered
+	buf.Reset()
+	w, err = NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	w.Add(input[0])
+	assert.NoError(t, w.Write())
+	_, err = parquet.Recover(bytes.NewReader(buf.Bytes()))
+	assert.EqualError(t, err, "unable to recover the file, it doesn't have any sync markers")
+}
+
+func TestWritePageIndex(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []func(*ParquetWriter) error
+	}{
+		{name: "v1", opts: []func(*ParquetWriter) error{Uncompressed}},
+		{name: "v2", opts: []func(*ParquetWriter) error{DataPageV2}},
+		{name: "dictionary", opts: []func(*ParquetWriter) error{Dictionary, Snappy}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, append(tc.opts, PageIndex(true), MaxPageSize(3))...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := goldenPeople()
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			b := buf.Bytes()
+			r, err := NewParquetReader(bytes.NewReader(b))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			footer, err := parquet.ReadMetaData(bytes.NewReader(b))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// the indexes are between the last column chunk and the footer
+			last := footer.RowGroups[len(footer.RowGroups)-1].Columns
+			end := last[len(last)-1].FileOffset + last[len(last)-1].MetaData.TotalCompressedSize
+			size := int64(binary.LittleEndian.Uint32(b[len(b)-8:]))
+			var indexes int64
+
+			for i, rg := range footer.RowGroups {
+				for _, ch := range rg.Columns {
+					col := strings.Join(ch.MetaData.PathInSchema, ".")
+					name := fmt.Sprintf("row group %d, column %s", i, col)
+					if !assert.NotNil(t, ch.OffsetIndexOffset, name) {
+						return
+					}
+					indexes += int64(*ch.OffsetIndexLength)
+					if ch.ColumnIndexLength != nil {
+						indexes += int64(*ch.ColumnIndexLength)
+					}
+
+					pages, err := r.PageIndex(i, col)
+					if !assert.NoError(t, err, name) {
+						return
+					}
+
+					var rows, values int64
+					for j, pg := range pages {
+						br := bytes.NewReader(b)
+						br.Seek(pg.Offset, io.SeekStart)
+						ph, err := parquet.PageHeader(br)
+						if !assert.NoError(t, err, name) {
+							return
+						}
+						pos, _ := br.Seek(int64(ph.CompressedPageSize), io.SeekCurrent)
+						assert.Equal(t, int64(pg.Size), pos-pg.Offset, name)
+						if j == 0 {
+							assert.Equal(t, ch.MetaData.DataPageOffset, pg.Offset, name)
+						}
+						if ph.DataPageHeaderV2 != nil {
+							assert.Equal(t, rows, pg.FirstRow, name)
+						} else {
+							// v1 headers don't have the number of rows
+							assert.True(t, pg.FirstRow >= rows, name)
+							rows = pg.FirstRow
+						}
+
+						var st *sch.Statistics
+						if ph.DataPageHeaderV2 != nil {
+							st = ph.DataPageHeaderV2.Statistics
+							rows += int64(ph.DataPageHeaderV2.NumRows)
+							values += int64(ph.DataPageHeaderV2.NumValues)
+						} else {
+							st = ph.DataPageHeader.Statistics
+							values += int64(ph.DataPageHeader.NumValues)
+						}
+
+						if ch.ColumnIndexOffset != nil {
+							assert.Equal(t, st.MinValue == nil, pg.Null, name)
+							if !pg.Null {
+								assert.Equal(t, st.MinValue, pg.Min, name)
+								assert.Equal(t, st.MaxValue, pg.Max, name)
+							}
+						}
+					}
+					assert.Equal(t, ch.MetaData.NumValues, values, name)
+				}
+			}
+			assert.Equal(t, int64(len(b))-8-size, end+indexes)
+
+			// the rows of a page of a required column are its values
+			pages, err := r.PageIndex(1, "happiness")
+			if assert.NoError(t, err) && assert.True(t, len(pages) > 1) {
+				assert.Equal(t, int64(3), pages[1].FirstRow)
+			}
+
+			// there is a null count for each page of an optional column
+			pages, err = r.PageIndex(0, "sadness")
+			if assert.NoError(t, err) {
+				for _, pg := range pages {
+					assert.NotNil(t, pg.NullCount)
+				}
+			}
+
+			var i int
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				assert.Equal(t, *getExpected(input, i), p)
+				i++
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, 25, i)
+		})
+	}
+}
+
+// addPageIndex adds an offset index and a column index (made from the
+// statistics in the page headers) for the required column col to the
+// parquet file b.
+func TestWritePageIndexSchemaOverride(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, PageIndex(true), MaxPageSize(3), SchemaOverride(map[string]parquet.Field{
+		"id": {Name: "ident"},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		w.Add(Person{Being: Being{ID: int32(i)}})
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// the renamed column has page indexes like the others
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, ch := range footer.RowGroups[0].Columns {
+		col := strings.Join(ch.MetaData.PathInSchema, ".")
+		assert.NotNil(t, ch.OffsetIndexOffset, col)
+		if col == "ident" {
+			assert.NotNil(t, ch.ColumnIndexOffset, col)
+		}
+	}
+
+	meta := parquet.New()
+	r := bytes.NewReader(buf.Bytes())
+	if !assert.NoError(t, meta.ReadFooter(r)) {
+		return
+	}
+	pages, err := meta.PageIndex(r, 0, "ident")
+	if assert.NoError(t, err) && assert.Len(t, pages, 4) {
+		assert.Equal(t, writeInt32(0), pages[0].Min)
+		assert.Equal(t, writeInt32(9), pages[3].Max)
+	}
+}
+
+func addPageIndex(b []byte, col string) ([]byte, error) {
+	footer, err := parquet.ReadMetaData(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(b[len(b)-8:])
+	out := append([]byte{}, b[:len(b)-8-int(size)]...)
+
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	write := func(s thrift.TStruct) (int64, int32, error) {
+		data, err := ts.Write(context.Background(), s)
+		offset := int64(len(out))
+		out = append(out, data...)
+		return offset, int32(len(data)), err
+	}
+
+	for _, rg := range footer.RowGroups {
+		for _, ch := range rg.Columns {
+			if strings.Join(ch.MetaData.PathInSchema, ".") != col {
+				continue
+			}
+
+			oi := sch.NewOffsetIndex()
+			ci := sch.NewColumnIndex()
+			r := bytes.NewReader(b)
+			offset := ch.MetaData.DataPageOffset
+			r.Seek(offset, io.SeekStart)
+			var rows int64
+			for rows < ch.MetaData.NumValues {
+				ph, err := parquet.PageHeader(r)
+				if err != nil {
+					return nil, err
+				}
+				end, _ := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent)
+
+				oi.PageLocations = append(oi.PageLocations, &sch.PageLocation{Offset: offset, CompressedPageSize: int32(end - offset), FirstRowIndex: rows})
+				ci.NullPages = append(ci.NullPages, false)
+				ci.MinValues = append(ci.MinValues, ph.DataPageHeader.Statistics.MinValue)
+				ci.MaxValues = append(ci.MaxValues, ph.DataPageHeader.Statistics.MaxValue)
+				rows += int64(ph.DataPageHeader.NumValues)
+				offset = end
+			}
+
+			oo, ol, err := write(oi)
+			if err != nil {
+				return nil, err
+			}
+			ch.OffsetIndexOffset, ch.OffsetIndexLength = &oo, &ol
+
+			co, cl, err := write(ci)
+			if err != nil {
+				return nil, err
+			}
+			ch.ColumnIndexOffset, ch.ColumnIndexLength = &co, &cl
+		}
+	}
+
+	data, err := ts.Write(context.Background(), footer)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, data...)
+	out = appendUint32(out, uint32(len(data)))
+	return append(out, "PAR1"...), nil
+}
+
+// setFooter returns the parquet file b with its footer
+// changed by fn.
+func setFooter(b []byte, fn func(*sch.FileMetaData)) ([]byte, error) {
+	footer, err := parquet.ReadMetaData(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	fn(footer)
+
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	data, err := ts.Write(context.Background(), footer)
+	if err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(b[len(b)-8:])
+	out := append([]byte{}, b[:len(b)-8-int(size)]...)
+	out = append(out, data...)
+	out = appendUint32(out, uint32(len(data)))
+	return append(out, "PAR1"...), nil
+}
+
+func TestThriftUnknownField(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	w.Add(Person{Being: Being{ID: 1}})
+	assert.NoError(t, w.Close())
+
+	// the footer gets a binary field (with an id that FileMetaData
+	// doesn't have) that says it is far longer than the file, which
+	// is skipped without allocating it
+	b := buf.Bytes()
+	size := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := b[len(b)-8-size : len(b)-9] // without its STOP
+	field := []byte{0x08}                 // a BINARY field with a long form id
+	field = appendUvarint(field, 1000<<1)
+	field = appendUvarint(field, 1<<30)
+	field = append(field, 0) // the footer's STOP
+
+	out := append([]byte{}, b[:len(b)-8-size]...)
+	out = append(out, footer...)
+	out = append(out, field...)
+	out = appendUint32(out, uint32(len(footer)+len(field)))
+	out = append(out, "PAR1"...)
+
+	_, err = NewParquetReader(bytes.NewReader(out))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid thrift length 1073741824")
+	}
+}
+
+// appendUint32 appends n to b in little endian order.
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+// appendUvarint appends n to b as a varint.
+func appendUvarint(b []byte, n uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], n)]...)
+}
+
+func TestBloomFilterOffset(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, Uncompressed)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(5, 10)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	// a bloom filter (that isn't a page) is put between the
+	// last column chunk and the footer
+	b := buf.Bytes()
+	end := int64(len(b) - 8 - int(binary.LittleEndian.Uint32(b[len(b)-8:])))
+	bloom := bytes.Repeat([]byte{0xff}, 32)
+	b = append(append(append([]byte{}, b[:end]...), bloom...), b[end:]...)
+
+	// every column chunk says its bloom filter is there, and the last
+	// one's size includes it (as some writers do)
+	var col string
+	b, err = setFooter(b, func(footer *sch.FileMetaData) {
+		for _, rg := range footer.RowGroups {
+			for _, ch := range rg.Columns {
+				ch.MetaData.BloomFilterOffset = &end
+			}
+		}
+		last := footer.RowGroups[len(footer.RowGroups)-1].Columns
+		last[len(last)-1].MetaData.TotalCompressedSize += int64(len(bloom))
+		col = strings.Join(last[len(last)-1].MetaData.PathInSchema, ".")
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out, err := SafeRead(bytes.NewReader(b))
+	if assert.NoError(t, err) {
+		var expected []Person
+		for i := 0; i < 10; i++ {
+			expected = append(expected, *getExpected(input, i))
+		}
+		assert.Equal(t, expected, out)
+	}
+
+	r, err := NewParquetReader(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var pages int
+	assert.NoError(t, r.ForEachPage(col, func(sch.PageHeader) error {
+		pages++
+		return nil
+	}))
+	assert.Equal(t, 2, pages)
+}
+
+func TestRepeatedValuesAndRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := []Person{
+		{Being: Being{ID: 1}, Friends: []Being{{ID: 2}, {ID: 3}, {ID: 4}}},
+		{Being: Being{ID: 2}},
+		{Being: Being{ID: 3}, Friends: []Being{{ID: 1}}},
+		{Being: Being{ID: 4}, Friends: []Being{{ID: 1}, {ID: 2}}},
+		{Being: Being{ID: 5}},
+	}
+	for _, p := range input {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// the chunk has a value for each friend and a null for
+	// each person without friends, but only 5 rows
+	meta := parquet.New()
+	if !assert.NoError(t, meta.ReadFooter(bytes.NewReader(buf.Bytes()))) {
+		return
+	}
+	pages, err := meta.Pages()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 8, pages["friends.id"][0].N)
+	assert.Equal(t, int64(5), pages["friends.id"][0].Rows)
+	assert.Equal(t, 5, pages["id"][0].N)
+	assert.Equal(t, int64(5), pages["id"][0].Rows)
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, input, out)
+	}
+
+	// a chunk that has a slightly different number of values than its
+	// metadata says is read with the values in its pages, but one whose
+	// metadata is far off is an error
+	testCases := []struct {
+		numValues int64
+		err       string
+	}{
+		{numValues: 7},
+		{numValues: 9},
+		{numValues: 6, err: "column friends.id has 8 values but its column chunk says it has 6, which is too far off for the file to be trusted"},
+		{numValues: 80, err: "column friends.id has 8 values but its column chunk says it has 80, which is too far off for the file to be trusted"},
+	}
+
+	for _, tc := range testCases {
+		b, err := setFooter(buf.Bytes(), func(footer *sch.FileMetaData) {
+			for _, ch := range footer.RowGroups[0].Columns {
+				if strings.Join(ch.MetaData.PathInSchema, ".") == "friends.id" {
+					ch.MetaData.NumValues = tc.numValues
+				}
+			}
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		out, err = SafeRead(bytes.NewReader(b))
+		if tc.err == "" {
+			if assert.NoError(t, err, tc.numValues) {
+				assert.Equal(t, input, out, tc.numValues)
+			}
+			continue
+		}
+
+		var re *parquet.ReadColumnError
+		if assert.True(t, errors.As(err, &re), tc.numValues) {
+			assert.EqualError(t, re.Err, tc.err)
+		}
+	}
+}
+
+func TestRequiredValues(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var input []Person
+	for i := 0; i < 5; i++ {
+		p := Person{Being: Being{ID: int32(i)}}
+		input = append(input, p)
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// the id column has 3 pages of 5 values.  Only the values in the
+	// pages are read, so a footer that is a little off isn't an error,
+	// but pages that don't have a value for each row are, and so is
+	// a footer that is short (or corrupt) enough not to be trusted.
+	testCases := []struct {
+		name      string
+		numValues int64
+		rows      int64
+		err       string
+	}{
+		{name: "fewer", numValues: 4},
+		{name: "more", numValues: 6},
+		{
+			name:      "fewer rows",
+			numValues: 3,
+			rows:      3,
+			err:       "column id has 5 values but its row group has 3 rows (and its column chunk says it has 3 values)",
+		},
+		{
+			name:      "short",
+			numValues: 2,
+			err:       "column id has 2 values but the row group has 5 rows",
+		},
+		{
+			name:      "negative",
+			numValues: -1,
+			err:       "column id has -1 values",
+		},
+		{
+			name:      "too many",
+			numValues: 1 << 40,
+			err:       "column id has 5 values but its column chunk says it has 1099511627776, which is too far off for the file to be trusted",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := setFooter(buf.Bytes(), func(footer *sch.FileMetaData) {
+				if tc.rows > 0 {
+					footer.NumRows = tc.rows
+					footer.RowGroups[0].NumRows = tc.rows
+				}
+				for _, ch := range footer.RowGroups[0].Columns {
+					if strings.Join(ch.MetaData.PathInSchema, ".") == "id" {
+						ch.MetaData.NumValues = tc.numValues
+					}
+				}
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			out, err := SafeRead(bytes.NewReader(b))
+			if tc.err == "" {
+				if assert.NoError(t, err) {
+					assert.Equal(t, input, out)
+				}
+				return
+			}
+
+			assert.Error(t, err)
+			assert.Contains(t, fmt.Sprint(err), tc.err)
+		})
+	}
+}
+
+func TestReadColumnError(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, Uncompressed)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, rowgroup := range getPeople(3, 6) {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	// the name column of the second row group says it's
+	// snappy but its pages aren't compressed
+	var offset int64
+	b, err := setFooter(buf.Bytes(), func(footer *sch.FileMetaData) {
+		for _, ch := range footer.RowGroups[1].Columns {
+			if strings.Join(ch.MetaData.PathInSchema, ".") == "name" {
+				ch.MetaData.Codec = sch.CompressionCodec_SNAPPY
+				offset = ch.MetaData.DataPageOffset
+			}
+		}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = SafeRead(bytes.NewReader(b))
+	var re *parquet.ReadColumnError
+	if assert.True(t, errors.As(err, &re)) {
+		assert.Equal(t, "name", re.Column)
+		assert.Equal(t, 1, re.RowGroup)
+		assert.Equal(t, offset, re.Offset)
+		assert.Equal(t, re.Err, errors.Unwrap(re))
+		assert.Contains(t, err.Error(), fmt.Sprintf("unable to read field name (row group 1, offset %d), err: ", offset))
+	}
+}
+
+func TestOptionalRowGroups(t *testing.T) {
+	// the nulls are spread unevenly across the row groups: the first
+	// has every value, the second none, and the others some
+	groups := [][]Person{
+		{{Sadness: pint64(1), Code: pstring("a"), Keen: pbool(true)}, {Sadness: pint64(2), Code: pstring("b"), Keen: pbool(false)}, {Sadness: pint64(3), Code: pstring("c"), Keen: pbool(true)}},
+		{{}, {}},
+		{{Sadness: pint64(4)}, {Code: pstring("d")}, {}, {Keen: pbool(true)}, {Sadness: pint64(5), Code: pstring("e")}},
+		{{}, {Sadness: pint64(6), Code: pstring("f"), Keen: pbool(false)}},
+	}
+
+	var input []Person
+	for _, rg := range groups {
+		input = append(input, rg...)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, rg := range groups {
+		for _, p := range rg {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, input, out)
+	}
+
+	// every column chunk of a field is read before any of them are
+	// scanned, and then some are read after others were partly scanned
+	fields := []Field{
+		NewInt64OptionalField(readSadness, writeSadness, []string{"sadness"}, []int{1}),
+		NewStringOptionalField(readCode, writeCode, []string{"code"}, []int{1}),
+		NewBoolOptionalField(readKeen, writeKeen, []string{"keen"}, []int{1}),
+	}
+
+	for _, f := range fields {
+		t.Run(f.Name(), func(t *testing.T) {
+			r := bytes.NewReader(buf.Bytes())
+			meta := parquet.New(f.Schema())
+			if !assert.NoError(t, meta.ReadFooter(r)) {
+				return
+			}
+			pages, err := meta.Pages()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, pages[f.Name()], len(groups))
+
+			read := func(pg parquet.Page) {
+				_, err := r.Seek(pg.Offset, io.SeekStart)
+				assert.NoError(t, err)
+				assert.NoError(t, f.Read(r, pg))
+			}
+
+			scan := func(n int) []Person {
+				out := make([]Person, n)
+				for i := range out {
+					f.Scan(&out[i])
+				}
+				return out
+			}
+
+			for _, pg := range pages[f.Name()] {
+				read(pg)
+			}
+			assertColumn(t, f.Name(), input, scan(len(input)))
+
+			read(pages[f.Name()][0])
+			got := scan(1)
+			read(pages[f.Name()][1])
+			read(pages[f.Name()][2])
+			got = append(got, scan(6)...)
+			read(pages[f.Name()][3])
+			got = append(got, scan(5)...)
+			assertColumn(t, f.Name(), input, got)
+		})
+	}
+}
+
+// assertColumn checks that the column col of got
+// (and none of its other columns) is from expected.
+func assertColumn(t *testing.T, col string, expected, got []Person) {
+	if !assert.Len(t, got, len(expected)) {
+		return
+	}
+
+	for i, p := range expected {
+		var want Person
+		switch col {
+		case "sadness":
+			want.Sadness = p.Sadness
+		case "code":
+			want.Code = p.Code
+		case "keen":
+			want.Keen = p.Keen
+		}
+		assert.Equal(t, want, got[i], "row %d", i)
+	}
+}
+
+func TestLenient(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, Uncompressed)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var input []Person
+	for i := 0; i < 5; i++ {
+		p := Person{Being: Being{ID: int32(i), Name: fmt.Sprintf("person %d", i)}, Code: pstring("abc")}
+		input = append(input, p)
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	// the name column says it's snappy but its pages aren't compressed
+	b, err := setFooter(buf.Bytes(), func(footer *sch.FileMetaData) {
+		for _, ch := range footer.RowGroups[0].Columns {
+			if strings.Join(ch.MetaData.PathInSchema, ".") == "name" {
+				ch.MetaData.Codec = sch.CompressionCodec_SNAPPY
+			}
+		}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = SafeRead(bytes.NewReader(b))
+	var re *parquet.ReadColumnError
+	if assert.True(t, errors.As(err, &re)) {
+		assert.Equal(t, "name", re.Column)
+	}
+
+	out, err := SafeRead(bytes.NewReader(b), Lenient)
+	if assert.NoError(t, err) {
+		assert.Equal(t, input, out)
+	}
+}
+
+func TestLenientFramedSnappy(t *testing.T) {
+	// a page of ids that was compressed with snappy's
+	// framing format instead of the block format
+	var data []byte
+	for i := int32(1); i <= 3; i++ {
+		data = append(data, writeInt32(i)...)
+	}
+	var framed bytes.Buffer
+	sw := snappy.NewBufferedWriter(&framed)
+	sw.Write(data)
+	assert.NoError(t, sw.Close())
+
+	f := NewInt32Field(readID, writeID, []string{"id"})
+
+	// file returns a file whose page header says
+	// that the page decompresses to size bytes
+	file := func(size int) []byte {
+		var buf bytes.Buffer
+		buf.Write([]byte("PAR1"))
+		meta := parquet.New(f.Schema())
+		for i := 0; i < 3; i++ {
+			meta.NextDoc()
+		}
+		assert.NoError(t, meta.WritePageHeader(&buf, []string{"id"}, size, framed.Len(), 3, 3, 0, 0, sch.CompressionCodec_SNAPPY, valueStats(writeInt32(1))))
+		buf.Write(framed.Bytes())
+		assert.NoError(t, meta.EndRowGroup(3))
+		assert.NoError(t, meta.Footer(&buf))
+		buf.Write([]byte("PAR1"))
+		return buf.Bytes()
+	}
+
+	read := func(b []byte, lenient bool) ([]int32, error) {
+		r := bytes.NewReader(b)
+		meta := parquet.New(f.Schema())
+		if err := meta.ReadFooter(r); err != nil {
+			return nil, err
+		}
+		meta.SetLenient(lenient)
+
+		pages, err := meta.Pages()
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []int32
+		for _, pg := range pages["id"] {
+			if _, err := r.Seek(pg.Offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			if err := f.Read(r, pg); err != nil {
+				return nil, err
+			}
+			for i := int64(0); i < pg.Rows; i++ {
+				var p Person
+				f.Scan(&p)
+				ids = append(ids, p.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	_, err := read(file(len(data)), false)
+	assert.Error(t, err)
+
+	ids, err := read(file(len(data)), true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int32{1, 2, 3}, ids)
+	}
+
+	// the page has to decompress to the size that its header says
+	_, err = read(file(len(data)+4), true)
+	assert.EqualError(t, err, "framed snappy page: unexpected EOF")
+
+	_, err = read(file(len(data)-4), true)
+	assert.EqualError(t, err, "framed snappy page decodes to more than 8 bytes")
+}
+
+func TestFloat16(t *testing.T) {
+	testCases := []struct {
+		in   float32
+		bits parquet.Float16
+		out  float32
+	}{
+		{in: 0, bits: 0x0000, out: 0},
+		{in: 1, bits: 0x3c00, out: 1},
+		{in: -2, bits: 0xc000, out: -2},
+		{in: 0.1, bits: 0x2e66, out: 0.099975586},
+		{in: 65504, bits: 0x7bff, out: 65504},
+		// too large, or too small, for a half precision float
+		{in: 65520, bits: 0x7c00, out: float32(math.Inf(1))},
+		{in: -1e10, bits: 0xfc00, out: float32(math.Inf(-1))},
+		{in: 1e-8, bits: 0x0000, out: 0},
+		// subnormals
+		{in: 5.9604645e-08, bits: 0x0001, out: 5.9604645e-08},
+		{in: 6.097555e-05, bits: 0x03ff, out: 6.097555e-05},
+		// 1 + 2^-11 is halfway between 1 and the next
+		// Float16, so it is rounded to the even one
+		{in: 1.00048828125, bits: 0x3c00, out: 1},
+		{in: 1.00146484375, bits: 0x3c02, out: 1.001953125},
+	}
+
+	for _, tc := range testCases {
+		h := parquet.NewFloat16(tc.in)
+		assert.Equal(t, tc.bits, h, fmt.Sprintf("%g", tc.in))
+		assert.Equal(t, tc.out, h.Float32(), fmt.Sprintf("%g", tc.in))
+	}
+
+	assert.True(t, math.IsNaN(float64(parquet.NewFloat16(float32(math.NaN())).Float32())))
+	assert.True(t, math.Signbit(float64(parquet.NewFloat16(float32(math.Copysign(0, -1))).Float32())))
+}
+
+func TestDecimalAsFloat(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the bits of each boldness are an int64 so that the
+	// boldness column can be made a DECIMAL with a scale of 2
+	unscaled := []int64{12345, -50, 0, 7}
+	for i, n := range unscaled {
+		w.Add(Person{Being: Being{ID: int32(i)}, Boldness: math.Float64frombits(uint64(n))})
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	b, err := setFooter(buf.Bytes(), func(footer *sch.FileMetaData) {
+		for _, se := range footer.Schema {
+			if se.Name == "boldness" {
+				se.Type = sch.TypePtr(sch.Type_INT64)
+				se.ConvertedType = sch.ConvertedTypePtr(sch.ConvertedType_DECIMAL)
+				se.Scale = thrift.Int32Ptr(2)
+				se.Precision = thrift.Int32Ptr(10)
+			}
+		}
+		for _, ch := range footer.RowGroups[0].Columns {
+			if strings.Join(ch.MetaData.PathInSchema, ".") == "boldness" {
+				ch.MetaData.Type = sch.Type_INT64
+				ch.MetaData.Statistics = nil
+			}
+		}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out, err := SafeRead(bytes.NewReader(b), DecimalAsFloat)
+	if assert.NoError(t, err) && assert.Len(t, out, len(unscaled)) {
+		for i, want := range []float64{123.45, -0.5, 0, 0.07} {
+			assert.InDelta(t, want, out[i].Boldness, 1e-9)
+		}
+	}
+
+	// without DecimalAsFloat the unscaled values' bits are the floats
+	out, err = SafeRead(bytes.NewReader(b))
+	if assert.NoError(t, err) && assert.Len(t, out, len(unscaled)) {
+		assert.Equal(t, uint64(12345), math.Float64bits(out[0].Boldness))
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	fld := parquet.Field{Name: "bogus", Path: []string{"bogus"}, Types: []int{0}, Type: Int32Type, RepetitionType: parquet.RepetitionRequired}
+	meta := parquet.New(fld)
+	meta.NextDoc()
+
+	col := parquet.NewRequiredField(fld.Path, parquet.RequiredFieldUncompressed)
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt32(1), 1, valueStats(writeInt32(1)))) {
+		return
+	}
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	_, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	var unknown *parquet.UnknownColumnError
+	if assert.True(t, errors.As(err, &unknown)) {
+		assert.Equal(t, "bogus", unknown.Column)
+	}
+}
+
+func TestEndRowGroup(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	id := parquet.Field{Name: "id", Path: []string{"id"}, Types: []int{0}, Type: Int32Type, RepetitionType: parquet.RepetitionRequired}
+	happiness := parquet.Field{Name: "happiness", Path: []string{"happiness"}, Types: []int{0}, Type: Int64Type, RepetitionType: parquet.RepetitionRequired}
+	meta := parquet.New(id, happiness)
+	meta.NextDoc()
+	meta.NextDoc()
+
+	// the pages of a column chunk (from different writers) add up
+	col := parquet.NewRequiredField(id.Path, parquet.RequiredFieldUncompressed)
+	for _, i := range []int32{1, 2} {
+		if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt32(i), 1, valueStats(writeInt32(i)))) {
+			return
+		}
+	}
+
+	// a column chunk that is missing a row
+	col = parquet.NewRequiredField(happiness.Path, parquet.RequiredFieldUncompressed)
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt64(1), 1, valueStats(writeInt64(1)))) {
+		return
+	}
+
+	assert.EqualError(t, meta.EndRowGroup(2), "the column chunk of happiness in row group 0 has 1 rows but the row group has 2 rows")
+	assert.EqualError(t, meta.EndRowGroup(3), "the column chunk of id in row group 0 has 2 rows but the row group has 3 rows")
+
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt64(2), 1, valueStats(writeInt64(2)))) {
+		return
+	}
+	assert.NoError(t, meta.EndRowGroup(2))
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Len(t, footer.RowGroups, 1) {
+		assert.Equal(t, int64(2), footer.RowGroups[0].NumRows)
+	}
+}
+
+func TestIgnoreUnknownColumns(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	bogus := parquet.Field{Name: "bogus", Path: []string{"bogus"}, Types: []int{0}, Type: Int64Type, RepetitionType: parquet.RepetitionRequired}
+	id := parquet.Field{Name: "id", Path: []string{"id"}, Types: []int{0}, Type: Int32Type, RepetitionType: parquet.RepetitionRequired}
+	meta := parquet.New(bogus, id)
+	meta.NextDoc()
+
+	col := parquet.NewRequiredField(bogus.Path, parquet.RequiredFieldUncompressed)
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt64(99), 1, valueStats(writeInt64(99)))) {
+		return
+	}
+
+	col = parquet.NewRequiredField(id.Path, parquet.RequiredFieldUncompressed)
+	if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt32(7), 1, valueStats(writeInt32(7)))) {
+		return
+	}
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), IgnoreUnknownColumns)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var people []Person
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		people = append(people, p)
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, []Person{{Being: Being{ID: 7}}}, people)
+}
+
+func TestLegacyRepetitionTypes(t *testing.T) {
+	// id is optional in the file and hobby is a required group, so
+	// the definition levels of id, hobby.name, and hobby.difficulty
+	// are different from the ones that Person's fields have.
+	id := parquet.Field{Name: "id", Path: []string{"id"}, Types: []int{1}, Type: Int32Type, RepetitionType: parquet.RepetitionOptional}
+	name := parquet.Field{Name: "name", Path: []string{"hobby", "name"}, Types: []int{0, 0}, Type: StringType, RepetitionType: parquet.RepetitionRequired}
+	difficulty := parquet.Field{Name: "difficulty", Path: []string{"hobby", "difficulty"}, Types: []int{0, 1}, Type: Int32Type, RepetitionType: parquet.RepetitionOptional}
+
+	file := func(ids []uint8) []byte {
+		var buf bytes.Buffer
+		buf.Write([]byte("PAR1"))
+		meta := parquet.New(id, name, difficulty)
+		for range ids {
+			meta.NextDoc()
+		}
+
+		var vals []byte
+		for i, def := range ids {
+			if def == 1 {
+				vals = append(vals, writeInt32(int32(i+1))...)
+			}
+		}
+
+		col := parquet.NewOptionalField(id.Path, id.Types, parquet.OptionalFieldUncompressed)
+		col.Defs = ids
+		if !assert.NoError(t, col.DoWrite(&buf, meta, vals, len(ids), valueStats(writeInt32(1)))) {
+			return nil
+		}
+
+		names := parquet.NewRequiredField(name.Path, parquet.RequiredFieldUncompressed)
+		vals = nil
+		for _, s := range []string{"a", "b", "c"} {
+			vals = append(append(vals, writeInt32(int32(len(s)))...), s...)
+		}
+		if !assert.NoError(t, names.DoWrite(&buf, meta, vals, 3, valueStats("a"))) {
+			return nil
+		}
+
+		col = parquet.NewOptionalField(difficulty.Path, difficulty.Types, parquet.OptionalFieldUncompressed)
+		col.Defs = []uint8{1, 0, 1}
+		vals = append(writeInt32(5), writeInt32(7)...)
+		if !assert.NoError(t, col.DoWrite(&buf, meta, vals, 3, valueStats(writeInt32(5)))) {
+			return nil
+		}
+
+		assert.NoError(t, meta.Footer(&buf))
+		buf.Write([]byte("PAR1"))
+		return buf.Bytes()
+	}
+
+	r, err := NewParquetReader(bytes.NewReader(file([]uint8{1, 1, 1})))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var people []Person
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		people = append(people, p)
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, []Person{
+		{Being: Being{ID: 1}, Hobby: &Hobby{Name: "a", Difficulty: pint32(5)}},
+		{Being: Being{ID: 2}, Hobby: &Hobby{Name: "b"}},
+		{Being: Being{ID: 3}, Hobby: &Hobby{Name: "c", Difficulty: pint32(7)}},
+	}, people)
+
+	// id can't be null in Person
+	_, err = NewParquetReader(bytes.NewReader(file([]uint8{1, 0, 1})))
+	assert.EqualError(t, err, "unable to read field id (row group 0, offset 4), err: column id has a null value but the field is required")
+}
+
+func TestMixedCompression(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	id := parquet.Field{Name: "id", Path: []string{"id"}, Types: []int{0}, Type: Int32Type, RepetitionType: parquet.RepetitionRequired}
+	meta := parquet.New(id)
+
+	// the first page of each column chunk sets the chunk's codec
+	rowGroups := [][]func(*parquet.RequiredField){
+		{parquet.RequiredFieldSnappy, parquet.RequiredFieldGzip, parquet.RequiredFieldSnappy},
+		{parquet.RequiredFieldGzip, parquet.RequiredFieldSnappy},
+	}
+
+	var expected []Person
+	for i, pages := range rowGroups {
+		if i > 0 {
+			meta.StartRowGroup(id)
+		}
+
+		for _, comp := range pages {
+			meta.NextDoc()
+			v := int32(len(expected))
+			col := parquet.NewRequiredField(id.Path, comp)
+			if !assert.NoError(t, col.DoWrite(&buf, meta, writeInt32(v), 1, valueStats(writeInt32(v)))) {
+				return
+			}
+			expected = append(expected, Person{Being: Being{ID: v}})
+		}
+	}
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	people, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, people)
+}
+
+func TestRowGroupColumns(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	id := NewInt32Field(readID, writeID, []string{"id"})
+	name := NewStringField(readName, writeName, []string{"name"})
+	age := NewInt32OptionalField(readAge, writeAge, []string{"age"}, []int{1})
+	meta := parquet.New(id.Schema(), name.Schema(), age.Schema())
+
+	// the first row group has id and name, and the second has
+	// id and age (the row group that New starts is left empty)
+	rowGroups := []struct {
+		fields []Field
+		people []Person
+	}{
+		{
+			fields: []Field{id, name},
+			people: []Person{
+				{Being: Being{ID: 1, Name: "a"}},
+				{Being: Being{ID: 2, Name: "b"}},
+			},
+		},
+		{
+			fields: []Field{NewInt32Field(readID, writeID, []string{"id"}), age},
+			people: []Person{
+				{Being: Being{ID: 3, Age: pint32(30)}},
+				{Being: Being{ID: 4}},
+				{Being: Being{ID: 5, Age: pint32(50)}},
+			},
+		},
+	}
+
+	var expected []Person
+	for _, rg := range rowGroups {
+		var schema []parquet.Field
+		for _, f := range rg.fields {
+			schema = append(schema, f.Schema())
+		}
+		meta.StartRowGroup(schema...)
+
+		for _, p := range rg.people {
+			meta.NextDoc()
+			for _, f := range rg.fields {
+				f.Add(p)
+			}
+		}
+
+		for _, f := range rg.fields {
+			if !assert.NoError(t, f.Write(&buf, meta)) {
+				return
+			}
+		}
+		if !assert.NoError(t, meta.EndRowGroup(int64(len(rg.people)))) {
+			return
+		}
+		expected = append(expected, rg.people...)
+	}
+
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	// the columns that a row group doesn't have are zero (or null),
+	// whether the rows are scanned or skipped
+	for _, skip := range []bool{false, true} {
+		r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var people []Person
+		for i := 0; r.Next(); i++ {
+			if skip && i%2 == 1 {
+				continue
+			}
+			var p Person
+			r.Scan(&p)
+			people = append(people, p)
+		}
+		assert.NoError(t, r.Error())
+
+		if skip {
+			assert.Equal(t, []Person{expected[0], expected[2], expected[4]}, people)
+		} else {
+			assert.Equal(t, expected, people)
+		}
+	}
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c, err := r.ReadColumn("age")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var ages []*int32
+	for c.Next() {
+		var p Person
+		c.Scan(&p)
+		ages = append(ages, p.Age)
+	}
+	assert.NoError(t, c.Error())
+	assert.Equal(t, []*int32{nil, nil, pint32(30), nil, pint32(50)}, ages)
+
+	var out bytes.Buffer
+	assert.NoError(t, DumpColumn(bytes.NewReader(buf.Bytes()), "name", &out))
+	assert.Equal(t, "a\nb\nnull\nnull\nnull\n", out.String())
+
+	size, err := r.ColumnCompressedSize("age")
+	assert.NoError(t, err)
+	assert.True(t, size > 0)
+}
+
+func TestForEachPage(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(4))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 30)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	rd := bytes.NewReader(buf.Bytes())
+	footer, err := parquet.ReadMetaData(rd)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var expected []sch.PageHeader
+	for _, rg := range footer.RowGroups {
+		for _, col := range rg.Columns {
+			if strings.Join(col.MetaData.PathInSchema, ".") == "id" {
+				h, err := parquet.PageHeadersAtOffset(rd, col.MetaData.DataPageOffset, col.MetaData.NumValues)
+				if !assert.NoError(t, err) {
+					return
+				}
+				expected = append(expected, h...)
+			}
+		}
+	}
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+
+		// walking the pages doesn't disturb the rows that are being read
+		if i == 5 {
+			var pages []sch.PageHeader
+			err := r.ForEachPage("id", func(ph sch.PageHeader) error {
+				pages = append(pages, ph)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 9, len(pages))
+			assert.Equal(t, expected, pages)
+		}
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 30, i)
+
+	stop := errors.New("stop")
+	var n int
+	err = r.ForEachPage("id", func(ph sch.PageHeader) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+
+	var unknown *parquet.UnknownColumnError
+	err = r.ForEachPage("bogus", func(ph sch.PageHeader) error { return nil })
+	assert.True(t, errors.As(err, &unknown))
+}
+
+// externalCreatedBy is the created_by of the files in testdata/external,
+// which another implementation wrote (see testdata/external/README.md).
+const externalCreatedBy = "parquet-go version latest"
+
+// openExternal opens a file in testdata/external and checks that
+// it was written by the other implementation.
+func openExternal(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "external", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	footer, err := parquet.ReadMetaData(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if footer.GetCreatedBy() != externalCreatedBy {
+		t.Fatalf("%s was created by %q, not %q", name, footer.GetCreatedBy(), externalCreatedBy)
+	}
+	return f
+}
+
+func TestDeltaEncodedStrings(t *testing.T) {
+	testCases := []struct {
+		file     string
+		names    []string
+		codes    []*string
+		encoding sch.Encoding
+	}{
+		{
+			file:     "delta_length_byte_array.parquet",
+			names:    []string{"Hello", "World", "Foobar", "ABCDEF"},
+			codes:    []*string{pstring("us"), nil, pstring("gb"), pstring("")},
+			encoding: sch.Encoding_DELTA_LENGTH_BYTE_ARRAY,
+		},
+		{
+			file:     "delta_byte_array.parquet",
+			names:    []string{"axis", "axle", "babble", "babyhood"},
+			codes:    []*string{pstring("abc"), nil, pstring("abd"), pstring("abd")},
+			encoding: sch.Encoding_DELTA_BYTE_ARRAY,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			r, err := NewParquetReader(openExternal(t, tc.file))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, col := range []string{"name", "code"} {
+				var encodings []sch.Encoding
+				assert.NoError(t, r.ForEachPage(col, func(ph sch.PageHeader) error {
+					encodings = append(encodings, ph.DataPageHeader.Encoding)
+					return nil
+				}))
+				assert.Equal(t, []sch.Encoding{tc.encoding}, encodings, col)
+			}
+
+			var expected, people []Person
+			for i := range tc.names {
+				expected = append(expected, Person{Being: Being{Name: tc.names[i]}, Code: tc.codes[i]})
+			}
+
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				people = append(people, p)
+			}
+
+			assert.NoError(t, r.Error())
+			assert.Equal(t, expected, people)
+		})
+	}
+}
+
+func TestRLEBools(t *testing.T) {
+	r, err := NewParquetReader(openExternal(t, "rle_bool.parquet"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the file was written with a small page size, so each
+	// column has several pages
+	for _, col := range []string{"hungry", "keen"} {
+		var encodings []sch.Encoding
+		assert.NoError(t, r.ForEachPage(col, func(ph sch.PageHeader) error {
+			encodings = append(encodings, ph.DataPageHeader.Encoding)
+			return nil
+		}))
+		assert.Len(t, encodings, 6, col)
+		for _, enc := range encodings {
+			assert.Equal(t, sch.Encoding_RLE, enc, col)
+		}
+	}
+
+	var expected, people []Person
+	for i := 0; i < 25; i++ {
+		p := Person{Being: Being{ID: int32(i)}, Hungry: i%3 == 0 || (i >= 5 && i < 15)}
+		if i%4 != 0 {
+			p.Keen = pbool(i >= 8 && i < 20)
+		}
+		expected = append(expected, p)
+	}
+
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		people = append(people, p)
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, expected, people)
+}
+
+func TestDictionary(t *testing.T) {
+	names := []string{"mallory", "alice", "bob"}
+	var input [][]Person
+	for i := 0; i < 2; i++ {
+		var rg []Person
+		for j := 0; j < 10; j++ {
+			p := Person{Being: Being{ID: int32(i*10 + j), Name: names[(i+j)%len(names)]}}
+			if j%4 != 0 {
+				p.Code = pstring(names[j%2])
+			}
+			rg = append(rg, p)
+		}
+		input = append(input, rg)
+	}
+
+	testCases := []struct {
+		name   string
+		opt    func(*ParquetWriter) error
+		sorted bool
+	}{
+		{name: "unsorted", opt: Dictionary},
+		{name: "sorted", opt: SortedDictionary, sorted: true},
+	}
+
+	for _, tc := range testCases {
+		for _, comp := range compressionCases {
+			t.Run(fmt.Sprintf("%s %s", tc.name, comp), func(t *testing.T) {
+				var buf bytes.Buffer
+				w, err := NewParquetWriter(&buf, MaxPageSize(4), compressionTest[comp], tc.opt)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, rowgroup := range input {
+					for _, p := range rowgroup {
+						w.Add(p)
+					}
+					assert.NoError(t, w.Write())
+				}
+				assert.NoError(t, w.Close())
+
+				r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// each column chunk has a dictionary page and then
+				// 3 pages of indices
+				var types []sch.PageType
+				assert.NoError(t, r.ForEachPage("name", func(ph sch.PageHeader) error {
+					types = append(types, ph.Type)
+					if ph.DictionaryPageHeader != nil {
+						assert.Equal(t, int32(3), ph.DictionaryPageHeader.NumValues)
+						assert.Equal(t, tc.sorted, ph.DictionaryPageHeader.GetIsSorted())
+					} else {
+						assert.Equal(t, sch.Encoding_RLE_DICTIONARY, ph.DataPageHeader.Encoding)
+					}
+					return nil
+				}))
+				chunk := []sch.PageType{sch.PageType_DICTIONARY_PAGE, sch.PageType_DATA_PAGE, sch.PageType_DATA_PAGE, sch.PageType_DATA_PAGE}
+				assert.Equal(t, append(chunk, chunk...), types)
+
+				var i int
+				for r.Next() {
+					var p Person
+					r.Scan(&p)
+					assert.Equal(t, *getExpected(input, i), p)
+					i++
+				}
+				assert.NoError(t, r.Error())
+				assert.Equal(t, 20, i)
+
+				footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, col := range footer.RowGroups[0].Columns {
+					switch strings.Join(col.MetaData.PathInSchema, ".") {
+					case "name", "code":
+						assert.NotNil(t, col.MetaData.DictionaryPageOffset)
+						assert.Contains(t, col.MetaData.Encodings, sch.Encoding_RLE_DICTIONARY)
+					case "id":
+						assert.Nil(t, col.MetaData.DictionaryPageOffset)
+						assert.NotContains(t, col.MetaData.Encodings, sch.Encoding_RLE_DICTIONARY)
+					}
+				}
+			})
+		}
+	}
+
+	// nested and repeated string columns are dictionary encoded too
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3), SortedDictionary)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	people := getPeople(10, 25)
+	for _, rowgroup := range people {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Equal(t, 25, len(out)) {
+		for i, p := range out {
+			assert.Equal(t, *getExpected(people, i), p)
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	seq := []int64{5, -3, 100, -100, 0}
+	var input [][]Person
+	for i := 0; i < 2; i++ {
+		var rg []Person
+		for j := 0; j < 12; j++ {
+			n := seq[j%len(seq)]
+			p := Person{
+				Being:     Being{ID: int32(n)},
+				Happiness: n * 1e12,
+				Birthday:  uint32(n),
+			}
+			if j%3 != 0 {
+				p.Sadness = pint64(-n)
+				p.Anniversary = puint64(uint64(n))
+			}
+			if j == 7 {
+				p.ID = math.MinInt32
+				p.Happiness = math.MaxInt64
+				p.Sadness = pint64(math.MinInt64)
+				p.Birthday = math.MaxUint32
+				p.Anniversary = puint64(math.MaxUint64)
+			}
+			rg = append(rg, p)
+		}
+		input = append(input, rg)
+	}
+
+	for _, comp := range compressionCases {
+		t.Run(comp, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, MaxPageSize(5), compressionTest[comp], Delta)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, col := range []string{"id", "happiness", "sadness", "birthday", "anniversary", "funkiness"} {
+				var encodings []sch.Encoding
+				assert.NoError(t, r.ForEachPage(col, func(ph sch.PageHeader) error {
+					encodings = append(encodings, ph.DataPageHeader.Encoding)
+					return nil
+				}))
+
+				expected := sch.Encoding_DELTA_BINARY_PACKED
+				if col == "funkiness" {
+					expected = sch.Encoding_PLAIN
+				}
+				assert.Equal(t, 6, len(encodings), col)
+				for _, enc := range encodings {
+					assert.Equal(t, expected, enc, col)
+				}
+			}
+
+			var i int
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				assert.Equal(t, *getExpected(input, i), p)
+				i++
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, 24, i)
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	var input []Person
+	for i := 0; i < 10; i++ {
+		p := Person{Being: Being{ID: int32(i), Name: fmt.Sprintf("name-%d", i)}}
+		if i%2 == 0 {
+			p.Age = pint32(int32(i * 10))
+		}
+		if i%3 == 0 {
+			p.Hobby = &Hobby{Name: "chess", Skills: []Skill{{Name: "openings"}, {Name: "endgames"}}}
+		}
+		input = append(input, p)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i, p := range input {
+		w.Add(p)
+		if i == 4 {
+			assert.NoError(t, w.Write())
+		}
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var i int
+	for r.Next() {
+		v, ok := r.Value("id")
+		assert.True(t, ok)
+		assert.Equal(t, int32(i), v)
+
+		v, ok = r.Value("age")
+		assert.Equal(t, i%2 == 0, ok)
+		if ok {
+			assert.Equal(t, int32(i*10), v)
+		}
+
+		v, ok = r.Value("hobby.skills.name")
+		assert.Equal(t, i%3 == 0, ok)
+		if ok {
+			assert.Equal(t, []string{"openings", "endgames"}, v)
+		}
+
+		_, ok = r.Value("bogus")
+		assert.False(t, ok)
+
+		// only every other row is scanned, the fields
+		// still skip the rows that aren't
+		if i%2 == 1 {
+			var p Person
+			r.Scan(&p)
+			assert.Equal(t, input[i], p)
+
+			_, ok = r.Value("id")
+			assert.False(t, ok)
+		}
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, len(input), i)
+}
+
+func TestReserve(t *testing.T) {
+	s := parquet.Reserve([]string{"a"}, 3)
+	assert.Equal(t, []string{"a"}, s)
+	assert.Equal(t, 4, cap(s))
+
+	// s is big enough, so it's reused
+	r := parquet.Reserve(s, 2)
+	assert.Same(t, &s[0], &r[0])
+}
+
+func TestPos(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		w.Add(Person{Being: Being{ID: int32(i)}})
+		if i == 4 {
+			assert.NoError(t, w.Write())
+		}
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(-1), r.Pos())
+
+	// the rows that aren't scanned don't change Pos
+	var i int64
+	var p Person
+	for r.Next() {
+		if i%3 == 0 {
+			r.Scan(&p)
+			assert.Equal(t, int32(i), p.ID)
+		}
+		assert.Equal(t, i-i%3, r.Pos())
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, int64(9), r.Pos())
+}
+
+func TestDeltaLength(t *testing.T) {
+	var input []Person
+	for i := 0; i < 12; i++ {
+		p := Person{Being: Being{ID: int32(i)}, BFF: fmt.Sprintf("bff-%d", i%2)}
+		if i%4 == 0 {
+			p.Name = fmt.Sprintf("name-%d", i)
+		}
+		switch i % 3 {
+		case 1:
+			p.Code = pstring("")
+		case 2:
+			p.Code = pstring(fmt.Sprintf("code-%d", i))
+		}
+		input = append(input, p)
+	}
+
+	for _, v2 := range []bool{false, true} {
+		t.Run(fmt.Sprintf("v2=%t", v2), func(t *testing.T) {
+			// the string columns that aren't delta length encoded
+			// are still dictionary encoded
+			opts := []func(*ParquetWriter) error{MaxPageSize(5), Dictionary, DeltaLength("name", "code")}
+			if v2 {
+				opts = append(opts, DataPageV2)
+			}
+
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, opts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, p := range input {
+				w.Add(p)
+			}
+			assert.NoError(t, w.Write())
+			assert.NoError(t, w.Close())
+
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expected := map[string]sch.Encoding{
+				"name": sch.Encoding_DELTA_LENGTH_BYTE_ARRAY,
+				"code": sch.Encoding_DELTA_LENGTH_BYTE_ARRAY,
+				"bff":  sch.Encoding_RLE_DICTIONARY,
+			}
+			for col, enc := range expected {
+				var encodings []sch.Encoding
+				assert.NoError(t, r.ForEachPage(col, func(ph sch.PageHeader) error {
+					switch {
+					case ph.DataPageHeaderV2 != nil:
+						encodings = append(encodings, ph.DataPageHeaderV2.Encoding)
+					case ph.DataPageHeader != nil:
+						encodings = append(encodings, ph.DataPageHeader.Encoding)
+					}
+					return nil
+				}))
+				assert.Equal(t, []sch.Encoding{enc, enc, enc}, encodings, col)
+			}
+
+			var people []Person
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				people = append(people, p)
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, input, people)
+		})
+	}
+
+	_, err := NewParquetWriter(&bytes.Buffer{}, DeltaLength("happiness"))
+	assert.EqualError(t, err, "happiness isn't a string column")
+}
+
+func TestByteBools(t *testing.T) {
+	var input []Person
+	for i := 0; i < 20; i++ {
+		p := Person{Being: Being{ID: int32(i)}, Hungry: i%3 == 0}
+		if i%4 != 0 {
+			p.Keen = pbool(i%2 == 0)
+		}
+		input = append(input, p)
+	}
+
+	write := func(opts ...func(*ParquetWriter) error) []byte {
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, append(opts, MaxPageSize(8))...)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		for _, p := range input {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+		assert.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	read := func(data []byte, opts ...func(*ParquetReader)) []Person {
+		r, err := NewParquetReader(bytes.NewReader(data), opts...)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		var people []Person
+		for r.Next() {
+			var p Person
+			r.Scan(&p)
+			people = append(people, p)
+		}
+		assert.NoError(t, r.Error())
+		return people
+	}
+
+	size := func(data []byte, col string) int64 {
+		r, err := NewParquetReader(bytes.NewReader(data))
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		n, err := r.ColumnCompressedSize(col)
+		assert.NoError(t, err)
+		return n
+	}
+
+	plain := write()
+	bb := write(ByteBools())
+	assert.Equal(t, input, read(bb, ReadByteBools()))
+	assert.Equal(t, input, read(bb, ReadByteBools("hungry", "keen")))
+	assert.NotEqual(t, input, read(bb))
+	assert.Greater(t, size(bb, "hungry"), size(plain, "hungry"))
+	assert.Greater(t, size(bb, "keen"), size(plain, "keen"))
+
+	// only keen has a byte per value
+	keen := write(ByteBools("keen"))
+	assert.Equal(t, input, read(keen, ReadByteBools("keen")))
+	assert.Equal(t, size(plain, "hungry"), size(keen, "hungry"))
+
+	r, err := NewParquetReader(bytes.NewReader(keen), ReadByteBools("keen"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	cr, err := r.ReadColumn("keen")
+	if assert.NoError(t, err) {
+		var keens []*bool
+		for cr.Next() {
+			var p Person
+			cr.Scan(&p)
+			keens = append(keens, p.Keen)
+		}
+		assert.NoError(t, cr.Error())
+		for i, p := range input {
+			assert.Equal(t, p.Keen, keens[i])
+		}
+	}
+
+	_, err = NewParquetWriter(&bytes.Buffer{}, ByteBools("name"))
+	assert.EqualError(t, err, "name isn't a bool column")
+	_, err = NewParquetReader(bytes.NewReader(plain), ReadByteBools("id"))
+	assert.EqualError(t, err, "id isn't a bool column")
+}
+
+func TestStatistics(t *testing.T) {
+	input := getPeople(50, 100)
+
+	write := func(opts ...func(*ParquetWriter) error) []byte {
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, append(opts, MaxPageSize(20), StatsTruncateLength(8))...)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		for _, rg := range input {
+			for _, p := range rg {
+				w.Add(p)
+			}
+			assert.NoError(t, w.Write())
+		}
+		assert.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	// computing the stats when the pages are written
+	// gives the same stats (so the same file)
+	onAdd := write()
+	onWrite := write(Statistics(parquet.StatsOnWrite))
+	assert.Equal(t, onAdd, onWrite)
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(onWrite))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pages, err := parquet.PageHeaders(footer, bytes.NewReader(onWrite))
+	if assert.NoError(t, err) && assert.NotEmpty(t, pages) {
+		assert.NotNil(t, pages[0].DataPageHeader.Statistics.MinValue)
+	}
+
+	_, err = NewParquetWriter(&bytes.Buffer{}, Statistics(parquet.StatsMode(7)))
+	assert.EqualError(t, err, "invalid stats mode 7")
+}
+
+func TestDataPageV2(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []func(*ParquetWriter) error
+	}{
+		{name: "plain"},
+		{name: "dictionary", opts: []func(*ParquetWriter) error{Dictionary}},
+		{name: "delta", opts: []func(*ParquetWriter) error{Delta}},
+	}
+
+	input := goldenPeople()
+	for _, tc := range testCases {
+		for _, comp := range compressionCases {
+			t.Run(fmt.Sprintf("%s %s", tc.name, comp), func(t *testing.T) {
+				var buf bytes.Buffer
+				opts := append([]func(*ParquetWriter) error{MaxPageSize(4), compressionTest[comp], DataPageV2}, tc.opts...)
+				w, err := NewParquetWriter(&buf, opts...)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, rowgroup := range input {
+					for _, p := range rowgroup {
+						w.Add(p)
+					}
+					assert.NoError(t, w.Write())
+				}
+				assert.NoError(t, w.Close())
+
+				r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// the first page of each row group has rows 0-3, 10-13, and 20-23
+				var pages []sch.DataPageHeaderV2
+				assert.NoError(t, r.ForEachPage("age", func(ph sch.PageHeader) error {
+					if assert.Equal(t, sch.PageType_DATA_PAGE_V2, ph.Type) {
+						pages = append(pages, *ph.DataPageHeaderV2)
+					}
+					return nil
+				}))
+				if assert.Equal(t, 8, len(pages)) {
+					pg := pages[0]
+					assert.Equal(t, int32(4), pg.NumValues)
+					assert.Equal(t, int32(4), pg.NumRows)
+					assert.Equal(t, int32(2), pg.NumNulls)
+					assert.Equal(t, int64(2), pg.Statistics.GetNullCount())
+					assert.Equal(t, []byte{20, 0, 0, 0}, pg.Statistics.MinValue)
+					assert.Equal(t, []byte{22, 0, 0, 0}, pg.Statistics.MaxValue)
+				}
+
+				// friends.id is repeated so there are more values than rows
+				var rows, values int32
+				assert.NoError(t, r.ForEachPage("friends.id", func(ph sch.PageHeader) error {
+					rows += ph.DataPageHeaderV2.NumRows
+					values += ph.DataPageHeaderV2.NumValues
+					return nil
+				}))
+				assert.Equal(t, int32(25), rows)
+				assert.Equal(t, int32(25+6), values)
+
+				var i int
+				for r.Next() {
+					var p Person
+					r.Scan(&p)
+					assert.Equal(t, *getExpected(input, i), p)
+					i++
+				}
+				assert.NoError(t, r.Error())
+				assert.Equal(t, 25, i)
+			})
+		}
+	}
+}
+
+func TestReadColumn(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := goldenPeople()
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ages, err := r.ReadColumn("age")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	names, err := r.ReadColumn("friends.name")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the column readers can be interleaved with the row reader
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		expected := *getExpected(input, i)
+		assert.Equal(t, expected, p)
+
+		assert.True(t, ages.Next())
+		var age Person
+		ages.Scan(&age)
+		assert.Equal(t, Person{Being: Being{Age: expected.Age}}, age)
+
+		// friends.id comes before friends.name so it is read too
+		assert.True(t, names.Next())
+		var friends Person
+		names.Scan(&friends)
+		var expectedFriends []Being
+		for _, f := range expected.Friends {
+			expectedFriends = append(expectedFriends, Being{ID: f.ID, Name: f.Name})
+		}
+		assert.Equal(t, expectedFriends, friends.Friends)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 25, i)
+	assert.False(t, ages.Next())
+	assert.NoError(t, ages.Error())
+	assert.False(t, names.Next())
+	assert.NoError(t, names.Error())
+
+	// nested columns that need the columns before them
+	testCases := []struct {
+		col string
+		get func(p Person) interface{}
+	}{
+		{col: "hobby.difficulty", get: func(p Person) interface{} {
+			if p.Hobby == nil {
+				return nil
+			}
+			return p.Hobby.Difficulty
+		}},
+		{col: "hobby.skills.difficulty", get: func(p Person) interface{} {
+			if p.Hobby == nil {
+				return nil
+			}
+			return p.Hobby.Skills
+		}},
+		{col: "friends.age", get: func(p Person) interface{} { return p.Friends }},
+	}
+
+	for _, tc := range testCases {
+		cr, err := r.ReadColumn(tc.col)
+		if !assert.NoError(t, err, tc.col) {
+			continue
+		}
+
+		var n int
+		for cr.Next() {
+			var p Person
+			cr.Scan(&p)
+			assert.Equal(t, tc.get(*getExpected(input, n)), tc.get(p), tc.col)
+			n++
+		}
+		assert.NoError(t, cr.Error(), tc.col)
+		assert.Equal(t, 25, n, tc.col)
+	}
+
+	_, err = r.ReadColumn("nope")
+	assert.EqualError(t, err, "unknown field: nope")
+
+	_, err = r.ReadColumn("friends.nope")
+	assert.EqualError(t, err, "unknown field: friends.nope")
+}
+
+func TestOptionalColumn(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// two row groups
+	w.Add(Person{Being: Being{ID: 1, Age: pint32(30)}, Code: pstring("a"), Hobby: &Hobby{Name: "chess"}})
+	w.Add(Person{Being: Being{ID: 2}})
+	assert.NoError(t, w.Write())
+	w.Add(Person{Being: Being{ID: 3, Age: pint32(40)}, Hobby: &Hobby{Name: "golf"}})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// it can be called while r is being read
+	assert.True(t, r.Next())
+	var p Person
+	r.Scan(&p)
+	assert.Equal(t, int32(1), p.ID)
+
+	vals, defs, err := r.OptionalColumn("age")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int32{30, 40}, vals)
+		assert.Equal(t, []int64{1, 0, 1}, defs)
+	}
+
+	vals, defs, err = r.OptionalColumn("code")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a"}, vals)
+		assert.Equal(t, []int64{1, 0, 0}, defs)
+	}
+
+	// a required column of an optional struct
+	vals, defs, err = r.OptionalColumn("hobby.name")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"chess", "golf"}, vals)
+		assert.Equal(t, []int64{1, 0, 1}, defs)
+	}
+
+	// a column that's always null still has its type
+	vals, defs, err = r.OptionalColumn("sadness")
+	if assert.NoError(t, err) {
+		assert.IsType(t, []int64{}, vals)
+		assert.Empty(t, vals)
+		assert.Equal(t, []int64{0, 0, 0}, defs)
+	}
+
+	_, _, err = r.OptionalColumn("id")
+	assert.EqualError(t, err, "column id isn't optional")
+	_, _, err = r.OptionalColumn("friends.id")
+	assert.EqualError(t, err, "column friends.id is repeated")
+	_, _, err = r.OptionalColumn("nope")
+	assert.True(t, errors.As(err, new(*parquet.UnknownColumnError)))
+
+	var out []Person
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		out = append(out, p)
+	}
+	if assert.NoError(t, r.Error()) && assert.Len(t, out, 2) {
+		assert.Equal(t, int32(2), out[0].ID)
+		assert.Equal(t, int32(3), out[1].ID)
+	}
+}
+
+func TestReadTypedColumn(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// two row groups
+	born := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		w.Add(Person{Being: Being{ID: int32(i), Name: fmt.Sprintf("person %d", i)}, Happiness: int64(i * 10), Born: born.AddDate(i, 0, 0)})
+		if i == 2 {
+			assert.NoError(t, w.Write())
+		}
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// it can be called while r is being read
+	assert.True(t, r.Next())
+	var p Person
+	r.Scan(&p)
+
+	// dst is used if it's big enough
+	dst := make([]int64, 10)
+	happiness, err := r.ReadInt64Column("happiness", dst)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int64{0, 10, 20, 30, 40}, happiness)
+		assert.Same(t, &dst[0], &happiness[0])
+	}
+
+	// and grown if it isn't
+	names, err := r.ReadStringColumn("name", make([]string, 0, 1))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"person 0", "person 1", "person 2", "person 3", "person 4"}, names)
+	}
+
+	times, err := r.ReadTimeColumn("born", nil)
+	if assert.NoError(t, err) && assert.Len(t, times, 5) {
+		assert.True(t, born.AddDate(4, 0, 0).Equal(times[4]))
+	}
+
+	_, err = r.ReadInt64Column("sadness", nil)
+	assert.EqualError(t, err, "column sadness isn't a required int64 column")
+	_, err = r.ReadStringColumn("id", nil)
+	assert.EqualError(t, err, "column id isn't a required string column")
+	_, err = r.ReadInt32Column("nope", nil)
+	assert.True(t, errors.As(err, new(*parquet.UnknownColumnError)))
+
+	var n int
+	for r.Next() {
+		r.Scan(&p)
+		n++
+		assert.Equal(t, int32(n), p.ID)
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 4, n)
+}
+
+func TestProjection(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := goldenPeople()
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	// the happiness column chunks are overwritten so reading
+	// them (which the projection doesn't need) would fail
+	b := append([]byte{}, buf.Bytes()...)
+	footer, err := parquet.ReadMetaData(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, rg := range footer.RowGroups {
+		for _, ch := range rg.Columns {
+			if ch.MetaData.PathInSchema[0] == "happiness" {
+				start := ch.MetaData.DataPageOffset
+				copy(b[start:start+ch.MetaData.TotalCompressedSize], bytes.Repeat([]byte{0xff}, int(ch.MetaData.TotalCompressedSize)))
+			}
+		}
+	}
+
+	_, err = SafeRead(bytes.NewReader(b))
+	assert.Error(t, err)
+
+	r, err := NewSummaryParquetReader(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// s is reused to make sure that each row replaces the last one
+	var s Summary
+	var i int
+	for r.Next() {
+		r.Scan(&s)
+		p := getExpected(input, i)
+		assert.Equal(t, Summary{ID: p.ID, Age: p.Age, Hobby: p.Hobby, Friends: p.Friends, Born: p.Born}, s)
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 25, i)
+
+	r, err = NewSummaryParquetReader(bytes.NewReader(b), Limit(7))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	i = 0
+	for r.Next() {
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 7, i)
+}
+
+func TestScanReuse(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := goldenPeople()
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	friends, err := r.ReadColumn("friends.age")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the optional, nested, and repeated fields of the previous
+	// record must not show up in the records that don't have them,
+	// and fields that aren't in the file are left alone
+	p := Person{Secret: "shh"}
+	col := Person{Secret: "shh", Hungry: true}
+	var i int
+	for r.Next() {
+		r.Scan(&p)
+		expected := *getExpected(input, i)
+		expected.Secret = "shh"
+		assert.Equal(t, expected, p)
+
+		assert.True(t, friends.Next())
+		friends.Scan(&col)
+		assert.Equal(t, expected.Friends, col.Friends)
+		assert.Equal(t, "shh", col.Secret)
+		assert.True(t, col.Hungry)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.NoError(t, friends.Error())
+	assert.Equal(t, 25, i)
+}
+
+func TestCheckRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, p := range getPeople(3, 3)[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	n := buf.Len()
+
+	// a column with an extra row (in the child writer of the
+	// second page) would make the row group corrupt
+	for _, p := range getPeople(3, 3)[0] {
+		w.Add(p)
+	}
+	w.child.fields[2].Add(newPerson(4))
+	assert.EqualError(t, w.Write(), "column age has 2 rows but 1 rows have been added")
+	assert.Equal(t, n, buf.Len())
+
+	// a repeated column's rows are counted by its repetition levels
+	w, err = NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	w.Add(Person{Friends: []Being{{ID: 1}, {ID: 2}}})
+	assert.NoError(t, w.checkRows())
+	for _, f := range w.fields {
+		if f.Name() == "friends.id" {
+			f.Add(Person{})
+		}
+	}
+	assert.EqualError(t, w.checkRows(), "column friends.id has 2 rows but 1 rows have been added")
+}
+
+func TestWriteWithMeta(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// a row group without rows isn't written, so neither is its metadata
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.WriteWithMeta(map[string]string{"partition": "none"}))
+
+	people := getPeople(3, 9)
+	for _, p := range people[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.WriteWithMeta(map[string]string{"partition": "a"}))
+
+	for _, p := range people[1] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+
+	meta := map[string]string{"partition": "c", "source": "s3://bucket/c"}
+	for _, p := range people[2] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.WriteWithMeta(meta))
+	meta["partition"] = "d"
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := []map[string]string{
+		{"partition": "a"},
+		{},
+		{"partition": "c", "source": "s3://bucket/c"},
+	}
+	for i, e := range expected {
+		m, err := r.RowGroupMetadata(i)
+		assert.NoError(t, err)
+		assert.Equal(t, e, m, fmt.Sprintf("row group %d", i))
+	}
+
+	_, err = r.RowGroupMetadata(3)
+	assert.EqualError(t, err, "invalid row group 3, the file has 3 row groups")
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(people, i), p)
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 9, i)
+}
+
+func TestMaxPageSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, MaxPageSize(size))
+		assert.EqualError(t, err, fmt.Sprintf("invalid max page size %d, it must be at least 1", size))
+		assert.Nil(t, w)
+
+		// nothing is written if an option is invalid
+		assert.Equal(t, 0, buf.Len())
+	}
+}
+
+// TestColumnSizes checks the sizes in the footer against the
+// sizes of the pages (and their headers) of each column chunk.
+func TestColumnSizes(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []func(*ParquetWriter) error
+	}{
+		{name: "uncompressed", opts: []func(*ParquetWriter) error{Uncompressed}},
+		{name: "snappy", opts: []func(*ParquetWriter) error{Snappy}},
+		{name: "dictionary", opts: []func(*ParquetWriter) error{Snappy, Dictionary}},
+		{name: "data page v2", opts: []func(*ParquetWriter) error{Snappy, DataPageV2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, append(tc.opts, MaxPageSize(4))...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, rowgroup := range goldenPeople() {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			r := bytes.NewReader(buf.Bytes())
+			footer, err := parquet.ReadMetaData(r)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for i, rg := range footer.RowGroups {
+				var rowGroupSize int64
+				for _, col := range rg.Columns {
+					name := fmt.Sprintf("row group %d, column %s", i, strings.Join(col.MetaData.PathInSchema, "."))
+					offset := col.MetaData.DataPageOffset
+					if col.MetaData.DictionaryPageOffset != nil {
+						offset = *col.MetaData.DictionaryPageOffset
+					}
+
+					_, err := r.Seek(offset, io.SeekStart)
+					if !assert.NoError(t, err, name) {
+						return
+					}
+
+					var compressed, uncompressed, values, pages int64
+					for values < col.MetaData.NumValues {
+						start, _ := r.Seek(0, io.SeekCurrent)
+						ph, err := parquet.PageHeader(r)
+						if !assert.NoError(t, err, name) {
+							return
+						}
+						end, _ := r.Seek(int64(ph.CompressedPageSize), io.SeekCurrent)
+
+						header := end - start - int64(ph.CompressedPageSize)
+						compressed += header + int64(ph.CompressedPageSize)
+						uncompressed += header + int64(ph.UncompressedPageSize)
+						switch {
+						case ph.DataPageHeader != nil:
+							values += int64(ph.DataPageHeader.NumValues)
+						case ph.DataPageHeaderV2 != nil:
+							values += int64(ph.DataPageHeaderV2.NumValues)
+						}
+						pages++
+					}
+
+					assert.Greater(t, pages, int64(1), name)
+					assert.Equal(t, compressed, col.MetaData.TotalCompressedSize, name)
+					assert.Equal(t, uncompressed, col.MetaData.TotalUncompressedSize, name)
+					rowGroupSize += uncompressed
+				}
+				assert.Equal(t, rowGroupSize, rg.TotalByteSize, i)
+			}
+		})
+	}
+}
+
+// goldenFiles are written with options that cover all of the
+// encodings and codecs that the writer uses.
+var goldenFiles = []struct {
+	name string
+	opts []func(*ParquetWriter) error
+}{
+	{name: "uncompressed", opts: []func(*ParquetWriter) error{Uncompressed}},
+	{name: "snappy", opts: []func(*ParquetWriter) error{Snappy}},
+	{name: "gzip", opts: []func(*ParquetWriter) error{Gzip}},
+	{name: "dictionary", opts: []func(*ParquetWriter) error{Snappy, SortedDictionary}},
+	{name: "delta", opts: []func(*ParquetWriter) error{Snappy, Delta}},
+	{name: "data_page_v2", opts: []func(*ParquetWriter) error{Snappy, DataPageV2}},
+}
+
+// TestGolden makes sure that the bytes that are written don't change
+// without anyone noticing.  If a change to the writer is intended, run
+// the tests with -update and check the new files with TestReadableBy.
+func TestGolden(t *testing.T) {
+	for _, gf := range goldenFiles {
+		t.Run(gf.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, append(gf.opts, MaxPageSize(7))...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := goldenPeople()
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			pth := filepath.Join("testdata", "golden", gf.name+".parquet")
+			if *update {
+				assert.NoError(t, os.WriteFile(pth, buf.Bytes(), 0644))
+			}
+
+			expected, err := os.ReadFile(pth)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, bytes.Equal(expected, buf.Bytes()), "%s doesn't match what was written, run the tests with -update if the change is intended", pth)
+
+			out, err := SafeRead(bytes.NewReader(expected))
+			if assert.NoError(t, err) && assert.Equal(t, getLen(input), len(out)) {
+				for i, p := range out {
+					assert.Equal(t, *getExpected(input, i), p)
+				}
+			}
+		})
+	}
+}
+
+// TestReadableBy reads the golden files with each of the other parquet
+// implementations that are installed.  CI installs pyarrow and sets
+// PARQUETTEST_REQUIRE so that it fails instead of skipping.
+func TestReadableBy(t *testing.T) {
+	for _, tool := range parquettest.Tools() {
+		for _, gf := range goldenFiles {
+			t.Run(fmt.Sprintf("%s %s", tool, gf.name), func(t *testing.T) {
+				err := parquettest.AssertReadableBy(filepath.Join("testdata", "golden", gf.name+".parquet"), tool)
+				if errors.Is(err, parquettest.ErrNotInstalled) {
+					if parquettest.Required(tool) {
+						t.Fatalf("%s is not installed but %s requires it", tool, parquettest.RequireEnv)
+					}
+					t.Skipf("%s is not installed", tool)
+				}
+				assert.NoError(t, err)
+			})
+		}
+	}
+}
+
+// TestExternalTypes reads a file that another implementation wrote
+// with a column of each type that Person has.
+func TestExternalTypes(t *testing.T) {
+	r, err := NewParquetReader(openExternal(t, "types.parquet"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	born := time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)
+	expected := []Person{
+		{
+			Being:     Being{ID: 1, Name: "ann", Age: pint32(30)},
+			Happiness: 1 << 40, Sadness: pint64(-5), Code: pstring("a1"),
+			Funkiness: 0.5, Boldness: 1.25, Lameness: pfloat32(-0.75), Keen: pbool(true),
+			Birthday: math.MaxUint32, Anniversary: puint64(math.MaxUint64), BFF: "bob", Hungry: true,
+			Hobby: &Hobby{Name: "chess", Difficulty: pint32(7), Skills: []Skill{
+				{Name: "openings", Difficulty: "hard"},
+				{Name: "endgames", Difficulty: "harder"},
+			}},
+			Friends: []Being{{ID: 2, Name: "bob", Age: pint32(31)}, {ID: 3, Name: "cat"}},
+			Sleepy:  true, Born: born, Died: ptimeTime(time.Date(2021, 6, 7, 8, 9, 10, 11000000, time.Local)),
+		},
+		{Being: Being{ID: 2, Name: "bob"}, Happiness: -2, BFF: "ann", Born: born.Add(time.Microsecond)},
+		{
+			Being: Being{ID: 3, Age: pint32(0)}, Code: pstring(""), Keen: pbool(false), Birthday: 7, Anniversary: puint64(0),
+			Hobby: &Hobby{Name: "naps"}, Friends: []Being{{ID: 1, Name: "ann", Age: pint32(30)}},
+			Born: time.Unix(0, 0).UTC(),
+		},
+	}
+
+	var people []Person
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		people = append(people, p)
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, expected, people)
+}
+
+func TestLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 30)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), Limit(15))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, int64(15), r.Rows())
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 15, i)
+
+	// a limit of 0 (or less) reads no rows
+	for _, n := range []int64{0, -1} {
+		r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), Limit(n))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, int64(0), r.Rows(), n)
+		assert.False(t, r.Next(), n)
+		assert.NoError(t, r.Error())
+	}
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closingBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+// syncBuffer is a bytes.Buffer that can be written to by
+// the goroutine started by FlushInterval while it is checked.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Len()
+}
+
+func TestFlushInterval(t *testing.T) {
+	_, err := NewParquetWriter(&bytes.Buffer{}, FlushInterval(0))
+	assert.EqualError(t, err, "invalid flush interval 0s, it must be greater than 0")
+
+	var buf syncBuffer
+	w, err := NewParquetWriter(&buf, FlushInterval(10*time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// wait waits for a row group to be written without calling Write
+	wait := func() {
+		n := buf.Len()
+		for i := 0; i < 200 && buf.Len() == n; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+		assert.NotEqual(t, n, buf.Len())
+	}
+
+	input := getPeople(3, 6)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		wait()
+	}
+
+	// the goroutine doesn't write empty row groups
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.buf.Bytes()))
+	if assert.NoError(t, err) {
+		// a row group can be written between two calls to Add, so
+		// there are at least two (and none of them are empty)
+		assert.True(t, len(footer.RowGroups) >= 2)
+		for _, rg := range footer.RowGroups {
+			assert.True(t, rg.NumRows > 0)
+		}
+	}
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 6, i)
+}
+
+func TestConcurrentClose(t *testing.T) {
+	var buf syncBuffer
+	w, err := NewParquetWriter(&buf, FlushInterval(time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, p := range getPeople(5, 5)[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+
+	// only one Close writes the footer, and none of them
+	// panic by closing the goroutine's channel again
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- w.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var closed int
+	for err := range errs {
+		if err == nil {
+			continue
+		}
+		assert.Equal(t, parquet.ErrWriterClosed, err)
+		closed++
+	}
+	assert.Equal(t, cap(errs)-1, closed)
+
+	r, err := NewParquetReader(bytes.NewReader(buf.buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(5), r.Rows())
+	}
+}
+
+func TestCloseWritesTrailerOnce(t *testing.T) {
+	var buf writeCounter
+	w, err := NewParquetWriter(&buf, MaxPageSize(4))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 10)
+	for _, p := range input[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+
+	// the footer and the trailing marker are one write
+	writes := buf.writes
+	assert.NoError(t, w.Close())
+	assert.Equal(t, writes+1, buf.writes)
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(10), r.Rows())
+	}
+
+	// a failed Close doesn't leave part of a footer behind
+	buf = writeCounter{}
+	w, err = NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, p := range input[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+
+	n := buf.Len()
+	buf.fail = true
+	assert.EqualError(t, w.Close(), "unable to write")
+	assert.Equal(t, n, buf.Len())
+}
+
+func TestSingleRowGroup(t *testing.T) {
+	_, err := NewParquetWriter(&bytes.Buffer{}, SingleRowGroup, FlushInterval(time.Second))
+	assert.EqualError(t, err, "SingleRowGroup can't be used with FlushInterval, which writes a row group every 1s")
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, SingleRowGroup, MaxPageSize(4))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 30)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.WriteWithMeta(map[string]string{"partition": "a"}))
+
+	// nothing but the header is written until Close
+	assert.Equal(t, 4, buf.Len())
+	assert.NoError(t, w.Close())
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Len(t, footer.RowGroups, 1) {
+		assert.Equal(t, int64(30), footer.RowGroups[0].NumRows)
+	}
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m, err := r.RowGroupMetadata(0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"partition": "a"}, m)
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+	}
+
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 30, i)
+}
+
+func TestWriteJSONArray(t *testing.T) {
+	input := getPeople(20, 20)
+	data, err := json.Marshal(input[0])
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, WriteJSONArray(&buf, bytes.NewReader(data), Uncompressed)) {
+		return
+	}
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) && assert.Len(t, out, 20) {
+		for i, p := range out {
+			assert.Equal(t, *getExpected(input, i), p)
+		}
+	}
+
+	testCases := []struct {
+		in  string
+		err string
+	}{
+		{in: `{"ID": 1}`, err: "invalid JSON array, expected [ but got {"},
+		{in: `[{"ID": 1}, {"ID": "two"}]`, err: "unable to decode record 1 of the JSON array: json: cannot unmarshal string"},
+		{in: `[{"ID": 1}`, err: "unable to decode record 1 of the JSON array: unexpected end of JSON input"},
+	}
+
+	// the json package's errors go on to say
+	// where the value that it couldn't decode is
+	for _, tc := range testCases {
+		err := WriteJSONArray(io.Discard, strings.NewReader(tc.in))
+		if assert.Error(t, err, tc.in) {
+			assert.True(t, strings.HasPrefix(err.Error(), tc.err), err.Error())
+		}
+	}
+}
+
+func TestOnAdd(t *testing.T) {
+	errNegative := errors.New("negative id")
+	onAdd := func(p *Person) error {
+		if p.ID < 0 {
+			return fmt.Errorf("%w %d", errNegative, p.ID)
+		}
+		if p.Happiness > 100 {
+			p.Happiness = 100
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, OnAdd(onAdd))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, w.Add(Person{Being: Being{ID: 1}, Happiness: 50}))
+	assert.True(t, errors.Is(w.Add(Person{Being: Being{ID: -1}}), errNegative))
+	assert.NoError(t, w.Add(Person{Being: Being{ID: 2}, Happiness: 500}))
+	assert.EqualError(t, w.Add(Person{Being: Being{ID: -2}}), "negative id -2")
+
+	// the records that were added are written
+	// before the rejected ones are reported
+	err = w.Write()
+	assert.EqualError(t, err, "2 record(s) rejected by OnAdd, the first with: negative id -1")
+	assert.True(t, errors.Is(err, errNegative))
+	assert.NoError(t, w.Write())
+
+	w.Add(Person{Being: Being{ID: 3}})
+	assert.NoError(t, w.Write())
+	w.Add(Person{Being: Being{ID: -3}})
+	assert.EqualError(t, w.Close(), "1 record(s) rejected by OnAdd, the first with: negative id -3")
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Person{
+			{Being: Being{ID: 1}, Happiness: 50},
+			{Being: Being{ID: 2}, Happiness: 100},
+			{Being: Being{ID: 3}},
+		}, out)
+	}
+
+	// a SortedWriter checks the records as they are added
+	buf.Reset()
+	sw, err := NewSortedWriter(&buf, func(a, b Person) bool { return a.ID < b.ID }, 2, OnAdd(onAdd))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, id := range []int32{5, -1, 4, 3} {
+		sw.Add(Person{Being: Being{ID: id}, Happiness: 1000})
+	}
+	assert.EqualError(t, sw.Close(), "1 record(s) rejected by OnAdd, the first with: negative id -1")
+
+	out, err = SafeRead(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Person{
+			{Being: Being{ID: 3}, Happiness: 100},
+			{Being: Being{ID: 4}, Happiness: 100},
+			{Being: Being{ID: 5}, Happiness: 100},
+		}, out)
+	}
+}
+
+func TestSortedWriter(t *testing.T) {
+	// the records are sorted by their happiness, and the ones
+	// with the same happiness stay in the order they were added
+	var input []Person
+	for i, p := range getPeople(100, 100)[0] {
+		p.Happiness = int64((i * 37) % 10)
+		input = append(input, p)
+	}
+	less := func(a, b Person) bool { return a.Happiness < b.Happiness }
+
+	expected := append([]Person{}, input...)
+	sort.SliceStable(expected, func(i, j int) bool { return less(expected[i], expected[j]) })
+
+	testCases := []struct {
+		name    string
+		maxRows int
+	}{
+		{name: "in memory", maxRows: 1000},
+		{name: "runs", maxRows: 7},
+		{name: "one record per run", maxRows: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			var buf bytes.Buffer
+			w, err := NewSortedWriter(&buf, less, tc.maxRows, SortingColumns(parquet.SortingColumn{Column: "happiness"}))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, p := range input {
+				w.Add(p)
+			}
+			assert.NoError(t, w.Close())
+
+			// the runs are removed
+			runs, err := os.ReadDir(dir)
+			if assert.NoError(t, err) {
+				assert.Empty(t, runs)
+			}
+
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var out []Person
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				out = append(out, p)
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, expected, out)
+
+			footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+			if !assert.NoError(t, err) {
+				return
+			}
+			rows := tc.maxRows
+			if rows > len(input) {
+				rows = len(input)
+			}
+			assert.Len(t, footer.RowGroups, (len(input)+rows-1)/rows)
+			assert.Equal(t, int64(rows), footer.RowGroups[0].NumRows)
+
+			for i := range footer.RowGroups {
+				cols, err := r.SortingColumns(i)
+				if assert.NoError(t, err) {
+					assert.Equal(t, []parquet.SortingColumn{{Column: "happiness"}}, cols)
+				}
+			}
+		})
+	}
+
+	_, err := NewSortedWriter(&bytes.Buffer{}, less, 0)
+	assert.EqualError(t, err, "invalid max rows 0, it must be at least 1")
+
+	_, err = NewSortedWriter(&bytes.Buffer{}, less, 10, SortingColumns(parquet.SortingColumn{Column: "nope"}))
+	assert.EqualError(t, err, "invalid sorting column, there is no column nope")
+}
+
+func TestSortingColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, SortingColumns(
+		parquet.SortingColumn{Column: "id"},
+		parquet.SortingColumn{Column: "hobby.name", Descending: true, NullsFirst: true},
+	))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w.Add(Person{Being: Being{ID: 1}})
+	w.Add(Person{Being: Being{ID: 2}, Hobby: &Hobby{Name: "b"}})
+	w.Add(Person{Being: Being{ID: 2}, Hobby: &Hobby{Name: "a"}})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cols, err := r.SortingColumns(0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []parquet.SortingColumn{
+			{Column: "id"},
+			{Column: "hobby.name", Descending: true, NullsFirst: true},
+		}, cols)
+	}
+
+	_, err = r.SortingColumns(1)
+	assert.EqualError(t, err, "invalid row group 1, the file has 1 row groups")
+
+	// a file that doesn't say how it is sorted
+	buf.Reset()
+	w, err = NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	w.Add(Person{})
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	r, err = NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	cols, err = r.SortingColumns(0)
+	assert.NoError(t, err)
+	assert.Empty(t, cols)
+}
+
+func TestSplitWriter(t *testing.T) {
+	testCases := []struct {
+		name  string
+		size  int64
+		files int
+	}{
+		{name: "a file per row group", size: 1, files: 3},
+		{name: "one file", size: 1 << 20, files: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var files []*closingBuffer
+			next := func() (io.Writer, error) {
+				files = append(files, &closingBuffer{})
+				return files[len(files)-1], nil
+			}
+
+			w := NewSplitWriter(next, tc.size, Uncompressed)
+			input := getPeople(10, 30)
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			if !assert.Equal(t, tc.files, len(files)) {
+				return
+			}
+
+			var i int
+			for _, f := range files {
+				assert.True(t, f.closed)
+				people, err := SafeRead(bytes.NewReader(f.Bytes()))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, p := range people {
+					assert.Equal(t, *getExpected(input, i), p)
+					i++
+				}
+			}
+			assert.Equal(t, 30, i)
+		})
+	}
+}
+
+func TestMaxPageBytes(t *testing.T) {
+	// a page header that claims that its 4 bytes decompress to 1GB
+	var buf bytes.Buffer
+	buf.Write([]byte("PAR1"))
+
+	fld := parquet.Field{Name: "id", Path: []string{"id"}, Types: []int{0}, Type: Int32Type, RepetitionType: parquet.RepetitionRequired}
+	meta := parquet.New(fld)
+	meta.NextDoc()
+	if !assert.NoError(t, meta.WritePageHeader(&buf, fld.Path, 1<<30, 4, 1, 1, 0, 0, sch.CompressionCodec_SNAPPY, valueStats(writeInt32(1)))) {
+		return
+	}
+	buf.Write(writeInt32(1))
+	assert.NoError(t, meta.Footer(&buf))
+	buf.Write([]byte("PAR1"))
+
+	var alloc countingAllocator
+	_, err := NewParquetReader(bytes.NewReader(buf.Bytes()), WithAllocator(&alloc))
+	assert.EqualError(t, err, "unable to read field id (row group 0, offset 4), err: page size 1073741824 is larger than the maximum page size of 268435456 bytes")
+	assert.Equal(t, 0, alloc.allocs)
+
+	// the limit can be lowered (or raised)
+	buf.Reset()
+	w, err := NewParquetWriter(&buf, MaxPageSize(5))
+	if !assert.NoError(t, err) {
+		return
+	}
+	input := getPeople(10, 10)
+	for _, p := range input[0] {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	_, err = SafeRead(bytes.NewReader(buf.Bytes()), MaxPageBytes(8))
+	assert.EqualError(t, err, "unable to read field id (row group 0, offset 4), err: page size 20 is larger than the maximum page size of 8 bytes")
+
+	people, err := SafeRead(bytes.NewReader(buf.Bytes()), MaxPageBytes(1<<10))
+	assert.NoError(t, err)
+	assert.Len(t, people, 10)
+}
+
+// readAtCounter records the calls to ReadAt.
+type readAtCounter struct {
+	r     io.ReaderAt
+	calls []parquet.ByteRange
+}
+
+func (c *readAtCounter) ReadAt(p []byte, off int64) (int, error) {
+	c.calls = append(c.calls, parquet.ByteRange{Offset: off, Length: int64(len(p))})
+	return c.r.ReadAt(p, off)
+}
+
+func TestRangeReader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(3), Dictionary)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 30)
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	b := buf.Bytes()
+	ra := &readAtCounter{r: bytes.NewReader(b)}
+	rr := parquet.NewRangeReader(ra, int64(len(b)))
+
+	// the size of the footer, the header, and the footer
+	footer, err := rr.ReadFooter()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, ra.calls, 3)
+
+	// the column chunks of a column aren't next to each other
+	ranges, err := parquet.ColumnChunkRanges(footer, 0, "happiness")
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, err := NewParquetReader(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var expected []parquet.ByteRange
+	for i := range footer.RowGroups {
+		offset, length, err := r.ColumnChunkLocation(i, "happiness")
+		assert.NoError(t, err)
+		expected = append(expected, parquet.ByteRange{Offset: offset, Length: length})
+	}
+	assert.Equal(t, expected, ranges)
+
+	// unless the gap between them can be read too
+	ranges, err = parquet.ColumnChunkRanges(footer, 1<<20, "happiness")
+	if assert.NoError(t, err) {
+		last := expected[len(expected)-1]
+		assert.Equal(t, []parquet.ByteRange{{Offset: expected[0].Offset, Length: last.Offset + last.Length - expected[0].Offset}}, ranges)
+	}
+
+	_, err = parquet.ColumnChunkRanges(footer, 0, "bogus")
+	var unknown *parquet.UnknownColumnError
+	assert.True(t, errors.As(err, &unknown))
+
+	// but the chunks of all the columns of all the row groups are
+	ranges, err = parquet.ColumnChunkRanges(footer, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	size := int64(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	assert.Equal(t, []parquet.ByteRange{{Offset: 4, Length: int64(len(b)) - 8 - size - 4}}, ranges)
+
+	// so the whole file is read with one more call
+	rr.Prefetch(ranges...)
+	r, err = NewParquetReader(rr)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var i int
+	for r.Next() {
+		var p Person
+		r.Scan(&p)
+		assert.Equal(t, *getExpected(input, i), p)
+		i++
+	}
+	assert.NoError(t, r.Error())
+	assert.Equal(t, 30, i)
+	assert.Len(t, ra.calls, 4)
+}
+
+type countingAllocator struct {
+	allocs int
+	frees  int
+}
+
+func (c *countingAllocator) Alloc(n int) []byte {
+	c.allocs++
+	return make([]byte, n)
+}
+
+func (c *countingAllocator) Free([]byte) {
+	c.frees++
+}
+
+func TestAllocator(t *testing.T) {
+	for _, comp := range compressionCases {
+		t.Run(comp, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, MaxPageSize(3), compressionTest[comp])
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := getPeople(10, 20)
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			var a countingAllocator
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), WithAllocator(&a))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var i int
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				assert.Equal(t, *getExpected(input, i), p)
+				i++
+			}
+
+			assert.NoError(t, r.Error())
+			assert.Equal(t, 20, i)
+			assert.True(t, a.allocs > 0)
+			assert.Equal(t, a.allocs, a.frees)
+		})
+	}
+}
+
+func TestWithBuffers(t *testing.T) {
+	b := parquet.NewFieldBuffers()
+
+	// the files have smaller row groups (and fewer rows) each time,
+	// so each reader refills buffers that held more values
+	for _, tc := range []struct {
+		rowGroupSize int
+		rows         int
+	}{
+		{rowGroupSize: 10, rows: 30},
+		{rowGroupSize: 7, rows: 20},
+		{rowGroupSize: 3, rows: 5},
+	} {
+		t.Run(fmt.Sprintf("%d rows", tc.rows), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewParquetWriter(&buf, MaxPageSize(4))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			input := getPeople(tc.rowGroupSize, tc.rows)
+			for _, rowgroup := range input {
+				for _, p := range rowgroup {
+					w.Add(p)
+				}
+				assert.NoError(t, w.Write())
+			}
+			assert.NoError(t, w.Close())
+
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), WithBuffers(b))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var i int
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				assert.Equal(t, *getExpected(input, i), p)
+				i++
+			}
+
+			assert.NoError(t, r.Error())
+			assert.Equal(t, tc.rows, i)
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	type stats struct {
+		min      []byte
+		max      []byte
+		nilCount *int64
+	}
+
+	type testCase struct {
+		name     string
+		input    [][]Person
+		pageSize int
+		stats    []stats
+		col      string
+	}
+
+	testCases := []testCase{
+		{
+			name: "int64 single stat",
+			col:  "happiness",
+			input: [][]Person{
+				{
+					{Happiness: 1},
+					{Happiness: 2},
+					{Happiness: 22},
+				},
+			},
+			stats: []stats{
+				{min: writeInt64(1), max: writeInt64(22)},
+			},
+		},
+		{
+			name:     "int64 two pages",
+			col:      "happiness",
+			pageSize: 2,
+			input: [][]Person{
+				{
+					{Happiness: 1},
+					{Happiness: 2},
+					{Happiness: 22},
+				},
+			},
+			stats: []stats{
+				{min: writeInt64(1), max: writeInt64(2)},
+				{min: writeInt64(22), max: writeInt64(22)},
+			},
+		},
+		{
+			name: "int64 no stats",
+			col:  "happiness",
+			input: [][]Person{
+				{
+					{Being: Being{ID: 1}},
+					{Being: Being{ID: 2}},
+					{Being: Being{ID: 3}},
+				},
+			},
+			stats: []stats{
+				{min: writeInt64(0), max: writeInt64(0)},
+			},
+		},
+		{
+			name: "optional int64 no stats",
+			col:  "sadness",
+			input: [][]Person{
+				{
+					{Being: Being{ID: 1}},
+					{Being: Being{ID: 2}},
+					{Being: Being{ID: 3}},
+				},
+			},
+			stats: []stats{
+				{min: nil, max: nil, nilCount: pint64(3)},
+			},
+		},
+		{
+			name:     "optional int64 all nil multiple pages",
+			col:      "sadness",
+			pageSize: 2,
+			input: [][]Person{
+				{
+					{Being: Being{ID: 1}},
+					{Being: Being{ID: 2}},
+					{Being: Being{ID: 3}},
+					{Being: Being{ID: 4}},
+					{Being: Being{ID: 5}},
+				},
+			},
+			stats: []stats{
+				{min: nil, max: nil, nilCount: pint64(2)},
+				{min: nil, max: nil, nilCount: pint64(2)},
+				{min: nil, max: nil, nilCount: pint64(1)},
+			},
+		},
+		{
+			name: "int32 stats",
+			col:  "birthday",
+			input: [][]Person{
+				{
+					{Birthday: 10},
+					{Birthday: 20},
+					{Birthday: 30},
+				},
+			},
+			stats: []stats{
+				{min: writeInt32(10), max: writeInt32(30)},
+			},
+		},
+		{
+			name: "float64 stats",
+			col:  "boldness",
+			input: [][]Person{
+				{
+					{Boldness: 0.5},
+					{Boldness: 500.0},
+					{Boldness: -50.5},
+				},
+			},
+			stats: []stats{
+				{min: writeFloat64(-50.5), max: writeFloat64(500.0)},
+			},
+		},
+		{
+			name: "float32 optional stats",
+			col:  "lameness",
+			input: [][]Person{
+				{
+					{Lameness: pfloat32(0.5)},
+					{Lameness: pfloat32(500.0)},
+					{Lameness: pfloat32(50.5)},
+					{Lameness: nil},
+				},
+			},
+			stats: []stats{
+				{min: writeFloat32(0.5), max: writeFloat32(500.0), nilCount: pint64(1)},
+			},
+		},
+		{
+			name: "bool stats",
+			col:  "hungry",
+			input: [][]Person{
+				{
+					{Hungry: true},
+					{Hungry: false},
+					{Hungry: true},
+				},
+			},
+			stats: []stats{
+				{},
+			},
+		},
+		{
+			name: "optional bool stats",
+			col:  "keen",
+			input: [][]Person{
+				{
+					{Keen: pbool(true)},
+					{Keen: nil},
+					{Keen: nil},
+				},
+			},
+			stats: []stats{
+				{nilCount: pint64(2)},
+			},
+		},
+		{
+			name: "string stats",
+			col:  "bff",
+			input: [][]Person{
+				{
+					{BFF: "Fred"},
+					{BFF: "Val"},
+					{BFF: "Miranda"},
+				},
+			},
+			stats: []stats{
+				{min: []byte("Fred"), max: []byte("Val")},
+			},
+		},
+		{
+			name: "string optional stats",
+			col:  "code",
+			input: [][]Person{
+				{
+					{Code: pstring("Fred")},
+					{Code: nil},
+					{Code: pstring("Miranda")},
+				},
+			},
+			stats: []stats{
+				{min: []byte("Fred"), max: []byte("Miranda"), nilCount: pint64(1)},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		for j, comp := range []string{"uncompressed", "snappy"} {
+			t.Run(fmt.Sprintf("%02d %s %s", 2*i+j, tc.name, comp), func(t *testing.T) {
+				if tc.pageSize == 0 {
+					tc.pageSize = 100
+				}
+				var buf bytes.Buffer
+				w, err := NewParquetWriter(&buf, MaxPageSize(tc.pageSize), compressionTest[comp])
+				assert.Nil(t, err, tc.name)
+				for _, rowgroup := range tc.input {
+					for _, p := range rowgroup {
+						w.Add(p)
+					}
+					assert.Nil(t, w.Write(), tc.name)
+				}
+
+				err = w.Close()
+				assert.Nil(t, err, tc.name)
+
+				r := bytes.NewReader(buf.Bytes())
+				footer, err := parquet.ReadMetaData(r)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				pages, err := getPageHeaders(r, tc.col, footer)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				if !assert.Equal(t, len(tc.stats), len(pages), tc.name) {
+					return
+				}
+				for i, st := range tc.stats {
+					ph := pages[i]
+					assert.Equal(t, st.min, ph.DataPageHeader.Statistics.MinValue)
+					assert.Equal(t, st.max, ph.DataPageHeader.Statistics.MaxValue)
+					if st.nilCount == nil {
+						assert.Equal(t, st.nilCount, ph.DataPageHeader.Statistics.NullCount)
+					} else {
+						assert.Equal(t, *st.nilCount, *ph.DataPageHeader.Statistics.NullCount)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	names := map[string]string{}
+	for _, f := range Fields(compressionUnknown) {
+		names[f.Name()] = f.Schema().String()
+	}
+
+	for col, s := range map[string]string{
+		"id":          "id INT32 REQUIRED",
+		"age":         "age INT32 OPTIONAL",
+		"code":        "code BYTE_ARRAY OPTIONAL STRING",
+		"hungry":      "hungry BOOLEAN REQUIRED",
+		"anniversary": "anniversary INT64 OPTIONAL INTEGER(64, unsigned)",
+		"friends.id":  "friends.id INT32 REQUIRED",
+		"born":        "born INT64 REQUIRED TIMESTAMP(MICROS, utc)",
+		"died":        "died INT64 OPTIONAL TIMESTAMP(MILLIS, local)",
+	} {
+		assert.Equal(t, s, names[col], col)
+	}
+
+	assert.Equal(t, "OPTIONAL", parquet.Optional.String())
+	assert.Equal(t, "MICROS", parquet.Micros.String())
+	assert.Equal(t, "RepetitionType(7)", parquet.RepetitionType(7).String())
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+	schema := func() []parquet.Field {
+		var out []parquet.Field
+		for _, f := range Fields(compressionUnknown) {
+			out = append(out, f.Schema())
+		}
+		return out
+	}
+
+	fp := parquet.SchemaFingerprint(schema())
+	assert.Len(t, fp, 64)
+	assert.Equal(t, fp, parquet.SchemaFingerprint(schema()))
+
+	changes := map[string]func(fields []parquet.Field) []parquet.Field{
+		"name": func(fields []parquet.Field) []parquet.Field {
+			fields[1].Name = "nom"
+			fields[1].Path = []string{"nom"}
+			return fields
+		},
+		"type": func(fields []parquet.Field) []parquet.Field {
+			fields[0].Type = Int64Type
+			return fields
+		},
+		"repetition": func(fields []parquet.Field) []parquet.Field {
+			fields[0].RepetitionType = parquet.RepetitionOptional
+			return fields
+		},
+		"logical type": func(fields []parquet.Field) []parquet.Field {
+			for i, f := range fields {
+				if f.Name == "born" {
+					ts := parquet.Timestamp{Unit: parquet.Millis}
+					fields[i].LogicalType = ts.LogicalType()
+				}
+			}
+			return fields
+		},
+		"order": func(fields []parquet.Field) []parquet.Field {
+			fields[0], fields[1] = fields[1], fields[0]
+			return fields
+		},
+		"removed": func(fields []parquet.Field) []parquet.Field {
+			return fields[:len(fields)-1]
+		},
+	}
+
+	for name, change := range changes {
+		assert.NotEqual(t, fp, parquet.SchemaFingerprint(change(schema())), name)
+	}
+}
+
+func TestTruncateStats(t *testing.T) {
+	testCases := []struct {
+		name string
+		v    string
+		n    int
+		min  string
+		max  string
+	}{
+		{name: "short enough", v: "abc", n: 3, min: "abc", max: "abc"},
+		{name: "no limit", v: "abcdef", n: 0, min: "abcdef", max: "abcdef"},
+		{name: "ascii", v: "abcdef", n: 3, min: "abc", max: "abd"},
+		{name: "trailing 0xff", v: "ab\xff\xffcd", n: 4, min: "ab\xff\xff", max: "ac"},
+		{name: "all 0xff", v: "\xff\xff\xff", n: 2, min: "\xff\xff", max: "\xff\xff\xff"},
+		{name: "rune boundary", v: "aé€b", n: 4, min: "aé", max: "aê"},
+		{name: "rune that gets longer", v: "a\u007fb", n: 2, min: "a\u007f", max: "b"},
+		{name: "max rune", v: "\U0010ffffb", n: 4, min: "\U0010ffff", max: "\U0010ffffb"},
+		{name: "surrogates", v: "\ud7ffb", n: 3, min: "\ud7ff", max: "\ue000"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			min := parquet.TruncateMin([]byte(tc.v), tc.n)
+			max := parquet.TruncateMax([]byte(tc.v), tc.n)
+			assert.Equal(t, tc.min, string(min))
+			assert.Equal(t, tc.max, string(max))
+			assert.True(t, tc.min <= tc.v)
+			assert.True(t, tc.max >= tc.v)
+		})
+	}
+}
+
+func TestStatsTruncateLength(t *testing.T) {
+	_, err := NewParquetWriter(&bytes.Buffer{}, StatsTruncateLength(0))
+	assert.EqualError(t, err, "invalid stats truncate length 0, it must be at least 1")
+
+	for _, v2 := range []bool{false, true} {
+		opts := []func(*ParquetWriter) error{StatsTruncateLength(4), MaxPageSize(2)}
+		if v2 {
+			opts = append(opts, DataPageV2)
+		}
+
+		var buf bytes.Buffer
+		w, err := NewParquetWriter(&buf, opts...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := []Person{
+			{BFF: "aaaaaaaa", Code: pstring("zzzzzz")},
+			{BFF: "abc", Code: pstring("ab")},
+			{BFF: "hello world"},
+		}
+		for _, p := range input {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+		assert.NoError(t, w.Close())
+
+		r := bytes.NewReader(buf.Bytes())
+		footer, err := parquet.ReadMetaData(r)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := map[string][][2]string{
+			// the second page of each column has a child writer's
+			// stats, which must be truncated too
+			"bff":  {{"aaaa", "abc"}, {"hell", "helm"}},
+			"code": {{"ab", "zzz{"}, {"", ""}},
+		}
+
+		for col, stats := range expected {
+			pages, err := getPageHeaders(r, col, footer)
+			if !assert.NoError(t, err) || !assert.Len(t, pages, len(stats)) {
+				return
+			}
+
+			for i, ph := range pages {
+				var st *sch.Statistics
+				if v2 {
+					st = ph.DataPageHeaderV2.Statistics
+				} else {
+					st = ph.DataPageHeader.Statistics
+				}
+				assert.Equal(t, stats[i][0], string(st.MinValue), col)
+				assert.Equal(t, stats[i][1], string(st.MaxValue), col)
+			}
+		}
+
+		rr, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var i int
+		for rr.Next() {
+			var p Person
+			rr.Scan(&p)
+			assert.Equal(t, input[i].BFF, p.BFF)
+			i++
+		}
+		assert.NoError(t, rr.Error())
+		assert.Equal(t, 3, i)
+	}
+}
+
+func getPageHeaders(r io.ReadSeeker, name string, footer *sch.FileMetaData) ([]sch.PageHeader, error) {
+	var out []sch.PageHeader
+	for _, rg := range footer.RowGroups {
+		for _, col := range rg.Columns {
+			pth := col.MetaData.PathInSchema
+			if pth[len(pth)-1] == name {
+				h, err := parquet.PageHeadersAtOffset(r, col.MetaData.DataPageOffset, col.MetaData.NumValues)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, h...)
+			}
+		}
+	}
+	return out, nil
+}
+
+var compressionTest = map[string]func(*ParquetWriter) error{
+	"uncompressed": Uncompressed,
+	"snappy":       Snappy,
+	"gzip":         Gzip,
+}
+
+func getLen(peeps [][]Person) int {
+	var l int
+	for _, rg := range peeps {
+		l += len(rg)
+	}
+	return l
+}
+
+func getExpected(peeps [][]Person, i int) *Person {
+	for _, rg := range peeps {
+		if i < len(rg) {
+			return &rg[i]
+		}
+		i -= len(rg)
+	}
+	return nil
+}
+
+func getOptBools(count int) [][]Person {
+	var out [][]Person
+	var rg []Person
+	for i := 0; i < count; i++ {
+		if i > 0 && i%100 == 0 {
+			out = append(out, rg)
+			rg = []Person{}
+		}
+		r := rand.Intn(3)
+		var b *bool
+		switch r {
+		case 0:
+			x := true
+			b = &x
+		case 1:
+			x := false
+			b = &x
+		}
+		rg = append(rg, Person{Keen: b})
+	}
+	if len(rg) > 0 {
+		out = append(out, rg)
+	}
+	return out
+}
+
+func getBools(count int) [][]Person {
+	var out [][]Person
+	var rg []Person
+	for i := 0; i < count; i++ {
+		if i > 0 && i%100 == 0 {
+			out = append(out, rg)
+			rg = []Person{}
+		}
+		rg = append(rg, Person{Sleepy: rand.Intn(2) == 1})
+	}
+	if len(rg) > 0 {
+		out = append(out, rg)
+	}
+	return out
+}
+
+func randString(n int) *string {
+	if rand.Intn(2) == 0 {
+		return nil
+	}
+
+	b := make([]rune, n)
+	for i := range b {
+		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+	}
+	s := string(b)
+	return &s
+}
+
+func TestRequireExactSchema(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	people := getPeople(5, 5)[0]
+	for _, p := range people {
+		w.Add(p)
+	}
+	assert.NoError(t, w.Write())
+	assert.NoError(t, w.Close())
+
+	expected := func() []parquet.Field {
+		var out []parquet.Field
+		for _, f := range Fields(compressionUnknown) {
+			out = append(out, f.Schema())
+		}
+		return out
+	}
+
+	// a field is removed, one that isn't in the file is
+	// added, and two of them are changed
+	fields := expected()
+	var changed []parquet.Field
+	for _, f := range fields {
+		switch f.Name {
+		case "keen":
+			continue
+		case "age":
+			f.Type = Int64Type
+		case "hobby.name":
+			f.Types = []int{0, 0}
+		}
+		changed = append(changed, f)
+	}
+	changed = append(changed, parquet.Field{Name: "bogus", Path: []string{"bogus"}, Types: []int{0}, Type: Int64Type, RepetitionType: parquet.RepetitionRequired})
+
+	testCases := []struct {
+		name   string
+		fields []parquet.Field
+		err    string
+	}{
+		{name: "exact", fields: expected()},
+		{
+			name:   "different",
+			fields: changed,
+			err:    "the file's schema isn't the expected schema: missing columns bogus; unexpected columns keen; age is INT32 OPTIONAL, not INT64 OPTIONAL; the path of hobby.name has the repetition types [OPTIONAL REQUIRED], not [REQUIRED REQUIRED]",
+		},
+		{
+			name: "empty",
+			err:  "the file's schema isn't the expected schema: unexpected columns " + strings.Join(columnNames(expected()), ", "),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), RequireExactSchema(tc.fields))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				var mismatch *parquet.SchemaMismatchError
+				assert.True(t, errors.As(err, &mismatch))
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+			var out []Person
+			for r.Next() {
+				var p Person
+				r.Scan(&p)
+				out = append(out, p)
+			}
+			assert.NoError(t, r.Error())
+			assert.Equal(t, people, out)
+		})
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	var doc struct {
+		Columns []parquet.SchemaColumn `json:"columns"`
+	}
+	if !assert.NoError(t, json.Unmarshal([]byte(SchemaJSON()), &doc)) {
+		return
+	}
+
+	var names []string
+	cols := map[string]parquet.SchemaColumn{}
+	for _, c := range doc.Columns {
+		names = append(names, c.Name)
+		cols[c.Name] = c
+	}
+	assert.Equal(t, columnNames(fieldSchema), names)
+
+	expected := []parquet.SchemaColumn{
+		{Name: "id", Path: []string{"id"}, PhysicalType: "INT32", Repetition: "REQUIRED", Repetitions: []string{"REQUIRED"}},
+		{Name: "age", Path: []string{"age"}, PhysicalType: "INT32", Repetition: "OPTIONAL", Repetitions: []string{"OPTIONAL"}, Nullable: true},
+		{
+			Name: "anniversary", Path: []string{"anniversary"}, PhysicalType: "INT64", Repetition: "OPTIONAL", Repetitions: []string{"OPTIONAL"},
+			Nullable: true, LogicalType: "INTEGER(64, unsigned)", ConvertedType: "UINT_64",
+		},
+		{
+			Name: "born", Path: []string{"born"}, PhysicalType: "INT64", Repetition: "REQUIRED", Repetitions: []string{"REQUIRED"},
+			LogicalType: "TIMESTAMP(MICROS, utc)", ConvertedType: "TIMESTAMP_MICROS",
+		},
+		{
+			Name: "hobby.skills.name", Path: []string{"hobby", "skills", "name"}, PhysicalType: "BYTE_ARRAY", Repetition: "REQUIRED",
+			Repetitions: []string{"OPTIONAL", "REPEATED", "REQUIRED"}, Nullable: true, Repeated: true, LogicalType: "STRING", ConvertedType: "UTF8",
+		},
+		{
+			Name: "friends.age", Path: []string{"friends", "age"}, PhysicalType: "INT32", Repetition: "OPTIONAL",
+			Repetitions: []string{"REPEATED", "OPTIONAL"}, Nullable: true, Repeated: true,
+		},
+	}
+	for _, c := range expected {
+		assert.Equal(t, c, cols[c.Name], c.Name)
+	}
+
+	// the keys are always in the same order
+	assert.Contains(t, SchemaJSON(), `{
+      "name": "name",
+      "path": [
+        "name"
+      ],
+      "physical_type": "BYTE_ARRAY",
+      "repetition": "REQUIRED",
+      "repetitions": [
+        "REQUIRED"
+      ],
+      "nullable": false,
+      "repeated": false,
+      "logical_type": "STRING",
+      "converted_type": "UTF8"
+    }`)
+}
+
+func columnNames(fields []parquet.Field) []string {
+	out := make([]string, len(fields))
+	for i, f := range fields {
+		out[i] = strings.Join(f.Path, ".")
+	}
+	return out
+}
+
+func TestWriterClosed(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// adding after Write starts the next row group
+	people := getPeople(3, 6)[0:2]
+	for _, rowgroup := range people {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+	written := append([]byte{}, buf.Bytes()...)
+
+	// after Close the records aren't added
+	assert.Equal(t, parquet.ErrWriterClosed, w.Add(newPerson(100)))
+	assert.Equal(t, parquet.ErrWriterClosed, w.Write())
+	assert.Equal(t, parquet.ErrWriterClosed, w.WriteWithMeta(map[string]string{"a": "b"}))
+	assert.Equal(t, parquet.ErrWriterClosed, w.Close())
+	assert.Equal(t, written, buf.Bytes())
+
+	footer, err := parquet.ReadMetaData(bytes.NewReader(buf.Bytes()))
+	if assert.NoError(t, err) {
+		assert.Len(t, footer.RowGroups, 2)
+	}
+
+	out, err := SafeRead(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, append(people[0], people[1]...), out)
+}
+
+func TestReadTail(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 35)
+	var people []Person
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		people = append(people, rowgroup...)
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	// the second and third row groups are overwritten, so
+	// reading them would return an error
+	data := append([]byte{}, buf.Bytes()...)
+	footer, err := parquet.ReadMetaData(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, rg := range footer.RowGroups[1:3] {
+		for _, ch := range rg.Columns {
+			start := ch.MetaData.DataPageOffset
+			if ch.MetaData.DictionaryPageOffset != nil {
+				start = *ch.MetaData.DictionaryPageOffset
+			}
+			copy(data[start:start+ch.MetaData.TotalCompressedSize], make([]byte, ch.MetaData.TotalCompressedSize))
+		}
+	}
+
+	_, err = SafeRead(bytes.NewReader(data))
+	assert.Error(t, err)
+
+	testCases := []struct {
+		n        int
+		data     []byte
+		expected []Person
+	}{
+		{n: 3, data: data, expected: people[32:]},
+		{n: 5, data: data, expected: people[30:]},
+		{n: 12, data: buf.Bytes(), expected: people[23:]},
+		{n: 100, data: buf.Bytes(), expected: people},
+		{n: 0, data: data, expected: []Person{}},
+	}
+
+	for _, tc := range testCases {
+		r, err := NewParquetReader(bytes.NewReader(tc.data))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		dst := make([]Person, tc.n)
+		n, err := r.ReadTail(tc.n, dst)
+		if assert.NoError(t, err, tc.n) {
+			assert.Equal(t, len(tc.expected), n, tc.n)
+			assert.Equal(t, tc.expected, dst[:n], tc.n)
+		}
+		assert.False(t, r.Next())
+	}
+
+	// the rows are read from where the reader is, if it's
+	// already past some of them
+	r, err := NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; i < 25; i++ {
+		assert.True(t, r.Next())
+	}
+	_, err = r.ReadTail(20, make([]Person, 20))
+	assert.EqualError(t, err, "can't read the last 20 rows, the reader has already passed row 15")
+
+	n, err := r.ReadTail(10, make([]Person, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+
+	r, err = NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = r.ReadTail(5, make([]Person, 4))
+	assert.EqualError(t, err, "can't read the last 5 rows into a slice of length 4")
+}
+
+func TestDumpColumn(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(3, 5)
+	input[0][1].Friends = []Being{{ID: 101}, {ID: 201}}
+	input[0][2].Hobby = &Hobby{Name: "knitting"}
+	input[1][1].Hobby = &Hobby{Name: "knitting"}
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	testCases := []struct {
+		col      string
+		expected string
+		err      string
+	}{
+		{col: "id", expected: "0\n1\n2\n3\n4\n"},
+		{col: "age", expected: "20\nnull\n22\nnull\n24\n"},
+		{col: "hobby.name", expected: "null\nnull\nknitting\nnull\nknitting\n"},
+		{col: "friends.id", expected: "null\n[101 201]\nnull\nnull\nnull\n"},
+		{col: "nope", err: "unknown field: nope"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.col, func(t *testing.T) {
+			var out bytes.Buffer
+			err := DumpColumn(bytes.NewReader(buf.Bytes()), tc.col, &out)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, out.String())
+			}
+		})
+	}
 }
 
-func getLen(peeps [][]Person) int {
-	var l int
-	for _, rg := range peeps {
-		l += len(rg)
+func TestInternStrings(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(4))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	input := getPeople(10, 20)
+	for i, rowgroup := range input {
+		for j, p := range rowgroup {
+			p.Name = fmt.Sprintf("category %d", j%3)
+			input[i][j] = p
+			w.Add(p)
+		}
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+
+	for _, opts := range [][]func(*ParquetReader){nil, {InternStrings}} {
+		r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), opts...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// the same value from different pages and row groups
+		// only shares its memory when the strings are interned
+		shared := map[string]uintptr{}
+		var i, same int
+		for r.Next() {
+			var p Person
+			r.Scan(&p)
+			assert.Equal(t, *getExpected(input, i), p)
+			i++
+
+			data := (*reflect.StringHeader)(unsafe.Pointer(&p.Name)).Data
+			if d, ok := shared[p.Name]; ok && d == data {
+				same++
+			}
+			shared[p.Name] = data
+		}
+		assert.NoError(t, r.Error())
+		assert.Equal(t, 20, i)
+
+		if opts == nil {
+			assert.Equal(t, 0, same)
+		} else {
+			assert.Equal(t, 17, same)
+		}
 	}
-	return l
 }
 
-func getExpected(peeps [][]Person, i int) *Person {
-	for _, rg := range peeps {
-		if i < len(rg) {
-			return &rg[i]
+func TestArrowWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewArrowWriter(&buf)
+	input := getPeople(6, 10)
+	var people []Person
+	for _, rowgroup := range input {
+		for _, p := range rowgroup {
+			w.Add(p)
+			people = append(people, p)
 		}
-		i -= len(rg)
+		assert.NoError(t, w.Write())
 	}
-	return nil
+	assert.NoError(t, w.Close())
+
+	fields, batches, err := readArrowStream(t, buf.Bytes())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	assert.Equal(t, []string{"id", "name", "age", "happiness", "sadness", "code", "funkiness", "boldness", "lameness", "keen", "birthday", "anniversary", "bff", "hungry", "hobby.name", "hobby.difficulty", "Sleepy", "born", "died"}, names)
+	assert.Equal(t, arrowField{name: "age", nullable: true, typ: 2, width: 32, signed: true}, fields[2])
+	assert.Equal(t, arrowField{name: "birthday", typ: 2, width: 32}, fields[10])
+	assert.Equal(t, arrowField{name: "lameness", nullable: true, typ: 3, unit: 1}, fields[8])
+	assert.Equal(t, arrowField{name: "born", typ: 10, unit: 2, tz: "UTC"}, fields[17])
+	assert.Equal(t, arrowField{name: "died", nullable: true, typ: 10, unit: 1}, fields[18])
+
+	if !assert.Len(t, batches, 2) {
+		return
+	}
+
+	cols := map[string][]interface{}{}
+	for _, batch := range batches {
+		for i, col := range batch {
+			cols[fields[i].name] = append(cols[fields[i].name], col...)
+		}
+	}
+
+	for i, p := range people {
+		assert.Equal(t, int64(p.ID), cols["id"][i])
+		assert.Equal(t, p.Name, cols["name"][i])
+		assert.Equal(t, uint64(p.Birthday), cols["birthday"][i])
+		assert.Equal(t, p.Born.UnixMicro(), cols["born"][i])
+		assert.Equal(t, p.Hungry, cols["hungry"][i])
+		assert.Equal(t, float64(p.Boldness), cols["boldness"][i])
+
+		if p.Age == nil {
+			assert.Nil(t, cols["age"][i])
+		} else {
+			assert.Equal(t, int64(*p.Age), cols["age"][i])
+		}
+
+		if p.Keen == nil {
+			assert.Nil(t, cols["keen"][i])
+		} else {
+			assert.Equal(t, *p.Keen, cols["keen"][i])
+		}
+
+		if p.Hobby == nil {
+			assert.Nil(t, cols["hobby.name"][i])
+		} else {
+			assert.Equal(t, p.Hobby.Name, cols["hobby.name"][i])
+		}
+
+		if p.Anniversary == nil {
+			assert.Nil(t, cols["anniversary"][i])
+		} else {
+			assert.Equal(t, *p.Anniversary, cols["anniversary"][i])
+		}
+	}
+
+	// a stream without any records only has the schema
+	buf.Reset()
+	w = NewArrowWriter(&buf)
+	assert.NoError(t, w.Close())
+	fields, batches, err = readArrowStream(t, buf.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, fields, 19)
+	assert.Len(t, batches, 0)
 }
 
-func getOptBools(count int) [][]Person {
-	var out [][]Person
-	var rg []Person
-	for i := 0; i < count; i++ {
-		if i > 0 && i%100 == 0 {
-			out = append(out, rg)
-			rg = []Person{}
+// TestArrowWriterPyarrow reads the stream that the ArrowWriter
+// writes with pyarrow, if it is installed.
+func TestArrowWriterPyarrow(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "people.arrows")
+	f, err := os.Create(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewArrowWriter(f)
+	var people []Person
+	for _, rowgroup := range getPeople(6, 10) {
+		for _, p := range rowgroup {
+			w.Add(p)
+			people = append(people, p)
 		}
-		r := rand.Intn(3)
-		var b *bool
-		switch r {
-		case 0:
-			x := true
-			b = &x
+		assert.NoError(t, w.Write())
+	}
+	assert.NoError(t, w.Close())
+	assert.NoError(t, f.Close())
+
+	rows, err := parquettest.ArrowRows(pth)
+	if errors.Is(err, parquettest.ErrNotInstalled) {
+		if parquettest.Required("pyarrow") {
+			t.Fatalf("pyarrow is not installed but %s requires it", parquettest.RequireEnv)
+		}
+		t.Skip("pyarrow is not installed")
+	}
+	if !assert.NoError(t, err) || !assert.Len(t, rows, len(people)) {
+		return
+	}
+
+	for i, p := range people {
+		row := rows[i]
+		assert.Equal(t, float64(p.ID), row["id"], i)
+		assert.Equal(t, p.Name, row["name"], i)
+		assert.Equal(t, p.Hungry, row["hungry"], i)
+		assert.Equal(t, float64(p.Birthday), row["birthday"], i)
+		if p.Age == nil {
+			assert.Nil(t, row["age"], i)
+		} else {
+			assert.Equal(t, float64(*p.Age), row["age"], i)
+		}
+		if p.Hobby == nil {
+			assert.Nil(t, row["hobby.name"], i)
+		} else {
+			assert.Equal(t, p.Hobby.Name, row["hobby.name"], i)
+		}
+	}
+}
+
+// arrowField is a field of the schema of an Arrow stream.  typ is
+// the id of its type, and unit is the precision of a float or the
+// unit of a timestamp.
+type arrowField struct {
+	name     string
+	nullable bool
+	typ      uint8
+	width    int32
+	signed   bool
+	unit     int16
+	tz       string
+}
+
+// readArrowStream reads the schema and the values (nil if they are
+// null) of the record batches of an Arrow IPC stream.  It only
+// understands the types that the ArrowWriter writes.  An offset or
+// length that points past the end of the stream fails the test.
+func readArrowStream(t *testing.T, data []byte) ([]arrowField, [][][]interface{}, error) {
+	t.Helper()
+	var fields []arrowField
+	var batches [][][]interface{}
+	stream := arrowBuf{t: t, buf: data}
+	for {
+		if stream.uint32(0) != 0xffffffff {
+			return nil, nil, fmt.Errorf("missing continuation marker")
+		}
+
+		size := int(stream.uint32(4))
+		if size == 0 {
+			return fields, batches, nil
+		}
+
+		meta := arrowBuf{t: t, buf: stream.bytes(8, size)}
+		msg := fbTable{buf: meta, pos: int(meta.uint32(0))}
+		if v := msg.int16(0); v != 4 {
+			return nil, nil, fmt.Errorf("metadata version %d", v)
+		}
+
+		bodyLength := int(msg.int64(3))
+		body := arrowBuf{t: t, buf: stream.bytes(8+size, bodyLength)}
+		stream.buf = stream.buf[8+size+bodyLength:]
+		header := msg.table(2)
+
+		switch msg.uint8(1) {
 		case 1:
-			x := false
-			b = &x
+			start, n := header.vector(1)
+			for i := 0; i < n; i++ {
+				pos := start + 4*i
+				f := fbTable{buf: meta, pos: pos + int(meta.uint32(pos))}
+				if _, n := f.vector(5); n > 0 {
+					return nil, nil, fmt.Errorf("field %s has children", f.str(0))
+				}
+
+				af := arrowField{name: f.str(0), nullable: f.uint8(1) == 1, typ: f.uint8(2)}
+				typ := f.table(3)
+				switch af.typ {
+				case 2:
+					af.width, af.signed = typ.int32(0), typ.uint8(1) == 1
+				case 3:
+					af.unit = typ.int16(0)
+				case 10:
+					af.unit, af.tz = typ.int16(0), typ.str(1)
+				}
+				fields = append(fields, af)
+			}
+		case 3:
+			rows := int(header.int64(0))
+			nodes, _ := header.vector(1)
+			buffers, _ := header.vector(2)
+			buffer := func() arrowBuf {
+				offset := int(meta.uint64(buffers))
+				length := int(meta.uint64(buffers + 8))
+				buffers += 16
+				return arrowBuf{t: t, buf: body.bytes(offset, length)}
+			}
+
+			var batch [][]interface{}
+			for _, f := range fields {
+				if n := int(meta.uint64(nodes)); n != rows {
+					return nil, nil, fmt.Errorf("column %s has %d rows but the batch has %d", f.name, n, rows)
+				}
+				nodes += 16
+
+				valid := buffer()
+				var offsets arrowBuf
+				if f.typ == 5 {
+					offsets = buffer()
+				}
+				values := buffer()
+
+				col := make([]interface{}, rows)
+				for i := range col {
+					if len(valid.buf) > 0 && valid.uint8(i/8)&(1<<(i%8)) == 0 {
+						continue
+					}
+
+					switch f.typ {
+					case 2:
+						if f.width == 32 && f.signed {
+							col[i] = int64(int32(values.uint32(4 * i)))
+						} else if f.width == 32 {
+							col[i] = uint64(values.uint32(4 * i))
+						} else if f.signed {
+							col[i] = int64(values.uint64(8 * i))
+						} else {
+							col[i] = values.uint64(8 * i)
+						}
+					case 3:
+						if f.unit == 1 {
+							col[i] = float64(math.Float32frombits(values.uint32(4 * i)))
+						} else {
+							col[i] = math.Float64frombits(values.uint64(8 * i))
+						}
+					case 5:
+						start, end := int(offsets.uint32(4*i)), int(offsets.uint32(4*i+4))
+						col[i] = string(values.bytes(start, end-start))
+					case 6:
+						col[i] = values.uint8(i/8)&(1<<(i%8)) != 0
+					case 10:
+						col[i] = int64(values.uint64(8 * i))
+					}
+				}
+				batch = append(batch, col)
+			}
+			batches = append(batches, batch)
+		default:
+			return nil, nil, fmt.Errorf("unexpected message type %d", msg.uint8(1))
 		}
-		rg = append(rg, Person{Keen: b})
 	}
-	if len(rg) > 0 {
-		out = append(out, rg)
+}
+
+// arrowBuf is part of an Arrow stream.  Reading past its end fails
+// the test instead of panicking.
+type arrowBuf struct {
+	t   *testing.T
+	buf []byte
+}
+
+// bytes returns the n bytes at p.
+func (b arrowBuf) bytes(p, n int) []byte {
+	b.t.Helper()
+	if p < 0 || n < 0 || p+n > len(b.buf) {
+		b.t.Fatalf("invalid Arrow stream, %d bytes at %d are past its end (%d bytes)", n, p, len(b.buf))
 	}
-	return out
+	return b.buf[p : p+n]
 }
 
-func getBools(count int) [][]Person {
-	var out [][]Person
-	var rg []Person
-	for i := 0; i < count; i++ {
-		if i > 0 && i%100 == 0 {
-			out = append(out, rg)
-			rg = []Person{}
+func (b arrowBuf) uint8(p int) uint8 {
+	b.t.Helper()
+	return b.bytes(p, 1)[0]
+}
+
+func (b arrowBuf) uint16(p int) uint16 {
+	b.t.Helper()
+	return binary.LittleEndian.Uint16(b.bytes(p, 2))
+}
+
+func (b arrowBuf) uint32(p int) uint32 {
+	b.t.Helper()
+	return binary.LittleEndian.Uint32(b.bytes(p, 4))
+}
+
+func (b arrowBuf) uint64(p int) uint64 {
+	b.t.Helper()
+	return binary.LittleEndian.Uint64(b.bytes(p, 8))
+}
+
+// fbTable is a table of a flatbuffer that starts at pos.
+type fbTable struct {
+	buf arrowBuf
+	pos int
+}
+
+// field returns the position of field i (or -1 if it isn't set).
+func (t fbTable) field(i int) int {
+	t.buf.t.Helper()
+	vt := t.pos - int(int32(t.buf.uint32(t.pos)))
+	if 4+2*i >= int(t.buf.uint16(vt)) {
+		return -1
+	}
+
+	off := int(t.buf.uint16(vt + 4 + 2*i))
+	if off == 0 {
+		return -1
+	}
+	return t.pos + off
+}
+
+func (t fbTable) uint8(i int) uint8 {
+	t.buf.t.Helper()
+	if p := t.field(i); p >= 0 {
+		return t.buf.uint8(p)
+	}
+	return 0
+}
+
+func (t fbTable) int16(i int) int16 {
+	t.buf.t.Helper()
+	if p := t.field(i); p >= 0 {
+		return int16(t.buf.uint16(p))
+	}
+	return 0
+}
+
+func (t fbTable) int32(i int) int32 {
+	t.buf.t.Helper()
+	if p := t.field(i); p >= 0 {
+		return int32(t.buf.uint32(p))
+	}
+	return 0
+}
+
+func (t fbTable) int64(i int) int64 {
+	t.buf.t.Helper()
+	if p := t.field(i); p >= 0 {
+		if p%8 != 0 {
+			t.buf.t.Fatalf("invalid Arrow stream, field %d at %d isn't aligned", i, p)
 		}
-		rg = append(rg, Person{Sleepy: rand.Intn(2) == 1})
+		return int64(t.buf.uint64(p))
 	}
-	if len(rg) > 0 {
-		out = append(out, rg)
+	return 0
+}
+
+// table returns the table that field i refers to.
+func (t fbTable) table(i int) fbTable {
+	t.buf.t.Helper()
+	p := t.field(i)
+	if p < 0 {
+		t.buf.t.Fatalf("invalid Arrow stream, table field %d isn't set", i)
 	}
-	return out
+	return fbTable{buf: t.buf, pos: p + int(t.buf.uint32(p))}
 }
 
-func randString(n int) *string {
-	if rand.Intn(2) == 0 {
-		return nil
+// vector returns the position of the first element
+// of the vector that field i refers to and its length.
+func (t fbTable) vector(i int) (int, int) {
+	t.buf.t.Helper()
+	p := t.field(i)
+	if p < 0 {
+		return 0, -1
 	}
+	v := p + int(t.buf.uint32(p))
+	return v + 4, int(t.buf.uint32(v))
+}
 
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+func (t fbTable) str(i int) string {
+	t.buf.t.Helper()
+	start, n := t.vector(i)
+	if n < 0 {
+		return ""
 	}
-	s := string(b)
-	return &s
+	return string(t.buf.bytes(start, n))
 }
 
 func getPeople(rgSize, n int) [][]Person {
@@ -902,6 +6429,12 @@ func newPerson(i int) Person {
 		anv = &x
 	}
 
+	var died *time.Time
+	if i%4 == 0 {
+		d := time.Date(2050, 1, 2, 3, 4, 5, int(time.Millisecond)*i, time.Local)
+		died = &d
+	}
+
 	return Person{
 		Being: Being{
 			ID:  int32(i),
@@ -916,6 +6449,118 @@ func newPerson(i int) Person {
 		Keen:        keen,
 		Birthday:    uint32(i * 1000),
 		Anniversary: anv,
+		Born:        time.Date(1980, 1, 2, 3, 4, 5, 0, time.UTC).Add(time.Duration(i) * time.Microsecond),
+		Died:        died,
+	}
+}
+
+// goldenPeople is like getPeople except that none of the
+// values are random.
+// swing is the ids of the golden people.
+var swing = []int32{math.MinInt32, math.MaxInt32, math.MinInt32, 0, -1}
+
+func goldenPeople() [][]Person {
+	out := getPeople(10, 25)
+	for i := range out {
+		for j := range out[i] {
+			n := i*10 + j
+			p := &out[i][j]
+			p.Code = nil
+			if n%3 != 0 {
+				p.Code = pstring(fmt.Sprintf("code-%d", n%4))
+			}
+			p.Funkiness = float32(n) / 4
+			p.Boldness = float64(n) / 8
+			p.Lameness = nil
+			if n%2 == 0 {
+				p.Lameness = pfloat32(float32(n) / 2)
+			}
+			p.Name = fmt.Sprintf("person %d", n%6)
+			// the ids swing between the ends of an int32, so
+			// their deltas don't fit in one without wrapping
+			p.ID = swing[n%len(swing)]
+			p.BFF = fmt.Sprintf("bff %d", n%3)
+			p.Hungry = n%2 == 1
+			if n%5 == 0 {
+				p.Hobby = &Hobby{
+					Name:       "knitting",
+					Difficulty: pint32(int32(n)),
+					Skills:     []Skill{{Name: "purl", Difficulty: "hard"}, {Name: "knit", Difficulty: "easy"}},
+				}
+			}
+			if n%4 == 1 {
+				p.Friends = []Being{{ID: int32(n + 100), Name: "friend", Age: pint32(40)}, {ID: int32(n + 200)}}
+			}
+		}
+	}
+	return out
+}
+
+// writeCounter counts the writes to it, and fails them once fail is set.
+type writeCounter struct {
+	bytes.Buffer
+	writes int
+	fail   bool
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.fail {
+		return 0, fmt.Errorf("unable to write")
+	}
+	return w.Buffer.Write(p)
+}
+
+// peakWriter records the memory that is in use (after a garbage
+// collection) each time it is written to.
+type peakWriter struct {
+	peak   uint64
+	total  uint64
+	writes uint64
+}
+
+func (w *peakWriter) Write(p []byte) (int, error) {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if ms.HeapAlloc > w.peak {
+		w.peak = ms.HeapAlloc
+	}
+	w.total += ms.HeapAlloc
+	w.writes++
+	return len(p), nil
+}
+
+// BenchmarkWriteWide reports how much memory (on top of the rows
+// themselves) is in use while a large row group is written: the
+// most and the mean over each write to the io.Writer.
+func BenchmarkWriteWide(b *testing.B) {
+	input := getPeople(100000, 100000)[0]
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		var pw peakWriter
+		w, err := NewParquetWriter(&pw, MaxPageSize(10000))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, p := range input {
+			w.Add(p)
+		}
+
+		if err := w.Write(); err != nil {
+			b.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+
+		b.ReportMetric(float64(pw.peak)-float64(ms.HeapAlloc), "peak-B")
+		b.ReportMetric(float64(pw.total/pw.writes)-float64(ms.HeapAlloc), "mean-B")
 	}
 }
 
@@ -946,6 +6591,46 @@ func BenchmarkRead(b *testing.B) {
 	}
 }
 
+// BenchmarkBuffers reads a file over and over with and without
+// reusing the same FieldBuffers.
+func BenchmarkBuffers(b *testing.B) {
+	var buf bytes.Buffer
+	w, err := NewParquetWriter(&buf, MaxPageSize(10000))
+	assert.Nil(b, err)
+	for _, rowgroup := range getPeople(1000, 5000) {
+		for _, p := range rowgroup {
+			w.Add(p)
+		}
+		assert.Nil(b, w.Write())
+	}
+	assert.Nil(b, w.Close())
+
+	read := func(b *testing.B, opts ...func(*ParquetReader)) {
+		for i := 0; i < b.N; i++ {
+			r, err := NewParquetReader(bytes.NewReader(buf.Bytes()), opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			var p Person
+			for r.Next() {
+				r.Scan(&p)
+			}
+			if err := r.Error(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("new", func(b *testing.B) {
+		read(b)
+	})
+
+	b.Run("reused", func(b *testing.B) {
+		read(b, WithBuffers(parquet.NewFieldBuffers()))
+	})
+}
+
 func BenchmarkWrite(b *testing.B) {
 	var buf bytes.Buffer
 	w, err := NewParquetWriter(&buf, MaxPageSize(10000))
@@ -962,6 +6647,49 @@ func BenchmarkWrite(b *testing.B) {
 	assert.Nil(b, err, "benchmark write")
 }
 
+// BenchmarkStatistics compares computing the statistics of the
+// pages as the rows are added with computing them when each page
+// is written.
+func BenchmarkStatistics(b *testing.B) {
+	input := getPeople(10000, 10000)[0]
+	modes := map[string]parquet.StatsMode{
+		"add":   parquet.StatsOnAdd,
+		"write": parquet.StatsOnWrite,
+	}
+
+	for _, name := range []string{"add", "write"} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w, err := NewParquetWriter(io.Discard, MaxPageSize(1000), Statistics(modes[name]))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				for _, p := range input {
+					w.Add(p)
+				}
+
+				if err := w.Write(); err != nil {
+					b.Fatal(err)
+				}
+
+				if err := w.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// valueStats are the stats of a page, written by hand,
+// that only has one value.
+type valueStats []byte
+
+func (s valueStats) NullCount() *int64     { return nil }
+func (s valueStats) DistinctCount() *int64 { return nil }
+func (s valueStats) Min() []byte           { return s }
+func (s valueStats) Max() []byte           { return s }
+
 func writeInt64(i int64) []byte {
 	var buf bytes.Buffer
 	binary.Write(&buf, binary.LittleEndian, i)
@@ -1020,6 +6748,8 @@ type Person struct {
 	Hobby       *Hobby   `parquet:"hobby"`
 	Friends     []Being  `parquet:"friends"`
 	Sleepy      bool
+	Born        time.Time  `parquet:"born"`
+	Died        *time.Time `parquet:"died,unit=millis,utc=false"`
 }
 
 /*
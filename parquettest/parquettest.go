@@ -0,0 +1,156 @@
+// Package parquettest has helpers for checking that the files
+// written by parquet can be read by other parquet implementations.
+package parquettest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ErrNotInstalled is returned by AssertReadableBy when the tool
+// (or the library it needs) isn't installed.  Tests usually skip
+// instead of failing when they get it, unless the tool is Required.
+var ErrNotInstalled = errors.New("parquettest: tool is not installed")
+
+type tool struct {
+	bin  string
+	args func(path string) []string
+
+	// missing is the exit code that means the library the
+	// tool needs isn't installed (0 if there isn't one).
+	missing int
+}
+
+const pyarrowScript = `
+import sys
+try:
+    import pyarrow.parquet as pq
+except ImportError:
+    sys.exit(3)
+pq.read_table(sys.argv[1]).to_pylist()
+`
+
+var tools = map[string]tool{
+	// pyarrow decodes every value of every column
+	"pyarrow": {
+		bin:     "python3",
+		args:    func(path string) []string { return []string{"-c", pyarrowScript, path} },
+		missing: 3,
+	},
+	"duckdb": {
+		bin: "duckdb",
+		args: func(path string) []string {
+			p := strings.Replace(path, "'", "''", -1)
+			return []string{"-c", fmt.Sprintf("SELECT * FROM read_parquet('%s')", p)}
+		},
+	},
+	// parquet-tools is the parquet-mr command line tool
+	"parquet-tools": {
+		bin:  "parquet-tools",
+		args: func(path string) []string { return []string{"cat", path} },
+	},
+}
+
+// RequireEnv is the environment variable that lists (separated by
+// commas) the tools that have to be installed, for example in CI.
+const RequireEnv = "PARQUETTEST_REQUIRE"
+
+// Required is true if tool is listed in RequireEnv, so a test should
+// fail instead of skipping when AssertReadableBy returns
+// ErrNotInstalled.
+func Required(tool string) bool {
+	for _, name := range strings.Split(os.Getenv(RequireEnv), ",") {
+		if strings.TrimSpace(name) == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// Tools returns the names of the tools that AssertReadableBy knows about.
+func Tools() []string {
+	out := make([]string, 0, len(tools))
+	for name := range tools {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AssertReadableBy reads every row of the parquet file at path with
+// tool (see Tools) and returns an error, which includes the tool's
+// output, if the tool can't read it.  If the tool isn't installed
+// the error is ErrNotInstalled.
+func AssertReadableBy(path, tool string) error {
+	t, ok := tools[tool]
+	if !ok {
+		return fmt.Errorf("parquettest: unknown tool %q, expected one of %s", tool, strings.Join(Tools(), ", "))
+	}
+
+	_, err := t.run(tool, path)
+	return err
+}
+
+// run runs t with path and returns its stdout.
+func (t tool) run(name, path string) ([]byte, error) {
+	bin, err := exec.LookPath(t.bin)
+	if err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(bin, t.args(path)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && t.missing != 0 && exitErr.ExitCode() == t.missing {
+		return nil, ErrNotInstalled
+	}
+
+	if err != nil {
+		out := append(stdout.Bytes(), stderr.Bytes()...)
+		return nil, fmt.Errorf("parquettest: %s can't read %s: %s: %s", name, path, err, bytes.TrimSpace(out))
+	}
+	return stdout.Bytes(), nil
+}
+
+const pyarrowStreamScript = `
+import json, sys
+try:
+    import pyarrow.ipc as ipc
+except ImportError:
+    sys.exit(3)
+with open(sys.argv[1], 'rb') as f:
+    json.dump(ipc.open_stream(f).read_all().to_pylist(), sys.stdout, default=str)
+`
+
+// ArrowRows reads the Arrow IPC stream at path with pyarrow and
+// returns its rows, decoded from the JSON of pyarrow's to_pylist (so
+// numbers are float64s, and timestamps are strings).  If python3 or
+// pyarrow isn't installed the error is ErrNotInstalled.
+func ArrowRows(path string) ([]map[string]interface{}, error) {
+	t := tool{
+		bin:     "python3",
+		args:    func(path string) []string { return []string{"-c", pyarrowStreamScript, path} },
+		missing: 3,
+	}
+
+	out, err := t.run("pyarrow", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("parquettest: unable to decode pyarrow's rows of %s: %s", path, err)
+	}
+	return rows, nil
+}
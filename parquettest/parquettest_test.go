@@ -0,0 +1,27 @@
+package parquettest_test
+
+import (
+	"testing"
+
+	"github.com/rclayton-godaddy/parquet/parquettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTools(t *testing.T) {
+	assert.Equal(t, []string{"duckdb", "parquet-tools", "pyarrow"}, parquettest.Tools())
+}
+
+func TestUnknownTool(t *testing.T) {
+	err := parquettest.AssertReadableBy("testdata/x.parquet", "spark")
+	assert.EqualError(t, err, `parquettest: unknown tool "spark", expected one of duckdb, parquet-tools, pyarrow`)
+}
+
+func TestRequired(t *testing.T) {
+	t.Setenv(parquettest.RequireEnv, "duckdb, pyarrow")
+	assert.True(t, parquettest.Required("pyarrow"))
+	assert.True(t, parquettest.Required("duckdb"))
+	assert.False(t, parquettest.Required("parquet-tools"))
+
+	t.Setenv(parquettest.RequireEnv, "")
+	assert.False(t, parquettest.Required("pyarrow"))
+}
@@ -0,0 +1,260 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// ByteRange is a section of a file.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+func (b ByteRange) end() int64 {
+	return b.Offset + b.Length
+}
+
+// ColumnChunkRanges returns the byte ranges of the column chunks of cols
+// (or of every column if cols is empty) in every row group, in the order
+// that they are in the file.  Ranges that are at most gap bytes apart are
+// merged so that the bytes a reader needs can be fetched with as few
+// requests as possible (see RangeReader).
+func ColumnChunkRanges(footer *sch.FileMetaData, gap int64, cols ...string) ([]ByteRange, error) {
+	want := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		want[col] = true
+	}
+
+	var out []ByteRange
+	seen := map[string]bool{}
+	for i, rg := range footer.RowGroups {
+		for _, ch := range rg.Columns {
+			if ch.MetaData == nil {
+				return nil, fmt.Errorf("column chunk of row group %d has no metadata", i)
+			}
+
+			col := strings.Join(ch.MetaData.PathInSchema, ".")
+			if len(cols) > 0 && !want[col] {
+				continue
+			}
+			seen[col] = true
+
+			out = append(out, ByteRange{Offset: chunkOffset(ch), Length: ch.MetaData.TotalCompressedSize})
+		}
+	}
+
+	for _, col := range cols {
+		if !seen[col] && len(footer.RowGroups) > 0 {
+			return nil, &UnknownColumnError{Column: col}
+		}
+	}
+	return mergeRanges(out, gap), nil
+}
+
+// mergeRanges sorts ranges and merges the ones
+// that overlap or are at most gap bytes apart.
+func mergeRanges(ranges []ByteRange, gap int64) []ByteRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sorted := append([]ByteRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	out := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &out[len(out)-1]
+		if r.Offset-last.end() > gap {
+			out = append(out, r)
+			continue
+		}
+
+		if r.end() > last.end() {
+			last.Length = r.end() - last.Offset
+		}
+	}
+	return out
+}
+
+// RangeReader is an io.ReadSeeker that reads from an io.ReaderAt that
+// is slow to call (a file in object storage that is read with HTTP
+// range requests, for example).  Each range that is passed to Prefetch
+// is read with one call to ReadAt the first time any of its bytes are
+// read, and everything outside of the ranges is read with a ReadAt call
+// of its own.  Only the most recently read range is kept in memory (along
+// with the footer, see ReadFooter), so ranges should be added in the order
+// they will be read, which is what ColumnChunkRanges returns.
+type RangeReader struct {
+	r    io.ReaderAt
+	size int64
+	pos  int64
+
+	ranges []ByteRange
+	// cur is the index of the range in buf
+	cur int
+	buf []byte
+
+	// pinned are the header and footer,
+	// which are never let go of
+	pinned []cachedRange
+}
+
+type cachedRange struct {
+	ByteRange
+	data []byte
+}
+
+// NewRangeReader returns a RangeReader of the size bytes of r.
+func NewRangeReader(r io.ReaderAt, size int64) *RangeReader {
+	return &RangeReader{r: r, size: size, cur: -1}
+}
+
+// Prefetch sets the ranges (merging any that overlap) that
+// are each read with one call to ReadAt.
+func (r *RangeReader) Prefetch(ranges ...ByteRange) {
+	r.ranges = mergeRanges(ranges, 0)
+	r.cur = -1
+	r.buf = nil
+}
+
+// ReadFooter reads the footer (like ReadMetaData) with three
+// calls to ReadAt and keeps it, and the header that is checked before
+// it, in memory so that a reader that reads the footer again (like a
+// generated NewParquetReader) doesn't read them again.
+func (r *RangeReader) ReadFooter() (*sch.FileMetaData, error) {
+	var tail [8]byte
+	if r.size >= int64(len(tail)+len(magic)) {
+		if err := r.readAt(tail[:], r.size-8); err != nil {
+			return nil, err
+		}
+
+		n := int64(binary.LittleEndian.Uint32(tail[:4]))
+		if start := r.size - 8 - n; start >= int64(len(magic)) {
+			for _, b := range []ByteRange{{Offset: 0, Length: int64(len(magic))}, {Offset: start, Length: n + 8}} {
+				data := make([]byte, b.Length)
+				if err := r.readAt(data, b.Offset); err != nil {
+					return nil, err
+				}
+				r.pinned = append(r.pinned, cachedRange{ByteRange: b, data: data})
+			}
+		}
+	}
+	return ReadMetaData(r)
+}
+
+// Read reads from the current offset.
+func (r *RangeReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	data, err := r.cached()
+	if err != nil {
+		return 0, err
+	}
+
+	if data != nil {
+		n := copy(p, data)
+		r.pos += int64(n)
+		return n, nil
+	}
+
+	// only read up to the next range so that
+	// it is read with one call to ReadAt
+	end := r.size
+	for _, b := range r.ranges {
+		if b.Offset > r.pos {
+			end = b.Offset
+			break
+		}
+	}
+
+	if int64(len(p)) > end-r.pos {
+		p = p[:end-r.pos]
+	}
+
+	if err := r.readAt(p, r.pos); err != nil {
+		return 0, err
+	}
+	r.pos += int64(len(p))
+	return len(p), nil
+}
+
+// cached returns the data from the current offset to the end of the
+// range that it is in (reading the range if it hasn't been read yet),
+// or nil if it isn't in a range.
+func (r *RangeReader) cached() ([]byte, error) {
+	for _, c := range r.pinned {
+		if r.pos >= c.Offset && r.pos < c.end() {
+			return c.data[r.pos-c.Offset:], nil
+		}
+	}
+
+	i := sort.Search(len(r.ranges), func(i int) bool { return r.ranges[i].end() > r.pos })
+	if i == len(r.ranges) || r.ranges[i].Offset > r.pos {
+		return nil, nil
+	}
+
+	b := r.ranges[i]
+	if i != r.cur {
+		r.cur = -1
+		r.buf = make([]byte, min64(b.Length, r.size-b.Offset))
+		if err := r.readAt(r.buf, b.Offset); err != nil {
+			r.buf = nil
+			return nil, err
+		}
+		r.cur = i
+	}
+	return r.buf[r.pos-b.Offset:], nil
+}
+
+func (r *RangeReader) readAt(p []byte, offset int64) error {
+	n, err := r.r.ReadAt(p, offset)
+	if n == len(p) && errors.Is(err, io.EOF) {
+		// a ReaderAt can return io.EOF with the
+		// last bytes of its data
+		err = nil
+	}
+
+	if err == nil && n < len(p) {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Seek sets the offset of the next Read.
+func (r *RangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("invalid offset %d", offset)
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,200 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// syncMagic ends a sync marker (see WriteSyncMarker) the way
+// that magic ends a parquet file.
+var syncMagic = []byte("PSYN")
+
+// WriteSyncMarker writes a sync marker, which is the footer of the row
+// groups that have been written so far followed by its size and
+// syncMagic, so that Recover can rebuild the footer of a file that is
+// never closed (if the writer crashes, for example).  It is called
+// after EndRowGroup.  Nothing in the footer refers to a sync marker,
+// so readers skip over them.
+func (m *Metadata) WriteSyncMarker(w io.Writer) error {
+	i := len(m.rowGroups)
+	if i == 0 {
+		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
+	}
+
+	fmd, _ := m.fileMetaData()
+	buf, err := m.ts.Write(context.TODO(), fmd)
+	if err != nil {
+		return err
+	}
+
+	n := int64(len(buf) + 4 + len(syncMagic))
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(buf))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(syncMagic); err != nil {
+		return err
+	}
+
+	m.written += n
+	m.rowGroups[i-1].sync += n
+	return nil
+}
+
+// Recover rebuilds the metadata of a file that was written with sync
+// markers (see WriteSyncMarker) when its footer is missing or corrupt.
+// It returns the Metadata of the row groups that were written before
+// the last sync marker as though ReadFooter had read it.  A row group
+// that was only partly written is left out.  WriteFooter appends the
+// rebuilt footer to the file so that it can be read again.
+func Recover(r io.ReadSeeker) (*Metadata, error) {
+	if err := checkHeader(r); err != nil {
+		return nil, err
+	}
+
+	offsets, err := syncMarkers(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the values of a column can look like a sync marker, so
+	// the last one that is a valid footer is the one to use
+	for i := len(offsets) - 1; i >= 0; i-- {
+		fmd, err := readSyncMarker(r, offsets[i])
+		if err != nil {
+			continue
+		}
+
+		m := New()
+		m.metadata = fmd
+		return m, nil
+	}
+	return nil, fmt.Errorf("unable to recover the file, it doesn't have any sync markers")
+}
+
+// WriteFooter writes the footer that was read by ReadFooter (or rebuilt
+// by Recover) and the marker that ends a parquet file.
+func (m *Metadata) WriteFooter(w io.Writer) error {
+	if m.metadata == nil {
+		return fmt.Errorf("no footer, you must call ReadFooter or Recover first")
+	}
+
+	buf, err := m.ts.Write(context.TODO(), m.metadata)
+	if err != nil {
+		return err
+	}
+
+	n, err := w.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(n)); err != nil {
+		return err
+	}
+
+	_, err = w.Write(magic)
+	return err
+}
+
+// syncMarkers returns the offset of every syncMagic in r.
+func syncMarkers(r io.ReadSeeker) ([]int64, error) {
+	if _, err := r.Seek(int64(len(magic)), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var out []int64
+	buf := make([]byte, 64<<10)
+	pos := int64(len(magic))
+	var n int
+	for {
+		m, err := io.ReadFull(r, buf[n:])
+		n += m
+		for i := 0; ; {
+			j := bytes.Index(buf[i:n], syncMagic)
+			if j < 0 {
+				break
+			}
+			out = append(out, pos+int64(i+j))
+			i += j + 1
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the end of buf is kept in case a
+		// marker is split between two reads
+		keep := len(syncMagic) - 1
+		copy(buf, buf[n-keep:n])
+		pos += int64(n - keep)
+		n = keep
+	}
+}
+
+// readSyncMarker reads the footer of the sync marker whose syncMagic
+// is at offset and checks that its column chunks come before it.
+func readSyncMarker(r io.ReadSeeker, offset int64) (*sch.FileMetaData, error) {
+	if _, err := r.Seek(offset-4, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+
+	start := offset - 4 - int64(size)
+	if size == 0 || start < int64(len(magic)) {
+		return nil, fmt.Errorf("invalid sync marker size %d", size)
+	}
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	footer := make([]byte, size)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, err
+	}
+
+	fmd := sch.NewFileMetaData()
+	if err := fmd.Read(newThriftReader(bytes.NewReader(footer), int64(size))); err != nil {
+		return nil, err
+	}
+
+	if err := checkMetaData(fmd); err != nil {
+		return nil, err
+	}
+
+	if len(fmd.Schema) == 0 || len(fmd.RowGroups) == 0 {
+		return nil, fmt.Errorf("sync marker without a schema or row groups")
+	}
+
+	var rows int64
+	for _, rg := range fmd.RowGroups {
+		rows += rg.NumRows
+		for _, ch := range rg.Columns {
+			if o := chunkOffset(ch); o < int64(len(magic)) || o+ch.MetaData.TotalCompressedSize > start {
+				return nil, fmt.Errorf("column chunk at %d isn't before the sync marker at %d", o, start)
+			}
+		}
+	}
+
+	if rows != fmd.NumRows {
+		return nil, fmt.Errorf("sync marker has %d rows but its row groups have %d", fmd.NumRows, rows)
+	}
+	return fmd, nil
+}
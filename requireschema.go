@@ -0,0 +1,116 @@
+package parquet
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// RequireSchema returns a *SchemaMismatchError unless the columns of
+// the file that was read by ReadFooter are exactly fields: the file
+// must have a column with the path of each of them, with the same
+// physical type, repetition types, and annotations, and it can't have
+// any other columns.
+func (m *Metadata) RequireSchema(fields []Field) error {
+	got, err := m.SchemaFields()
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		want[strings.Join(f.Path, ".")] = f
+	}
+
+	var e SchemaMismatchError
+	have := make(map[string]bool, len(got))
+	for _, f := range got {
+		col := strings.Join(f.Path, ".")
+		have[col] = true
+		w, ok := want[col]
+		if !ok {
+			e.Unexpected = append(e.Unexpected, col)
+			continue
+		}
+
+		if d := schemaDiff(col, f, w); d != "" {
+			e.Different = append(e.Different, d)
+		}
+	}
+
+	for _, f := range fields {
+		if col := strings.Join(f.Path, "."); !have[col] {
+			e.Missing = append(e.Missing, col)
+		}
+	}
+
+	if len(e.Missing) == 0 && len(e.Unexpected) == 0 && len(e.Different) == 0 {
+		return nil
+	}
+	return &e
+}
+
+// schemaDiff describes how the column col (got) is different from the
+// expected one (want), or returns an empty string if it isn't.
+func schemaDiff(col string, got, want Field) string {
+	var g, w sch.SchemaElement
+	annotateField(got, &g)
+	annotateField(want, &w)
+	if g.GetType() != w.GetType() ||
+		g.GetTypeLength() != w.GetTypeLength() ||
+		g.GetRepetitionType() != w.GetRepetitionType() ||
+		g.GetScale() != w.GetScale() ||
+		g.GetPrecision() != w.GetPrecision() ||
+		g.IsSetConvertedType() != w.IsSetConvertedType() ||
+		g.GetConvertedType() != w.GetConvertedType() ||
+		!reflect.DeepEqual(g.LogicalType, w.LogicalType) {
+		return fmt.Sprintf("%s is %s, not %s", col, columnString(g), columnString(w))
+	}
+
+	if !reflect.DeepEqual(got.Types, want.Types) {
+		return fmt.Sprintf("the path of %s has the repetition types %s, not %s", col, typesString(got.Types), typesString(want.Types))
+	}
+	return ""
+}
+
+// annotateField is Field.annotate for a field whose
+// Type or RepetitionType might not be set.
+func annotateField(f Field, se *sch.SchemaElement) {
+	if f.Type != nil {
+		f.Type(se)
+	}
+	if f.RepetitionType != nil {
+		f.RepetitionType(se)
+	}
+	if f.ConvertedType != nil {
+		se.ConvertedType = f.ConvertedType
+	}
+	if f.LogicalType != nil {
+		se.LogicalType = f.LogicalType
+	}
+}
+
+// columnString describes the type of the column se, for example
+// "INT32 OPTIONAL" or "FIXED_LEN_BYTE_ARRAY(16) REQUIRED DECIMAL(38, 2)".
+func columnString(se sch.SchemaElement) string {
+	out := typeString(se) + " " + se.GetRepetitionType().String()
+	switch a := annotation(se.LogicalType, se.ConvertedType); {
+	case se.ConvertedType != nil && *se.ConvertedType == sch.ConvertedType_DECIMAL:
+		out += fmt.Sprintf(" DECIMAL(%d, %d)", se.GetPrecision(), se.GetScale())
+	case a != "":
+		out += " " + a
+	}
+	return out
+}
+
+// typesString returns the names of the repetition types
+// of a path, for example [REQUIRED OPTIONAL].
+func typesString(types []int) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = sch.FieldRepetitionType(t).String()
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
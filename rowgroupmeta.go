@@ -0,0 +1,76 @@
+package parquet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// RowGroupMetadataPrefix starts the keys of the footer's key/value
+// metadata that hold the metadata of a row group.  The row group's
+// metadata isn't part of the row group (parquet doesn't have key/value
+// metadata for a row group), so each of its keys is stored in the file's
+// key/value metadata as RowGroupMetadataPrefix, the index of the row
+// group in the footer, a dot, and the key ("rowgroup.2.partition", for
+// example).
+const RowGroupMetadataPrefix = "rowgroup."
+
+// SetRowGroupMetadata sets the key/value metadata of the row group
+// that is being written.  Nothing is stored if the row group doesn't
+// have any rows.
+func (m *Metadata) SetRowGroupMetadata(kv map[string]string) {
+	if len(m.rowGroups) == 0 {
+		return
+	}
+
+	meta := make(map[string]string, len(kv))
+	for k, v := range kv {
+		meta[k] = v
+	}
+	m.rowGroups[len(m.rowGroups)-1].meta = meta
+}
+
+// RowGroupMetadata returns the key/value metadata of row group rg
+// (it is empty if the row group doesn't have any).
+func (m *Metadata) RowGroupMetadata(rg int) (map[string]string, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	if rg < 0 || rg >= len(m.metadata.RowGroups) {
+		return nil, fmt.Errorf("invalid row group %d, the file has %d row groups", rg, len(m.metadata.RowGroups))
+	}
+
+	prefix := RowGroupMetadataPrefix + strconv.Itoa(rg) + "."
+	out := map[string]string{}
+	for _, kv := range m.metadata.KeyValueMetadata {
+		if !strings.HasPrefix(kv.Key, prefix) {
+			continue
+		}
+		out[strings.TrimPrefix(kv.Key, prefix)] = kv.GetValue()
+	}
+	return out, nil
+}
+
+// rowGroupMetadata returns the footer's key/value
+// metadata for the metadata of row group rg.
+func rowGroupMetadata(rg int, meta map[string]string) []*sch.KeyValue {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*sch.KeyValue, len(keys))
+	for i, k := range keys {
+		v := meta[k]
+		out[i] = &sch.KeyValue{
+			Key:   fmt.Sprintf("%s%d.%s", RowGroupMetadataPrefix, rg, k),
+			Value: &v,
+		}
+	}
+	return out
+}
@@ -1,6 +1,13 @@
 package schema
 
 // Code generated by Thrift Compiler (0.11.0).  DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING
+//
+// This file has been edited by hand since it was generated: the
+// Float16Type struct and LogicalType's FLOAT16 field (field 15), which
+// parquet-format added in 2.10, were written to match what the compiler
+// generates for the other logical types.  Regenerating the file from
+// an older parquet.thrift would drop them, so regenerate it from a
+// parquet.thrift that has FLOAT16.
 
 import (
 	"bytes"
@@ -1140,6 +1147,61 @@ func (p *UUIDType) String() string {
 	return fmt.Sprintf("UUIDType(%+v)", *p)
 }
 
+type Float16Type struct {
+}
+
+func NewFloat16Type() *Float16Type {
+	return &Float16Type{}
+}
+
+func (p *Float16Type) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *Float16Type) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Float16Type"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *Float16Type) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("Float16Type(%+v)", *p)
+}
+
 type MapType struct {
 }
 
@@ -2593,6 +2655,7 @@ func (p *BsonType) String() string {
 //  - JSON
 //  - BSON
 //  - UUID
+//  - FLOAT16
 type LogicalType struct {
 	STRING    *StringType    `thrift:"STRING,1" db:"STRING" json:"STRING,omitempty"`
 	MAP       *MapType       `thrift:"MAP,2" db:"MAP" json:"MAP,omitempty"`
@@ -2603,11 +2666,12 @@ type LogicalType struct {
 	TIME      *TimeType      `thrift:"TIME,7" db:"TIME" json:"TIME,omitempty"`
 	TIMESTAMP *TimestampType `thrift:"TIMESTAMP,8" db:"TIMESTAMP" json:"TIMESTAMP,omitempty"`
 	// unused field # 9
-	INTEGER *IntType  `thrift:"INTEGER,10" db:"INTEGER" json:"INTEGER,omitempty"`
-	UNKNOWN *NullType `thrift:"UNKNOWN,11" db:"UNKNOWN" json:"UNKNOWN,omitempty"`
-	JSON    *JsonType `thrift:"JSON,12" db:"JSON" json:"JSON,omitempty"`
-	BSON    *BsonType `thrift:"BSON,13" db:"BSON" json:"BSON,omitempty"`
-	UUID    *UUIDType `thrift:"UUID,14" db:"UUID" json:"UUID,omitempty"`
+	INTEGER *IntType     `thrift:"INTEGER,10" db:"INTEGER" json:"INTEGER,omitempty"`
+	UNKNOWN *NullType    `thrift:"UNKNOWN,11" db:"UNKNOWN" json:"UNKNOWN,omitempty"`
+	JSON    *JsonType    `thrift:"JSON,12" db:"JSON" json:"JSON,omitempty"`
+	BSON    *BsonType    `thrift:"BSON,13" db:"BSON" json:"BSON,omitempty"`
+	UUID    *UUIDType    `thrift:"UUID,14" db:"UUID" json:"UUID,omitempty"`
+	FLOAT16 *Float16Type `thrift:"FLOAT16,15" db:"FLOAT16" json:"FLOAT16,omitempty"`
 }
 
 func NewLogicalType() *LogicalType {
@@ -2730,6 +2794,15 @@ func (p *LogicalType) GetUUID() *UUIDType {
 	}
 	return p.UUID
 }
+
+var LogicalType_FLOAT16_DEFAULT *Float16Type
+
+func (p *LogicalType) GetFLOAT16() *Float16Type {
+	if !p.IsSetFLOAT16() {
+		return LogicalType_FLOAT16_DEFAULT
+	}
+	return p.FLOAT16
+}
 func (p *LogicalType) CountSetFieldsLogicalType() int {
 	count := 0
 	if p.IsSetSTRING() {
@@ -2771,6 +2844,9 @@ func (p *LogicalType) CountSetFieldsLogicalType() int {
 	if p.IsSetUUID() {
 		count++
 	}
+	if p.IsSetFLOAT16() {
+		count++
+	}
 	return count
 
 }
@@ -2827,6 +2903,10 @@ func (p *LogicalType) IsSetUUID() bool {
 	return p.UUID != nil
 }
 
+func (p *LogicalType) IsSetFLOAT16() bool {
+	return p.FLOAT16 != nil
+}
+
 func (p *LogicalType) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -2971,6 +3051,16 @@ func (p *LogicalType) Read(iprot thrift.TProtocol) error {
 					return err
 				}
 			}
+		case 15:
+			if fieldTypeId == thrift.STRUCT {
+				if err := p.ReadField15(iprot); err != nil {
+					return err
+				}
+			} else {
+				if err := iprot.Skip(fieldTypeId); err != nil {
+					return err
+				}
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -3090,6 +3180,14 @@ func (p *LogicalType) ReadField14(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *LogicalType) ReadField15(iprot thrift.TProtocol) error {
+	p.FLOAT16 = &Float16Type{}
+	if err := p.FLOAT16.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.FLOAT16), err)
+	}
+	return nil
+}
+
 func (p *LogicalType) Write(oprot thrift.TProtocol) error {
 	if c := p.CountSetFieldsLogicalType(); c != 1 {
 		return fmt.Errorf("%T write union: exactly one field must be set (%d set).", p, c)
@@ -3137,6 +3235,9 @@ func (p *LogicalType) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField14(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField15(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -3342,6 +3443,21 @@ func (p *LogicalType) writeField14(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
+func (p *LogicalType) writeField15(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFLOAT16() {
+		if err := oprot.WriteFieldBegin("FLOAT16", thrift.STRUCT, 15); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 15:FLOAT16: ", p), err)
+		}
+		if err := p.FLOAT16.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.FLOAT16), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 15:FLOAT16: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *LogicalType) String() string {
 	if p == nil {
 		return "<nil>"
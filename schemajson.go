@@ -0,0 +1,104 @@
+package parquet
+
+import (
+	"encoding/json"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// SchemaColumn describes a column in the document that SchemaJSON
+// returns.  Its fields (and their order) don't change, so the
+// document can be compared from one version of a schema to the next.
+type SchemaColumn struct {
+	// Name is the column's dotted path, for example "hobby.name".
+	Name string   `json:"name"`
+	Path []string `json:"path"`
+	// PhysicalType is how the values are stored, for example
+	// INT64 or BYTE_ARRAY, and TypeLength is the length of a
+	// FIXED_LEN_BYTE_ARRAY (it is 0 for the other types).
+	PhysicalType string `json:"physical_type"`
+	TypeLength   int32  `json:"type_length,omitempty"`
+	// Repetition is the column's own repetition type, and
+	// Repetitions are the repetition types of each part of its path.
+	Repetition  string   `json:"repetition"`
+	Repetitions []string `json:"repetitions"`
+	// Nullable is true if part of the path is OPTIONAL, so the
+	// column can be null, and Repeated is true if part of it is
+	// REPEATED, so the column holds a list of values.
+	Nullable bool `json:"nullable"`
+	Repeated bool `json:"repeated"`
+	// LogicalType and ConvertedType are the column's annotations,
+	// for example "TIMESTAMP(MILLIS, utc)" and "TIMESTAMP_MILLIS",
+	// which are empty if it doesn't have one.  Precision and Scale
+	// are only set for a DECIMAL.
+	LogicalType   string `json:"logical_type,omitempty"`
+	ConvertedType string `json:"converted_type,omitempty"`
+	Precision     int32  `json:"precision,omitempty"`
+	Scale         int32  `json:"scale,omitempty"`
+}
+
+// SchemaColumns describes fields (see SchemaColumn).
+func SchemaColumns(fields []Field) []SchemaColumn {
+	out := make([]SchemaColumn, len(fields))
+	for i, f := range fields {
+		var se sch.SchemaElement
+		annotateField(f, &se)
+		c := SchemaColumn{
+			Name:         strings.Join(f.Path, "."),
+			Path:         f.Path,
+			PhysicalType: se.GetType().String(),
+			TypeLength:   se.GetTypeLength(),
+			Repetition:   se.GetRepetitionType().String(),
+			Repetitions:  make([]string, len(f.Types)),
+			Precision:    se.GetPrecision(),
+			Scale:        se.GetScale(),
+		}
+
+		for j, t := range f.Types {
+			rt := sch.FieldRepetitionType(t)
+			c.Repetitions[j] = rt.String()
+			c.Nullable = c.Nullable || rt == sch.FieldRepetitionType_OPTIONAL
+			c.Repeated = c.Repeated || rt == sch.FieldRepetitionType_REPEATED
+		}
+
+		switch lt := se.LogicalType; {
+		case lt != nil && lt.DECIMAL != nil:
+			c.LogicalType = "DECIMAL"
+			c.Precision, c.Scale = lt.DECIMAL.Precision, lt.DECIMAL.Scale
+		case lt != nil:
+			c.LogicalType = annotation(lt, nil)
+		}
+		if se.ConvertedType != nil {
+			c.ConvertedType = se.ConvertedType.String()
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// SchemaJSON returns a JSON document that describes the columns of
+// fields, for a data dictionary, for example:
+//
+//	{
+//	  "columns": [
+//	    {
+//	      "name": "age",
+//	      ...
+//	      "physical_type": "INT32",
+//	      ...
+//	    }
+//	  ]
+//	}
+//
+// Each column is a SchemaColumn.
+func SchemaJSON(fields []Field) string {
+	doc := struct {
+		Columns []SchemaColumn `json:"columns"`
+	}{Columns: SchemaColumns(fields)}
+
+	// the document only has strings, numbers,
+	// and bools, so it can always be marshaled
+	out, _ := json.MarshalIndent(doc, "", "  ")
+	return string(out)
+}
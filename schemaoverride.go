@@ -0,0 +1,162 @@
+package parquet
+
+import (
+	"fmt"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// SetSchemaOverride replaces the schema of columns in the footer with
+// the fields in overrides, which is keyed by each column's dotted path.
+// It changes what the footer says about a column (its name, path, or
+// annotation, such as an INT64 that is a TIMESTAMP), not how its values
+// are written, so an override must have the column's physical type and
+// repetition types.  Any of an override's Path, Types, Type, and
+// RepetitionType that are left empty are the column's own (and so are
+// its annotations, if it has neither), and a Name without a Path
+// renames the column without moving it.
+func (m *Metadata) SetSchemaOverride(overrides map[string]Field) error {
+	out := make(map[string]Field, len(overrides))
+	for col, o := range overrides {
+		f, ok := m.schema.field(col)
+		if !ok {
+			return fmt.Errorf("invalid schema override, there is no column %s", col)
+		}
+
+		o, err := override(f, o)
+		if err != nil {
+			return fmt.Errorf("invalid schema override for column %s: %s", col, err)
+		}
+		out[col] = o
+	}
+
+	paths := map[string]string{}
+	for _, f := range m.schema.fields {
+		col := strings.Join(f.Path, ".")
+		pth := col
+		if o, ok := out[col]; ok {
+			pth = strings.Join(o.Path, ".")
+		}
+
+		if other, ok := paths[pth]; ok {
+			return fmt.Errorf("invalid schema override, columns %s and %s would both have the path %s", other, col, pth)
+		}
+		paths[pth] = col
+	}
+
+	m.overrides = out
+	return nil
+}
+
+// override fills in what o leaves out with what is in f (see
+// SetSchemaOverride) and makes sure that it is written the same way.
+func override(f, o Field) (Field, error) {
+	if o.Name == "" {
+		o.Name = f.Name
+	}
+	if len(o.Path) == 0 {
+		o.Path = append(append([]string{}, f.Path[:len(f.Path)-1]...), o.Name)
+	}
+	if len(o.Types) == 0 {
+		o.Types = f.Types
+	}
+	if o.Type == nil {
+		o.Type = f.Type
+	}
+	if o.RepetitionType == nil {
+		o.RepetitionType = f.RepetitionType
+	}
+	if o.ConvertedType == nil && o.LogicalType == nil {
+		o.ConvertedType, o.LogicalType = f.ConvertedType, f.LogicalType
+	}
+
+	if len(o.Types) != len(o.Path) {
+		return o, fmt.Errorf("it has %d repetition types for a path of length %d", len(o.Types), len(o.Path))
+	}
+
+	// the levels of the values depend on the repetition
+	// types of the column and the groups it is in
+	if len(o.Types) != len(f.Types) {
+		return o, fmt.Errorf("its path has length %d, not %d", len(o.Types), len(f.Types))
+	}
+	for i, t := range o.Types {
+		if t != f.Types[i] {
+			return o, fmt.Errorf("the repetition type of %s is %s, not %s", o.Path[i], sch.FieldRepetitionType(t), sch.FieldRepetitionType(f.Types[i]))
+		}
+	}
+
+	var fse, ose sch.SchemaElement
+	f.annotate(&fse)
+	o.annotate(&ose)
+	if fse.GetType() != ose.GetType() || fse.GetTypeLength() != ose.GetTypeLength() {
+		return o, fmt.Errorf("its physical type is %s, not %s", typeString(ose), typeString(fse))
+	}
+	if fse.GetRepetitionType() != ose.GetRepetitionType() {
+		return o, fmt.Errorf("its repetition type is %s, not %s", ose.GetRepetitionType(), fse.GetRepetitionType())
+	}
+	return o, nil
+}
+
+func typeString(se sch.SchemaElement) string {
+	if se.GetType() == sch.Type_FIXED_LEN_BYTE_ARRAY {
+		return fmt.Sprintf("%s(%d)", se.GetType(), se.GetTypeLength())
+	}
+	return se.GetType().String()
+}
+
+// field returns the field of the column col.
+func (s schema) field(col string) (Field, bool) {
+	for _, f := range s.fields {
+		if strings.Join(f.Path, ".") == col {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// overridden returns the schema with its fields replaced
+// by their overrides (see SetSchemaOverride).
+func (m *Metadata) overridden() schema {
+	if len(m.overrides) == 0 {
+		return m.schema
+	}
+
+	s := m.schema
+	s.fields = make([]Field, len(m.schema.fields))
+	for i, f := range m.schema.fields {
+		if o, ok := m.overrides[strings.Join(f.Path, ".")]; ok {
+			f = o
+		}
+		s.fields[i] = f
+	}
+	return s
+}
+
+// overridePath sets the path of ch to the path of its
+// column's override (if it has one).
+func (m *Metadata) overridePath(col string, ch *sch.ColumnChunk) {
+	o, ok := m.overrides[col]
+	if !ok {
+		return
+	}
+
+	// the chunk's metadata is shared with the row group
+	// that is being written, which still needs its path
+	md := *ch.MetaData
+	md.PathInSchema = o.Path
+	ch.MetaData = &md
+}
+
+// column returns the dotted path of the column whose chunks have
+// the path pth in the footer, which is the path of its override if
+// it has one (see overridePath).
+func (m *Metadata) column(pth []string) string {
+	col := strings.Join(pth, ".")
+	for c, o := range m.overrides {
+		if strings.Join(o.Path, ".") == col {
+			return c
+		}
+	}
+	return col
+}
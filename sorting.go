@@ -0,0 +1,86 @@
+package parquet
+
+import (
+	"fmt"
+	"strings"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// SortingColumn is a column that the rows of a row group are sorted
+// by (see Metadata.SetSortingColumns).
+type SortingColumn struct {
+	// Column is the column's dotted path.
+	Column string
+	// Descending is true if the values are sorted from
+	// the largest to the smallest.
+	Descending bool
+	// NullsFirst is true if the nulls come before the values.
+	NullsFirst bool
+}
+
+// SetSortingColumns records in the footer that the rows of every row
+// group are sorted by cols: by the first column, then by the second for
+// rows whose first columns are the same, and so on.  It doesn't sort
+// anything, so the rows must have been written in that order.
+func (m *Metadata) SetSortingColumns(cols ...SortingColumn) error {
+	out := make([]*sch.SortingColumn, len(cols))
+	for i, c := range cols {
+		idx := -1
+		for j, f := range m.schema.fields {
+			if strings.Join(f.Path, ".") == c.Column {
+				idx = j
+				break
+			}
+		}
+
+		if idx < 0 {
+			return fmt.Errorf("invalid sorting column, there is no column %s", c.Column)
+		}
+
+		out[i] = &sch.SortingColumn{
+			ColumnIdx:  int32(idx),
+			Descending: c.Descending,
+			NullsFirst: c.NullsFirst,
+		}
+	}
+
+	m.sortingColumns = out
+	return nil
+}
+
+// SortingColumns returns the columns that the rows of row group rg are
+// sorted by (it is empty if the footer doesn't say that they are).
+func (m *Metadata) SortingColumns(rg int) ([]SortingColumn, error) {
+	if m.metadata == nil {
+		return nil, fmt.Errorf("no footer, you must call ReadFooter first")
+	}
+
+	if rg < 0 || rg >= len(m.metadata.RowGroups) {
+		return nil, fmt.Errorf("invalid row group %d, the file has %d row groups", rg, len(m.metadata.RowGroups))
+	}
+
+	sc := m.metadata.RowGroups[rg].SortingColumns
+	if len(sc) == 0 {
+		return nil, nil
+	}
+
+	fields, err := m.SchemaFields()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]SortingColumn, len(sc))
+	for i, c := range sc {
+		if c.ColumnIdx < 0 || int(c.ColumnIdx) >= len(fields) {
+			return nil, fmt.Errorf("invalid sorting column %d, the file has %d columns", c.ColumnIdx, len(fields))
+		}
+
+		out[i] = SortingColumn{
+			Column:     strings.Join(fields[c.ColumnIdx].Path, "."),
+			Descending: c.Descending,
+			NullsFirst: c.NullsFirst,
+		}
+	}
+	return out, nil
+}
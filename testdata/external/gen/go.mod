@@ -0,0 +1,8 @@
+module github.com/rclayton-godaddy/parquet/testdata/external/gen
+
+go 1.18
+
+require (
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
+)
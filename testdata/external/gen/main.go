@@ -0,0 +1,169 @@
+// gen writes the files in testdata/external with
+// github.com/xitongsys/parquet-go, so the reader is tested against
+// files that it didn't write itself.  Run it from this directory:
+//
+//	go run .
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// person has a column of each of the types in the Person struct that
+// the tests generate code for.  parquet-go only writes UINT_32 and
+// UINT_64 columns from int32 and int64 fields, so -1 is the largest
+// uint.
+type person struct {
+	ID          int32    `parquet:"name=id, type=INT32"`
+	Name        string   `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Age         *int32   `parquet:"name=age, type=INT32, repetitiontype=OPTIONAL"`
+	Happiness   int64    `parquet:"name=happiness, type=INT64"`
+	Sadness     *int64   `parquet:"name=sadness, type=INT64, repetitiontype=OPTIONAL"`
+	Code        *string  `parquet:"name=code, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Funkiness   float32  `parquet:"name=funkiness, type=FLOAT"`
+	Boldness    float64  `parquet:"name=boldness, type=DOUBLE"`
+	Lameness    *float32 `parquet:"name=lameness, type=FLOAT, repetitiontype=OPTIONAL"`
+	Keen        *bool    `parquet:"name=keen, type=BOOLEAN, repetitiontype=OPTIONAL"`
+	Birthday    int32    `parquet:"name=birthday, type=INT32, convertedtype=UINT_32"`
+	Anniversary *int64   `parquet:"name=anniversary, type=INT64, convertedtype=UINT_64, repetitiontype=OPTIONAL"`
+	BFF         string   `parquet:"name=bff, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Hungry      bool     `parquet:"name=hungry, type=BOOLEAN"`
+	Hobby       *hobby   `parquet:"name=hobby, repetitiontype=OPTIONAL"`
+	Friends     []being  `parquet:"name=friends, repetitiontype=REPEATED"`
+	Sleepy      bool     `parquet:"name=Sleepy, type=BOOLEAN"`
+	Born        int64    `parquet:"name=born, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MICROS"`
+	Died        *int64   `parquet:"name=died, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=false, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL"`
+}
+
+type being struct {
+	ID   int32  `parquet:"name=id, type=INT32"`
+	Name string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Age  *int32 `parquet:"name=age, type=INT32, repetitiontype=OPTIONAL"`
+}
+
+type hobby struct {
+	Name       string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Difficulty *int32  `parquet:"name=difficulty, type=INT32, repetitiontype=OPTIONAL"`
+	Skills     []skill `parquet:"name=skills, repetitiontype=REPEATED"`
+}
+
+type skill struct {
+	Name       string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Difficulty string `parquet:"name=difficulty, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+type deltaLength struct {
+	Name string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=DELTA_LENGTH_BYTE_ARRAY"`
+	Code *string `parquet:"name=code, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL, encoding=DELTA_LENGTH_BYTE_ARRAY"`
+}
+
+type delta struct {
+	Name string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=DELTA_BYTE_ARRAY"`
+	Code *string `parquet:"name=code, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL, encoding=DELTA_BYTE_ARRAY"`
+}
+
+// rleBool has boolean columns with the RLE encoding, which parquet-go
+// only writes if the bit width (length) is set.
+type rleBool struct {
+	ID     int32 `parquet:"name=id, type=INT32"`
+	Hungry bool  `parquet:"name=hungry, type=BOOLEAN, length=1, encoding=RLE"`
+	Keen   *bool `parquet:"name=keen, type=BOOLEAN, repetitiontype=OPTIONAL, length=1, encoding=RLE"`
+}
+
+var out = flag.String("out", "..", "the directory to write the files to")
+
+func main() {
+	flag.Parse()
+
+	born := time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC).UnixMicro()
+	died := time.Date(2021, 6, 7, 8, 9, 10, 11000000, time.UTC).UnixMilli()
+	write("types.parquet", new(person), []interface{}{
+		person{
+			ID: 1, Name: "ann", Age: pint32(30), Happiness: 1 << 40, Sadness: pint64(-5), Code: pstring("a1"),
+			Funkiness: 0.5, Boldness: 1.25, Lameness: pfloat32(-0.75), Keen: pbool(true),
+			Birthday: -1, Anniversary: pint64(-1), BFF: "bob", Hungry: true,
+			Hobby: &hobby{Name: "chess", Difficulty: pint32(7), Skills: []skill{
+				{Name: "openings", Difficulty: "hard"},
+				{Name: "endgames", Difficulty: "harder"},
+			}},
+			Friends: []being{{ID: 2, Name: "bob", Age: pint32(31)}, {ID: 3, Name: "cat"}},
+			Sleepy:  true, Born: born, Died: pint64(died),
+		},
+		person{ID: 2, Name: "bob", Happiness: -2, BFF: "ann", Born: born + 1},
+		person{
+			ID: 3, Age: pint32(0), Code: pstring(""), Keen: pbool(false), Birthday: 7, Anniversary: pint64(0),
+			Hobby: &hobby{Name: "naps"}, Friends: []being{{ID: 1, Name: "ann", Age: pint32(30)}},
+		},
+	}, 0)
+
+	write("delta_length_byte_array.parquet", new(deltaLength), []interface{}{
+		deltaLength{Name: "Hello", Code: pstring("us")},
+		deltaLength{Name: "World"},
+		deltaLength{Name: "Foobar", Code: pstring("gb")},
+		deltaLength{Name: "ABCDEF", Code: pstring("")},
+	}, 0)
+
+	write("delta_byte_array.parquet", new(delta), []interface{}{
+		delta{Name: "axis", Code: pstring("abc")},
+		delta{Name: "axle"},
+		delta{Name: "babble", Code: pstring("abd")},
+		delta{Name: "babyhood", Code: pstring("abd")},
+	}, 0)
+
+	var bools []interface{}
+	for i := 0; i < 25; i++ {
+		b := rleBool{ID: int32(i), Hungry: i%3 == 0 || (i >= 5 && i < 15)}
+		if i%4 != 0 {
+			b.Keen = pbool(i >= 8 && i < 20)
+		}
+		bools = append(bools, b)
+	}
+	// a small page size so that each column has more than one page
+	write("rle_bool.parquet", new(rleBool), bools, 8)
+}
+
+// write writes rows to name in the output directory with snappy
+// compression.  If pageSize isn't 0 it replaces the default page size.
+func write(name string, schema interface{}, rows []interface{}, pageSize int64) {
+	fw, err := local.NewLocalFileWriter(filepath.Join(*out, name))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pw, err := writer.NewParquetWriter(fw, schema, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	if pageSize > 0 {
+		pw.PageSize = pageSize
+	}
+
+	for _, r := range rows {
+		if err := pw.Write(r); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := fw.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func pint32(i int32) *int32       { return &i }
+func pint64(i int64) *int64       { return &i }
+func pfloat32(f float32) *float32 { return &f }
+func pbool(b bool) *bool          { return &b }
+func pstring(s string) *string    { return &s }
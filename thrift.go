@@ -0,0 +1,158 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// ThriftProtocol is the thrift protocol that the footer is
+// written with (see Metadata.SetFooterProtocol).
+type ThriftProtocol int
+
+const (
+	// CompactProtocol is the protocol that the parquet spec requires
+	// for the footer and the page headers, and the only one that
+	// other implementations (and this package's reader) can read.
+	CompactProtocol ThriftProtocol = iota
+
+	// BinaryProtocol writes a larger footer that parquet readers
+	// can't read.  It is only for debugging, for example with thrift
+	// tools that only know the binary protocol.
+	BinaryProtocol
+)
+
+func (p ThriftProtocol) String() string {
+	switch p {
+	case CompactProtocol:
+		return "compact"
+	case BinaryProtocol:
+		return "binary"
+	default:
+		return fmt.Sprintf("ThriftProtocol(%d)", int(p))
+	}
+}
+
+// newThriftSerializer returns a serializer that writes
+// thrift structs with the compact protocol.
+func newThriftSerializer() *thrift.TSerializer {
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	return ts
+}
+
+// newBinarySerializer returns a serializer that writes
+// thrift structs with the binary protocol.
+func newBinarySerializer() *thrift.TSerializer {
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTBinaryProtocolFactoryDefault().GetProtocol(ts.Transport)
+	return ts
+}
+
+// boundedReader stops reading after n bytes.
+type boundedReader struct {
+	r io.Reader
+	n int64
+	b [1]byte
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	if b.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+	n, err := b.r.Read(p)
+	b.n -= int64(n)
+	return n, err
+}
+
+func (b *boundedReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(b, b.b[:])
+	return b.b[0], err
+}
+
+// thriftReader reads thrift structs (the footer and page headers) with
+// the compact protocol.  It makes sure that the length of every string,
+// binary, list, set, and map is no larger than the number of bytes that
+// are left, so malformed input returns an error instead of causing a
+// huge allocation.
+type thriftReader struct {
+	thrift.TProtocol
+	r *boundedReader
+}
+
+// newThriftReader reads at most n bytes from r.
+func newThriftReader(r io.Reader, n int64) *thriftReader {
+	if n < 0 {
+		n = math.MaxInt64
+	}
+	br := &boundedReader{r: r, n: n}
+	return &thriftReader{
+		TProtocol: thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: br}),
+		r:         br,
+	}
+}
+
+func (t *thriftReader) check(n int) error {
+	if n < 0 || int64(n) > t.r.n {
+		return fmt.Errorf("invalid thrift length %d, %d bytes remaining", n, t.r.n)
+	}
+	return nil
+}
+
+func (t *thriftReader) ReadBinary() ([]byte, error) {
+	n, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(t.r.n) {
+		return nil, fmt.Errorf("invalid thrift length %d, %d bytes remaining", n, t.r.n)
+	}
+
+	buf := make([]byte, n)
+	_, err = io.ReadFull(t.r, buf)
+	return buf, err
+}
+
+func (t *thriftReader) ReadString() (string, error) {
+	buf, err := t.ReadBinary()
+	return string(buf), err
+}
+
+// Skip skips a field that the struct that is being read doesn't know
+// of.  The compact protocol's Skip would read the field with its own
+// ReadBinary (which isn't bounded), so it is skipped with the methods
+// of t instead.
+func (t *thriftReader) Skip(typ thrift.TType) error {
+	return thrift.SkipDefaultDepth(t, typ)
+}
+
+func (t *thriftReader) ReadListBegin() (thrift.TType, int, error) {
+	typ, n, err := t.TProtocol.ReadListBegin()
+	if err != nil {
+		return typ, n, err
+	}
+	return typ, n, t.check(n)
+}
+
+func (t *thriftReader) ReadSetBegin() (thrift.TType, int, error) {
+	typ, n, err := t.TProtocol.ReadSetBegin()
+	if err != nil {
+		return typ, n, err
+	}
+	return typ, n, t.check(n)
+}
+
+func (t *thriftReader) ReadMapBegin() (thrift.TType, thrift.TType, int, error) {
+	kt, vt, n, err := t.TProtocol.ReadMapBegin()
+	if err != nil {
+		return kt, vt, n, err
+	}
+	return kt, vt, n, t.check(n)
+}
@@ -0,0 +1,160 @@
+package parquet
+
+import (
+	"fmt"
+	"time"
+
+	sch "github.com/rclayton-godaddy/parquet/schema"
+)
+
+// TimeUnit is the precision of a TIMESTAMP column.
+type TimeUnit int
+
+const (
+	Millis TimeUnit = iota
+	Micros
+	Nanos
+	// Seconds is only used by Timestamps that are Epoch
+	// (a TIMESTAMP can't be in seconds).
+	Seconds
+)
+
+// String returns the name of the unit (MILLIS,
+// MICROS, or NANOS) as it is in a parquet schema.
+func (u TimeUnit) String() string {
+	switch u {
+	case Millis:
+		return "MILLIS"
+	case Micros:
+		return "MICROS"
+	case Nanos:
+		return "NANOS"
+	case Seconds:
+		return "SECONDS"
+	}
+	return fmt.Sprintf("TimeUnit(%d)", int(u))
+}
+
+// Timestamp describes how a time.Time is stored in an INT64 column.
+// If AdjustedToUTC is true the value is an instant (the number of
+// units since the unix epoch in UTC), otherwise it is a local (wall
+// clock) time that isn't tied to a time zone.  If Epoch is true the
+// column is a plain INT64 (it doesn't have a TIMESTAMP annotation)
+// of units since the unix epoch in UTC.
+type Timestamp struct {
+	Unit          TimeUnit
+	AdjustedToUTC bool
+	Epoch         bool
+}
+
+// LogicalType returns the TIMESTAMP logical type annotation
+// (nil if the Timestamp is Epoch).
+func (t Timestamp) LogicalType() *sch.LogicalType {
+	if t.Epoch {
+		return nil
+	}
+
+	unit := &sch.TimeUnit{}
+	switch t.Unit {
+	case Millis:
+		unit.MILLIS = &sch.MilliSeconds{}
+	case Micros:
+		unit.MICROS = &sch.MicroSeconds{}
+	default:
+		unit.NANOS = &sch.NanoSeconds{}
+	}
+
+	return &sch.LogicalType{
+		TIMESTAMP: &sch.TimestampType{IsAdjustedToUTC: t.AdjustedToUTC, Unit: unit},
+	}
+}
+
+// ConvertedType returns the legacy converted type annotation.  There
+// is only one for timestamps that are adjusted to UTC and are in
+// millis or micros, otherwise (or if it is Epoch) it is nil.
+func (t Timestamp) ConvertedType() *sch.ConvertedType {
+	if !t.AdjustedToUTC || t.Epoch {
+		return nil
+	}
+
+	var ct sch.ConvertedType
+	switch t.Unit {
+	case Millis:
+		ct = sch.ConvertedType_TIMESTAMP_MILLIS
+	case Micros:
+		ct = sch.ConvertedType_TIMESTAMP_MICROS
+	default:
+		return nil
+	}
+	return &ct
+}
+
+// Int64 converts tm to the value that is stored in the column.
+func (t Timestamp) Int64(tm time.Time) int64 {
+	if !t.AdjustedToUTC {
+		tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), time.UTC)
+	}
+
+	switch t.Unit {
+	case Seconds:
+		return tm.Unix()
+	case Millis:
+		return tm.Unix()*1e3 + int64(tm.Nanosecond())/1e6
+	case Micros:
+		return tm.Unix()*1e6 + int64(tm.Nanosecond())/1e3
+	default:
+		return tm.UnixNano()
+	}
+}
+
+// Time converts a value that was read from the column to a time.Time.
+// Timestamps that are adjusted to UTC are returned in UTC and local
+// timestamps are returned with the same wall clock in time.Local.
+func (t Timestamp) Time(v int64) time.Time {
+	var tm time.Time
+	switch t.Unit {
+	case Seconds:
+		tm = time.Unix(v, 0)
+	case Millis:
+		tm = time.Unix(v/1e3, (v%1e3)*1e6)
+	case Micros:
+		tm = time.Unix(v/1e6, (v%1e6)*1e3)
+	default:
+		tm = time.Unix(0, v)
+	}
+
+	tm = tm.UTC()
+	if t.AdjustedToUTC {
+		return tm
+	}
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), time.Local)
+}
+
+// TimestampOf returns the Timestamp that a column's logical type
+// (or legacy converted type) describes.  It returns false if the
+// column isn't annotated as a TIMESTAMP.
+func TimestampOf(lt *sch.LogicalType, ct *sch.ConvertedType) (Timestamp, bool) {
+	if lt != nil && lt.TIMESTAMP != nil {
+		ts := Timestamp{AdjustedToUTC: lt.TIMESTAMP.IsAdjustedToUTC}
+		switch u := lt.TIMESTAMP.Unit; {
+		case u != nil && u.MILLIS != nil:
+			ts.Unit = Millis
+		case u != nil && u.MICROS != nil:
+			ts.Unit = Micros
+		default:
+			ts.Unit = Nanos
+		}
+		return ts, true
+	}
+
+	if ct != nil {
+		switch *ct {
+		case sch.ConvertedType_TIMESTAMP_MILLIS:
+			return Timestamp{Unit: Millis, AdjustedToUTC: true}, true
+		case sch.ConvertedType_TIMESTAMP_MICROS:
+			return Timestamp{Unit: Micros, AdjustedToUTC: true}, true
+		}
+	}
+
+	return Timestamp{}, false
+}
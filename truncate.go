@@ -0,0 +1,81 @@
+package parquet
+
+import (
+	"unicode/utf8"
+)
+
+// TruncateMin truncates a min statistic to at most n bytes.  A prefix
+// of a value is never greater than the value, so it is still a lower
+// bound.  If v is UTF-8 it is cut at the end of a rune so that the
+// statistic is still valid UTF-8.
+func TruncateMin(v []byte, n int) []byte {
+	if n <= 0 || len(v) <= n {
+		return v
+	}
+
+	if !utf8.Valid(v) {
+		return v[:n]
+	}
+	return v[:runeBoundary(v, n)]
+}
+
+// TruncateMax truncates a max statistic to at most n bytes.  Since a
+// prefix of a value is less than the value, the last byte (or rune, if v
+// is UTF-8) of the prefix is incremented so that it is still an upper
+// bound.  If there is nothing that can be incremented (every byte of the
+// prefix is 0xff, for example) v is returned as is.
+func TruncateMax(v []byte, n int) []byte {
+	if n <= 0 || len(v) <= n {
+		return v
+	}
+
+	if !utf8.Valid(v) {
+		return truncateMaxBytes(v, n)
+	}
+	return truncateMaxRunes(v, n)
+}
+
+func truncateMaxBytes(v []byte, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		if v[i] == 0xff {
+			continue
+		}
+
+		out := make([]byte, i+1)
+		copy(out, v[:i])
+		out[i] = v[i] + 1
+		return out
+	}
+	return v
+}
+
+func truncateMaxRunes(v []byte, n int) []byte {
+	end := runeBoundary(v, n)
+	for end > 0 {
+		r, size := utf8.DecodeLastRune(v[:end])
+		start := end - size
+
+		next := r + 1
+		if next >= 0xd800 && next <= 0xdfff {
+			// surrogates aren't valid runes
+			next = 0xe000
+		}
+
+		if next <= utf8.MaxRune && start+utf8.RuneLen(next) <= n {
+			out := make([]byte, start, start+utf8.RuneLen(next))
+			copy(out, v[:start])
+			return utf8.AppendRune(out, next)
+		}
+		end = start
+	}
+	return v
+}
+
+// runeBoundary returns the largest i <= n that
+// is the start of a rune of the UTF-8 v.
+func runeBoundary(v []byte, n int) int {
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return n
+}